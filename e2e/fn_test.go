@@ -18,15 +18,20 @@
 package e2e_test
 
 import (
+	"flag"
 	"os"
 	"path/filepath"
-	"strings"
 	"testing"
 
 	"github.com/GoogleContainerTools/kpt/internal/fnruntime"
 	"github.com/GoogleContainerTools/kpt/pkg/test/runner"
 )
 
+// update has the same effect as setting KPT_E2E_UPDATE_EXPECTED to "true",
+// e.g. `go test ./e2e -run TestFnRender -update`.
+var update = flag.Bool("update", false,
+	"rewrite the expected artifacts of the test cases instead of comparing against them")
+
 func TestFnRender(t *testing.T) {
 	runAllTests(t, filepath.Join(".", "testdata", "fn-render"))
 }
@@ -39,6 +44,10 @@ func TestFnSink(t *testing.T) {
 	runAllTests(t, filepath.Join(".", "testdata", "fn-sink"))
 }
 
+func TestCommands(t *testing.T) {
+	runAllTests(t, filepath.Join(".", "testdata", "commands"))
+}
+
 // runTests will scan test cases in 'path', run the command
 // on all of the packages in path, and test that
 // the diff between the results and the original package is as
@@ -60,24 +69,14 @@ func runTests(t *testing.T, cases *runner.TestCases, sequential bool) {
 			continue
 		}
 		// If the current function runtime doesn't match, we skip this test case.
-		currRuntime := strings.ToLower(os.Getenv(fnruntime.ContainerRuntimeEnv))
-		if len(c.Config.Runtimes) > 0 {
-			skip := true
-			for _, rt := range c.Config.Runtimes {
-				if currRuntime == strings.ToLower(rt) {
-					skip = false
-					break
-				}
-			}
-			if skip {
-				continue
-			}
+		if !c.Config.MatchesRuntime(os.Getenv(fnruntime.ContainerRuntimeEnv)) {
+			continue
 		}
 		t.Run(c.Path, func(t *testing.T) {
 			if !c.Config.Sequential {
 				t.Parallel()
 			}
-			r, err := runner.NewRunner(t, c, c.Config.TestType)
+			r, err := runner.NewRunner(t, c, c.Config.TestType, runner.WithUpdateExpected(*update))
 			if err != nil {
 				t.Fatalf("failed to create test runner: %s", err)
 			}
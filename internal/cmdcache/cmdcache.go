@@ -0,0 +1,163 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cmdcache contains the cache command group
+package cmdcache
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/GoogleContainerTools/kpt/internal/docs/generated/pkgdocs"
+	"github.com/GoogleContainerTools/kpt/internal/errors"
+	"github.com/GoogleContainerTools/kpt/internal/printer"
+	"github.com/GoogleContainerTools/kpt/internal/util/cache"
+	"github.com/GoogleContainerTools/kpt/internal/util/cmdutil"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// NewCommand returns the cache command group with its subcommands.
+func NewCommand(ctx context.Context, parent string) *cobra.Command {
+	c := &cobra.Command{
+		Use:   "cache",
+		Short: pkgdocs.CacheShort,
+		Long:  pkgdocs.CacheShort + "\n" + pkgdocs.CacheLong,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			h, err := cmd.Flags().GetBool("help")
+			if err != nil {
+				return err
+			}
+			if h {
+				return cmd.Help()
+			}
+			return cmd.Usage()
+		},
+	}
+	c.AddCommand(
+		newListRunner(ctx, parent).C,
+		newPruneRunner(ctx, parent).C,
+		newWarmRunner(ctx, parent).C,
+	)
+	return c
+}
+
+func newListRunner(ctx context.Context, parent string) *listRunner {
+	r := &listRunner{
+		ctx: ctx,
+	}
+	c := &cobra.Command{
+		Use:          "list",
+		Aliases:      []string{"ls"},
+		Args:         cobra.NoArgs,
+		Short:        pkgdocs.ListShort,
+		Long:         pkgdocs.ListShort + "\n" + pkgdocs.ListLong,
+		Example:      pkgdocs.ListExamples,
+		RunE:         r.runE,
+		SilenceUsage: true,
+	}
+	r.C = c
+	cmdutil.FixDocs("kpt", parent, c)
+	return r
+}
+
+type listRunner struct {
+	ctx context.Context
+	cache.ListCommand
+	C *cobra.Command
+}
+
+func (r *listRunner) runE(_ *cobra.Command, _ []string) error {
+	r.Output = printer.FromContextOrDie(r.ctx).OutStream()
+	return r.Run(r.ctx)
+}
+
+func newPruneRunner(ctx context.Context, parent string) *pruneRunner {
+	r := &pruneRunner{
+		ctx: ctx,
+	}
+	c := &cobra.Command{
+		Use:          "prune [flags]",
+		Args:         cobra.NoArgs,
+		Short:        pkgdocs.PruneShort,
+		Long:         pkgdocs.PruneShort + "\n" + pkgdocs.PruneLong,
+		Example:      pkgdocs.PruneExamples,
+		PreRunE:      r.preRunE,
+		RunE:         r.runE,
+		SilenceUsage: true,
+	}
+	c.Flags().DurationVar(&r.MaxAge, "max-age", 0,
+		"remove the repos that haven't been used for longer than the duration, e.g. 720h")
+	c.Flags().StringVar(&r.maxSize, "max-size", "",
+		"remove the least recently used repos until the cache uses at most the given size, e.g. 2Gi")
+	c.Flags().BoolVar(&r.All, "all", false,
+		"remove every repo from the cache")
+	c.Flags().BoolVar(&r.DryRun, "dry-run", false,
+		"print the repos that would be removed without removing them")
+	r.C = c
+	cmdutil.FixDocs("kpt", parent, c)
+	return r
+}
+
+type pruneRunner struct {
+	ctx context.Context
+	cache.PruneCommand
+	C       *cobra.Command
+	maxSize string
+}
+
+func (r *pruneRunner) preRunE(_ *cobra.Command, _ []string) error {
+	const op errors.Op = "cmdcache.preRunE"
+	if r.maxSize != "" {
+		q, err := resource.ParseQuantity(r.maxSize)
+		if err != nil {
+			return errors.E(op, errors.InvalidParam, fmt.Errorf("invalid max size %q: %w", r.maxSize, err))
+		}
+		r.MaxSize = q.Value()
+	}
+	return r.Validate()
+}
+
+func (r *pruneRunner) runE(_ *cobra.Command, _ []string) error {
+	return r.Run(r.ctx)
+}
+
+func newWarmRunner(ctx context.Context, parent string) *warmRunner {
+	r := &warmRunner{
+		ctx: ctx,
+	}
+	c := &cobra.Command{
+		Use:          "warm PATH...",
+		Args:         cobra.MinimumNArgs(1),
+		Short:        pkgdocs.WarmShort,
+		Long:         pkgdocs.WarmShort + "\n" + pkgdocs.WarmLong,
+		Example:      pkgdocs.WarmExamples,
+		RunE:         r.runE,
+		SilenceUsage: true,
+	}
+	r.C = c
+	cmdutil.FixDocs("kpt", parent, c)
+	return r
+}
+
+type warmRunner struct {
+	ctx context.Context
+	cache.WarmCommand
+	C *cobra.Command
+}
+
+func (r *warmRunner) runE(_ *cobra.Command, args []string) error {
+	r.Paths = args
+	return r.Run(r.ctx)
+}
@@ -18,8 +18,10 @@ package cmddiff
 import (
 	"context"
 	"os"
+	"strings"
 
 	"github.com/GoogleContainerTools/kpt/internal/docs/generated/pkgdocs"
+	"github.com/GoogleContainerTools/kpt/internal/gitutil"
 	"github.com/GoogleContainerTools/kpt/internal/pkg"
 	"github.com/GoogleContainerTools/kpt/internal/printer"
 	"github.com/GoogleContainerTools/kpt/internal/util/argutil"
@@ -55,8 +57,12 @@ func NewRunner(ctx context.Context, parent string) *Runner {
 		"diff tool to use to show the changes")
 	c.Flags().StringVar(&r.DiffToolOpts, "diff-tool-opts", diffToolOpts,
 		"diff tool commandline options to use to show the changes")
+	c.Flags().StringVar(&r.OutputFormat, "output", diff.OutputText,
+		"output format of the changes e.g. "+strings.Join(diff.SupportedOutputFormats, ", "))
 	c.Flags().BoolVar(&r.Debug, "debug", false,
 		"when true, prints additional debug information and do not delete staged pkg dirs")
+	c.Flags().BoolVar(&r.offline, "offline", false,
+		"only use upstream repos from the kpt cache, without fetching from the network")
 	r.C = c
 	r.Output = printer.FromContextOrDie(r.ctx).OutStream()
 	cmdutil.FixDocs("kpt", parent, c)
@@ -74,9 +80,11 @@ type Runner struct {
 	diff.Command
 	C        *cobra.Command
 	diffType string
+	offline  bool
 }
 
 func (r *Runner) preRunE(_ *cobra.Command, args []string) error {
+	r.ctx = gitutil.ContextWithOffline(r.ctx, r.offline)
 	if len(args) == 0 {
 		args = append(args, pkg.CurDir)
 	}
@@ -22,10 +22,12 @@ import (
 
 	docs "github.com/GoogleContainerTools/kpt/internal/docs/generated/pkgdocs"
 	"github.com/GoogleContainerTools/kpt/internal/errors"
+	"github.com/GoogleContainerTools/kpt/internal/gitutil"
 	"github.com/GoogleContainerTools/kpt/internal/pkg"
 	"github.com/GoogleContainerTools/kpt/internal/types"
 	"github.com/GoogleContainerTools/kpt/internal/util/argutil"
 	"github.com/GoogleContainerTools/kpt/internal/util/cmdutil"
+	"github.com/GoogleContainerTools/kpt/internal/util/fetch"
 	"github.com/GoogleContainerTools/kpt/internal/util/get"
 	"github.com/GoogleContainerTools/kpt/internal/util/parse"
 	"github.com/GoogleContainerTools/kpt/internal/util/pathutil"
@@ -40,7 +42,7 @@ func NewRunner(ctx context.Context, parent string) *Runner {
 		ctx: ctx,
 	}
 	c := &cobra.Command{
-		Use:        "get REPO_URI[.git]/PKG_PATH[@VERSION] [LOCAL_DEST_DIRECTORY]",
+		Use:        "get {REPO_URI[.git]/PKG_PATH[@VERSION] | DIRECTORY | TARBALL} [LOCAL_DEST_DIRECTORY]",
 		Args:       cobra.MinimumNArgs(1),
 		Short:      docs.GetShort,
 		Long:       docs.GetShort + "\n" + docs.GetLong,
@@ -56,6 +58,11 @@ func NewRunner(ctx context.Context, parent string) *Runner {
 			strings.Join(kptfilev1.UpdateStrategiesAsStrings(), ","))
 	c.Flags().BoolVar(&r.isDeploymentInstance, "for-deployment", false,
 		"(Experimental) indicates if this package will be deployed to a cluster.")
+	c.Flags().StringVar(&r.verificationPolicy, "verification-policy", "",
+		"path to an upstream verification policy with the trusted keys that the upstream tag or commit "+
+			"must be signed with. Defaults to the value of $"+fetch.VerificationPolicyEnv)
+	c.Flags().BoolVar(&r.offline, "offline", false,
+		"only use upstream repos from the kpt cache, without fetching from the network")
 	_ = c.RegisterFlagCompletionFunc("strategy", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return kptfilev1.UpdateStrategiesAsStrings(), cobra.ShellCompDirectiveDefault
 	})
@@ -73,10 +80,13 @@ type Runner struct {
 	Command              *cobra.Command
 	strategy             string
 	isDeploymentInstance bool
+	offline              bool
+	verificationPolicy   string
 }
 
 func (r *Runner) preRunE(_ *cobra.Command, args []string) error {
 	const op errors.Op = "cmdget.preRunE"
+	r.ctx = gitutil.ContextWithOffline(r.ctx, r.offline)
 	if len(args) == 1 {
 		args = append(args, pkg.CurDir)
 	} else {
@@ -89,20 +99,32 @@ func (r *Runner) preRunE(_ *cobra.Command, args []string) error {
 			args[1] = resolvedPath
 		}
 	}
-	t, err := parse.GitParseArgs(r.ctx, args)
-	if err != nil {
-		return errors.E(op, err)
+	var destination string
+	if parse.IsLocalUpstream(args[0]) {
+		t, err := parse.LocalParseArgs(args)
+		if err != nil {
+			return errors.E(op, err)
+		}
+		r.Get.Dir = t.Dir
+		r.Get.Tarball = t.Tarball
+		destination = t.Destination
+	} else {
+		t, err := parse.GitParseArgs(r.ctx, args)
+		if err != nil {
+			return errors.E(op, err)
+		}
+		r.Get.Git = &t.Git
+		destination = t.Destination
 	}
 
-	r.Get.Git = &t.Git
-	absDestPath, _, err := pathutil.ResolveAbsAndRelPaths(t.Destination)
+	absDestPath, _, err := pathutil.ResolveAbsAndRelPaths(destination)
 	if err != nil {
 		return err
 	}
 
 	p, err := pkg.New(filesys.FileSystemOrOnDisk{}, absDestPath)
 	if err != nil {
-		return errors.E(op, types.UniquePath(t.Destination), err)
+		return errors.E(op, types.UniquePath(destination), err)
 	}
 	r.Get.Destination = string(p.UniquePath)
 
@@ -112,6 +134,12 @@ func (r *Runner) preRunE(_ *cobra.Command, args []string) error {
 	}
 	r.Get.UpdateStrategy = strategy
 	r.Get.IsDeploymentInstance = r.isDeploymentInstance
+
+	policy, err := fetch.LoadVerificationPolicy(r.verificationPolicy)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	r.Get.VerificationPolicy = policy
 	return nil
 }
 
@@ -0,0 +1,88 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cmdinfo contains the info command
+package cmdinfo
+
+import (
+	"context"
+	"strings"
+
+	"github.com/GoogleContainerTools/kpt/internal/docs/generated/pkgdocs"
+	"github.com/GoogleContainerTools/kpt/internal/pkg"
+	"github.com/GoogleContainerTools/kpt/internal/printer"
+	"github.com/GoogleContainerTools/kpt/internal/util/argutil"
+	"github.com/GoogleContainerTools/kpt/internal/util/cmdutil"
+	"github.com/GoogleContainerTools/kpt/internal/util/info"
+	"github.com/GoogleContainerTools/kpt/internal/util/pathutil"
+	"github.com/spf13/cobra"
+)
+
+// NewRunner returns a command runner.
+func NewRunner(ctx context.Context, parent string) *Runner {
+	r := &Runner{
+		ctx: ctx,
+	}
+	c := &cobra.Command{
+		Use:          "info [DIR] [flags]",
+		Args:         cobra.MaximumNArgs(1),
+		Short:        pkgdocs.InfoShort,
+		Long:         pkgdocs.InfoShort + "\n" + pkgdocs.InfoLong,
+		Example:      pkgdocs.InfoExamples,
+		PreRunE:      r.preRunE,
+		RunE:         r.runE,
+		SilenceUsage: true,
+	}
+	c.Flags().StringVar(&r.OutputFormat, "output", info.OutputText,
+		"output format of the report e.g. "+strings.Join(info.SupportedOutputFormats, ", "))
+	_ = c.RegisterFlagCompletionFunc("output", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return info.SupportedOutputFormats, cobra.ShellCompDirectiveDefault
+	})
+	r.C = c
+	cmdutil.FixDocs("kpt", parent, c)
+	return r
+}
+
+// NewCommand returns an info command instance.
+func NewCommand(ctx context.Context, parent string) *cobra.Command {
+	return NewRunner(ctx, parent).C
+}
+
+// Runner contains the run function
+type Runner struct {
+	ctx context.Context
+	info.Command
+	C *cobra.Command
+}
+
+func (r *Runner) preRunE(_ *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		args = append(args, pkg.CurDir)
+	}
+	resolvedPath, err := argutil.ResolveSymlink(r.ctx, args[0])
+	if err != nil {
+		return err
+	}
+	absResolvedPath, _, err := pathutil.ResolveAbsAndRelPaths(resolvedPath)
+	if err != nil {
+		return err
+	}
+	r.Path = absResolvedPath
+	r.Output = printer.FromContextOrDie(r.ctx).OutStream()
+	return r.Validate()
+}
+
+func (r *Runner) runE(_ *cobra.Command, _ []string) error {
+	return r.Run(r.ctx)
+}
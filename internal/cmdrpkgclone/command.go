@@ -68,6 +68,7 @@ func newRunner(ctx context.Context, rcg *genericclioptions.ConfigFlags) *runner
 	c.Flags().StringVar(&r.ref, "ref", "", "Branch in the repository where the upstream package is located.")
 	c.Flags().StringVar(&r.repository, "repository", "", "Repository to which package will be cloned (downstream repository).")
 	c.Flags().StringVar(&r.revision, "revision", "v1", "Revision of the downstream package.")
+	c.Flags().StringVar(&r.subpackage, "subpackage", "", "Directory within the existing draft package revision NAME into which the package will be cloned.")
 
 	return r
 }
@@ -87,6 +88,7 @@ type runner struct {
 	repository string // Target repository
 	revision   string // Target package revision
 	target     string // Target package name
+	subpackage string // Target subpackage directory
 }
 
 func (r *runner) preRunE(cmd *cobra.Command, args []string) error {
@@ -107,9 +109,12 @@ func (r *runner) preRunE(cmd *cobra.Command, args []string) error {
 		return errors.E(op, fmt.Errorf("SOURCE_PACKAGE and NAME are required positional arguments; %d provided", len(args)))
 	}
 
-	if r.repository == "" {
+	// When cloning into a subpackage, NAME is an existing package revision
+	// which determines the downstream repository.
+	if r.repository == "" && r.subpackage == "" {
 		return errors.E(op, fmt.Errorf("--repository is required to specify downstream repository"))
 	}
+	r.clone.Subpackage = r.subpackage
 
 	source := args[0]
 	target := args[1]
@@ -172,6 +177,14 @@ func (r *runner) preRunE(cmd *cobra.Command, args []string) error {
 func (r *runner) runE(cmd *cobra.Command, args []string) error {
 	const op errors.Op = command + ".runE"
 
+	if r.subpackage != "" {
+		if err := r.cloneIntoSubpackage(); err != nil {
+			return errors.E(op, err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "%s updated\n", r.target)
+		return nil
+	}
+
 	pr := &porchapi.PackageRevision{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "PackageRevision",
@@ -200,6 +213,31 @@ func (r *runner) runE(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// cloneIntoSubpackage adds a clone task to the existing draft package revision,
+// which clones the upstream package into the subpackage directory.
+func (r *runner) cloneIntoSubpackage() error {
+	var pr porchapi.PackageRevision
+	if err := r.client.Get(r.ctx, client.ObjectKey{
+		Namespace: *r.cfg.Namespace,
+		Name:      r.target,
+	}, &pr); err != nil {
+		return err
+	}
+	if pr.Spec.Lifecycle != porchapi.PackageRevisionLifecycleDraft {
+		return fmt.Errorf("cannot clone into a subpackage of package revision %s with lifecycle %q; only drafts can be modified", r.target, pr.Spec.Lifecycle)
+	}
+	if r.repository != "" && r.repository != pr.Spec.RepositoryName {
+		return fmt.Errorf("repository %s specified by --repository contradicts repository %s of package revision %s",
+			r.repository, pr.Spec.RepositoryName, r.target)
+	}
+
+	pr.Spec.Tasks = append(pr.Spec.Tasks, porchapi.Task{
+		Type:  porchapi.TaskTypeClone,
+		Clone: &r.clone,
+	})
+	return r.client.Update(r.ctx, &pr)
+}
+
 func toMergeStrategy(strategy string) (porchapi.PackageMergeStrategy, error) {
 	switch strategy {
 	case string(porchapi.ResourceMerge):
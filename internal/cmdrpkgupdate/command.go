@@ -17,6 +17,7 @@ package cmdrpkgupdate
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/GoogleContainerTools/kpt/internal/errors"
 	"github.com/GoogleContainerTools/kpt/internal/util/porch"
@@ -124,7 +125,7 @@ func (r *runner) doUpdate(pr *porchapi.PackageRevision) error {
 	case porchapi.RepositoryTypeGit:
 		cloneTask.Clone.Upstream.Git.Ref = r.revision
 	case porchapi.RepositoryTypeOCI:
-		return fmt.Errorf("update not implemented for oci packages")
+		cloneTask.Clone.Upstream.Oci.Image = imageWithTag(cloneTask.Clone.Upstream.Oci.Image, r.revision)
 	default:
 		upstreamPr := r.findPackageRevision(cloneTask.Clone.Upstream.UpstreamRef.Name)
 		if upstreamPr == nil {
@@ -139,6 +140,18 @@ func (r *runner) doUpdate(pr *porchapi.PackageRevision) error {
 	return r.client.Update(r.ctx, pr)
 }
 
+// imageWithTag returns the image with the tag, replacing the current tag or
+// digest of the image.
+func imageWithTag(image, tag string) string {
+	if i := strings.LastIndex(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image + ":" + tag
+}
+
 func (r *runner) findPackageRevision(prName string) *porchapi.PackageRevision {
 	for i := range r.prs {
 		pr := r.prs[i]
@@ -152,7 +165,8 @@ func (r *runner) findPackageRevision(prName string) *porchapi.PackageRevision {
 func (r *runner) findCloneTask(pr *porchapi.PackageRevision) (*porchapi.Task, bool) {
 	for i := len(pr.Spec.Tasks) - 1; i >= 0; i-- {
 		t := pr.Spec.Tasks[i]
-		if t.Type == porchapi.TaskTypeClone {
+		// Subpackages are cloned from their own upstreams.
+		if t.Type == porchapi.TaskTypeClone && t.Clone.Subpackage == "" {
 			return &t, true
 		}
 	}
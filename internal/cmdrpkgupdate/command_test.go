@@ -0,0 +1,37 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmdrpkgupdate
+
+import (
+	"testing"
+)
+
+func TestImageWithTag(t *testing.T) {
+	for _, tc := range []struct {
+		image string
+		want  string
+	}{
+		{"gcr.io/project/blueprints/basens", "gcr.io/project/blueprints/basens:v2"},
+		{"gcr.io/project/blueprints/basens:v1", "gcr.io/project/blueprints/basens:v2"},
+		{"gcr.io/project/blueprints/basens@sha256:0123456789abcdef", "gcr.io/project/blueprints/basens:v2"},
+		{"gcr.io/project/blueprints/basens:v1@sha256:0123456789abcdef", "gcr.io/project/blueprints/basens:v2"},
+		{"localhost:5000/basens", "localhost:5000/basens:v2"},
+		{"localhost:5000/basens:v1", "localhost:5000/basens:v2"},
+	} {
+		if got := imageWithTag(tc.image, "v2"); got != tc.want {
+			t.Errorf("imageWithTag(%q) = %q, want %q", tc.image, got, tc.want)
+		}
+	}
+}
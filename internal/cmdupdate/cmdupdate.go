@@ -24,14 +24,18 @@ import (
 
 	docs "github.com/GoogleContainerTools/kpt/internal/docs/generated/pkgdocs"
 	"github.com/GoogleContainerTools/kpt/internal/errors"
+	"github.com/GoogleContainerTools/kpt/internal/gitutil"
 	"github.com/GoogleContainerTools/kpt/internal/pkg"
 	"github.com/GoogleContainerTools/kpt/internal/types"
 	"github.com/GoogleContainerTools/kpt/internal/util/argutil"
 	"github.com/GoogleContainerTools/kpt/internal/util/cmdutil"
+	"github.com/GoogleContainerTools/kpt/internal/util/fetch"
+	"github.com/GoogleContainerTools/kpt/internal/util/merge"
 	"github.com/GoogleContainerTools/kpt/internal/util/pathutil"
 	"github.com/GoogleContainerTools/kpt/internal/util/update"
 	kptfilev1 "github.com/GoogleContainerTools/kpt/pkg/api/kptfile/v1"
 	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"sigs.k8s.io/kustomize/kyaml/filesys"
 )
 
@@ -55,6 +59,26 @@ func NewRunner(ctx context.Context, parent string) *Runner {
 		"the update strategy that will be used when updating the package. This will change "+
 			"the default strategy for the package -- must be one of: "+
 			strings.Join(kptfilev1.UpdateStrategiesAsStrings(), ","))
+	c.Flags().BoolVar(&r.Update.DryRun, "dry-run", false,
+		"print the changes the update would make and the conflicts between local and upstream "+
+			"changes, without modifying the package")
+	c.Flags().StringVar(&r.onConflict, "on-conflict", string(update.UseUpstream),
+		"how conflicts between local and upstream changes are handled with the resource-merge strategy "+
+			"-- must be one of: "+strings.Join(update.ConflictResolutionsAsStrings(), ","))
+	c.Flags().StringArrayVar(&r.schemaPaths, "schema", nil,
+		"path to a file or directory with OpenAPI documents or CRDs, used with the resource-merge strategy "+
+			"to merge the lists of custom resources. Can be repeated.")
+	c.Flags().BoolVar(&r.schemaFromCluster, "schema-from-cluster", false,
+		"use the OpenAPI schema of the current cluster to merge the lists of custom resources "+
+			"with the resource-merge strategy")
+	c.Flags().StringVar(&r.verificationPolicy, "verification-policy", "",
+		"path to an upstream verification policy with the trusted keys that the upstream tag or commit "+
+			"must be signed with. Defaults to the value of $"+fetch.VerificationPolicyEnv)
+	c.Flags().BoolVar(&r.offline, "offline", false,
+		"only use upstream repos from the kpt cache, without fetching from the network")
+	_ = c.RegisterFlagCompletionFunc("on-conflict", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return update.ConflictResolutionsAsStrings(), cobra.ShellCompDirectiveDefault
+	})
 	_ = c.RegisterFlagCompletionFunc("strategy", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return kptfilev1.UpdateStrategiesAsStrings(), cobra.ShellCompDirectiveDefault
 	})
@@ -70,14 +94,20 @@ func NewCommand(ctx context.Context, parent string) *cobra.Command {
 // Runner contains the run function.
 // TODO, support listing versions
 type Runner struct {
-	ctx      context.Context
-	strategy string
-	Update   update.Command
-	Command  *cobra.Command
+	ctx                context.Context
+	strategy           string
+	onConflict         string
+	schemaPaths        []string
+	schemaFromCluster  bool
+	offline            bool
+	verificationPolicy string
+	Update             update.Command
+	Command            *cobra.Command
 }
 
 func (r *Runner) preRunE(_ *cobra.Command, args []string) error {
 	const op errors.Op = "cmdupdate.preRunE"
+	r.ctx = gitutil.ContextWithOffline(r.ctx, r.offline)
 	if len(args) == 0 {
 		args = append(args, pkg.CurDir)
 	}
@@ -87,6 +117,8 @@ func (r *Runner) preRunE(_ *cobra.Command, args []string) error {
 		r.Update.Strategy = kptfilev1.UpdateStrategyType(r.strategy)
 	}
 
+	r.Update.ConflictResolution = update.ConflictResolution(r.onConflict)
+
 	parts := strings.Split(args[0], "@")
 	if len(parts) > 2 {
 		return errors.E(op, errors.InvalidParam, fmt.Errorf("at most 1 version permitted"))
@@ -120,11 +152,25 @@ func (r *Runner) preRunE(_ *cobra.Command, args []string) error {
 	if len(parts) > 1 {
 		r.Update.Ref = parts[1]
 	}
+
+	policy, err := fetch.LoadVerificationPolicy(r.verificationPolicy)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	r.Update.VerificationPolicy = policy
 	return nil
 }
 
 func (r *Runner) runE(c *cobra.Command, _ []string) error {
 	const op errors.Op = "cmdupdate.runE"
+	r.Update.Input = c.InOrStdin()
+	if len(r.schemaPaths) > 0 || r.schemaFromCluster {
+		schemas, err := r.loadSchemas()
+		if err != nil {
+			return errors.E(op, r.Update.Pkg.UniquePath, err)
+		}
+		r.Update.Schemas = schemas
+	}
 	if err := r.Update.Run(r.ctx); err != nil {
 		return errors.E(op, r.Update.Pkg.UniquePath, err)
 	}
@@ -132,6 +178,33 @@ func (r *Runner) runE(c *cobra.Command, _ []string) error {
 	return nil
 }
 
+// loadSchemas loads the schemas from the --schema paths and, with
+// --schema-from-cluster, from the cluster of the current kubeconfig context.
+func (r *Runner) loadSchemas() (*merge.Schemas, error) {
+	const op errors.Op = "cmdupdate.loadSchemas"
+	schemas := merge.NewSchemas()
+	if r.schemaFromCluster {
+		dc, err := genericclioptions.NewConfigFlags(true).ToDiscoveryClient()
+		if err != nil {
+			return nil, errors.E(op, fmt.Errorf("error creating discovery client: %w", err))
+		}
+		doc, err := dc.OpenAPISchema()
+		if err != nil {
+			return nil, errors.E(op, fmt.Errorf("error fetching the OpenAPI schema from the cluster: %w", err))
+		}
+		if err := schemas.AddOpenAPIDocument(doc); err != nil {
+			return nil, errors.E(op, err)
+		}
+	}
+	// Schemas from files take precedence over the schemas of the cluster.
+	for _, p := range r.schemaPaths {
+		if err := schemas.AddPath(p); err != nil {
+			return nil, errors.E(op, errors.InvalidParam, err)
+		}
+	}
+	return schemas, nil
+}
+
 func resolveRelPath(path types.UniquePath) (string, error) {
 	const op errors.Op = "cmdupdate.resolveRelPath"
 	cwd, err := os.Getwd()
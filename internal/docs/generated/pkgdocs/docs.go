@@ -7,6 +7,98 @@ The ` + "`" + `pkg` + "`" + ` command group contains subcommands for fetching, u
 from git repositories.
 `
 
+var CacheShort = `Manage the upstream repositories in the kpt cache.`
+var CacheLong = `
+The ` + "`" + `cache` + "`" + ` command group contains subcommands for inspecting, pruning and
+pre-populating the upstream git repositories that kpt caches when fetching
+packages. Packages whose upstream is in the cache can be fetched, updated and
+diffed without network access with the ` + "`" + `--offline` + "`" + ` flag.
+`
+
+var ListShort = `List the upstream repositories in the kpt cache.`
+var ListLong = `
+  kpt pkg cache list
+
+Env Vars:
+
+  KPT_CACHE_DIR:
+    Controls where to cache remote packages when fetching them.
+    Defaults to <HOME>/.kpt/repos/
+    On macOS and Linux <HOME> is determined by the $HOME env variable, while on
+    Windows it is given by the %USERPROFILE% env variable.
+`
+var ListExamples = `
+  # List the cached upstream repositories.
+  $ kpt pkg cache list
+`
+
+var PruneShort = `Remove upstream repositories from the kpt cache.`
+var PruneLong = `
+  kpt pkg cache prune [flags]
+
+Flags:
+
+  --max-age:
+    Remove the repositories that haven't been used for longer than the duration,
+    e.g. 720h.
+  
+  --max-size:
+    Remove the least recently used repositories until the cache uses at most the
+    given amount of disk space, e.g. 500Mi or 2Gi.
+  
+  --all:
+    Remove every repository from the cache.
+  
+  --dry-run:
+    Print the repositories that would be removed without removing them.
+
+One of ` + "`" + `--max-age` + "`" + `, ` + "`" + `--max-size` + "`" + ` or ` + "`" + `--all` + "`" + ` must be set. If both ` + "`" + `--max-age` + "`" + `
+and ` + "`" + `--max-size` + "`" + ` are set, repositories are removed if they match either.
+
+Env Vars:
+
+  KPT_CACHE_DIR:
+    Controls where to cache remote packages when fetching them.
+    Defaults to <HOME>/.kpt/repos/
+    On macOS and Linux <HOME> is determined by the $HOME env variable, while on
+    Windows it is given by the %USERPROFILE% env variable.
+`
+var PruneExamples = `
+  # Remove the repositories that haven't been used for 30 days.
+  $ kpt pkg cache prune --max-age 720h
+
+  # Limit the cache to 2GiB, removing the least recently used repositories first.
+  $ kpt pkg cache prune --max-size 2Gi
+`
+
+var WarmShort = `Fetch the upstream repositories of packages to the kpt cache.`
+var WarmLong = `
+  kpt pkg cache warm PATH...
+
+Args:
+
+  PATH:
+    A Kptfile, or a directory with packages. Every package in a directory,
+    including nested subpackages, is included.
+
+Env Vars:
+
+  KPT_CACHE_DIR:
+    Controls where to cache remote packages when fetching them.
+    Defaults to <HOME>/.kpt/repos/
+    On macOS and Linux <HOME> is determined by the $HOME env variable, while on
+    Windows it is given by the %USERPROFILE% env variable.
+`
+var WarmExamples = `
+  # Cache the upstreams of the packages in the current directory, and update
+  # them later without network access.
+  $ kpt pkg cache warm .
+  $ kpt pkg update --offline
+
+  # Cache the upstreams of a list of Kptfiles.
+  $ kpt pkg cache warm wordpress/Kptfile mysql/Kptfile
+`
+
 var CatShort = `Print the resources in a file/directory`
 var CatLong = `
   kpt pkg cat [FILE | DIR]
@@ -68,6 +160,24 @@ Flags:
   
     # Show changes using the diff command with recursive options.
     kpt pkg diff @master --diff-tool meld --diff-tool-opts "-r"
+  
+  --output:
+    The output format of the changes (text by default). Following formats are
+    supported:
+  
+    text: Shows the changes using the command line diffing tool.
+    json: Shows the added, removed and modified resources, with the paths and
+          old and new values of the changed fields, as JSON. Resources are
+          identified the same way as by 'kpt pkg update'.
+    krm: Same as json, but as a KRM resource of kind PackageDiff in YAML.
+  
+    # Show the resources changed in upstream since the local package was fetched.
+    kpt pkg diff @master --diff-type remote --output json
+  
+  --offline:
+    Only use the upstream repositories in the kpt cache, without fetching from
+    the network. Refs are resolved from the branches and tags that have been
+    fetched before. See 'kpt pkg cache warm' for populating the cache.
 
 Environment Variables:
 
@@ -88,16 +198,25 @@ Environment Variables:
     Defaults to <HOME>/.kpt/repos/
     On macOS and Linux <HOME> is determined by the $HOME env variable, while on
     Windows it is given by the %USERPROFILE% env variable.
+  
+  KPT_GIT_CLIENT:
+    Controls how remote packages are fetched. Set to 'exec' to use the git
+    binary, or to 'go-git' to fetch them without git being installed.
+    Defaults to 'exec' if git is on the PATH, and 'go-git' otherwise.
 `
 var DiffExamples = `
 
   # Show changes in current package relative to upstream source package.
   $ kpt pkg diff
+
+  # Show the resources changed in the current package relative to upstream source
+  # package as JSON.
+  $ kpt pkg diff --output json
 `
 
 var GetShort = `Fetch a package from a git repo.`
 var GetLong = `
-  kpt pkg get REPO_URI[.git]/PKG_PATH[@VERSION] [LOCAL_DEST_DIRECTORY] [flags]
+  kpt pkg get {REPO_URI[.git]/PKG_PATH[@VERSION] | DIRECTORY | TARBALL} [LOCAL_DEST_DIRECTORY] [flags]
 
 Args:
 
@@ -118,6 +237,24 @@ Args:
   VERSION:
     A git tag, branch, ref or commit for the remote version of the package
     to fetch. Defaults to the default branch of the repository.
+    It can also be a semantic version range, like ~1.4, ^2 or 1.x, which
+    fetches the highest matching tag. The range is kept in the Kptfile, so
+    updates follow the newest matching tag. A branch or tag with the same
+    name as the range, like a 1.x branch, takes precedence.
+  
+  DIRECTORY:
+    A local directory with a Kptfile to copy the package from. The path is
+    recorded in the Kptfile relative to the fetched package.
+  
+  TARBALL:
+    An http(s) URL or a local path of a .tar, .tar.gz or .tgz file to extract
+    the package from. If the tarball has a single top-level directory, its
+    content is the package. Local paths are recorded in the Kptfile relative
+    to the fetched package.
+  
+    The content of packages fetched from a directory or tarball is stored in
+    the kpt cache, so 'kpt pkg update' can merge changes to the directory or a
+    new tarball into the local package.
   
   LOCAL_DEST_DIRECTORY:
     The local directory to write the package to. Defaults to a subdirectory of the
@@ -140,6 +277,30 @@ Flags:
     (Experimental) indicates if the fetched package is a deployable instance that
     will be deployed to a cluster.
     It is ` + "`" + `false` + "`" + ` by default.
+  
+  --offline:
+    Only use the upstream repositories in the kpt cache, without fetching from
+    the network. Refs are resolved from the branches and tags that have been
+    fetched before. See 'kpt pkg cache warm' for populating the cache.
+  
+  --verification-policy:
+    Path to an UpstreamVerificationPolicy file. When set, the upstream tag or
+    commit must be signed with one of the trusted GPG or SSH keys in the policy,
+    or the package is not fetched. The verified signature is recorded in the
+    upstreamLock of the Kptfile. Packages from directory and tarball upstreams
+    can't be verified, so they are rejected. Defaults to $KPT_VERIFICATION_POLICY.
+  
+      apiVersion: kpt.dev/v1alpha1
+      kind: UpstreamVerificationPolicy
+      # One of tag, commit or tagOrCommit. Defaults to tagOrCommit.
+      require: tag
+      trustedKeys:
+      - name: release-team
+        gpg: |
+          -----BEGIN PGP PUBLIC KEY BLOCK-----
+          ...
+      - name: ci
+        ssh: ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAA... ci@example.com
 
 Env Vars:
 
@@ -148,6 +309,15 @@ Env Vars:
     Defaults to <HOME>/.kpt/repos/
     On macOS and Linux <HOME> is determined by the $HOME env variable, while on
     Windows it is given by the %USERPROFILE% env variable.
+  
+  KPT_GIT_CLIENT:
+    Controls how remote packages are fetched. Set to 'exec' to use the git
+    binary, or to 'go-git' to fetch them without git being installed.
+    Defaults to 'exec' if git is on the PATH, and 'go-git' otherwise.
+  
+  KPT_VERIFICATION_POLICY:
+    Path to the UpstreamVerificationPolicy used when --verification-policy is
+    not provided.
 `
 var GetExamples = `
 
@@ -171,6 +341,49 @@ var GetExamples = `
   # Create a deployable instance of examples package from github.com/kubernetes/examples
   # This will create a new directory 'examples' for the package.
   $ kpt pkg get https://github.com/kubernetes/examples.git/@6fe2792 --for-deployment
+
+  # Fetch the highest v0.x release of package wordpress, and follow new
+  # v0.x releases when the package is updated.
+  $ kpt pkg get https://github.com/GoogleContainerTools/kpt.git/package-examples/wordpress@^0.9
+
+  # Fetch a package from a local directory, to try out local changes to a
+  # blueprint before publishing them.
+  $ kpt pkg get ../blueprints/wordpress
+
+  # Fetch a package from a tarball.
+  $ kpt pkg get https://example.com/packages/wordpress-v1.tgz wordpress
+`
+
+var InfoShort = `Report the metadata and origin of packages.`
+var InfoLong = `
+  kpt pkg info [DIR] [flags]
+
+Args:
+
+  DIR:
+    Directory with the packages to report. Defaults to the current working
+    directory.
+
+Flags:
+
+  --output:
+    Output format of the report. Supported values:
+  
+      * text: Print a table with a row for each package. This is the default.
+      * json: Print a JSON list with an object for each package, for use in
+        automation.
+      * spdx: Print an SPDX 2.3 document in JSON, with a package for every kpt
+        package and function image.
+`
+var InfoExamples = `
+  # Report the packages in the current directory.
+  $ kpt pkg info
+
+  # Write an SPDX document for the packages under my-packages/.
+  $ kpt pkg info my-packages/ --output spdx > my-packages.spdx.json
+
+  # List the licenses of the packages.
+  $ kpt pkg info --output json | jq -r '.[] | "\(.package) \(.info.license)"'
 `
 
 var InitShort = `Initialize an empty package.`
@@ -203,6 +416,47 @@ var InitExamples = `
   $ kpt pkg init
 `
 
+var OutdatedShort = `Report the packages that are behind their upstream.`
+var OutdatedLong = `
+  kpt pkg outdated [DIR] [flags]
+
+Args:
+
+  DIR:
+    Directory with the packages to check. Defaults to the current working
+    directory.
+
+Flags:
+
+  --output:
+    Output format of the report. Supported values:
+  
+      * text: Print a table with a row for each package. This is the default.
+      * json: Print a JSON list with an object for each package, for use in
+        automation.
+
+Env Vars:
+
+  KPT_CACHE_DIR:
+    Controls where to cache remote packages when fetching them.
+    Defaults to <HOME>/.kpt/repos/
+    On macOS and Linux <HOME> is determined by the $HOME env variable, while on
+    Windows it is given by the %USERPROFILE% env variable.
+  
+  KPT_GIT_CLIENT:
+    Controls how remote packages are fetched. Set to 'exec' to use the git
+    binary, or to 'go-git' to fetch them without git being installed.
+    Defaults to 'exec' if git is on the PATH, and 'go-git' otherwise.
+`
+var OutdatedExamples = `
+  # Report the packages in the current directory that are behind their upstream.
+  $ kpt pkg outdated
+
+  # List the packages under my-packages/ that are not at the latest version of
+  # their upstream ref.
+  $ kpt pkg outdated my-packages/ --output json | jq -r '.[] | select(.upToDate | not) | .package'
+`
+
 var TreeShort = `Display resources, files and packages in a tree structure.`
 var TreeLong = `
   kpt pkg tree [DIR]
@@ -226,14 +480,50 @@ Args:
     A git tag, branch, ref or commit. Specified after the local_package
     with @ -- pkg@version.
     Defaults the ref specified in the Upstream section of the package Kptfile.
+    A version can only be given for packages fetched from git. Packages fetched
+    from a directory are updated to the current content of the directory, and
+    packages fetched from a tarball to the content of the tarball in the
+    Kptfile, which can be changed to update to a new tarball.
   
     Version types:
       * branch: update the local contents to the tip of the remote branch
       * tag: update the local contents to the remote tag
       * commit: update the local contents to the remote commit
+      * semantic version range, like ~1.4, ^2 or 1.x: update the local contents
+        to the highest matching tag
 
 Flags:
 
+  --dry-run:
+    Perform the update on a temporary copy of the package and print the changes
+    it would make to the local package, as well as the fields that were changed
+    differently in the local package and upstream. The local package is not
+    modified.
+  
+  --on-conflict:
+    Defines how conflicts are handled with the resource-merge strategy. A
+    conflict is a field that was changed to different values in the local
+    package and in upstream, or a resource that was deleted on one side and
+    modified on the other. Conflicts are reported after the update.
+  
+      * upstream: Use the upstream value of conflicting fields. This is the
+        default.
+      * stop: Do not update the package if there are conflicts, and write them
+        with conflict markers to the .kpt-update-conflicts file in the package.
+        Setting the resolution of a conflict in that file to local or upstream
+        and running the update again uses the chosen value.
+      * prompt: Ask whether to use the local or the upstream value of each
+        conflicting field.
+  
+  --schema:
+    Path to a file or directory with CRDs or OpenAPI documents describing the
+    schemas of custom resources. They are used by the resource-merge strategy to
+    merge lists of custom resources by their keys. Can be repeated.
+  
+  --schema-from-cluster:
+    Use the OpenAPI schemas of the cluster in the current kubeconfig context to
+    merge lists of custom resources. Schemas from --schema take precedence.
+  
   --strategy:
     Defines which strategy should be used to update the package. This will change
     the update strategy for the current kpt package for the current and future
@@ -246,6 +536,30 @@ Flags:
         since it was fetched.
       * force-delete-replace: Wipe all the local changes to the package and replace
         it with the remote version.
+  
+  --offline:
+    Only use the upstream repositories in the kpt cache, without fetching from
+    the network. Refs are resolved from the branches and tags that have been
+    fetched before. See 'kpt pkg cache warm' for populating the cache.
+  
+  --verification-policy:
+    Path to an UpstreamVerificationPolicy file. When set, the upstream tag or
+    commit must be signed with one of the trusted GPG or SSH keys in the policy,
+    or the package is not updated. The verified signature is recorded in the
+    upstreamLock of the Kptfile. Packages from directory and tarball upstreams
+    can't be verified, so they are rejected. Defaults to $KPT_VERIFICATION_POLICY.
+  
+      apiVersion: kpt.dev/v1alpha1
+      kind: UpstreamVerificationPolicy
+      # One of tag, commit or tagOrCommit. Defaults to tagOrCommit.
+      require: tag
+      trustedKeys:
+      - name: release-team
+        gpg: |
+          -----BEGIN PGP PUBLIC KEY BLOCK-----
+          ...
+      - name: ci
+        ssh: ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAA... ci@example.com
 
 Env Vars:
 
@@ -254,6 +568,15 @@ Env Vars:
     Defaults to <HOME>/.kpt/repos/
     On macOS and Linux <HOME> is determined by the $HOME env variable, while on
     Windows it is given by the %USERPROFILE% env variable.
+  
+  KPT_GIT_CLIENT:
+    Controls how remote packages are fetched. Set to 'exec' to use the git
+    binary, or to 'go-git' to fetch them without git being installed.
+    Defaults to 'exec' if git is on the PATH, and 'go-git' otherwise.
+  
+  KPT_VERIFICATION_POLICY:
+    Path to the UpstreamVerificationPolicy used when --verification-policy is
+    not provided.
 `
 var UpdateExamples = `
   # Update package in the current directory.
@@ -267,4 +590,12 @@ var UpdateExamples = `
   # Update with the fast-forward strategy.
   # git add . && git commit -m "some message"
   $ kpt pkg update my-package-dir/@master --strategy fast-forward
+
+  # Choose the value to use for each field changed both locally and in
+  # upstream.
+  $ kpt pkg update my-package-dir/@v1.4 --on-conflict prompt
+
+  # Preview the changes and conflicts of updating my-package-dir/ to v1.4
+  # without modifying it.
+  $ kpt pkg update my-package-dir/@v1.4 --dry-run
 `
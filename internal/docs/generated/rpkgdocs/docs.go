@@ -40,7 +40,9 @@ Args:
         blueprint-e982b2196b35a4f5e81e92f49a430fe463aa9f1a
   
   TARGET_PACKAGE_NAME:
-    The name of the new package.
+    The name of the new package. If --subpackage is set, the name of an
+    existing draft package revision into which the source package will
+    be cloned.
   
 
 Flags:
@@ -66,6 +68,12 @@ Flags:
     Update strategy that should be used when updating the new
     package revision. Must be one of: resource-merge, fast-forward,  or 
     force-delete-replace. The default value is resource-merge.
+  
+  --subpackage
+    Directory within the existing draft package revision
+    TARGET_PACKAGE_NAME into which the source package will be cloned. The
+    subpackage keeps its own reference to the source that can be used to
+    pull in updates.
 `
 var CloneExamples = `
   # clone the blueprint-e982b2196b35a4f5e81e92f49a430fe463aa9f1a package and create a new package revision called
@@ -75,6 +83,10 @@ var CloneExamples = `
   # clone the git repository at https://github.com/repo/blueprint.git at reference base/v0 and in directory base. The new
   # package revision will be created in repository blueprint and namespace default.
   $ kpt alpha rpkg clone https://github.com/repo/blueprint.git bar --repository=blueprint --ref=base/v0 --namespace=default --directory=base
+
+  # clone the git repository at https://github.com/repo/blueprint.git in directory redis into the subpackage
+  # redis of the existing draft package revision blueprint-0f1c7e9a6a3b5d2c8e4f6a1b3c5d7e9f0a2b4c6d.
+  $ kpt alpha rpkg clone https://github.com/repo/blueprint.git blueprint-0f1c7e9a6a3b5d2c8e4f6a1b3c5d7e9f0a2b4c6d --directory=redis --subpackage=redis
 `
 
 var CopyShort = `Create a new package revision from an existing one.`
@@ -0,0 +1,254 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gitutil
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/GoogleContainerTools/kpt/internal/errors"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+type offlineKey struct{}
+
+// ContextWithOffline returns a new context where upstream repos are only
+// read from the kpt cache, and never fetched from the network.
+func ContextWithOffline(ctx context.Context, offline bool) context.Context {
+	return context.WithValue(ctx, offlineKey{}, offline)
+}
+
+// OfflineFromContext returns true if upstream repos must only be read from
+// the kpt cache.
+func OfflineFromContext(ctx context.Context) bool {
+	offline, _ := ctx.Value(offlineKey{}).(bool)
+	return offline
+}
+
+// RepoCacheDir returns the directory where upstream repos are cached. It is
+// given by KPT_CACHE_DIR and defaults to UserHomeDir/.kpt/repos.
+func RepoCacheDir() (string, error) {
+	const op errors.Op = "gitutil.RepoCacheDir"
+	dir := os.Getenv(RepoCacheDirEnv)
+	if dir != "" {
+		return dir, nil
+	}
+
+	// cache location unspecified, use UserHomeDir/.kpt/repos
+	dir, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.E(op, errors.IO, fmt.Errorf(
+			"error looking up user home dir: %w", err))
+	}
+	return filepath.Join(dir, ".kpt", "repos"), nil
+}
+
+// CachedRepo is an upstream repo, or a snapshot of a directory or tarball
+// upstream, in the kpt cache.
+type CachedRepo struct {
+	// Dir is the path to the cache repo.
+	Dir string `json:"dir" yaml:"dir"`
+
+	// URI is the upstream repo, or the content hash for snapshots of
+	// directory and tarball upstreams.
+	URI string `json:"uri" yaml:"uri"`
+
+	// Size is the disk usage of the cache repo in bytes.
+	Size int64 `json:"size" yaml:"size"`
+
+	// LastUsed is the last time a package was fetched from the cache repo.
+	LastUsed time.Time `json:"lastUsed" yaml:"lastUsed"`
+}
+
+// ListCachedRepos returns the upstream repos in the kpt cache, with the most
+// recently used first. Directories that aren't git repos are ignored.
+func ListCachedRepos() ([]CachedRepo, error) {
+	const op errors.Op = "gitutil.ListCachedRepos"
+	cacheDir, err := RepoCacheDir()
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+	entries, err := ioutil.ReadDir(cacheDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.E(op, errors.IO, err)
+	}
+
+	var repos []CachedRepo
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		dir := filepath.Join(cacheDir, e.Name())
+		repo, err := gogit.PlainOpen(dir)
+		if err != nil {
+			continue
+		}
+		var uri string
+		if remote, err := repo.Remote(gogit.DefaultRemoteName); err == nil && len(remote.Config().URLs) > 0 {
+			uri = remote.Config().URLs[0]
+		}
+		size, err := dirSize(dir)
+		if err != nil {
+			return nil, errors.E(op, errors.IO, err)
+		}
+		repos = append(repos, CachedRepo{
+			Dir:      dir,
+			URI:      uri,
+			Size:     size,
+			LastUsed: e.ModTime(),
+		})
+	}
+	sort.SliceStable(repos, func(i, j int) bool {
+		return repos[i].LastUsed.After(repos[j].LastUsed)
+	})
+	return repos, nil
+}
+
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+// markUsed updates the modification time of the cache repo, which is used as
+// the time it was last used when pruning the cache.
+func markUsed(repoCacheDir string) {
+	now := time.Now()
+	_ = os.Chtimes(repoCacheDir, now, now)
+}
+
+// openCacheRepo opens the cache repo for the uri. It returns an error if the
+// repo hasn't been cached.
+func (gur *GitUpstreamRepo) openCacheRepo() (*gogit.Repository, string, error) {
+	kptCacheDir, err := gur.getRepoCacheDir()
+	if err != nil {
+		return nil, "", err
+	}
+	repoCacheDir := filepath.Join(kptCacheDir, gur.getRepoDir(gur.URI))
+	repo, err := gogit.PlainOpen(repoCacheDir)
+	if err != nil {
+		return nil, "", fmt.Errorf("repo %q is not in the kpt cache", gur.URI)
+	}
+	return repo, repoCacheDir, nil
+}
+
+// updateRefsFromCache reads the branches and tags from the cache repo rather
+// than listing them from the upstream repo. Only the refs that have been
+// fetched before are known.
+func (gur *GitUpstreamRepo) updateRefsFromCache() error {
+	const op errors.Op = "gitutil.updateRefsFromCache"
+	repo, _, err := gur.openCacheRepo()
+	if err != nil {
+		return errors.E(op, errors.Repo(gur.URI), err)
+	}
+	refs, err := repo.References()
+	if err != nil {
+		return errors.E(op, errors.Repo(gur.URI), errors.Git, err)
+	}
+	defer refs.Close()
+
+	heads := make(map[string]string)
+	tags := make(map[string]string)
+	remotePrefix := "refs/remotes/" + gogit.DefaultRemoteName + "/"
+	_ = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() != plumbing.HashReference {
+			return nil
+		}
+		name := ref.Name().String()
+		switch {
+		case strings.HasPrefix(name, remotePrefix):
+			heads[strings.TrimPrefix(name, remotePrefix)] = ref.Hash().String()
+		case ref.Name().IsTag():
+			tags[strings.TrimPrefix(name, "refs/tags/")] = ref.Hash().String()
+		}
+		return nil
+	})
+	gur.Heads = heads
+	gur.Tags = tags
+	return nil
+}
+
+// getRepoFromCache verifies that the refs have been fetched to the cache
+// repo before, and returns the path to the cache repo.
+func (gur *GitUpstreamRepo) getRepoFromCache(refs []string) (string, error) {
+	const op errors.Op = "gitutil.getRepoFromCache"
+	repo, repoCacheDir, err := gur.openCacheRepo()
+	if err != nil {
+		return "", errors.E(op, errors.Repo(gur.URI), err)
+	}
+	for _, ref := range refs {
+		s := ref
+		if commit, found := gur.ResolveRef(ref); found {
+			s = commit
+		}
+		if _, err := ResolveCommit(repo, s); err != nil {
+			return "", errors.E(op, errors.Repo(gur.URI), errors.Git,
+				fmt.Errorf("ref %q is not in the kpt cache", ref))
+		}
+		gur.fetchedRefs[ref] = true
+	}
+	markUsed(repoCacheDir)
+	return repoCacheDir, nil
+}
+
+// getDefaultBranchFromCache returns the default branch recorded in the cache
+// repo the last time it was looked up from the upstream repo.
+func (gur *GitUpstreamRepo) getDefaultBranchFromCache() (string, error) {
+	const op errors.Op = "gitutil.getDefaultBranchFromCache"
+	repo, _, err := gur.openCacheRepo()
+	if err != nil {
+		return "", errors.E(op, errors.Repo(gur.URI), err)
+	}
+	ref, err := repo.Storer.Reference(remoteHEAD())
+	if err != nil || ref.Type() != plumbing.SymbolicReference {
+		return "", errors.E(op, errors.Repo(gur.URI),
+			fmt.Errorf("unable to detect default branch in repo from the kpt cache"))
+	}
+	return strings.TrimPrefix(ref.Target().String(), "refs/remotes/"+gogit.DefaultRemoteName+"/"), nil
+}
+
+// recordDefaultBranch stores the default branch of the upstream repo in the
+// cache repo, so it can be used in offline mode. Nothing is recorded if the
+// repo hasn't been cached.
+func (gur *GitUpstreamRepo) recordDefaultBranch(branch string) {
+	repo, _, err := gur.openCacheRepo()
+	if err != nil {
+		return
+	}
+	_ = repo.Storer.SetReference(plumbing.NewSymbolicReference(remoteHEAD(),
+		plumbing.NewRemoteReferenceName(gogit.DefaultRemoteName, branch)))
+}
+
+func remoteHEAD() plumbing.ReferenceName {
+	return plumbing.NewRemoteReferenceName(gogit.DefaultRemoteName, plumbing.HEAD.String())
+}
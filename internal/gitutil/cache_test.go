@@ -0,0 +1,109 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gitutil_test
+
+import (
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/gitutil"
+	"github.com/GoogleContainerTools/kpt/internal/printer/fake"
+	"github.com/GoogleContainerTools/kpt/internal/testutil"
+	"github.com/GoogleContainerTools/kpt/internal/testutil/pkgbuilder"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGitUpstreamRepo_offline(t *testing.T) {
+	for _, client := range []string{ExecGitClient, GoGitClient} {
+		t.Run(client, func(t *testing.T) {
+			t.Setenv(GitClientEnv, client)
+			t.Setenv(RepoCacheDirEnv, t.TempDir())
+
+			repoContent := map[string][]testutil.Content{
+				testutil.Upstream: {
+					{
+						Pkg: pkgbuilder.NewRootPkg().
+							WithResource(pkgbuilder.DeploymentResource),
+						Branch: "main",
+						Tag:    "v1",
+					},
+					{
+						Pkg: pkgbuilder.NewRootPkg().
+							WithResource(pkgbuilder.ConfigMapResource),
+						Branch: "main",
+						Tag:    "v2",
+					},
+				},
+			}
+			g, _, clean := testutil.SetupReposAndWorkspace(t, repoContent)
+			defer clean()
+			if !assert.NoError(t, testutil.UpdateRepos(t, g, repoContent)) {
+				t.FailNow()
+			}
+			uri := g[testutil.Upstream].RepoDirectory
+
+			offlineCtx := ContextWithOffline(fake.CtxWithDefaultPrinter(), true)
+			_, err := NewGitUpstreamRepo(offlineCtx, uri)
+			if !assert.Error(t, err) {
+				t.FailNow()
+			}
+			assert.Contains(t, err.Error(), "is not in the kpt cache")
+
+			// Populate the cache.
+			gur, err := NewGitUpstreamRepo(fake.CtxWithDefaultPrinter(), uri)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			_, err = gur.GetRepo(fake.CtxWithDefaultPrinter(), []string{"v1", "main"})
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			_, err = gur.GetDefaultBranch(fake.CtxWithDefaultPrinter())
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+
+			gur, err = NewGitUpstreamRepo(offlineCtx, uri)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.EqualValues(t, []string{"main"}, toKeys(gur.Heads))
+			assert.EqualValues(t, []string{"v1"}, toKeys(gur.Tags))
+
+			branch, err := gur.GetDefaultBranch(offlineCtx)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, "main", branch)
+
+			_, err = gur.GetRepo(offlineCtx, []string{"v1", "main"})
+			assert.NoError(t, err)
+
+			_, err = gur.GetRepo(offlineCtx, []string{"v2"})
+			if !assert.Error(t, err) {
+				t.FailNow()
+			}
+			assert.Contains(t, err.Error(), `ref "v2" is not in the kpt cache`)
+
+			repos, err := ListCachedRepos()
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			if assert.Len(t, repos, 1) {
+				assert.Equal(t, uri, repos[0].URI)
+				assert.Greater(t, repos[0].Size, int64(0))
+			}
+		})
+	}
+}
@@ -26,11 +26,13 @@ import (
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/GoogleContainerTools/kpt/internal/errors"
 	"github.com/GoogleContainerTools/kpt/internal/printer"
+	"github.com/Masterminds/semver/v3"
 )
 
 // RepoCacheDirEnv is the name of the environment variable that controls the cache directory
@@ -149,7 +151,9 @@ func WithFetchedRefs(a map[string]bool) NewGitUpstreamRepoOption {
 func NewGitUpstreamRepo(ctx context.Context, uri string, opts ...NewGitUpstreamRepoOption) (*GitUpstreamRepo, error) {
 	const op errors.Op = "gitutil.NewGitUpstreamRepo"
 	g := &GitUpstreamRepo{
-		URI: uri,
+		URI:     uri,
+		goGit:   UseGoGit(),
+		offline: OfflineFromContext(ctx),
 	}
 	for _, opt := range opts {
 		opt(g)
@@ -177,6 +181,13 @@ type GitUpstreamRepo struct {
 
 	// fetchedRefs keeps track of refs already fetched from remote
 	fetchedRefs map[string]bool
+
+	// goGit is true if the repo is fetched with go-git rather than the
+	// git binary.
+	goGit bool
+
+	// offline is true if the repo must only be read from the kpt cache.
+	offline bool
 }
 
 func (gur *GitUpstreamRepo) GetFetchedRefs() []string {
@@ -192,6 +203,12 @@ func (gur *GitUpstreamRepo) GetFetchedRefs() []string {
 // download any objects, only refs.
 func (gur *GitUpstreamRepo) updateRefs(ctx context.Context) error {
 	const op errors.Op = "gitutil.updateRefs"
+	if gur.offline {
+		return gur.updateRefsFromCache()
+	}
+	if gur.goGit {
+		return gur.updateRefsGoGit(ctx)
+	}
 	repoCacheDir, err := gur.cacheRepo(ctx, gur.URI, []string{}, []string{})
 	if err != nil {
 		return errors.E(op, errors.Repo(gur.URI), err)
@@ -244,16 +261,46 @@ func (gur *GitUpstreamRepo) updateRefs(ctx context.Context) error {
 // directory.
 func (gur *GitUpstreamRepo) GetRepo(ctx context.Context, refs []string) (string, error) {
 	const op errors.Op = "gitutil.GetRepo"
-	dir, err := gur.cacheRepo(ctx, gur.URI, refs, []string{})
+	if gur.offline {
+		return gur.getRepoFromCache(refs)
+	}
+	var dir string
+	var err error
+	if gur.goGit {
+		dir, err = gur.cacheRepoGoGit(ctx, gur.URI, refs)
+	} else {
+		dir, err = gur.cacheRepo(ctx, gur.URI, refs, []string{})
+	}
 	if err != nil {
 		return "", errors.E(op, errors.Repo(gur.URI), err)
 	}
+	markUsed(dir)
 	return dir, nil
 }
 
 // GetDefaultBranch returns the name of the branch pointed to by the
 // HEAD symref. This is the default branch of the repository.
 func (gur *GitUpstreamRepo) GetDefaultBranch(ctx context.Context) (string, error) {
+	if gur.offline {
+		return gur.getDefaultBranchFromCache()
+	}
+	var branch string
+	var err error
+	if gur.goGit {
+		branch, err = gur.getDefaultBranchGoGit(ctx)
+	} else {
+		branch, err = gur.getDefaultBranchGitExec(ctx)
+	}
+	if err != nil {
+		return "", err
+	}
+	gur.recordDefaultBranch(branch)
+	return branch, nil
+}
+
+// getDefaultBranchGitExec returns the branch the HEAD of the upstream repo
+// points to, using the git binary.
+func (gur *GitUpstreamRepo) getDefaultBranchGitExec(ctx context.Context) (string, error) {
 	const op errors.Op = "gitutil.GetDefaultBranch"
 	cacheRepo, err := gur.cacheRepo(ctx, gur.URI, []string{}, []string{})
 	if err != nil {
@@ -322,6 +369,106 @@ func (gur *GitUpstreamRepo) ResolveRef(ref string) (string, bool) {
 	return gur.ResolveTag(ref)
 }
 
+// IsSemverRange returns true if the ref is a semantic version range, like
+// `~1.4`, `^2` or `1.x`, rather than a branch, tag or commit. Ranges contain
+// operators, or wildcards like `x` in place of the minor or patch version.
+func IsSemverRange(ref string) bool {
+	if !strings.ContainsAny(ref, "~^*<>=|, ") && !hasWildcardSegment(ref) {
+		return false
+	}
+	_, err := semver.NewConstraint(ref)
+	return err == nil
+}
+
+// hasWildcardSegment returns true if the minor or patch version of the ref
+// is an `x` or `X` wildcard, like in `1.x` or `1.2.X`.
+func hasWildcardSegment(ref string) bool {
+	segments := strings.Split(ref, ".")
+	for _, s := range segments[1:] {
+		if s == "x" || s == "X" {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveSemverRange resolves the semantic version range to the highest
+// matching tag in the upstream repo. Only tags with the provided prefix are
+// considered, and the prefix is removed before the rest of the tag is parsed
+// as a version. It returns the full name of the tag and the commit SHA. If no
+// tag matches the range, the last return value will be false.
+func (gur *GitUpstreamRepo) ResolveSemverRange(ref, prefix string) (string, string, bool) {
+	constraint, err := semver.NewConstraint(ref)
+	if err != nil {
+		return "", "", false
+	}
+	var tag string
+	var highest *semver.Version
+	for t := range gur.Tags {
+		if !strings.HasPrefix(t, prefix) {
+			continue
+		}
+		v, err := semver.NewVersion(strings.TrimPrefix(t, prefix))
+		if err != nil || !constraint.Check(v) {
+			continue
+		}
+		// Tags like v1.2 and v1.2.0 are the same version, so we pick the
+		// shortest name to make the result deterministic.
+		if highest == nil || v.GreaterThan(highest) ||
+			(v.Equal(highest) && (len(t) < len(tag) || (len(t) == len(tag) && t < tag))) {
+			highest = v
+			tag = t
+		}
+	}
+	if highest == nil {
+		return "", "", false
+	}
+	return tag, gur.Tags[tag], true
+}
+
+// NewerTags returns the tags in the upstream repo with a higher semantic
+// version than the provided tag, sorted by version. Only tags with the same
+// prefix as the provided tag, e.g. 'java/' for 'java/v1.2.0', are considered.
+// Pre-releases are only included if the provided tag is a pre-release.
+func (gur *GitUpstreamRepo) NewerTags(tag string) []string {
+	if _, found := gur.ResolveTag(tag); !found {
+		return nil
+	}
+	tag = strings.TrimPrefix(tag, "refs/tags/")
+	var prefix string
+	if i := strings.LastIndex(tag, "/"); i >= 0 {
+		prefix = tag[:i+1]
+	}
+	current, err := semver.NewVersion(strings.TrimPrefix(tag, prefix))
+	if err != nil {
+		return nil
+	}
+
+	versions := make(map[string]*semver.Version)
+	var tags []string
+	for t := range gur.Tags {
+		if !strings.HasPrefix(t, prefix) || strings.Contains(strings.TrimPrefix(t, prefix), "/") {
+			continue
+		}
+		v, err := semver.NewVersion(strings.TrimPrefix(t, prefix))
+		if err != nil || !v.GreaterThan(current) {
+			continue
+		}
+		if v.Prerelease() != "" && current.Prerelease() == "" {
+			continue
+		}
+		versions[t] = v
+		tags = append(tags, t)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if versions[tags[i]].Equal(versions[tags[j]]) {
+			return tags[i] < tags[j]
+		}
+		return versions[tags[i]].LessThan(versions[tags[j]])
+	})
+	return tags
+}
+
 // getRepoDir returns the cache directory name for a remote repo
 // This takes the md5 hash of the repo uri and then base32 encodes it to make
 // sure it doesn't contain characters that isn't legal in directory names.
@@ -332,19 +479,11 @@ func (gur *GitUpstreamRepo) getRepoDir(uri string) string {
 // getRepoCacheDir
 func (gur *GitUpstreamRepo) getRepoCacheDir() (string, error) {
 	const op errors.Op = "gitutil.getRepoCacheDir"
-	var err error
-	dir := os.Getenv(RepoCacheDirEnv)
-	if dir != "" {
-		return dir, nil
-	}
-
-	// cache location unspecified, use UserHomeDir/.kpt/repos
-	dir, err = os.UserHomeDir()
+	dir, err := RepoCacheDir()
 	if err != nil {
-		return "", errors.E(op, errors.IO, fmt.Errorf(
-			"error looking up user home dir: %w", err))
+		return "", errors.E(op, err)
 	}
-	return filepath.Join(dir, ".kpt", "repos"), nil
+	return dir, nil
 }
 
 // cacheRepo fetches a remote repo to a cache location, and fetches the provided refs.
@@ -417,6 +556,12 @@ loop:
 				return "", errors.E(op, errors.Git, fmt.Errorf(
 					"error running `git fetch` for ref %q: %w", s, err))
 			}
+			// Fetching a tag doesn't create it in the cache repo, so we
+			// record it to be able to resolve it in offline mode.
+			if commit, found := gur.ResolveTag(s); found {
+				tag := "refs/tags/" + strings.TrimPrefix(s, "refs/tags/")
+				_, _ = gitRunner.Run(ctx, "update-ref", tag, commit)
+			}
 			gur.fetchedRefs[s] = true
 		default:
 			// In other situations (like a short commit sha), we have to do
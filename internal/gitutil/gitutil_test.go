@@ -441,3 +441,90 @@ func toKeys(m map[string]string) []string {
 	sort.Strings(keys)
 	return keys
 }
+
+func TestIsSemverRange(t *testing.T) {
+	testCases := map[string]bool{
+		"main":           false,
+		"v1.4":           false,
+		"v1.4.0":         false,
+		"java/v1":        false,
+		"~1.4":           true,
+		"^2":             true,
+		">=1.2, <2":      true,
+		"1.*":            true,
+		"1.x":            true,
+		"v1.2.X":         true,
+		"x":              false,
+		"release.x.y":    false,
+		"~1.4 || ^2.0.1": true,
+		"~not-a-version": false,
+	}
+	for ref, expected := range testCases {
+		ref, expected := ref, expected
+		t.Run(ref, func(t *testing.T) {
+			assert.Equal(t, expected, IsSemverRange(ref))
+		})
+	}
+}
+
+func TestGitUpstreamRepo_ResolveSemverRange(t *testing.T) {
+	gur := &GitUpstreamRepo{
+		Tags: map[string]string{
+			"v1.3.0":      "a",
+			"v1.4.0":      "b",
+			"v1.4.2":      "c",
+			"v1.5":        "d",
+			"v1.5.0":      "d",
+			"v2.0.0":      "e",
+			"v2.1.0-rc.1": "f",
+			"java/v1.4.7": "g",
+			"latest":      "h",
+		},
+	}
+	testCases := map[string]struct {
+		ref            string
+		prefix         string
+		expectedTag    string
+		expectedCommit string
+		expectedFound  bool
+	}{
+		"tilde range": {
+			ref:            "~1.4",
+			expectedTag:    "v1.4.2",
+			expectedCommit: "c",
+			expectedFound:  true,
+		},
+		"caret range picks the shortest tag name": {
+			ref:            "^1",
+			expectedTag:    "v1.5",
+			expectedCommit: "d",
+			expectedFound:  true,
+		},
+		"pre-releases are skipped": {
+			ref:            ">=2",
+			expectedTag:    "v2.0.0",
+			expectedCommit: "e",
+			expectedFound:  true,
+		},
+		"package-specific tags": {
+			ref:            "~1.4",
+			prefix:         "java/",
+			expectedTag:    "java/v1.4.7",
+			expectedCommit: "g",
+			expectedFound:  true,
+		},
+		"no matching tag": {
+			ref:           "^3",
+			expectedFound: false,
+		},
+	}
+	for tn, tc := range testCases {
+		tc := tc
+		t.Run(tn, func(t *testing.T) {
+			tag, commit, found := gur.ResolveSemverRange(tc.ref, tc.prefix)
+			assert.Equal(t, tc.expectedFound, found)
+			assert.Equal(t, tc.expectedTag, tag)
+			assert.Equal(t, tc.expectedCommit, commit)
+		})
+	}
+}
@@ -0,0 +1,292 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gitutil
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/GoogleContainerTools/kpt/internal/errors"
+	"github.com/GoogleContainerTools/kpt/internal/printer"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// GitClientEnv is the environment variable that selects how kpt fetches
+// upstream repos.
+const GitClientEnv = "KPT_GIT_CLIENT"
+
+const (
+	// ExecGitClient runs the git binary.
+	ExecGitClient = "exec"
+	// GoGitClient uses go-git, which doesn't require git to be installed.
+	GoGitClient = "go-git"
+)
+
+// UseGoGit returns true if upstream repos should be fetched with go-git rather
+// than the git binary. This is the case if KPT_GIT_CLIENT is set to go-git,
+// or if it isn't set and git is not on the PATH.
+func UseGoGit() bool {
+	switch os.Getenv(GitClientEnv) {
+	case GoGitClient:
+		return true
+	case ExecGitClient:
+		return false
+	}
+	_, err := exec.LookPath("git")
+	return err != nil
+}
+
+// UsesGoGit returns true if the upstream repo is fetched with go-git.
+func (gur *GitUpstreamRepo) UsesGoGit() bool {
+	return gur.goGit
+}
+
+// updateRefsGoGit lists the refs of the upstream repo with go-git. Like
+// updateRefs, it doesn't download any objects.
+func (gur *GitUpstreamRepo) updateRefsGoGit(ctx context.Context) error {
+	const op errors.Op = "gitutil.updateRefsGoGit"
+	refs, err := gur.listRemote(ctx)
+	if err != nil {
+		return errors.E(op, errors.Repo(gur.URI), err)
+	}
+
+	heads := make(map[string]string)
+	tags := make(map[string]string)
+	for _, ref := range refs {
+		if ref.Type() != plumbing.HashReference {
+			continue
+		}
+		switch {
+		case ref.Name().IsBranch():
+			heads[ref.Name().Short()] = ref.Hash().String()
+		case ref.Name().IsTag():
+			tags[strings.TrimPrefix(ref.Name().String(), "refs/tags/")] = ref.Hash().String()
+		}
+	}
+	gur.Heads = heads
+	gur.Tags = tags
+	return nil
+}
+
+// getDefaultBranchGoGit returns the branch the HEAD of the upstream repo
+// points to.
+func (gur *GitUpstreamRepo) getDefaultBranchGoGit(ctx context.Context) (string, error) {
+	const op errors.Op = "gitutil.getDefaultBranchGoGit"
+	refs, err := gur.listRemote(ctx)
+	if err != nil {
+		return "", errors.E(op, errors.Repo(gur.URI), err)
+	}
+	for _, ref := range refs {
+		if ref.Name() == plumbing.HEAD && ref.Type() == plumbing.SymbolicReference {
+			return ref.Target().Short(), nil
+		}
+	}
+	return "", errors.E(op, errors.Repo(gur.URI),
+		fmt.Errorf("unable to detect default branch in repo"))
+}
+
+func (gur *GitUpstreamRepo) listRemote(ctx context.Context) ([]*plumbing.Reference, error) {
+	remote := gogit.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: gogit.DefaultRemoteName,
+		URLs: []string{gur.URI},
+	})
+	refs, err := remote.ListContext(ctx, &gogit.ListOptions{})
+	if err != nil {
+		return nil, errors.E(errors.Git, fmt.Errorf("error listing refs: %w", err))
+	}
+	return refs, nil
+}
+
+// cacheRepoGoGit fetches the provided refs with go-git to the cache repo for
+// the uri, and returns the path to the cache repo. Branches and tags are
+// fetched with a depth of one, and so are commits if the upstream repo allows
+// fetching them directly. Otherwise all branches and tags are fetched.
+func (gur *GitUpstreamRepo) cacheRepoGoGit(ctx context.Context, uri string, refs []string) (string, error) {
+	const op errors.Op = "gitutil.cacheRepoGoGit"
+	kptCacheDir, err := gur.getRepoCacheDir()
+	if err != nil {
+		return "", errors.E(op, err)
+	}
+	if err := os.MkdirAll(kptCacheDir, 0700); err != nil {
+		return "", errors.E(op, errors.IO, fmt.Errorf(
+			"error creating cache directory for repo: %w", err))
+	}
+
+	// The cache repo has the same layout as the one created with the git
+	// binary, so the two can be used interchangeably.
+	repoCacheDir := filepath.Join(kptCacheDir, gur.getRepoDir(uri))
+	repo, err := gogit.PlainOpen(repoCacheDir)
+	if err == gogit.ErrRepositoryNotExists {
+		repo, err = gogit.PlainInit(repoCacheDir, false)
+		if err != nil {
+			return "", errors.E(op, errors.Git, fmt.Errorf("error initializing cache repo: %w", err))
+		}
+		_, err = repo.CreateRemote(&config.RemoteConfig{
+			Name: gogit.DefaultRemoteName,
+			URLs: []string{uri},
+		})
+		if err != nil {
+			return "", errors.E(op, errors.Git, fmt.Errorf("error adding origin remote: %w", err))
+		}
+	} else if err != nil {
+		return "", errors.E(op, errors.Git, fmt.Errorf("error opening cache repo: %w", err))
+	}
+
+	pr := printer.FromContextOrDie(ctx)
+	for _, ref := range refs {
+		// check if ref was previously fetched
+		if _, fetched := gur.fetchedRefs[ref]; fetched {
+			continue
+		}
+		s := ref
+
+		var refSpec config.RefSpec
+		if commit, found := gur.ResolveBranch(s); found {
+			refSpec = config.RefSpec(fmt.Sprintf("+refs/heads/%s:refs/remotes/origin/%s",
+				strings.TrimPrefix(s, "refs/heads/"), strings.TrimPrefix(s, "refs/heads/")))
+			s = commit
+		} else if commit, found := gur.ResolveTag(s); found {
+			tag := strings.TrimPrefix(s, "refs/tags/")
+			refSpec = config.RefSpec(fmt.Sprintf("+refs/tags/%s:refs/tags/%s", tag, tag))
+			s = commit
+		} else if plumbing.IsHash(s) {
+			if _, err := repo.Object(plumbing.AnyObject, plumbing.NewHash(s)); err == nil {
+				gur.fetchedRefs[ref] = true
+				continue
+			}
+			refSpec = config.RefSpec(fmt.Sprintf("+%s:refs/kpt/%s", s, s))
+		}
+
+		if refSpec != "" {
+			pr.Printf("Fetching %s from %s\n", refSpec.Src(), uri)
+			err := repo.FetchContext(ctx, &gogit.FetchOptions{
+				RefSpecs: []config.RefSpec{refSpec},
+				Depth:    1,
+				Tags:     gogit.NoTags,
+			})
+			if err == nil || err == gogit.NoErrAlreadyUpToDate {
+				gur.fetchedRefs[ref] = true
+				continue
+			}
+			// Fetching a commit directly isn't supported by every server, so
+			// we fall back to a full fetch for commits.
+			if !refSpec.IsExactSHA1() {
+				return "", errors.E(op, errors.Git, fmt.Errorf(
+					"error fetching ref %q: %w", ref, err))
+			}
+		}
+
+		// In other situations (like a short commit sha), we have to do
+		// a full fetch from the remote.
+		pr.Printf("Fetching all refs from %s\n", uri)
+		err := repo.FetchContext(ctx, &gogit.FetchOptions{
+			RefSpecs: []config.RefSpec{
+				"+refs/heads/*:refs/remotes/origin/*",
+				"+refs/tags/*:refs/tags/*",
+			},
+			Tags: gogit.NoTags,
+		})
+		// go-git reports an empty request rather than being up to date if
+		// every ref is already in the cache repo.
+		if err != nil && err != gogit.NoErrAlreadyUpToDate && err != transport.ErrEmptyUploadPackRequest {
+			return "", errors.E(op, errors.Git, fmt.Errorf(
+				"error fetching origin: %w", err))
+		}
+		if _, err := ResolveCommit(repo, s); err != nil {
+			return "", errors.E(op, errors.Git, fmt.Errorf(
+				"error verifying results from fetch: %w", err))
+		}
+		gur.fetchedRefs[ref] = true
+	}
+	return repoCacheDir, nil
+}
+
+// ResolveCommit returns the commit for the revision in the repo. The revision
+// can be a commit or tag SHA, including short SHAs, or the name of a ref.
+func ResolveCommit(repo *gogit.Repository, revision string) (*object.Commit, error) {
+	hash, err := repo.ResolveRevision(plumbing.Revision(revision))
+	if err != nil {
+		switch {
+		case plumbing.IsHash(revision):
+			h := plumbing.NewHash(revision)
+			hash = &h
+		case isShortHash(revision):
+			if hash, err = resolveShortHash(repo, revision); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unable to resolve %q: %w", revision, err)
+		}
+	}
+	obj, err := repo.Object(plumbing.AnyObject, *hash)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find %q: %w", revision, err)
+	}
+	switch o := obj.(type) {
+	case *object.Commit:
+		return o, nil
+	case *object.Tag:
+		return o.Commit()
+	default:
+		return nil, fmt.Errorf("%q is a %s, not a commit", revision, obj.Type())
+	}
+}
+
+// isShortHash returns true if s looks like an abbreviated object SHA.
+func isShortHash(s string) bool {
+	if len(s) < 4 || len(s) >= 40 {
+		return false
+	}
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// resolveShortHash finds the commit whose SHA starts with the abbreviated
+// SHA. go-git doesn't resolve abbreviated SHAs, so the commits are searched.
+func resolveShortHash(repo *gogit.Repository, short string) (*plumbing.Hash, error) {
+	iter, err := repo.CommitObjects()
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve %q: %w", short, err)
+	}
+	defer iter.Close()
+	var matches []plumbing.Hash
+	_ = iter.ForEach(func(c *object.Commit) error {
+		if strings.HasPrefix(c.Hash.String(), short) {
+			matches = append(matches, c.Hash)
+		}
+		return nil
+	})
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("unable to resolve %q: %w", short, plumbing.ErrReferenceNotFound)
+	case 1:
+		return &matches[0], nil
+	default:
+		return nil, fmt.Errorf("short SHA %q is ambiguous", short)
+	}
+}
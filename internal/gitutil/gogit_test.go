@@ -0,0 +1,110 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gitutil_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/GoogleContainerTools/kpt/internal/gitutil"
+	"github.com/GoogleContainerTools/kpt/internal/printer/fake"
+	"github.com/GoogleContainerTools/kpt/internal/testutil"
+	"github.com/GoogleContainerTools/kpt/internal/testutil/pkgbuilder"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUseGoGit(t *testing.T) {
+	t.Setenv(GitClientEnv, GoGitClient)
+	assert.True(t, UseGoGit())
+
+	t.Setenv(GitClientEnv, ExecGitClient)
+	assert.False(t, UseGoGit())
+}
+
+func TestGitUpstreamRepo_goGit(t *testing.T) {
+	t.Setenv(GitClientEnv, GoGitClient)
+
+	repoContent := map[string][]testutil.Content{
+		testutil.Upstream: {
+			{
+				Pkg: pkgbuilder.NewRootPkg().
+					WithResource(pkgbuilder.DeploymentResource),
+				Branch: "main",
+				Tag:    "v1",
+			},
+			{
+				Pkg: pkgbuilder.NewRootPkg().
+					WithResource(pkgbuilder.ConfigMapResource),
+				Branch:       "foo",
+				CreateBranch: true,
+				Tag:          "abc/123",
+			},
+		},
+	}
+	g, _, clean := testutil.SetupReposAndWorkspace(t, repoContent)
+	defer clean()
+	if !assert.NoError(t, testutil.UpdateRepos(t, g, repoContent)) {
+		t.FailNow()
+	}
+	upstreamPath := g[testutil.Upstream].RepoDirectory
+
+	gur, err := NewGitUpstreamRepo(fake.CtxWithDefaultPrinter(), upstreamPath)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.True(t, gur.UsesGoGit())
+	assert.EqualValues(t, []string{"foo", "main"}, toKeys(gur.Heads))
+	assert.EqualValues(t, []string{"abc/123", "v1"}, toKeys(gur.Tags))
+
+	defaultRef, err := gur.GetDefaultBranch(fake.CtxWithDefaultPrinter())
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, "foo", defaultRef)
+
+	runner, err := NewLocalGitRunner(upstreamPath)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	rr, err := runner.Run(fake.CtxWithDefaultPrinter(), "rev-parse", "v1")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	v1Commit := strings.TrimSpace(rr.Stdout)
+
+	refs := []string{"main", "abc/123", v1Commit, v1Commit[:7]}
+	dir, err := gur.GetRepo(fake.CtxWithDefaultPrinter(), refs)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	repo, err := gogit.PlainOpen(dir)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	for _, r := range refs {
+		sha, found := gur.ResolveRef(r)
+		if !found {
+			// Assume the ref is a commit...
+			sha = r
+		}
+		commit, err := ResolveCommit(repo, sha)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.True(t, strings.HasPrefix(commit.Hash.String(), sha))
+	}
+}
@@ -0,0 +1,281 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cache contains libraries for managing the upstream repos in the
+// kpt cache.
+package cache
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/GoogleContainerTools/kpt/internal/errors"
+	"github.com/GoogleContainerTools/kpt/internal/gitutil"
+	"github.com/GoogleContainerTools/kpt/internal/pkg"
+	"github.com/GoogleContainerTools/kpt/internal/printer"
+	"github.com/GoogleContainerTools/kpt/internal/types"
+	"github.com/GoogleContainerTools/kpt/internal/util/fetch"
+	"github.com/GoogleContainerTools/kpt/internal/util/git"
+	"github.com/GoogleContainerTools/kpt/internal/util/pkgutil"
+	kptfilev1 "github.com/GoogleContainerTools/kpt/pkg/api/kptfile/v1"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+// ListCommand prints the upstream repos and the snapshots of directory and
+// tarball upstreams in the kpt cache.
+type ListCommand struct {
+	// Output is where the list is written.
+	Output io.Writer
+
+	// Repos contains the cached repos after Run.
+	Repos []gitutil.CachedRepo
+}
+
+// Run lists the cached repos, with the most recently used first.
+func (c *ListCommand) Run(_ context.Context) error {
+	const op errors.Op = "cache.List"
+	if c.Output == nil {
+		c.Output = os.Stdout
+	}
+	repos, err := listCacheEntries()
+	if err != nil {
+		return errors.E(op, err)
+	}
+	c.Repos = repos
+
+	w := tabwriter.NewWriter(c.Output, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "REPO\tSIZE\tLAST USED\tDIRECTORY")
+	for _, r := range repos {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.URI, FormatSize(r.Size),
+			r.LastUsed.Format(time.RFC3339), filepath.Base(r.Dir))
+	}
+	return w.Flush()
+}
+
+// listCacheEntries returns the upstream repos and the snapshots of directory
+// and tarball upstreams in the kpt cache, with the most recently used first.
+func listCacheEntries() ([]gitutil.CachedRepo, error) {
+	repos, err := gitutil.ListCachedRepos()
+	if err != nil {
+		return nil, err
+	}
+	snapshots, err := fetch.ListCachedSnapshots()
+	if err != nil {
+		return nil, err
+	}
+	entries := append(repos, snapshots...)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].LastUsed.After(entries[j].LastUsed)
+	})
+	return entries, nil
+}
+
+// PruneCommand removes upstream repos and snapshots from the kpt cache.
+type PruneCommand struct {
+	// MaxAge removes the repos that haven't been used for longer than
+	// MaxAge, if set.
+	MaxAge time.Duration
+
+	// MaxSize removes the least recently used repos until the cache uses at
+	// most MaxSize bytes, if set.
+	MaxSize int64
+
+	// All removes every repo from the cache.
+	All bool
+
+	// DryRun only prints the repos that would be removed.
+	DryRun bool
+
+	// Pruned contains the repos that were removed after Run.
+	Pruned []gitutil.CachedRepo
+}
+
+// Validate makes sure the command is properly configured.
+func (c *PruneCommand) Validate() error {
+	const op errors.Op = "cache.ValidatePrune"
+	if c.MaxAge < 0 || c.MaxSize < 0 {
+		return errors.E(op, errors.InvalidParam,
+			fmt.Errorf("max age and max size must not be negative"))
+	}
+	if !c.All && c.MaxAge == 0 && c.MaxSize == 0 {
+		return errors.E(op, errors.MissingParam,
+			fmt.Errorf("one of max age, max size or all must be set"))
+	}
+	return nil
+}
+
+// Run removes the repos that are too old, and then the least recently used
+// repos until the cache is small enough.
+func (c *PruneCommand) Run(ctx context.Context) error {
+	const op errors.Op = "cache.Prune"
+	if err := c.Validate(); err != nil {
+		return errors.E(op, err)
+	}
+	repos, err := listCacheEntries()
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	var total int64
+	for _, r := range repos {
+		total += r.Size
+	}
+	// The repos are sorted with the most recently used first, so we remove
+	// them from the end.
+	now := time.Now()
+	c.Pruned = nil
+	for i := len(repos) - 1; i >= 0; i-- {
+		r := repos[i]
+		tooOld := c.MaxAge > 0 && now.Sub(r.LastUsed) > c.MaxAge
+		tooBig := c.MaxSize > 0 && total > c.MaxSize
+		if !c.All && !tooOld && !tooBig {
+			continue
+		}
+		c.Pruned = append(c.Pruned, r)
+		total -= r.Size
+	}
+
+	pr := printer.FromContextOrDie(ctx)
+	action := "Removed"
+	if c.DryRun {
+		action = "Would remove"
+	}
+	var freed int64
+	for _, r := range c.Pruned {
+		if !c.DryRun {
+			if err := os.RemoveAll(r.Dir); err != nil {
+				return errors.E(op, errors.IO, types.UniquePath(r.Dir), err)
+			}
+		}
+		freed += r.Size
+		pr.Printf("%s %s (%s)\n", action, r.URI, FormatSize(r.Size))
+	}
+	pr.Printf("%s %d repo(s), freeing %s.\n", action, len(c.Pruned), FormatSize(freed))
+	return nil
+}
+
+// WarmCommand fetches the upstream repos of packages to the kpt cache, so the
+// packages can be fetched and updated offline.
+type WarmCommand struct {
+	// Paths are Kptfiles, or directories with packages. Every package in a
+	// directory, including nested subpackages, is included.
+	Paths []string
+}
+
+// Run fetches the upstream ref and the locked commit of every package to the
+// cache, as well as the default branch of the upstream repos.
+func (c *WarmCommand) Run(ctx context.Context) error {
+	const op errors.Op = "cache.Warm"
+	pr := printer.FromContextOrDie(ctx)
+	repos := make(map[string]*gitutil.GitUpstreamRepo)
+	for _, p := range c.Paths {
+		kptfiles, err := findKptfiles(p)
+		if err != nil {
+			return errors.E(op, types.UniquePath(p), err)
+		}
+		for _, kfPath := range kptfiles {
+			kf, err := pkg.ReadKptfile(filesys.FileSystemOrOnDisk{}, filepath.Dir(kfPath))
+			if err != nil {
+				return errors.E(op, types.UniquePath(kfPath), err)
+			}
+			if kf.Upstream == nil || kf.Upstream.Git == nil {
+				continue
+			}
+			pr.Printf("Caching upstream of %s\n", kfPath)
+			if err := warmPackage(ctx, repos, kf); err != nil {
+				return errors.E(op, types.UniquePath(kfPath), err)
+			}
+		}
+	}
+	pr.Printf("\nCached %d repo(s).\n", len(repos))
+	return nil
+}
+
+// warmPackage fetches the refs of a single package to the cache.
+func warmPackage(ctx context.Context, repos map[string]*gitutil.GitUpstreamRepo, kf *kptfilev1.KptFile) error {
+	const op errors.Op = "cache.warmPackage"
+	g := kf.Upstream.Git
+	repoSpec := &git.RepoSpec{OrgRepo: g.Repo, Path: g.Directory, Ref: g.Ref}
+	upstreamRepo, found := repos[repoSpec.CloneSpec()]
+	if !found {
+		r, err := gitutil.NewGitUpstreamRepo(ctx, repoSpec.CloneSpec())
+		if err != nil {
+			return errors.E(op, errors.Repo(repoSpec.CloneSpec()), err)
+		}
+		// The default branch is used when a package is fetched without a
+		// ref, so we record it in the cache too.
+		if _, err := r.GetDefaultBranch(ctx); err != nil {
+			return errors.E(op, errors.Repo(repoSpec.CloneSpec()), err)
+		}
+		upstreamRepo = r
+		repos[repoSpec.CloneSpec()] = upstreamRepo
+	}
+
+	ref, _, err := fetch.ResolveRef(upstreamRepo, g.Directory, g.Ref)
+	if err != nil {
+		return errors.E(op, errors.Repo(repoSpec.CloneSpec()), err)
+	}
+	refs := []string{ref}
+	if kf.UpstreamLock != nil && kf.UpstreamLock.Git != nil && kf.UpstreamLock.Git.Commit != "" {
+		refs = append(refs, kf.UpstreamLock.Git.Commit)
+	}
+	if _, err := upstreamRepo.GetRepo(ctx, refs); err != nil {
+		return errors.E(op, errors.Repo(repoSpec.CloneSpec()), err)
+	}
+	return nil
+}
+
+// findKptfiles returns the path itself if it is a file, and the Kptfiles of
+// every package in the directory otherwise.
+func findKptfiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	subPkgPaths, err := pkgutil.FindSubpackagesForPaths(pkg.All, true, path)
+	if err != nil {
+		return nil, err
+	}
+	var kptfiles []string
+	for _, p := range append([]string{"."}, subPkgPaths...) {
+		kfPath := filepath.Join(path, p, kptfilev1.KptFileName)
+		if _, err := os.Stat(kfPath); err == nil {
+			kptfiles = append(kptfiles, kfPath)
+		}
+	}
+	return kptfiles, nil
+}
+
+// FormatSize formats a number of bytes using binary units.
+func FormatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%dB", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
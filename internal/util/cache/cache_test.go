@@ -0,0 +1,204 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoogleContainerTools/kpt/internal/gitutil"
+	"github.com/GoogleContainerTools/kpt/internal/printer/fake"
+	"github.com/GoogleContainerTools/kpt/internal/testutil"
+	. "github.com/GoogleContainerTools/kpt/internal/util/cache"
+	kptfilev1 "github.com/GoogleContainerTools/kpt/pkg/api/kptfile/v1"
+	"github.com/GoogleContainerTools/kpt/pkg/kptfile/kptfileutil"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// createCachedRepo creates an empty cache repo for the uri, with a file of
+// the given size, last used the given duration ago.
+func createCachedRepo(t *testing.T, cacheDir, name, uri string, size int, age time.Duration) {
+	dir := filepath.Join(cacheDir, name)
+	repo, err := gogit.PlainInit(dir, false)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	_, err = repo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{uri}})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	if !assert.NoError(t, os.WriteFile(filepath.Join(dir, "data"), make([]byte, size), 0600)) {
+		t.FailNow()
+	}
+	lastUsed := time.Now().Add(-age)
+	if !assert.NoError(t, os.Chtimes(dir, lastUsed, lastUsed)) {
+		t.FailNow()
+	}
+}
+
+func TestPruneCommand(t *testing.T) {
+	testCases := map[string]struct {
+		command  PruneCommand
+		expected []string
+	}{
+		"max age": {
+			command:  PruneCommand{MaxAge: 36 * time.Hour},
+			expected: []string{"old"},
+		},
+		"max size": {
+			command:  PruneCommand{MaxSize: 150 * 1024},
+			expected: []string{"old", "recent"},
+		},
+		"max age and max size": {
+			command:  PruneCommand{MaxAge: 36 * time.Hour, MaxSize: 250 * 1024},
+			expected: []string{"old"},
+		},
+		"all": {
+			command:  PruneCommand{All: true},
+			expected: []string{"old", "recent", "new"},
+		},
+		"dry run": {
+			command:  PruneCommand{MaxAge: time.Hour, DryRun: true},
+			expected: []string{"old", "recent"},
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			cacheDir := t.TempDir()
+			t.Setenv(gitutil.RepoCacheDirEnv, cacheDir)
+			createCachedRepo(t, cacheDir, "new", "new", 100*1024, 0)
+			createCachedRepo(t, cacheDir, "recent", "recent", 100*1024, 24*time.Hour)
+			createCachedRepo(t, cacheDir, "old", "old", 10*1024, 48*time.Hour)
+
+			if !assert.NoError(t, tc.command.Run(fake.CtxWithDefaultPrinter())) {
+				t.FailNow()
+			}
+			var pruned []string
+			for _, r := range tc.command.Pruned {
+				pruned = append(pruned, r.URI)
+			}
+			assert.Equal(t, tc.expected, pruned)
+
+			for _, r := range tc.command.Pruned {
+				_, err := os.Stat(r.Dir)
+				assert.Equal(t, tc.command.DryRun, err == nil)
+			}
+		})
+	}
+}
+
+func TestPruneCommand_snapshots(t *testing.T) {
+	cacheDir := t.TempDir()
+	t.Setenv(gitutil.RepoCacheDirEnv, cacheDir)
+	createCachedRepo(t, cacheDir, "new", "new", 10*1024, 0)
+	hash := strings.Repeat("ab", 32)
+	snapshotDir := filepath.Join(cacheDir, "snapshots", hash)
+	if !assert.NoError(t, os.MkdirAll(snapshotDir, 0700)) {
+		t.FailNow()
+	}
+	if !assert.NoError(t, os.WriteFile(filepath.Join(snapshotDir, "Kptfile"), make([]byte, 1024), 0600)) {
+		t.FailNow()
+	}
+	lastUsed := time.Now().Add(-48 * time.Hour)
+	if !assert.NoError(t, os.Chtimes(snapshotDir, lastUsed, lastUsed)) {
+		t.FailNow()
+	}
+
+	list := &ListCommand{Output: &bytes.Buffer{}}
+	if !assert.NoError(t, list.Run(fake.CtxWithDefaultPrinter())) {
+		t.FailNow()
+	}
+	var listed []string
+	for _, r := range list.Repos {
+		listed = append(listed, r.URI)
+	}
+	assert.Equal(t, []string{"new", "sha256:" + hash}, listed)
+	assert.Equal(t, int64(1024), list.Repos[1].Size)
+
+	prune := &PruneCommand{MaxAge: 36 * time.Hour}
+	if !assert.NoError(t, prune.Run(fake.CtxWithDefaultPrinter())) {
+		t.FailNow()
+	}
+	if assert.Len(t, prune.Pruned, 1) {
+		assert.Equal(t, snapshotDir, prune.Pruned[0].Dir)
+	}
+	_, err := os.Stat(snapshotDir)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestPruneCommand_Validate(t *testing.T) {
+	err := (&PruneCommand{}).Validate()
+	if !assert.Error(t, err) {
+		t.FailNow()
+	}
+	assert.Contains(t, err.Error(), "one of max age, max size or all must be set")
+}
+
+func TestWarmCommand(t *testing.T) {
+	t.Setenv(gitutil.RepoCacheDirEnv, t.TempDir())
+	g, w, clean := testutil.SetupRepoAndWorkspace(t, testutil.Content{
+		Data:   testutil.Dataset1,
+		Branch: "master",
+		Tag:    "v1",
+	})
+	defer clean()
+
+	kf := kptfileutil.DefaultKptfile("pkg")
+	kf.Upstream = &kptfilev1.Upstream{
+		Type: kptfilev1.GitOrigin,
+		Git: &kptfilev1.Git{
+			Repo:      g.RepoDirectory,
+			Directory: "/java",
+			Ref:       "v1",
+		},
+	}
+	pkgDir := filepath.Join(w.WorkspaceDirectory, "pkg")
+	if !assert.NoError(t, os.MkdirAll(pkgDir, 0700)) {
+		t.FailNow()
+	}
+	if !assert.NoError(t, kptfileutil.WriteFile(pkgDir, kf)) {
+		t.FailNow()
+	}
+
+	err := (&WarmCommand{Paths: []string{w.WorkspaceDirectory}}).Run(fake.CtxWithDefaultPrinter())
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// The package's ref and the default branch can be resolved offline.
+	ctx := gitutil.ContextWithOffline(fake.CtxWithDefaultPrinter(), true)
+	gur, err := gitutil.NewGitUpstreamRepo(ctx, g.RepoDirectory)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	_, err = gur.GetRepo(ctx, []string{"v1"})
+	assert.NoError(t, err)
+	branch, err := gur.GetDefaultBranch(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "master", branch)
+
+	out := &bytes.Buffer{}
+	if !assert.NoError(t, (&ListCommand{Output: out}).Run(ctx)) {
+		t.FailNow()
+	}
+	assert.Contains(t, out.String(), g.RepoDirectory)
+}
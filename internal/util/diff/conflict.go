@@ -0,0 +1,219 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/GoogleContainerTools/kpt/internal/util/merge"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// resourceModified is the value of a side of a Conflict where the whole
+// resource was modified.
+const resourceModified = "<modified>"
+
+// Conflict describes a field of a resource that was changed differently in
+// the local package and in upstream since the original version.
+type Conflict struct {
+	APIVersion string `json:"apiVersion" yaml:"apiVersion"`
+	Kind       string `json:"kind" yaml:"kind"`
+	Namespace  string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Name       string `json:"name" yaml:"name"`
+
+	// File is the path of the file containing the resource in the local
+	// package, relative to the package root.
+	File string `json:"file" yaml:"file"`
+
+	// Path is the path to the conflicting field. If one side changed a field
+	// and the other side changed one of its parents, it is the path to the
+	// parent. It is empty if the whole resource was deleted on one side and
+	// modified on the other, in which case the value of the side that
+	// modified it is "<modified>".
+	Path string `json:"path,omitempty" yaml:"path,omitempty"`
+
+	// Original is the value of the field in the original version of the
+	// package.
+	Original interface{} `json:"original,omitempty" yaml:"original,omitempty"`
+
+	// Local is the value of the field in the local package.
+	Local interface{} `json:"local,omitempty" yaml:"local,omitempty"`
+
+	// Upstream is the value of the field in the updated upstream package.
+	Upstream interface{} `json:"upstream,omitempty" yaml:"upstream,omitempty"`
+}
+
+// FindConflicts returns the fields that were changed both in the local and
+// in the updated package relative to the original package, to different
+// values. Only the resources of the package itself are compared, not the
+// resources of its subpackages. The packages are expected to already have
+// merge comments so that resources are matched the same way as by
+// merge.Merge3.
+func FindConflicts(originalPath, localPath, updatedPath string) ([]Conflict, error) {
+	original, err := readResources(originalPath, false)
+	if err != nil {
+		return nil, err
+	}
+	local, err := readResources(localPath, false)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := readResources(updatedPath, false)
+	if err != nil {
+		return nil, err
+	}
+
+	matcher := &merge.ResourceMergeMatcher{}
+	find := func(node *yaml.RNode, nodes []*yaml.RNode) *yaml.RNode {
+		for _, n := range nodes {
+			if matcher.IsSameResource(node, n) {
+				return n
+			}
+		}
+		return nil
+	}
+
+	// resources renamed in upstream are merged with the local resources,
+	// so they are compared with them rather than deleted.
+	renames := merge.FindRenames(original, updated)
+
+	var conflicts []Conflict
+	for _, o := range original {
+		l := find(o, local)
+		u := find(o, updated)
+		if u == nil {
+			u = renames[o]
+		}
+		if l == nil && u == nil {
+			continue
+		}
+
+		var localChanges, upstreamChanges []FieldChange
+		if l != nil {
+			if localChanges, err = diffResources(o, l); err != nil {
+				return nil, err
+			}
+		}
+		if u != nil {
+			if upstreamChanges, err = diffResources(o, u); err != nil {
+				return nil, err
+			}
+		}
+
+		// the resource was deleted on one side and modified on the other.
+		if (l == nil && len(upstreamChanges) > 0) || (u == nil && len(localChanges) > 0) {
+			c, err := newConflict(o, l)
+			if err != nil {
+				return nil, err
+			}
+			if l != nil {
+				c.Local = resourceModified
+			}
+			if u != nil {
+				c.Upstream = resourceModified
+			}
+			conflicts = append(conflicts, c)
+			continue
+		}
+
+		// The values of the conflicting fields are read from the resources
+		// without the annotations ignored by the diff.
+		var versions []*yaml.RNode
+		for _, n := range []*yaml.RNode{o, l, u} {
+			n = n.Copy()
+			if err := clearInternalAnnotations(n); err != nil {
+				return nil, err
+			}
+			versions = append(versions, n)
+		}
+		reported := make(map[string]bool)
+		for _, lc := range localChanges {
+			for _, uc := range upstreamChanges {
+				if !pathsOverlap(lc.Path, uc.Path) {
+					continue
+				}
+				// report the conflict on the least specific field, so
+				// that the values of both sides are values of the same
+				// field and either can be used to resolve the conflict.
+				path := lc.Path
+				if len(uc.Path) < len(lc.Path) {
+					path = uc.Path
+				}
+				if reported[path] {
+					continue
+				}
+				var values []interface{}
+				for _, n := range versions {
+					v, err := GetFieldValue(n, path)
+					if err != nil {
+						return nil, err
+					}
+					values = append(values, v)
+				}
+				if reflect.DeepEqual(values[1], values[2]) {
+					// the same change was made on both sides.
+					continue
+				}
+				c, err := newConflict(o, l)
+				if err != nil {
+					return nil, err
+				}
+				c.Path = path
+				c.Original, c.Local, c.Upstream = values[0], values[1], values[2]
+				conflicts = append(conflicts, c)
+				reported[path] = true
+			}
+		}
+	}
+	return conflicts, nil
+}
+
+// newConflict returns a Conflict for the original resource, located in the
+// file of the local resource if it exists.
+func newConflict(original, local *yaml.RNode) (Conflict, error) {
+	meta, err := original.GetMeta()
+	if err != nil {
+		return Conflict{}, err
+	}
+	fileNode := original
+	if local != nil {
+		fileNode = local
+	}
+	path, _, err := kioutil.GetFileAnnotations(fileNode)
+	if err != nil {
+		return Conflict{}, err
+	}
+	return Conflict{
+		APIVersion: meta.APIVersion,
+		Kind:       meta.Kind,
+		Namespace:  meta.Namespace,
+		Name:       meta.Name,
+		File:       path,
+	}, nil
+}
+
+// pathsOverlap returns true if the field paths are equal or if one of them
+// is a parent of the other.
+func pathsOverlap(p1, p2 string) bool {
+	if len(p1) > len(p2) {
+		p1, p2 = p2, p1
+	}
+	if !strings.HasPrefix(p2, p1) {
+		return false
+	}
+	return len(p1) == len(p2) || p1 == "" || p2[len(p1)] == '.' || p2[len(p1)] == '['
+}
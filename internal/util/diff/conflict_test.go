@@ -0,0 +1,169 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindConflicts(t *testing.T) {
+	testCases := map[string]struct {
+		original  map[string]string
+		local     map[string]string
+		updated   map[string]string
+		conflicts []Conflict
+	}{
+		"no changes": {
+			original: map[string]string{"deployment.yaml": deploymentV1},
+			local:    map[string]string{"deployment.yaml": deploymentV1},
+			updated:  map[string]string{"deployment.yaml": deploymentV1},
+		},
+		"different fields changed": {
+			original: map[string]string{"deployment.yaml": deploymentV1},
+			local: map[string]string{"deployment.yaml": strings.Replace(deploymentV1,
+				"replicas: 3", "replicas: 4", 1)},
+			updated: map[string]string{"deployment.yaml": strings.Replace(deploymentV1,
+				"nginx:1.14", "nginx:1.15", 1)},
+		},
+		"same change on both sides": {
+			original: map[string]string{"deployment.yaml": deploymentV1},
+			local:    map[string]string{"deployment.yaml": deploymentV2},
+			updated:  map[string]string{"deployment.yaml": deploymentV2},
+		},
+		"same field changed to different values": {
+			original: map[string]string{"deployment.yaml": deploymentV1},
+			local: map[string]string{"deployment.yaml": strings.Replace(deploymentV1,
+				"nginx:1.14", "nginx:1.16", 1)},
+			updated: map[string]string{"deployment.yaml": deploymentV2},
+			conflicts: []Conflict{
+				{
+					APIVersion: "apps/v1",
+					Kind:       "Deployment",
+					Namespace:  "default",
+					Name:       "nginx",
+					File:       "deployment.yaml",
+					Path:       "spec.template.spec.containers[name=nginx].image",
+					Original:   "nginx:1.14",
+					Local:      "nginx:1.16",
+					Upstream:   "nginx:1.15",
+				},
+			},
+		},
+		"parent field removed on one side": {
+			original: map[string]string{"cm.yaml": configMap},
+			local: map[string]string{"cm.yaml": strings.Replace(configMap,
+				"foo: bar", "foo: baz", 1)},
+			updated: map[string]string{"cm.yaml": strings.Replace(configMap,
+				"data:\n  foo: bar\n", "", 1)},
+			conflicts: []Conflict{
+				{
+					APIVersion: "v1",
+					Kind:       "ConfigMap",
+					Name:       "cm",
+					File:       "cm.yaml",
+					Path:       "data",
+					Original:   map[string]interface{}{"foo": "bar"},
+					Local:      map[string]interface{}{"foo": "baz"},
+				},
+			},
+		},
+		"sibling field added on the other side": {
+			original: map[string]string{"cm.yaml": configMap},
+			local: map[string]string{"cm.yaml": strings.Replace(configMap,
+				"foo: bar", "foo: baz", 1)},
+			updated: map[string]string{"cm.yaml": strings.Replace(configMap,
+				"data:\n  foo: bar\n", "data:\n  foo: bar\n  bar: qux\n", 1)},
+		},
+		"parent field changed to a different kind on one side": {
+			original: map[string]string{"cm.yaml": configMap},
+			local: map[string]string{"cm.yaml": strings.Replace(configMap,
+				"foo: bar", "foo: baz", 1)},
+			updated: map[string]string{"cm.yaml": strings.Replace(configMap,
+				"data:\n  foo: bar\n", "data: []\n", 1)},
+			conflicts: []Conflict{
+				{
+					APIVersion: "v1",
+					Kind:       "ConfigMap",
+					Name:       "cm",
+					File:       "cm.yaml",
+					Path:       "data",
+					Original:   map[string]interface{}{"foo": "bar"},
+					Local:      map[string]interface{}{"foo": "baz"},
+					Upstream:   []interface{}{},
+				},
+			},
+		},
+		"resource renamed in upstream and modified in local": {
+			original: map[string]string{"cm.yaml": configMap},
+			local: map[string]string{"cm.yaml": strings.Replace(configMap,
+				"foo: bar", "foo: baz", 1)},
+			updated: map[string]string{"cm.yaml": strings.Replace(configMap,
+				"name: cm", "name: cm-new", 1)},
+		},
+		"resource renamed in upstream and modified differently in local": {
+			original: map[string]string{"cm.yaml": configMap},
+			local: map[string]string{"cm.yaml": strings.Replace(configMap,
+				"foo: bar", "foo: baz", 1)},
+			updated: map[string]string{"cm.yaml": strings.Replace(strings.Replace(configMap,
+				"name: cm", "name: cm-new\n  annotations:\n    kpt-rename-from: cm", 1),
+				"foo: bar", "foo: qux", 1)},
+			conflicts: []Conflict{
+				{
+					APIVersion: "v1",
+					Kind:       "ConfigMap",
+					Name:       "cm",
+					File:       "cm.yaml",
+					Path:       "data.foo",
+					Original:   "bar",
+					Local:      "baz",
+					Upstream:   "qux",
+				},
+			},
+		},
+		"resource deleted in upstream and modified in local": {
+			original: map[string]string{"cm.yaml": configMap},
+			local: map[string]string{"cm.yaml": strings.Replace(configMap,
+				"foo: bar", "foo: baz", 1)},
+			updated: map[string]string{},
+			conflicts: []Conflict{
+				{
+					APIVersion: "v1",
+					Kind:       "ConfigMap",
+					Name:       "cm",
+					File:       "cm.yaml",
+					Local:      resourceModified,
+				},
+			},
+		},
+	}
+
+	for tn, tc := range testCases {
+		tc := tc
+		t.Run(tn, func(t *testing.T) {
+			original := writePkg(t, tc.original)
+			local := writePkg(t, tc.local)
+			updated := writePkg(t, tc.updated)
+
+			conflicts, err := FindConflicts(original, local, updated)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, tc.conflicts, conflicts)
+		})
+	}
+}
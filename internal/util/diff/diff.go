@@ -101,6 +101,11 @@ type Command struct {
 	// DiffToolOpts refers to the commandline options to for the diffing tool.
 	DiffToolOpts string
 
+	// OutputFormat specifies how the changes are shown. The text format
+	// uses the diff tool, while json and krm show the resource-level
+	// changes. Defaults to text.
+	OutputFormat string
+
 	// When Debug is true, command will run with verbose logging and will not
 	// cleanup the staged packages to assist with debugging.
 	Debug bool
@@ -123,6 +128,9 @@ func (c *Command) Run(ctx context.Context) error {
 	if err != nil {
 		return errors.Errorf("package missing Kptfile at '%s': %v", c.Path, err)
 	}
+	if kptFile.Upstream == nil || kptFile.Upstream.Git == nil {
+		return errors.Errorf("package at '%s' must have a git upstream to be diffed", c.Path)
+	}
 
 	// Create a staging directory to store all compared packages
 	stagingDirectory, err := ioutil.TempDir("", "kpt-")
@@ -219,6 +227,16 @@ func (c *Command) Validate() error {
 			c.DiffType, SupportedDiffTypesLabel())
 	}
 
+	switch c.OutputFormat {
+	case "", OutputText:
+	case OutputJSON, OutputKRM:
+		// the diff tool is not used for structured output.
+		return nil
+	default:
+		return errors.Errorf("invalid output '%s': supported outputs are: %s",
+			c.OutputFormat, strings.Join(SupportedOutputFormats, ", "))
+	}
+
 	path, err := exec.LookPath(c.DiffTool)
 	if err != nil {
 		return errors.Errorf("diff-tool '%s' not found in the PATH", c.DiffTool)
@@ -235,6 +253,16 @@ func (c *Command) DefaultValues() {
 	if c.PkgGetter == nil {
 		c.PkgGetter = defaultPkgGetter{}
 	}
+	if c.OutputFormat == "" {
+		c.OutputFormat = OutputText
+	}
+	if c.PkgDiffer == nil && c.OutputFormat != OutputText {
+		c.PkgDiffer = &resourcePkgDiffer{
+			DiffType:     c.DiffType,
+			OutputFormat: c.OutputFormat,
+			Output:       c.Output,
+		}
+	}
 	if c.PkgDiffer == nil {
 		c.PkgDiffer = &defaultPkgDiffer{
 			DiffType:     c.DiffType,
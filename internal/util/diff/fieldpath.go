@@ -0,0 +1,219 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// SetFieldValue sets the field of the resource at path, as reported in a
+// FieldChange or a Conflict, to value. The field is removed if value is nil.
+// Missing parent fields are created.
+func SetFieldValue(node *yaml.RNode, path string, value interface{}) error {
+	segments := splitFieldPath(path)
+	if len(segments) == 0 {
+		return errors.Errorf("field path must not be empty")
+	}
+	var v *yaml.Node
+	if value != nil {
+		b, err := yaml.Marshal(value)
+		if err != nil {
+			return err
+		}
+		rn, err := yaml.Parse(string(b))
+		if err != nil {
+			return err
+		}
+		v = rn.YNode()
+	}
+
+	n := node.YNode()
+	if n.Kind == yaml.DocumentNode && len(n.Content) > 0 {
+		n = n.Content[0]
+	}
+	for i, seg := range segments {
+		last := i == len(segments)-1
+		switch n.Kind {
+		case yaml.MappingNode:
+			key := strings.TrimSuffix(strings.TrimPrefix(seg, "["), "]")
+			idx := -1
+			for j := 0; j+1 < len(n.Content); j += 2 {
+				if n.Content[j].Value == key {
+					idx = j
+					break
+				}
+			}
+			switch {
+			case last && v == nil:
+				if idx >= 0 {
+					n.Content = append(n.Content[:idx], n.Content[idx+2:]...)
+				}
+				return nil
+			case last && idx >= 0:
+				n.Content[idx+1] = v
+				return nil
+			case last:
+				n.Content = append(n.Content, yaml.NewStringRNode(key).YNode(), v)
+				return nil
+			case idx < 0 && v == nil:
+				return nil
+			case idx < 0:
+				child := newParentNode(segments[i+1])
+				n.Content = append(n.Content, yaml.NewStringRNode(key).YNode(), child)
+				n = child
+			default:
+				n = n.Content[idx+1]
+			}
+		case yaml.SequenceNode:
+			idx, err := elementIndex(n, seg)
+			if err != nil {
+				return errors.Errorf("invalid field path %q: %v", path, err)
+			}
+			switch {
+			case last && v == nil:
+				if idx >= 0 {
+					n.Content = append(n.Content[:idx], n.Content[idx+1:]...)
+				}
+				return nil
+			case last && idx >= 0:
+				n.Content[idx] = v
+				return nil
+			case last:
+				n.Content = append(n.Content, v)
+				return nil
+			case idx < 0 && v == nil:
+				return nil
+			case idx < 0:
+				name := strings.TrimPrefix(strings.TrimSuffix(strings.TrimPrefix(seg, "["), "]"), "name=")
+				child := yaml.NewMapRNode(&map[string]string{"name": name}).YNode()
+				n.Content = append(n.Content, child)
+				n = child
+			default:
+				n = n.Content[idx]
+			}
+		default:
+			return errors.Errorf("invalid field path %q: %q is not a map or a list", path,
+				strings.Join(segments[:i], ""))
+		}
+	}
+	return nil
+}
+
+// GetFieldValue returns the value of the field of the resource at path, as
+// reported in a FieldChange or a Conflict, or nil if there is no such field.
+func GetFieldValue(node *yaml.RNode, path string) (interface{}, error) {
+	n := node.YNode()
+	if n.Kind == yaml.DocumentNode && len(n.Content) > 0 {
+		n = n.Content[0]
+	}
+	for _, seg := range splitFieldPath(path) {
+		switch n.Kind {
+		case yaml.MappingNode:
+			key := strings.TrimSuffix(strings.TrimPrefix(seg, "["), "]")
+			var child *yaml.Node
+			for j := 0; j+1 < len(n.Content); j += 2 {
+				if n.Content[j].Value == key {
+					child = n.Content[j+1]
+					break
+				}
+			}
+			if child == nil {
+				return nil, nil
+			}
+			n = child
+		case yaml.SequenceNode:
+			idx, err := elementIndex(n, seg)
+			if err != nil {
+				return nil, errors.Errorf("invalid field path %q: %v", path, err)
+			}
+			if idx < 0 {
+				return nil, nil
+			}
+			n = n.Content[idx]
+		default:
+			return nil, nil
+		}
+	}
+	var v interface{}
+	if err := n.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// splitFieldPath splits a field path into its segments. Map keys are
+// returned as is unless they were written between brackets, list elements
+// are returned with their brackets.
+func splitFieldPath(path string) []string {
+	var segments []string
+	for i := 0; i < len(path); {
+		switch path[i] {
+		case '.':
+			i++
+		case '[':
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				end = len(path) - i - 1
+			}
+			segments = append(segments, path[i:i+end+1])
+			i += end + 1
+		default:
+			end := strings.IndexAny(path[i:], ".[")
+			if end < 0 {
+				end = len(path) - i
+			}
+			segments = append(segments, path[i:i+end])
+			i += end
+		}
+	}
+	return segments
+}
+
+// elementIndex returns the index of the list element identified by the
+// segment, either [name=<name>] or [<index>], or -1 if there is no such
+// element.
+func elementIndex(n *yaml.Node, seg string) (int, error) {
+	s := strings.TrimSuffix(strings.TrimPrefix(seg, "["), "]")
+	if name := strings.TrimPrefix(s, "name="); name != s {
+		for i, e := range n.Content {
+			if elementName(e) == name {
+				return i, nil
+			}
+		}
+		return -1, nil
+	}
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return -1, errors.Errorf("%q is not a list element", seg)
+	}
+	if i < 0 || i >= len(n.Content) {
+		return -1, nil
+	}
+	return i, nil
+}
+
+// newParentNode returns an empty node that can hold the field identified by
+// the next segment of a field path.
+func newParentNode(next string) *yaml.Node {
+	s := strings.TrimSuffix(strings.TrimPrefix(next, "["), "]")
+	if _, err := strconv.Atoi(s); err == nil || strings.HasPrefix(s, "name=") {
+		return &yaml.Node{Kind: yaml.SequenceNode}
+	}
+	return &yaml.Node{Kind: yaml.MappingNode}
+}
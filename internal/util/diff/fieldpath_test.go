@@ -0,0 +1,113 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestSetFieldValue(t *testing.T) {
+	testCases := map[string]struct {
+		path     string
+		value    interface{}
+		expected string
+	}{
+		"scalar field": {
+			path:     "spec.replicas",
+			value:    4,
+			expected: strings.Replace(deploymentV1, "replicas: 3", "replicas: 4", 1),
+		},
+		"named list element": {
+			path:     "spec.template.spec.containers[name=nginx].image",
+			value:    "nginx:1.16",
+			expected: strings.Replace(deploymentV1, "nginx:1.14", "nginx:1.16", 1),
+		},
+		"removed field": {
+			path:     "spec.replicas",
+			expected: strings.Replace(deploymentV1, "  replicas: 3\n", "", 1),
+		},
+		"new field with parent": {
+			path:  "metadata.labels[app.kubernetes.io/name]",
+			value: "nginx",
+			expected: strings.Replace(deploymentV1, "  namespace: default\n",
+				"  namespace: default\n  labels:\n    app.kubernetes.io/name: nginx\n", 1),
+		},
+		"list element by index": {
+			path:  "spec.template.spec.containers[1]",
+			value: map[string]interface{}{"name": "sidecar", "image": "sidecar:2.0"},
+			expected: strings.Replace(deploymentV1, "- name: sidecar\n        image: sidecar:1.0\n",
+				"- image: sidecar:2.0\n        name: sidecar\n", 1),
+		},
+	}
+
+	for tn, tc := range testCases {
+		tc := tc
+		t.Run(tn, func(t *testing.T) {
+			node, err := yaml.Parse(deploymentV1)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			if !assert.NoError(t, SetFieldValue(node, tc.path, tc.value)) {
+				t.FailNow()
+			}
+			assert.Equal(t, tc.expected, node.MustString())
+		})
+	}
+}
+
+func TestGetFieldValue(t *testing.T) {
+	testCases := map[string]struct {
+		path     string
+		expected interface{}
+	}{
+		"scalar field": {
+			path:     "spec.replicas",
+			expected: 3,
+		},
+		"named list element": {
+			path:     "spec.template.spec.containers[name=nginx].image",
+			expected: "nginx:1.14",
+		},
+		"list element by index": {
+			path:     "spec.template.spec.containers[1]",
+			expected: map[string]interface{}{"name": "sidecar", "image": "sidecar:1.0"},
+		},
+		"missing field": {
+			path: "metadata.labels[app.kubernetes.io/name]",
+		},
+		"missing list element": {
+			path: "spec.template.spec.containers[name=missing].image",
+		},
+	}
+
+	for tn, tc := range testCases {
+		tc := tc
+		t.Run(tn, func(t *testing.T) {
+			node, err := yaml.Parse(deploymentV1)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			value, err := GetFieldValue(node, tc.path)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, tc.expected, value)
+		})
+	}
+}
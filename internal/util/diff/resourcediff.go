@@ -0,0 +1,475 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/GoogleContainerTools/kpt/internal/util/addmergecomment"
+	"github.com/GoogleContainerTools/kpt/internal/util/attribution"
+	"github.com/GoogleContainerTools/kpt/internal/util/merge"
+	kptfilev1 "github.com/GoogleContainerTools/kpt/pkg/api/kptfile/v1"
+	"sigs.k8s.io/kustomize/kyaml/errors"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// A collection of supported output formats for the diff.
+const (
+	// OutputText shows the diff using the diff tool
+	OutputText string = "text"
+	// OutputJSON shows the resource-level diff as JSON
+	OutputJSON string = "json"
+	// OutputKRM shows the resource-level diff as a KRM resource
+	OutputKRM string = "krm"
+)
+
+var SupportedOutputFormats = []string{OutputText, OutputJSON, OutputKRM}
+
+// ResourceChangeType is the type of change made to a resource.
+type ResourceChangeType string
+
+const (
+	ResourceAdded    ResourceChangeType = "Added"
+	ResourceRemoved  ResourceChangeType = "Removed"
+	ResourceModified ResourceChangeType = "Modified"
+)
+
+const PackageDiffKind = "PackageDiff"
+
+// PackageDiff contains the resource-level changes between versions of a
+// package.
+type PackageDiff struct {
+	yaml.ResourceMeta `yaml:",inline"`
+
+	// DiffType is the type of diff comparison that was performed.
+	DiffType Type `json:"diffType" yaml:"diffType"`
+
+	// Changes is the list of changed resources.
+	Changes []ResourceChange `json:"changes,omitempty" yaml:"changes,omitempty"`
+}
+
+// ResourceChange describes how a single resource changed.
+type ResourceChange struct {
+	// Source is the version of the package the change was made in, either
+	// local or remote.
+	Source string `json:"source" yaml:"source"`
+
+	// Type is the type of change.
+	Type ResourceChangeType `json:"type" yaml:"type"`
+
+	APIVersion string `json:"apiVersion" yaml:"apiVersion"`
+	Kind       string `json:"kind" yaml:"kind"`
+	Namespace  string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Name       string `json:"name" yaml:"name"`
+
+	// File is the path of the file containing the resource, relative to
+	// the package root.
+	File string `json:"file" yaml:"file"`
+
+	// Fields is the list of changed fields. It is only set for modified
+	// resources.
+	Fields []FieldChange `json:"fields,omitempty" yaml:"fields,omitempty"`
+}
+
+// FieldChange describes how a single field of a resource changed.
+type FieldChange struct {
+	// Path is the path to the field, e.g. spec.template.spec.containers[name=nginx].image
+	Path string `json:"path" yaml:"path"`
+
+	// Old is the value before the change. It is not set if the field was added.
+	Old interface{} `json:"old,omitempty" yaml:"old,omitempty"`
+
+	// New is the value after the change. It is not set if the field was removed.
+	New interface{} `json:"new,omitempty" yaml:"new,omitempty"`
+}
+
+// DiffResources compares the resources in the given staged packages, which
+// must be in the same order as for PkgDiffer.Diff for the given diff type.
+// Resources are identified the same way as during a package update.
+func DiffResources(diffType Type, pkgs ...string) (*PackageDiff, error) {
+	// add merge comments before comparing so that resources are identified
+	// the same way as during a package update.
+	if err := addmergecomment.Process(pkgs...); err != nil {
+		return nil, err
+	}
+
+	pkgResources := make([][]*yaml.RNode, len(pkgs))
+	for i, p := range pkgs {
+		nodes, err := readResources(p, true)
+		if err != nil {
+			return nil, err
+		}
+		pkgResources[i] = nodes
+	}
+
+	pd := &PackageDiff{
+		ResourceMeta: yaml.ResourceMeta{
+			TypeMeta: yaml.TypeMeta{
+				APIVersion: kptfilev1.KptFileAPIVersion,
+				Kind:       PackageDiffKind,
+			},
+			ObjectMeta: yaml.ObjectMeta{
+				NameMeta: yaml.NameMeta{
+					Name: "diff",
+				},
+			},
+		},
+		DiffType: diffType,
+	}
+
+	var changes []ResourceChange
+	var err error
+	switch {
+	case diffType == Type3Way && len(pkgs) == 3:
+		// pkgs are local, upstream at original version and upstream at
+		// target version.
+		local, err := diffResourceLists(LocalPackageSource, pkgResources[1], pkgResources[0])
+		if err != nil {
+			return nil, err
+		}
+		remote, err := diffResourceLists(RemotePackageSource, pkgResources[1], pkgResources[2])
+		if err != nil {
+			return nil, err
+		}
+		changes = append(local, remote...)
+	case diffType == TypeRemote && len(pkgs) == 2:
+		changes, err = diffResourceLists(RemotePackageSource, pkgResources[0], pkgResources[1])
+	case (diffType == TypeLocal || diffType == TypeCombined) && len(pkgs) == 2:
+		// the local package is first, but the changes are relative to
+		// the upstream package.
+		changes, err = diffResourceLists(LocalPackageSource, pkgResources[1], pkgResources[0])
+	default:
+		return nil, errors.Errorf("unsupported diff type '%s' for %d packages", diffType, len(pkgs))
+	}
+	if err != nil {
+		return nil, err
+	}
+	pd.Changes = changes
+	return pd, nil
+}
+
+// readResources reads the resources in the package at path, excluding the
+// Kptfile.
+func readResources(path string, includeSubpackages bool) ([]*yaml.RNode, error) {
+	nodes, err := (&kio.LocalPackageReader{
+		PackagePath:        path,
+		PackageFileName:    kptfilev1.KptFileName,
+		IncludeSubpackages: includeSubpackages,
+		PreserveSeqIndent:  true,
+		WrapBareSeqNode:    true,
+	}).Read()
+	if err != nil {
+		return nil, errors.Errorf("failed to read resources in %q: %v", path, err)
+	}
+	return nodes, nil
+}
+
+// diffResourceLists returns the changes needed to go from the resources in
+// from to the resources in to.
+func diffResourceLists(source string, from, to []*yaml.RNode) ([]ResourceChange, error) {
+	matcher := &merge.ResourceMergeMatcher{}
+	var changes []ResourceChange
+	matched := make(map[int]bool)
+	for _, f := range from {
+		var match *yaml.RNode
+		for i, t := range to {
+			if !matched[i] && matcher.IsSameResource(f, t) {
+				matched[i] = true
+				match = t
+				break
+			}
+		}
+		if match == nil {
+			c, err := newResourceChange(source, ResourceRemoved, f)
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, c)
+			continue
+		}
+		fields, err := diffResources(f, match)
+		if err != nil {
+			return nil, err
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		c, err := newResourceChange(source, ResourceModified, match)
+		if err != nil {
+			return nil, err
+		}
+		c.Fields = fields
+		changes = append(changes, c)
+	}
+	for i, t := range to {
+		if matched[i] {
+			continue
+		}
+		c, err := newResourceChange(source, ResourceAdded, t)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		ci, cj := changes[i], changes[j]
+		if ci.File != cj.File {
+			return ci.File < cj.File
+		}
+		if ci.Kind != cj.Kind {
+			return ci.Kind < cj.Kind
+		}
+		if ci.Namespace != cj.Namespace {
+			return ci.Namespace < cj.Namespace
+		}
+		return ci.Name < cj.Name
+	})
+	return changes, nil
+}
+
+func newResourceChange(source string, changeType ResourceChangeType, node *yaml.RNode) (ResourceChange, error) {
+	meta, err := node.GetMeta()
+	if err != nil {
+		return ResourceChange{}, err
+	}
+	path, _, err := kioutil.GetFileAnnotations(node)
+	if err != nil {
+		return ResourceChange{}, err
+	}
+	return ResourceChange{
+		Source:     source,
+		Type:       changeType,
+		APIVersion: meta.APIVersion,
+		Kind:       meta.Kind,
+		Namespace:  meta.Namespace,
+		Name:       meta.Name,
+		File:       path,
+	}, nil
+}
+
+// diffResources returns the changed fields between two versions of a
+// resource, ignoring the annotations added by kpt and kyaml.
+func diffResources(from, to *yaml.RNode) ([]FieldChange, error) {
+	fromClone := from.Copy()
+	toClone := to.Copy()
+	for _, n := range []*yaml.RNode{fromClone, toClone} {
+		if err := clearInternalAnnotations(n); err != nil {
+			return nil, err
+		}
+	}
+	var changes []FieldChange
+	if err := diffNodes("", fromClone.YNode(), toClone.YNode(), &changes); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+func clearInternalAnnotations(n *yaml.RNode) error {
+	for _, a := range []string{kioutil.PathAnnotation, kioutil.IndexAnnotation,
+		kioutil.LegacyPathAnnotation, kioutil.LegacyIndexAnnotation, // nolint:staticcheck
+		kioutil.SeqIndentAnnotation, kioutil.IdAnnotation, kioutil.LegacyIdAnnotation, // nolint:staticcheck
+		kioutil.InternalAnnotationsMigrationResourceIDAnnotation, attribution.CNRMMetricsAnnotation} {
+		if err := n.PipeE(yaml.ClearAnnotation(a)); err != nil {
+			return err
+		}
+	}
+	return yaml.ClearEmptyAnnotations(n)
+}
+
+// diffNodes recursively compares from and to and appends the changed
+// fields to changes. Lists of maps with a name field are compared by name,
+// all other lists are compared by index.
+func diffNodes(path string, from, to *yaml.Node, changes *[]FieldChange) error {
+	if from != nil && from.Kind == yaml.DocumentNode && len(from.Content) > 0 {
+		from = from.Content[0]
+	}
+	if to != nil && to.Kind == yaml.DocumentNode && len(to.Content) > 0 {
+		to = to.Content[0]
+	}
+	switch {
+	case from == nil && to == nil:
+		return nil
+	case from == nil || to == nil || from.Kind != to.Kind:
+		return appendFieldChange(path, from, to, changes)
+	}
+
+	switch from.Kind {
+	case yaml.MappingNode:
+		toFields := make(map[string]*yaml.Node)
+		for i := 0; i+1 < len(to.Content); i += 2 {
+			toFields[to.Content[i].Value] = to.Content[i+1]
+		}
+		fromFields := make(map[string]bool)
+		for i := 0; i+1 < len(from.Content); i += 2 {
+			key := from.Content[i].Value
+			fromFields[key] = true
+			if err := diffNodes(fieldPath(path, key), from.Content[i+1], toFields[key], changes); err != nil {
+				return err
+			}
+		}
+		for i := 0; i+1 < len(to.Content); i += 2 {
+			key := to.Content[i].Value
+			if fromFields[key] {
+				continue
+			}
+			if err := diffNodes(fieldPath(path, key), nil, to.Content[i+1], changes); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		if isNamedList(from) && isNamedList(to) {
+			toElems := make(map[string]*yaml.Node)
+			for _, e := range to.Content {
+				toElems[elementName(e)] = e
+			}
+			fromElems := make(map[string]bool)
+			for _, e := range from.Content {
+				name := elementName(e)
+				fromElems[name] = true
+				if err := diffNodes(fmt.Sprintf("%s[name=%s]", path, name), e, toElems[name], changes); err != nil {
+					return err
+				}
+			}
+			for _, e := range to.Content {
+				name := elementName(e)
+				if fromElems[name] {
+					continue
+				}
+				if err := diffNodes(fmt.Sprintf("%s[name=%s]", path, name), nil, e, changes); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		for i := 0; i < len(from.Content) || i < len(to.Content); i++ {
+			var f, t *yaml.Node
+			if i < len(from.Content) {
+				f = from.Content[i]
+			}
+			if i < len(to.Content) {
+				t = to.Content[i]
+			}
+			if err := diffNodes(fmt.Sprintf("%s[%d]", path, i), f, t, changes); err != nil {
+				return err
+			}
+		}
+	default:
+		if from.Value != to.Value {
+			return appendFieldChange(path, from, to, changes)
+		}
+	}
+	return nil
+}
+
+func appendFieldChange(path string, from, to *yaml.Node, changes *[]FieldChange) error {
+	c := FieldChange{Path: path}
+	if from != nil {
+		if err := from.Decode(&c.Old); err != nil {
+			return err
+		}
+	}
+	if to != nil {
+		if err := to.Decode(&c.New); err != nil {
+			return err
+		}
+	}
+	*changes = append(*changes, c)
+	return nil
+}
+
+func fieldPath(path, key string) string {
+	if strings.ContainsAny(key, ".[]") {
+		return fmt.Sprintf("%s[%s]", path, key)
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// isNamedList returns true if all elements of the list are maps with a
+// unique name field.
+func isNamedList(n *yaml.Node) bool {
+	if len(n.Content) == 0 {
+		return false
+	}
+	names := make(map[string]bool)
+	for _, e := range n.Content {
+		name := elementName(e)
+		if name == "" || names[name] {
+			return false
+		}
+		names[name] = true
+	}
+	return true
+}
+
+func elementName(n *yaml.Node) string {
+	if n.Kind != yaml.MappingNode {
+		return ""
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == "name" && n.Content[i+1].Kind == yaml.ScalarNode {
+			return n.Content[i+1].Value
+		}
+	}
+	return ""
+}
+
+// resourcePkgDiffer implements PkgDiffer by writing the resource-level
+// changes between the packages in a structured format.
+type resourcePkgDiffer struct {
+	// DiffType specifies the type of changes to show
+	DiffType Type
+
+	// OutputFormat is either json or krm.
+	OutputFormat string
+
+	// Output is an io.Writer where command will write the output of the
+	// command.
+	Output io.Writer
+}
+
+func (d *resourcePkgDiffer) Diff(pkgs ...string) error {
+	pd, err := DiffResources(d.DiffType, pkgs...)
+	if err != nil {
+		return err
+	}
+	switch d.OutputFormat {
+	case OutputJSON:
+		b, err := json.MarshalIndent(pd, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(d.Output, "%s\n", b)
+		return err
+	case OutputKRM:
+		b, err := yaml.Marshal(pd)
+		if err != nil {
+			return err
+		}
+		_, err = d.Output.Write(b)
+		return err
+	default:
+		return errors.Errorf("unsupported output format '%s'", d.OutputFormat)
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	deploymentV1 = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  namespace: default
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.14
+      - name: sidecar
+        image: sidecar:1.0
+`
+	deploymentV2 = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  namespace: default
+  labels:
+    app: nginx
+spec:
+  replicas: 5
+  template:
+    spec:
+      containers:
+      - name: sidecar
+        image: sidecar:1.0
+      - name: nginx
+        image: nginx:1.15
+`
+	configMap = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm
+data:
+  foo: bar
+`
+	service = `apiVersion: v1
+kind: Service
+metadata:
+  name: svc
+`
+)
+
+func writePkg(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestDiffResources(t *testing.T) {
+	upstream := writePkg(t, map[string]string{
+		"deployment.yaml": deploymentV1,
+		"cm.yaml":         configMap,
+	})
+	target := writePkg(t, map[string]string{
+		"deployment.yaml": deploymentV2,
+		"svc.yaml":        service,
+	})
+
+	pd, err := DiffResources(TypeRemote, upstream, target)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, PackageDiffKind, pd.Kind)
+	assert.Equal(t, TypeRemote, pd.DiffType)
+	assert.Equal(t, []ResourceChange{
+		{
+			Source:     RemotePackageSource,
+			Type:       ResourceRemoved,
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+			Name:       "cm",
+			File:       "cm.yaml",
+		},
+		{
+			Source:     RemotePackageSource,
+			Type:       ResourceModified,
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+			Namespace:  "default",
+			Name:       "nginx",
+			File:       "deployment.yaml",
+			Fields: []FieldChange{
+				{Path: "metadata.labels", New: map[string]interface{}{"app": "nginx"}},
+				{Path: "spec.replicas", Old: 3, New: 5},
+				{Path: "spec.template.spec.containers[name=nginx].image", Old: "nginx:1.14", New: "nginx:1.15"},
+			},
+		},
+		{
+			Source:     RemotePackageSource,
+			Type:       ResourceAdded,
+			APIVersion: "v1",
+			Kind:       "Service",
+			Name:       "svc",
+			File:       "svc.yaml",
+		},
+	}, pd.Changes)
+}
+
+func TestDiffResources_Local(t *testing.T) {
+	local := writePkg(t, map[string]string{"deployment.yaml": deploymentV2})
+	upstream := writePkg(t, map[string]string{"deployment.yaml": deploymentV1})
+
+	pd, err := DiffResources(TypeLocal, local, upstream)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	if assert.Len(t, pd.Changes, 1) {
+		assert.Equal(t, LocalPackageSource, pd.Changes[0].Source)
+		assert.Contains(t, pd.Changes[0].Fields, FieldChange{Path: "spec.replicas", Old: 3, New: 5})
+	}
+}
+
+func TestResourcePkgDiffer(t *testing.T) {
+	upstream := writePkg(t, map[string]string{"cm.yaml": configMap})
+	target := writePkg(t, map[string]string{"cm.yaml": configMap, "svc.yaml": service})
+
+	out := &bytes.Buffer{}
+	d := &resourcePkgDiffer{DiffType: TypeRemote, OutputFormat: OutputKRM, Output: out}
+	if !assert.NoError(t, d.Diff(upstream, target)) {
+		t.FailNow()
+	}
+	assert.Equal(t, `apiVersion: kpt.dev/v1
+kind: PackageDiff
+metadata:
+  name: diff
+diffType: remote
+changes:
+- source: remote
+  type: Added
+  apiVersion: v1
+  kind: Service
+  name: svc
+  file: svc.yaml
+`, out.String())
+
+	out.Reset()
+	d.OutputFormat = OutputJSON
+	if !assert.NoError(t, d.Diff(upstream, target)) {
+		t.FailNow()
+	}
+	assert.Equal(t, `{
+  "apiVersion": "kpt.dev/v1",
+  "kind": "PackageDiff",
+  "metadata": {
+    "name": "diff"
+  },
+  "diffType": "remote",
+  "changes": [
+    {
+      "source": "remote",
+      "type": "Added",
+      "apiVersion": "v1",
+      "kind": "Service",
+      "name": "svc",
+      "file": "svc.yaml"
+    }
+  ]
+}
+`, out.String())
+}
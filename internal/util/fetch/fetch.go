@@ -42,6 +42,10 @@ import (
 // there.
 type Command struct {
 	Pkg *pkg.Pkg
+
+	// VerificationPolicy is the policy the signature of the upstream tag or
+	// commit is verified against before the package is fetched, if set.
+	VerificationPolicy *VerificationPolicy
 }
 
 // Run runs the Command.
@@ -56,13 +60,24 @@ func (c Command) Run(ctx context.Context) error {
 		return errors.E(op, c.Pkg.UniquePath, err)
 	}
 
+	switch kf.Upstream.Type {
+	case kptfilev1.DirOrigin, kptfilev1.TarballOrigin:
+		if err := VerifySnapshotUpstream(c.VerificationPolicy); err != nil {
+			return errors.E(op, c.Pkg.UniquePath, err)
+		}
+		if err := c.copySnapshot(ctx, kf.Upstream); err != nil {
+			return errors.E(op, c.Pkg.UniquePath, err)
+		}
+		return nil
+	}
+
 	g := kf.Upstream.Git
 	repoSpec := &git.RepoSpec{
 		OrgRepo: g.Repo,
 		Path:    g.Directory,
 		Ref:     g.Ref,
 	}
-	err = NewCloner(repoSpec).cloneAndCopy(ctx, c.Pkg.UniquePath.String())
+	err = NewCloner(repoSpec, WithVerificationPolicy(c.VerificationPolicy)).cloneAndCopy(ctx, c.Pkg.UniquePath.String())
 	if err != nil {
 		return errors.E(op, c.Pkg.UniquePath, err)
 	}
@@ -77,6 +92,19 @@ func (c Command) validate(kf *kptfilev1.KptFile) error {
 		return errors.E(op, errors.MissingParam, fmt.Errorf("kptfile doesn't contain upstream information"))
 	}
 
+	switch kf.Upstream.Type {
+	case kptfilev1.DirOrigin:
+		if kf.Upstream.Dir == nil || len(kf.Upstream.Dir.Path) == 0 {
+			return errors.E(op, errors.MissingParam, fmt.Errorf("kptfile upstream doesn't have a directory path"))
+		}
+		return nil
+	case kptfilev1.TarballOrigin:
+		if kf.Upstream.Tarball == nil || len(kf.Upstream.Tarball.URL) == 0 {
+			return errors.E(op, errors.MissingParam, fmt.Errorf("kptfile upstream doesn't have a tarball url"))
+		}
+		return nil
+	}
+
 	if kf.Upstream.Git == nil {
 		return errors.E(op, errors.MissingParam, fmt.Errorf("kptfile upstream doesn't have git information"))
 	}
@@ -94,6 +122,32 @@ func (c Command) validate(kf *kptfilev1.KptFile) error {
 	return nil
 }
 
+// copySnapshot copies the package from the directory or tarball upstream,
+// and records the hash of its content in the upstream lock.
+func (c Command) copySnapshot(ctx context.Context, upstream *kptfilev1.Upstream) error {
+	const op errors.Op = "fetch.copySnapshot"
+	pr := printer.FromContextOrDie(ctx)
+	dest := c.Pkg.UniquePath.String()
+
+	s, err := TakeSnapshot(ctx, dest, upstream)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	defer os.RemoveAll(s.Dir)
+
+	pr.Printf("Adding package from %q.\n", UpstreamSource(upstream))
+	if err := pkgutil.CopyPackage(s.Dir, dest, true, pkg.All); err != nil {
+		return errors.E(op, types.UniquePath(dest), err)
+	}
+	if err := kptfileutil.UpdateKptfileWithoutOrigin(dest, s.Dir, false); err != nil {
+		return errors.E(op, types.UniquePath(dest), err)
+	}
+	if err := kptfileutil.UpdateUpstreamLock(dest, s.Lock); err != nil {
+		return errors.E(op, types.UniquePath(dest), err)
+	}
+	return nil
+}
+
 // Cloner clones an upstream repo defined by a repoSpec.
 // Optionally, previously cloned repos can be cached
 // rather than recloning them each time.
@@ -103,6 +157,10 @@ type Cloner struct {
 
 	// cachedRepos
 	cachedRepo map[string]*gitutil.GitUpstreamRepo
+
+	// verificationPolicy is the policy the upstream tag or commit is
+	// verified against before the package is copied, if set.
+	verificationPolicy *VerificationPolicy
 }
 
 type NewClonerOption func(*Cloner)
@@ -113,6 +171,14 @@ func WithCachedRepo(r map[string]*gitutil.GitUpstreamRepo) NewClonerOption {
 	}
 }
 
+// WithVerificationPolicy verifies the signature of the upstream tag or commit
+// against the policy before the package is copied.
+func WithVerificationPolicy(p *VerificationPolicy) NewClonerOption {
+	return func(c *Cloner) {
+		c.verificationPolicy = p
+	}
+}
+
 func NewCloner(r *git.RepoSpec, opts ...NewClonerOption) *Cloner {
 	c := &Cloner{
 		repoSpec: r,
@@ -133,7 +199,7 @@ func (c *Cloner) cloneAndCopy(ctx context.Context, dest string) error {
 	const op errors.Op = "fetch.cloneAndCopy"
 	pr := printer.FromContextOrDie(ctx)
 
-	err := c.ClonerUsingGitExec(ctx)
+	err := c.Clone(ctx)
 	if err != nil {
 		return errors.E(op, errors.Git, types.UniquePath(dest), err)
 	}
@@ -157,14 +223,13 @@ func (c *Cloner) cloneAndCopy(ctx context.Context, dest string) error {
 	return nil
 }
 
-// ClonerUsingGitExec uses a local git install, as opposed
-// to say, some remote API, to obtain a local clone of
-// a remote repo. It looks for tags with the directory as a prefix to allow
-// for versioning multiple kpt packages in a single repo independently. It
-// relies on the private clonerUsingGitExec function to try fetching different
-// refs.
-func (c *Cloner) ClonerUsingGitExec(ctx context.Context) error {
-	const op errors.Op = "fetch.ClonerUsingGitExec"
+// Clone fetches the package from the upstream repo to a temp directory,
+// which is set as the Dir of the repoSpec. It looks for tags with the directory
+// as a prefix to allow for versioning multiple kpt packages in a single repo
+// independently. The repo is fetched with a local git install, or with go-git
+// if gitutil.UseGoGit returns true.
+func (c *Cloner) Clone(ctx context.Context) error {
+	const op errors.Op = "fetch.Clone"
 
 	// Create a local representation of the upstream repo. This will initialize
 	// the cache for the specified repo uri if it isn't already there. It also
@@ -179,18 +244,11 @@ func (c *Cloner) ClonerUsingGitExec(ctx context.Context) error {
 		c.cachedRepo[c.repoSpec.CloneSpec()] = upstreamRepo
 	}
 
-	// Check if we have a ref in the upstream that matches the package-specific
-	// reference. If we do, we use that reference.
-	ps := strings.Split(c.repoSpec.Path, "/")
-	for len(ps) != 0 {
-		p := path.Join(ps...)
-		packageRef := path.Join(strings.TrimLeft(p, "/"), c.repoSpec.Ref)
-		if _, found := upstreamRepo.ResolveTag(packageRef); found {
-			c.repoSpec.Ref = packageRef
-			break
-		}
-		ps = ps[:len(ps)-1]
+	ref, commit, err := ResolveRef(upstreamRepo, c.repoSpec.Path, c.repoSpec.Ref)
+	if err != nil {
+		return errors.E(op, errors.Git, errors.Repo(c.repoSpec.CloneSpec()), err)
 	}
+	c.repoSpec.Ref = ref
 
 	// Pull the required ref into the repo git cache.
 	dir, err := upstreamRepo.GetRepo(ctx, []string{c.repoSpec.Ref})
@@ -198,16 +256,64 @@ func (c *Cloner) ClonerUsingGitExec(ctx context.Context) error {
 		return errors.E(op, errors.Git, errors.Repo(c.repoSpec.CloneSpec()), err)
 	}
 
-	gitRunner, err := gitutil.NewLocalGitRunner(dir)
+	if c.verificationPolicy != nil {
+		tag, _ := upstreamRepo.ResolveTag(c.repoSpec.Ref)
+		v, err := c.verificationPolicy.Verify(dir, c.repoSpec.Ref, tag, commit)
+		if err != nil {
+			return errors.E(op, errors.Repo(c.repoSpec.CloneSpec()), err)
+		}
+		c.repoSpec.Verification = v
+	}
+
+	// We need to create a temp directory where we can copy the content of the repo.
+	// During update, we need to checkout multiple versions of the same repo, so
+	// we can't do merges directly from the cache.
+	c.repoSpec.Dir, err = ioutil.TempDir("", "kpt-get-")
 	if err != nil {
-		return errors.E(op, errors.Git, errors.Repo(c.repoSpec.CloneSpec()), err)
+		return errors.E(op, errors.Internal, fmt.Errorf("error creating temp directory: %w", err))
 	}
+	c.repoSpec.Commit = commit
 
-	// Find the commit SHA for the ref that was just fetched. We need the SHA
-	// rather than the ref to be able to do a hard reset of the cache repo.
-	commit, found := upstreamRepo.ResolveRef(c.repoSpec.Ref)
-	if !found {
-		commit = c.repoSpec.Ref
+	if upstreamRepo.UsesGoGit() {
+		err = c.copyCommitUsingGoGit(ctx, dir, commit)
+	} else {
+		err = c.copyCommitUsingGitExec(ctx, dir, commit)
+	}
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	// Verify that if a Kptfile exists in the package, it contains the correct
+	// version of the Kptfile.
+	_, err = pkg.ReadKptfile(filesys.FileSystemOrOnDisk{}, c.repoSpec.AbsPath())
+	if err != nil {
+		// A Kptfile isn't required, so it is fine if there is no Kptfile.
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+
+		// If the error is of type KptfileError, we replace it with a
+		// RemoteKptfileError. This allows us to provide information about the
+		// git source of the Kptfile instead of the path to some random
+		// temporary directory.
+		var kfError *pkg.KptfileError
+		if errors.As(err, &kfError) {
+			return &pkg.RemoteKptfileError{
+				RepoSpec: c.repoSpec,
+				Err:      kfError.Err,
+			}
+		}
+	}
+	return nil
+}
+
+// copyCommitUsingGitExec uses a local git install to check out the commit in
+// the cache repo at dir, and copies the package to the temp directory.
+func (c *Cloner) copyCommitUsingGitExec(ctx context.Context, dir, commit string) error {
+	const op errors.Op = "fetch.copyCommitUsingGitExec"
+	gitRunner, err := gitutil.NewLocalGitRunner(dir)
+	if err != nil {
+		return errors.E(op, errors.Git, errors.Repo(c.repoSpec.CloneSpec()), err)
 	}
 
 	// Reset the local repo to the commit we need. Doing a hard reset instead of
@@ -223,15 +329,6 @@ func (c *Cloner) ClonerUsingGitExec(ctx context.Context) error {
 		return errors.E(op, errors.Git, errors.Repo(c.repoSpec.CloneSpec()), err)
 	}
 
-	// We need to create a temp directory where we can copy the content of the repo.
-	// During update, we need to checkout multiple versions of the same repo, so
-	// we can't do merges directly from the cache.
-	c.repoSpec.Dir, err = ioutil.TempDir("", "kpt-get-")
-	if err != nil {
-		return errors.E(op, errors.Internal, fmt.Errorf("error creating temp directory: %w", err))
-	}
-	c.repoSpec.Commit = commit
-
 	pkgPath := filepath.Join(dir, c.repoSpec.Path)
 	// Verify that the requested path exists in the repo.
 	_, err = os.Stat(pkgPath)
@@ -248,29 +345,53 @@ func (c *Cloner) ClonerUsingGitExec(ctx context.Context) error {
 	if err != nil {
 		return errors.E(op, errors.Internal, fmt.Errorf("error copying package: %w", err))
 	}
+	return nil
+}
+
+// ResolveRef resolves the ref of the package in the directory of the upstream
+// repo to the ref that should be fetched, and the commit SHA it references.
+// A semantic version range resolves to the highest matching tag, unless a
+// branch or tag has the same name as the range, like a `1.x` branch. Tags with
+// the package directory as a prefix take precedence over tags for the whole
+// repo. If the ref isn't a branch or tag, it is returned as the commit.
+func ResolveRef(upstreamRepo *gitutil.GitUpstreamRepo, directory, ref string) (string, string, error) {
+	if _, found := upstreamRepo.ResolveRef(ref); !found && gitutil.IsSemverRange(ref) {
+		for _, prefix := range append(packageTagPrefixes(directory), "") {
+			if tag, commit, found := upstreamRepo.ResolveSemverRange(ref, prefix); found {
+				return tag, commit, nil
+			}
+		}
+		return "", "", fmt.Errorf("no tag matches the version range %q", ref)
+	}
 
-	// Verify that if a Kptfile exists in the package, it contains the correct
-	// version of the Kptfile.
-	_, err = pkg.ReadKptfile(filesys.FileSystemOrOnDisk{}, pkgPath)
-	if err != nil {
-		// A Kptfile isn't required, so it is fine if there is no Kptfile.
-		if errors.Is(err, os.ErrNotExist) {
-			return nil
+	// Check if we have a ref in the upstream that matches the package-specific
+	// reference. If we do, we use that reference.
+	for _, prefix := range packageTagPrefixes(directory) {
+		if commit, found := upstreamRepo.ResolveTag(prefix + ref); found {
+			return prefix + ref, commit, nil
 		}
+	}
 
-		// If the error is of type KptfileError, we replace it with a
-		// RemoteKptfileError. This allows us to provide information about the
-		// git source of the Kptfile instead of the path to some random
-		// temporary directory.
-		var kfError *pkg.KptfileError
-		if errors.As(err, &kfError) {
-			return &pkg.RemoteKptfileError{
-				RepoSpec: c.repoSpec,
-				Err:      kfError.Err,
-			}
+	// Find the commit SHA for the ref. We need the SHA rather than the ref
+	// to be able to do a hard reset of the cache repo.
+	if commit, found := upstreamRepo.ResolveRef(ref); found {
+		return ref, commit, nil
+	}
+	return ref, ref, nil
+}
+
+// packageTagPrefixes returns the prefixes of package-specific tags for the
+// package directory, starting with the most specific one.
+func packageTagPrefixes(directory string) []string {
+	var prefixes []string
+	ps := strings.Split(directory, "/")
+	for len(ps) != 0 {
+		if p := strings.TrimLeft(path.Join(ps...), "/"); p != "" {
+			prefixes = append(prefixes, p+"/")
 		}
+		ps = ps[:len(ps)-1]
 	}
-	return nil
+	return prefixes
 }
 
 // copyDir copies a src directory to a dst directory.
@@ -19,6 +19,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/GoogleContainerTools/kpt/internal/gitutil"
 	"github.com/GoogleContainerTools/kpt/internal/pkg"
 	pkgtesting "github.com/GoogleContainerTools/kpt/internal/pkg/testing"
 	"github.com/GoogleContainerTools/kpt/internal/printer/fake"
@@ -698,3 +699,217 @@ func TestCommand_Run_failInvalidTag(t *testing.T) {
 		t.FailNow()
 	}
 }
+
+func TestCommand_Run_semverRange(t *testing.T) {
+	g, w, clean := setupWorkspace(t)
+	defer clean()
+
+	// tag the initial commit v1.0.0, then add commits with dataset2 tagged
+	// v1.1.0 and dataset3 tagged v2.0.0
+	err := g.Tag("v1.0.0")
+	assert.NoError(t, err)
+	err = g.ReplaceData(testutil.Dataset2)
+	assert.NoError(t, err)
+	_, err = g.Commit("new-data for v1.1.0")
+	assert.NoError(t, err)
+	commit, err := g.GetCommit()
+	assert.NoError(t, err)
+	err = g.Tag("v1.1.0")
+	assert.NoError(t, err)
+	err = g.ReplaceData(testutil.Dataset3)
+	assert.NoError(t, err)
+	_, err = g.Commit("new-data for v2.0.0")
+	assert.NoError(t, err)
+	err = g.Tag("v2.0.0")
+	assert.NoError(t, err)
+
+	err = createKptfile(w, &kptfilev1.Git{
+		Repo:      g.RepoDirectory,
+		Directory: "/",
+		Ref:       "^1.0",
+	}, kptfilev1.ResourceMerge)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	err = Command{
+		Pkg: pkgtesting.CreatePkgOrFail(t, w.FullPackagePath()),
+	}.Run(fake.CtxWithDefaultPrinter())
+	assert.NoError(t, err)
+
+	// verify the cloned contents matches the highest tag in the range
+	g.AssertEqual(t, filepath.Join(g.DatasetDirectory, testutil.Dataset2), w.FullPackagePath(), false)
+
+	// verify the KptFile keeps the range and locks the resolved tag
+	g.AssertKptfile(t, w.FullPackagePath(), kptfilev1.KptFile{
+		ResourceMeta: yaml.ResourceMeta{
+			ObjectMeta: yaml.ObjectMeta{
+				NameMeta: yaml.NameMeta{
+					Name: g.RepoName,
+				},
+			},
+			TypeMeta: yaml.TypeMeta{
+				APIVersion: kptfilev1.KptFileAPIVersion,
+				Kind:       kptfilev1.KptFileKind},
+		},
+		Upstream: &kptfilev1.Upstream{
+			Type: "git",
+			Git: &kptfilev1.Git{
+				Directory: "/",
+				Repo:      g.RepoDirectory,
+				Ref:       "^1.0",
+			},
+			UpdateStrategy: kptfilev1.ResourceMerge,
+		},
+		UpstreamLock: &kptfilev1.UpstreamLock{
+			Type: "git",
+			Git: &kptfilev1.GitLock{
+				Directory: "/",
+				Repo:      g.RepoDirectory,
+				Ref:       "v1.1.0",
+				Commit:    commit,
+			},
+		},
+	})
+}
+
+func TestResolveRef(t *testing.T) {
+	upstreamRepo := &gitutil.GitUpstreamRepo{
+		Heads: map[string]string{"main": "a", "2.x": "b"},
+		Tags: map[string]string{
+			"v1.0.0":     "c",
+			"v1.1.0":     "d",
+			"v2.0.0":     "e",
+			"pkg/v1.2.0": "f",
+		},
+	}
+	testCases := map[string]struct {
+		directory      string
+		ref            string
+		expectedRef    string
+		expectedCommit string
+	}{
+		"branch": {
+			ref:            "main",
+			expectedRef:    "main",
+			expectedCommit: "a",
+		},
+		"range": {
+			ref:            "^1.0",
+			expectedRef:    "v1.1.0",
+			expectedCommit: "d",
+		},
+		"x-range": {
+			ref:            "1.x",
+			expectedRef:    "v1.1.0",
+			expectedCommit: "d",
+		},
+		"x-range with package tags": {
+			directory:      "/pkg",
+			ref:            "1.x",
+			expectedRef:    "pkg/v1.2.0",
+			expectedCommit: "f",
+		},
+		"branch named like an x-range": {
+			ref:            "2.x",
+			expectedRef:    "2.x",
+			expectedCommit: "b",
+		},
+	}
+	for tn, tc := range testCases {
+		tc := tc
+		t.Run(tn, func(t *testing.T) {
+			ref, commit, err := ResolveRef(upstreamRepo, tc.directory, tc.ref)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, tc.expectedRef, ref)
+			assert.Equal(t, tc.expectedCommit, commit)
+		})
+	}
+}
+
+func TestCommand_Run_failNoMatchingSemverRange(t *testing.T) {
+	g, w, clean := setupWorkspace(t)
+	defer clean()
+
+	err := g.Tag("v1.0.0")
+	assert.NoError(t, err)
+
+	err = createKptfile(w, &kptfilev1.Git{
+		Repo:      g.RepoDirectory,
+		Directory: "/",
+		Ref:       "~2.1",
+	}, kptfilev1.ResourceMerge)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	err = Command{
+		Pkg: pkgtesting.CreatePkgOrFail(t, w.FullPackagePath()),
+	}.Run(fake.CtxWithDefaultPrinter())
+	if !assert.Error(t, err) {
+		t.FailNow()
+	}
+	assert.Contains(t, err.Error(), `no tag matches the version range "~2.1"`)
+}
+
+// TestCommand_Run_goGit verifies Command can fetch a package without the
+// git binary.
+func TestCommand_Run_goGit(t *testing.T) {
+	t.Setenv(gitutil.GitClientEnv, gitutil.GoGitClient)
+	g, w, clean := setupWorkspace(t)
+	defer clean()
+
+	subdir := "java"
+	err := createKptfile(w, &kptfilev1.Git{
+		Repo:      g.RepoDirectory,
+		Directory: subdir,
+		Ref:       "master",
+	}, kptfilev1.ResourceMerge)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	absPath := filepath.Join(w.WorkspaceDirectory, g.RepoName)
+	err = Command{
+		Pkg: pkgtesting.CreatePkgOrFail(t, w.FullPackagePath()),
+	}.Run(fake.CtxWithDefaultPrinter())
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// verify the cloned contents matches the repository
+	g.AssertEqual(t, filepath.Join(g.DatasetDirectory, testutil.Dataset1, subdir), absPath, false)
+
+	commit, err := g.GetCommit()
+	assert.NoError(t, err)
+	kf, err := pkg.ReadKptfile(filesys.FileSystemOrOnDisk{}, absPath)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, commit, kf.UpstreamLock.Git.Commit)
+}
+
+func TestCommand_Run_goGit_failNoPath(t *testing.T) {
+	t.Setenv(gitutil.GitClientEnv, gitutil.GoGitClient)
+	g, w, clean := setupWorkspace(t)
+	defer clean()
+
+	err := createKptfile(w, &kptfilev1.Git{
+		Repo:      g.RepoDirectory,
+		Directory: "/does-not-exist",
+		Ref:       "master",
+	}, kptfilev1.ResourceMerge)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	err = Command{
+		Pkg: pkgtesting.CreatePkgOrFail(t, w.FullPackagePath()),
+	}.Run(fake.CtxWithDefaultPrinter())
+	if !assert.Error(t, err) {
+		t.FailNow()
+	}
+	assert.Contains(t, err.Error(), `path "/does-not-exist" does not exist in repo`)
+}
@@ -0,0 +1,107 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/GoogleContainerTools/kpt/internal/errors"
+	"github.com/GoogleContainerTools/kpt/internal/gitutil"
+	"github.com/GoogleContainerTools/kpt/internal/printer"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// copyCommitUsingGoGit writes the package directory at the commit in the cache
+// repo at dir to the temp directory. Only the objects of the package directory
+// are read, and the rest of the repo is never checked out.
+func (c *Cloner) copyCommitUsingGoGit(ctx context.Context, dir, commit string) error {
+	const op errors.Op = "fetch.copyCommitUsingGoGit"
+	pr := printer.FromContextOrDie(ctx)
+
+	repo, err := gogit.PlainOpen(dir)
+	if err != nil {
+		return errors.E(op, errors.Git, errors.Repo(c.repoSpec.CloneSpec()), err)
+	}
+	commitObj, err := gitutil.ResolveCommit(repo, commit)
+	if err != nil {
+		return errors.E(op, errors.Git, errors.Repo(c.repoSpec.CloneSpec()), err)
+	}
+	tree, err := commitObj.Tree()
+	if err != nil {
+		return errors.E(op, errors.Git, errors.Repo(c.repoSpec.CloneSpec()), err)
+	}
+
+	// Verify that the requested path exists in the repo.
+	if p := strings.Trim(filepath.ToSlash(filepath.Clean(c.repoSpec.Path)), "/"); p != "" && p != "." {
+		tree, err = tree.Tree(p)
+		if err != nil {
+			return errors.E(op,
+				errors.Internal,
+				os.ErrNotExist,
+				fmt.Errorf("path %q does not exist in repo %q", c.repoSpec.Path, c.repoSpec.OrgRepo))
+		}
+	}
+
+	dst := c.repoSpec.AbsPath()
+	if err := os.MkdirAll(dst, 0700); err != nil {
+		return errors.E(op, errors.IO, err)
+	}
+	err = tree.Files().ForEach(func(f *object.File) error {
+		switch f.Mode {
+		case filemode.Symlink:
+			pr.Printf("[Warn] Ignoring symlink %q \n", f.Name)
+			return nil
+		case filemode.Submodule:
+			return nil
+		}
+		return writeFile(f, filepath.Join(dst, filepath.FromSlash(f.Name)))
+	})
+	if err != nil {
+		return errors.E(op, errors.Internal, fmt.Errorf("error copying package: %w", err))
+	}
+	return nil
+}
+
+// writeFile writes the content of the git file to path.
+func writeFile(f *object.File, path string) error {
+	mode, err := f.Mode.ToOSFileMode()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	r, err := f.Reader()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode.Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,467 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fetch
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/GoogleContainerTools/kpt/internal/errors"
+	"github.com/GoogleContainerTools/kpt/internal/gitutil"
+	"github.com/GoogleContainerTools/kpt/internal/printer"
+	"github.com/GoogleContainerTools/kpt/internal/types"
+	kptfilev1 "github.com/GoogleContainerTools/kpt/pkg/api/kptfile/v1"
+	"github.com/otiai10/copy"
+)
+
+const (
+	// snapshotCacheDir is the directory in the kpt cache where the snapshots
+	// of packages from directory and tarball upstreams are stored.
+	snapshotCacheDir = "snapshots"
+
+	hashPrefix = "sha256:"
+)
+
+// Snapshot is a copy of a package from a directory or tarball upstream,
+// identified by the hash of its content.
+type Snapshot struct {
+	// Dir is the temp directory with the content of the package.
+	Dir string
+
+	// Hash is the hash of the content of the package.
+	Hash string
+
+	// Lock is the upstream lock of a package fetched from the snapshot.
+	Lock *kptfilev1.UpstreamLock
+}
+
+// AbsPath returns the absolute path to the content of the package.
+func (s *Snapshot) AbsPath() string {
+	return s.Dir
+}
+
+// IsTarball returns true if the path or URL refers to a tarball.
+func IsTarball(s string) bool {
+	for _, ext := range []string{".tar", ".tar.gz", ".tgz"} {
+		if strings.HasSuffix(s, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// UpstreamSource returns where the package is fetched from, for use in
+// messages.
+func UpstreamSource(u *kptfilev1.Upstream) string {
+	switch {
+	case u.Type == kptfilev1.DirOrigin && u.Dir != nil:
+		return u.Dir.Path
+	case u.Type == kptfilev1.TarballOrigin && u.Tarball != nil:
+		return u.Tarball.URL
+	case u.Git != nil:
+		return u.Git.Repo + "@" + u.Git.Ref
+	}
+	return ""
+}
+
+// TakeSnapshot copies the package from the directory or tarball upstream to
+// a temp directory, and stores it in the kpt cache by the hash of its content,
+// so it can be used as the origin when the package is updated. Relative paths
+// in upstream are resolved against pkgPath.
+func TakeSnapshot(ctx context.Context, pkgPath string, upstream *kptfilev1.Upstream) (*Snapshot, error) {
+	const op errors.Op = "fetch.TakeSnapshot"
+	dir, err := ioutil.TempDir("", "kpt-get-")
+	if err != nil {
+		return nil, errors.E(op, errors.Internal, fmt.Errorf("error creating temp directory: %w", err))
+	}
+	s := &Snapshot{Dir: dir}
+
+	switch {
+	case upstream.Type == kptfilev1.DirOrigin && upstream.Dir != nil:
+		s.Lock = &kptfilev1.UpstreamLock{
+			Type: kptfilev1.DirOrigin,
+			Dir:  &kptfilev1.DirLock{Path: upstream.Dir.Path},
+		}
+		err = copyUpstreamDir(ctx, resolvePath(pkgPath, upstream.Dir.Path), dir)
+	case upstream.Type == kptfilev1.TarballOrigin && upstream.Tarball != nil:
+		s.Lock = &kptfilev1.UpstreamLock{
+			Type:    kptfilev1.TarballOrigin,
+			Tarball: &kptfilev1.TarballLock{URL: upstream.Tarball.URL},
+		}
+		err = extractTarball(ctx, pkgPath, upstream.Tarball.URL, dir)
+	default:
+		err = fmt.Errorf("kptfile upstream doesn't have dir or tarball information")
+	}
+	if err == nil {
+		s.Hash, err = hashDir(dir)
+	}
+	if err == nil {
+		err = storeSnapshot(dir, s.Hash)
+	}
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, errors.E(op, types.UniquePath(pkgPath), err)
+	}
+	if s.Lock.Dir != nil {
+		s.Lock.Dir.Hash = s.Hash
+	} else {
+		s.Lock.Tarball.Hash = s.Hash
+	}
+	return s, nil
+}
+
+// OriginSnapshot returns a copy of the package at the upstream lock. The
+// snapshot in the kpt cache is used if there is one. Otherwise the package is
+// fetched again from the locked directory or tarball, which only succeeds if
+// its content hasn't changed since.
+func OriginSnapshot(ctx context.Context, pkgPath string, lock *kptfilev1.UpstreamLock) (*Snapshot, error) {
+	const op errors.Op = "fetch.OriginSnapshot"
+	upstream := &kptfilev1.Upstream{Type: lock.Type}
+	var hash string
+	switch {
+	case lock.Type == kptfilev1.DirOrigin && lock.Dir != nil:
+		upstream.Dir = &kptfilev1.Dir{Path: lock.Dir.Path}
+		hash = lock.Dir.Hash
+	case lock.Type == kptfilev1.TarballOrigin && lock.Tarball != nil:
+		upstream.Tarball = &kptfilev1.Tarball{URL: lock.Tarball.URL}
+		hash = lock.Tarball.Hash
+	default:
+		return nil, errors.E(op, types.UniquePath(pkgPath), errors.MissingParam,
+			fmt.Errorf("kptfile upstreamLock doesn't have dir or tarball information"))
+	}
+
+	cachePath, err := snapshotCachePath(hash)
+	if err != nil {
+		return nil, errors.E(op, types.UniquePath(pkgPath), err)
+	}
+	if _, err := os.Stat(cachePath); err == nil {
+		markSnapshotUsed(cachePath)
+		dir, err := ioutil.TempDir("", "kpt-get-")
+		if err != nil {
+			return nil, errors.E(op, errors.Internal, fmt.Errorf("error creating temp directory: %w", err))
+		}
+		if err := copy.Copy(cachePath, dir); err != nil {
+			_ = os.RemoveAll(dir)
+			return nil, errors.E(op, errors.IO, types.UniquePath(pkgPath), err)
+		}
+		return &Snapshot{Dir: dir, Hash: hash, Lock: lock}, nil
+	}
+
+	s, err := TakeSnapshot(ctx, pkgPath, upstream)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+	if s.Hash != hash {
+		_ = os.RemoveAll(s.Dir)
+		return nil, errors.E(op, types.UniquePath(pkgPath), fmt.Errorf(
+			"snapshot %s of the upstream package is not in the kpt cache, and %q has changed since it was fetched",
+			hash, UpstreamSource(upstream)))
+	}
+	return s, nil
+}
+
+// resolvePath resolves a path relative to the package directory.
+func resolvePath(pkgPath, p string) string {
+	p = filepath.FromSlash(p)
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(pkgPath, p)
+}
+
+// copyUpstreamDir copies the package in the upstream directory src to dst.
+func copyUpstreamDir(ctx context.Context, src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return fmt.Errorf("upstream directory %q does not exist", src)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("upstream %q is not a directory", src)
+	}
+	if err := copyDir(ctx, src, dst); err != nil {
+		return fmt.Errorf("error copying package: %w", err)
+	}
+	return nil
+}
+
+// extractTarball downloads or opens the tarball at url, and extracts it to
+// dst. If the tarball has a single top-level directory, its content is
+// extracted to dst instead.
+func extractTarball(ctx context.Context, pkgPath, url string, dst string) error {
+	r, err := openTarball(ctx, pkgPath, url)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	br := bufio.NewReader(r)
+	var tr *tar.Reader
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return fmt.Errorf("error reading tarball %q: %w", url, err)
+		}
+		defer gz.Close()
+		tr = tar.NewReader(gz)
+	} else {
+		tr = tar.NewReader(br)
+	}
+
+	pr := printer.FromContextOrDie(ctx)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("error reading tarball %q: %w", url, err)
+		}
+		name := path.Clean(strings.TrimPrefix(hdr.Name, "./"))
+		if name == "." {
+			continue
+		}
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return fmt.Errorf("tarball %q contains invalid path %q", url, hdr.Name)
+		}
+		if name == ".git" || strings.HasPrefix(name, ".git/") || strings.Contains(name, "/.git/") {
+			continue
+		}
+		target := filepath.Join(dst, filepath.FromSlash(name))
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0700); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := writeTarballFile(tr, target, hdr.FileInfo().Mode().Perm()); err != nil {
+				return err
+			}
+		case tar.TypeSymlink, tar.TypeLink:
+			pr.Printf("[Warn] Ignoring symlink %q \n", name)
+		}
+	}
+	return stripTopLevelDir(dst)
+}
+
+// openTarball opens the tarball at the path relative to pkgPath, or
+// downloads it if url is an http(s) URL.
+func openTarball(ctx context.Context, pkgPath, url string) (io.ReadCloser, error) {
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		f, err := os.Open(resolvePath(pkgPath, url))
+		if err != nil {
+			return nil, fmt.Errorf("error opening tarball: %w", err)
+		}
+		return f, nil
+	}
+
+	if gitutil.OfflineFromContext(ctx) {
+		return nil, fmt.Errorf("tarball %q can't be downloaded in offline mode", url)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tarball url %q: %w", url, err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error downloading tarball %q: %w", url, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("error downloading tarball %q: %s", url, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+func writeTarballFile(r io.Reader, path string, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm|0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// stripTopLevelDir moves the content of the only entry in dir up to dir, if
+// that entry is a directory.
+func stripTopLevelDir(dir string) error {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil || len(entries) != 1 || !entries[0].IsDir() {
+		return err
+	}
+	root := dir + ".root"
+	if err := os.Rename(filepath.Join(dir, entries[0].Name()), root); err != nil {
+		return err
+	}
+	if err := os.Remove(dir); err != nil {
+		return err
+	}
+	return os.Rename(root, dir)
+}
+
+// hashDir returns the hash of the paths and contents of the files in dir.
+func hashDir(dir string) (string, error) {
+	var files []string
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			rel, err := filepath.Rel(dir, p)
+			if err != nil {
+				return err
+			}
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(files)
+
+	h := sha256.New()
+	for _, f := range files {
+		b, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(f)))
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%x  %s\n", sha256.Sum256(b), f)
+	}
+	return hashPrefix + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// snapshotCachePath returns the directory of the snapshot with the hash in
+// the kpt cache.
+func snapshotCachePath(hash string) (string, error) {
+	sum := strings.TrimPrefix(hash, hashPrefix)
+	if b, err := hex.DecodeString(sum); err != nil || len(b) != sha256.Size || sum == hash {
+		return "", fmt.Errorf("invalid upstream content hash %q", hash)
+	}
+	cacheDir, err := gitutil.RepoCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, snapshotCacheDir, sum), nil
+}
+
+// storeSnapshot copies the package in dir to the kpt cache, unless there is
+// a snapshot with the hash already.
+func storeSnapshot(dir, hash string) error {
+	cachePath, err := snapshotCachePath(hash)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(cachePath); err == nil {
+		markSnapshotUsed(cachePath)
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0700); err != nil {
+		return err
+	}
+	// Copy to a temp directory first, so an interrupted copy never leaves an
+	// incomplete snapshot behind.
+	tmp, err := ioutil.TempDir(filepath.Dir(cachePath), "tmp-")
+	if err != nil {
+		return err
+	}
+	if err := copy.Copy(dir, tmp); err != nil {
+		_ = os.RemoveAll(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, cachePath); err != nil {
+		_ = os.RemoveAll(tmp)
+		if _, statErr := os.Stat(cachePath); statErr == nil {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// markSnapshotUsed updates the modification time of the snapshot, which is
+// used as the time it was last used when pruning the cache.
+func markSnapshotUsed(cachePath string) {
+	now := time.Now()
+	_ = os.Chtimes(cachePath, now, now)
+}
+
+// ListCachedSnapshots returns the snapshots in the kpt cache, with the most
+// recently used first. The URI of a snapshot is the hash of its content.
+func ListCachedSnapshots() ([]gitutil.CachedRepo, error) {
+	const op errors.Op = "fetch.ListCachedSnapshots"
+	cacheDir, err := gitutil.RepoCacheDir()
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+	entries, err := ioutil.ReadDir(filepath.Join(cacheDir, snapshotCacheDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.E(op, errors.IO, err)
+	}
+
+	var snapshots []gitutil.CachedRepo
+	for _, e := range entries {
+		// Skip the temp directories of snapshots that are being stored.
+		if b, err := hex.DecodeString(e.Name()); !e.IsDir() || err != nil || len(b) != sha256.Size {
+			continue
+		}
+		dir := filepath.Join(cacheDir, snapshotCacheDir, e.Name())
+		var size int64
+		err := filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() {
+				size += info.Size()
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, errors.E(op, errors.IO, err)
+		}
+		snapshots = append(snapshots, gitutil.CachedRepo{
+			Dir:      dir,
+			URI:      hashPrefix + e.Name(),
+			Size:     size,
+			LastUsed: e.ModTime(),
+		})
+	}
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].LastUsed.After(snapshots[j].LastUsed)
+	})
+	return snapshots, nil
+}
@@ -0,0 +1,244 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fetch_test
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleContainerTools/kpt/internal/gitutil"
+	"github.com/GoogleContainerTools/kpt/internal/pkg"
+	pkgtesting "github.com/GoogleContainerTools/kpt/internal/pkg/testing"
+	"github.com/GoogleContainerTools/kpt/internal/printer/fake"
+	. "github.com/GoogleContainerTools/kpt/internal/util/fetch"
+	kptfilev1 "github.com/GoogleContainerTools/kpt/pkg/api/kptfile/v1"
+	"github.com/GoogleContainerTools/kpt/pkg/kptfile/kptfileutil"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+const snapshotConfigMap = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm
+data:
+  foo: bar
+`
+
+// writeTarball writes a gzipped tarball with the files to path.
+func writeTarball(t *testing.T, path string, files map[string]string) {
+	f, err := os.Create(path)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		err := tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(content)),
+			Typeflag: tar.TypeReg,
+		})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		if _, err := tw.Write([]byte(content)); !assert.NoError(t, err) {
+			t.FailNow()
+		}
+	}
+	if !assert.NoError(t, tw.Close()) || !assert.NoError(t, gz.Close()) {
+		t.FailNow()
+	}
+}
+
+func TestCommand_Run_dir(t *testing.T) {
+	t.Setenv(gitutil.RepoCacheDirEnv, t.TempDir())
+	dir := t.TempDir()
+	upstream := filepath.Join(dir, "blueprint")
+	local := filepath.Join(dir, "local")
+	for _, d := range []string{upstream, local} {
+		if !assert.NoError(t, os.MkdirAll(d, 0700)) {
+			t.FailNow()
+		}
+	}
+	if !assert.NoError(t, os.WriteFile(filepath.Join(upstream, "cm.yaml"), []byte(snapshotConfigMap), 0600)) {
+		t.FailNow()
+	}
+
+	kf := kptfileutil.DefaultKptfile("local")
+	kf.Upstream = &kptfilev1.Upstream{
+		Type:           kptfilev1.DirOrigin,
+		Dir:            &kptfilev1.Dir{Path: "../blueprint"},
+		UpdateStrategy: kptfilev1.ResourceMerge,
+	}
+	if !assert.NoError(t, kptfileutil.WriteFile(local, kf)) {
+		t.FailNow()
+	}
+
+	p := pkgtesting.CreatePkgOrFail(t, local)
+	if !assert.NoError(t, Command{Pkg: p}.Run(fake.CtxWithDefaultPrinter())) {
+		t.FailNow()
+	}
+	b, err := os.ReadFile(filepath.Join(local, "cm.yaml"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, snapshotConfigMap, string(b))
+	}
+
+	kf, err = pkg.ReadKptfile(filesys.FileSystemOrOnDisk{}, local)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	if assert.NotNil(t, kf.UpstreamLock) && assert.NotNil(t, kf.UpstreamLock.Dir) {
+		assert.Equal(t, kptfilev1.DirOrigin, kf.UpstreamLock.Type)
+		assert.Equal(t, "../blueprint", kf.UpstreamLock.Dir.Path)
+		assert.Regexp(t, "^sha256:[0-9a-f]{64}$", kf.UpstreamLock.Dir.Hash)
+	}
+}
+
+func TestCommand_Run_failNoDirPath(t *testing.T) {
+	local := t.TempDir()
+	kf := kptfileutil.DefaultKptfile("local")
+	kf.Upstream = &kptfilev1.Upstream{Type: kptfilev1.DirOrigin}
+	if !assert.NoError(t, kptfileutil.WriteFile(local, kf)) {
+		t.FailNow()
+	}
+
+	err := Command{
+		Pkg: pkgtesting.CreatePkgOrFail(t, local),
+	}.Run(fake.CtxWithDefaultPrinter())
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "kptfile upstream doesn't have a directory path")
+	}
+}
+
+func TestTakeSnapshot_tarball(t *testing.T) {
+	testCases := map[string]struct {
+		files         map[string]string
+		expectedFiles []string
+		expectedErr   string
+	}{
+		"files at the root": {
+			files: map[string]string{
+				"Kptfile": "apiVersion: kpt.dev/v1\nkind: Kptfile\nmetadata:\n  name: bp\n",
+				"cm.yaml": snapshotConfigMap,
+			},
+			expectedFiles: []string{"Kptfile", "cm.yaml"},
+		},
+		"single top-level directory": {
+			files: map[string]string{
+				"bp-v1/cm.yaml":     snapshotConfigMap,
+				"bp-v1/sub/cm.yaml": snapshotConfigMap,
+			},
+			expectedFiles: []string{"cm.yaml", filepath.Join("sub", "cm.yaml")},
+		},
+		"path outside the package": {
+			files: map[string]string{
+				"../cm.yaml": snapshotConfigMap,
+			},
+			expectedErr: `contains invalid path "../cm.yaml"`,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			t.Setenv(gitutil.RepoCacheDirEnv, t.TempDir())
+			dir := t.TempDir()
+			writeTarball(t, filepath.Join(dir, "bp.tgz"), tc.files)
+
+			s, err := TakeSnapshot(fake.CtxWithDefaultPrinter(), dir, &kptfilev1.Upstream{
+				Type:    kptfilev1.TarballOrigin,
+				Tarball: &kptfilev1.Tarball{URL: "bp.tgz"},
+			})
+			if tc.expectedErr != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tc.expectedErr)
+				}
+				return
+			}
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			defer os.RemoveAll(s.Dir)
+
+			var files []string
+			_ = filepath.Walk(s.Dir, func(p string, info os.FileInfo, err error) error {
+				if err == nil && !info.IsDir() {
+					rel, _ := filepath.Rel(s.Dir, p)
+					files = append(files, rel)
+				}
+				return err
+			})
+			assert.ElementsMatch(t, tc.expectedFiles, files)
+			assert.Equal(t, s.Hash, s.Lock.Tarball.Hash)
+			assert.Equal(t, "bp.tgz", s.Lock.Tarball.URL)
+		})
+	}
+}
+
+func TestOriginSnapshot(t *testing.T) {
+	t.Setenv(gitutil.RepoCacheDirEnv, t.TempDir())
+	ctx := fake.CtxWithDefaultPrinter()
+	dir := t.TempDir()
+	cmPath := filepath.Join(dir, "blueprint", "cm.yaml")
+	if !assert.NoError(t, os.MkdirAll(filepath.Dir(cmPath), 0700)) ||
+		!assert.NoError(t, os.WriteFile(cmPath, []byte(snapshotConfigMap), 0600)) {
+		t.FailNow()
+	}
+	upstream := &kptfilev1.Upstream{
+		Type: kptfilev1.DirOrigin,
+		Dir:  &kptfilev1.Dir{Path: "blueprint"},
+	}
+
+	s, err := TakeSnapshot(ctx, dir, upstream)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(s.Dir)
+
+	// Changes to the upstream directory don't change the snapshot.
+	if !assert.NoError(t, os.WriteFile(cmPath, []byte("changed"), 0600)) {
+		t.FailNow()
+	}
+	origin, err := OriginSnapshot(ctx, dir, s.Lock)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(origin.Dir)
+	b, err := os.ReadFile(filepath.Join(origin.Dir, "cm.yaml"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, snapshotConfigMap, string(b))
+	}
+
+	// Without the snapshot in the cache, the origin can only be fetched from
+	// upstream if it hasn't changed.
+	t.Setenv(gitutil.RepoCacheDirEnv, t.TempDir())
+	_, err = OriginSnapshot(ctx, dir, s.Lock)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "has changed since it was fetched")
+	}
+	if !assert.NoError(t, os.WriteFile(cmPath, []byte(snapshotConfigMap), 0600)) {
+		t.FailNow()
+	}
+	origin, err = OriginSnapshot(ctx, dir, s.Lock)
+	if assert.NoError(t, err) {
+		defer os.RemoveAll(origin.Dir)
+		assert.Equal(t, s.Hash, origin.Hash)
+	}
+}
@@ -0,0 +1,406 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fetch
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/GoogleContainerTools/kpt/internal/errors"
+	"github.com/GoogleContainerTools/kpt/internal/gitutil"
+	kptfilev1 "github.com/GoogleContainerTools/kpt/pkg/api/kptfile/v1"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"golang.org/x/crypto/ssh"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// VerificationPolicyEnv is the environment variable with the path to the
+// upstream verification policy, if it isn't provided with a flag.
+const VerificationPolicyEnv = "KPT_VERIFICATION_POLICY"
+
+const (
+	// VerificationPolicyAPIVersion is the apiVersion of verification policies.
+	VerificationPolicyAPIVersion = "kpt.dev/v1alpha1"
+	// VerificationPolicyKind is the kind of verification policies.
+	VerificationPolicyKind = "UpstreamVerificationPolicy"
+)
+
+// SignatureRequirement defines which git object must be signed.
+type SignatureRequirement string
+
+const (
+	// RequireSignedTag requires that the upstream ref is an annotated tag
+	// signed by a trusted key.
+	RequireSignedTag SignatureRequirement = "tag"
+	// RequireSignedCommit requires that the upstream commit is signed by a
+	// trusted key.
+	RequireSignedCommit SignatureRequirement = "commit"
+	// RequireSignedTagOrCommit accepts a signed tag, and otherwise requires a
+	// signed commit. This is the default.
+	RequireSignedTagOrCommit SignatureRequirement = "tagOrCommit"
+)
+
+const (
+	pgpSignatureHeader = "-----BEGIN PGP SIGNATURE-----"
+	sshSignatureHeader = "-----BEGIN SSH SIGNATURE-----"
+	sshSignatureFooter = "-----END SSH SIGNATURE-----"
+)
+
+// VerificationPolicy defines the signatures that upstream packages must have
+// before they are fetched.
+type VerificationPolicy struct {
+	yaml.ResourceMeta `yaml:",inline"`
+
+	// Require is the git object that must be signed. Defaults to
+	// tagOrCommit.
+	Require SignatureRequirement `yaml:"require,omitempty"`
+
+	// TrustedKeys are the keys that upstream tags or commits can be signed
+	// with.
+	TrustedKeys []TrustedKey `yaml:"trustedKeys,omitempty"`
+}
+
+// TrustedKey is a public key that is trusted to sign upstream packages.
+// Exactly one of GPG and SSH must be set.
+type TrustedKey struct {
+	// Name identifies the key in the UpstreamLock of fetched packages.
+	Name string `yaml:"name"`
+
+	// GPG is an armored GPG public key.
+	GPG string `yaml:"gpg,omitempty"`
+
+	// SSH is an SSH public key in the authorized_keys format.
+	SSH string `yaml:"ssh,omitempty"`
+
+	sshKey ssh.PublicKey
+}
+
+// ReadVerificationPolicy reads and validates the verification policy at
+// path.
+func ReadVerificationPolicy(path string) (*VerificationPolicy, error) {
+	const op errors.Op = "fetch.ReadVerificationPolicy"
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.E(op, errors.IO, fmt.Errorf("unable to read verification policy: %w", err))
+	}
+	p := &VerificationPolicy{}
+	if err := yaml.Unmarshal(b, p); err != nil {
+		return nil, errors.E(op, errors.YAML, fmt.Errorf("unable to parse verification policy %q: %w", path, err))
+	}
+	if err := p.Validate(); err != nil {
+		return nil, errors.E(op, fmt.Errorf("invalid verification policy %q: %w", path, err))
+	}
+	return p, nil
+}
+
+// LoadVerificationPolicy reads the verification policy at path, or at the
+// path in KPT_VERIFICATION_POLICY if path is empty. It returns nil if neither
+// is set.
+func LoadVerificationPolicy(path string) (*VerificationPolicy, error) {
+	if path == "" {
+		path = os.Getenv(VerificationPolicyEnv)
+	}
+	if path == "" {
+		return nil, nil
+	}
+	return ReadVerificationPolicy(path)
+}
+
+// VerifySnapshotUpstream returns an error if a verification policy is set,
+// since packages from directory and tarball upstreams have no signatures that
+// could be verified. They are rejected rather than fetched unverified.
+func VerifySnapshotUpstream(p *VerificationPolicy) error {
+	const op errors.Op = "fetch.VerifySnapshotUpstream"
+	if p == nil {
+		return nil
+	}
+	return errors.E(op, errors.InvalidParam,
+		fmt.Errorf("signature verification is not supported for dir/tarball upstreams"))
+}
+
+// Validate makes sure the policy is well-formed and parses the SSH keys.
+func (p *VerificationPolicy) Validate() error {
+	const op errors.Op = "fetch.ValidateVerificationPolicy"
+	if p.APIVersion != VerificationPolicyAPIVersion || p.Kind != VerificationPolicyKind {
+		return errors.E(op, errors.InvalidParam, fmt.Errorf("expected apiVersion %q and kind %q",
+			VerificationPolicyAPIVersion, VerificationPolicyKind))
+	}
+	switch p.Require {
+	case "":
+		p.Require = RequireSignedTagOrCommit
+	case RequireSignedTag, RequireSignedCommit, RequireSignedTagOrCommit:
+	default:
+		return errors.E(op, errors.InvalidParam, fmt.Errorf("require must be one of %q, %q or %q",
+			RequireSignedTag, RequireSignedCommit, RequireSignedTagOrCommit))
+	}
+	// An empty policy would reject every package, which is almost certainly
+	// a mistake.
+	if len(p.TrustedKeys) == 0 {
+		return errors.E(op, errors.MissingParam, fmt.Errorf("at least one trusted key must be provided"))
+	}
+	for i := range p.TrustedKeys {
+		k := &p.TrustedKeys[i]
+		if k.Name == "" {
+			return errors.E(op, errors.MissingParam, fmt.Errorf("trusted key %d must have a name", i))
+		}
+		if (k.GPG == "") == (k.SSH == "") {
+			return errors.E(op, errors.InvalidParam,
+				fmt.Errorf("trusted key %q must have exactly one of gpg or ssh", k.Name))
+		}
+		if k.SSH != "" {
+			pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(k.SSH))
+			if err != nil {
+				return errors.E(op, errors.InvalidParam,
+					fmt.Errorf("unable to parse ssh key %q: %w", k.Name, err))
+			}
+			k.sshKey = pub
+		}
+	}
+	return nil
+}
+
+// Verify verifies the signature of the tag or commit in the repo at dir
+// according to the policy. tag is the hash of the tag the upstream ref
+// resolved to, or empty if the ref isn't a tag. Verification fails if the
+// required object isn't signed, or if the signature can't be verified with a
+// trusted key. A signed tag object must also have the name of ref, so that a
+// signed tag can't be passed off as another version.
+func (p *VerificationPolicy) Verify(dir, ref, tag, commit string) (*kptfilev1.GitVerification, error) {
+	const op errors.Op = "fetch.Verify"
+	repo, err := gogit.PlainOpen(dir)
+	if err != nil {
+		return nil, errors.E(op, errors.Git, err)
+	}
+
+	var tagErr error
+	if p.Require != RequireSignedCommit {
+		tagErr = fmt.Errorf("ref is not an annotated tag")
+		if tag != "" {
+			// Lightweight tags reference the commit directly.
+			if tagObj, err := repo.TagObject(plumbing.NewHash(tag)); err == nil {
+				v, err := p.verifyTag(tagObj, strings.TrimPrefix(ref, "refs/tags/"))
+				if err == nil {
+					return v, nil
+				}
+				tagErr = err
+			}
+		}
+		if p.Require == RequireSignedTag {
+			return nil, errors.E(op, errors.Git, fmt.Errorf(
+				"verification policy requires a signed tag: %w", tagErr))
+		}
+	}
+
+	commitObj, err := gitutil.ResolveCommit(repo, commit)
+	if err != nil {
+		return nil, errors.E(op, errors.Git, err)
+	}
+	v, err := p.verifyCommit(commitObj)
+	if err != nil {
+		if tagErr != nil {
+			return nil, errors.E(op, errors.Git, fmt.Errorf(
+				"verification policy requires a signed tag or commit: tag: %v; commit: %w", tagErr, err))
+		}
+		return nil, errors.E(op, errors.Git, fmt.Errorf(
+			"verification policy requires a signed commit: %w", err))
+	}
+	return v, nil
+}
+
+func (p *VerificationPolicy) verifyTag(t *object.Tag, name string) (*kptfilev1.GitVerification, error) {
+	// The signature covers the name in the tag object, not the name of the
+	// ref pointing to it.
+	if t.Name != name {
+		return nil, fmt.Errorf("tag %q points to the tag object of %q", name, t.Name)
+	}
+	if t.PGPSignature != "" {
+		v, err := p.verifyGPG(func(key string) (string, error) {
+			e, err := t.Verify(key)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%X", e.PrimaryKey.Fingerprint), nil
+		})
+		return withObject(v, "tag", t.Tagger.String()), err
+	}
+
+	// go-git only separates PGP signatures from the message of tags, so SSH
+	// signatures are still at the end of the message.
+	i := strings.Index(t.Message, sshSignatureHeader)
+	if i < 0 {
+		return nil, fmt.Errorf("tag %q is not signed", t.Name)
+	}
+	unsigned := *t
+	unsigned.Message = t.Message[:i]
+	payload, err := encode(unsigned.EncodeWithoutSignature)
+	if err != nil {
+		return nil, err
+	}
+	v, err := p.verifySSH(t.Message[i:], payload)
+	return withObject(v, "tag", t.Tagger.String()), err
+}
+
+func (p *VerificationPolicy) verifyCommit(c *object.Commit) (*kptfilev1.GitVerification, error) {
+	switch {
+	case strings.HasPrefix(c.PGPSignature, pgpSignatureHeader):
+		v, err := p.verifyGPG(func(key string) (string, error) {
+			e, err := c.Verify(key)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%X", e.PrimaryKey.Fingerprint), nil
+		})
+		return withObject(v, "commit", c.Committer.String()), err
+	case strings.HasPrefix(c.PGPSignature, sshSignatureHeader):
+		payload, err := encode(c.EncodeWithoutSignature)
+		if err != nil {
+			return nil, err
+		}
+		v, err := p.verifySSH(c.PGPSignature, payload)
+		return withObject(v, "commit", c.Committer.String()), err
+	case c.PGPSignature == "":
+		return nil, fmt.Errorf("commit %q is not signed", c.Hash)
+	default:
+		return nil, fmt.Errorf("commit %q has an unsupported signature type", c.Hash)
+	}
+}
+
+// verifyGPG tries the GPG keys of the policy with the verify function, which
+// returns the fingerprint of the key that made the signature.
+func (p *VerificationPolicy) verifyGPG(verify func(key string) (string, error)) (*kptfilev1.GitVerification, error) {
+	for _, k := range p.TrustedKeys {
+		if k.GPG == "" {
+			continue
+		}
+		fingerprint, err := verify(k.GPG)
+		if err != nil {
+			continue
+		}
+		return &kptfilev1.GitVerification{Key: k.Name, Fingerprint: fingerprint}, nil
+	}
+	return nil, fmt.Errorf("GPG signature is not from a trusted key")
+}
+
+// verifySSH verifies an armored SSH signature in the git namespace of the
+// payload, following the SSHSIG format of OpenSSH.
+func (p *VerificationPolicy) verifySSH(armored string, payload []byte) (*kptfilev1.GitVerification, error) {
+	sig, err := parseSSHSignature(armored)
+	if err != nil {
+		return nil, err
+	}
+	if sig.Namespace != "git" {
+		return nil, fmt.Errorf("SSH signature has namespace %q rather than \"git\"", sig.Namespace)
+	}
+	pub, err := ssh.ParsePublicKey(sig.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse SSH signature key: %w", err)
+	}
+
+	var h hash.Hash
+	switch sig.HashAlgorithm {
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return nil, fmt.Errorf("unsupported SSH signature hash algorithm %q", sig.HashAlgorithm)
+	}
+	h.Write(payload)
+	signed := append([]byte("SSHSIG"), ssh.Marshal(struct {
+		Namespace     string
+		Reserved      string
+		HashAlgorithm string
+		Hash          []byte
+	}{sig.Namespace, sig.Reserved, sig.HashAlgorithm, h.Sum(nil)})...)
+
+	signature := &ssh.Signature{}
+	if err := ssh.Unmarshal(sig.Signature, signature); err != nil {
+		return nil, fmt.Errorf("unable to parse SSH signature: %w", err)
+	}
+
+	for _, k := range p.TrustedKeys {
+		if k.sshKey == nil || !bytes.Equal(k.sshKey.Marshal(), pub.Marshal()) {
+			continue
+		}
+		if err := pub.Verify(signed, signature); err != nil {
+			return nil, fmt.Errorf("invalid SSH signature: %w", err)
+		}
+		return &kptfilev1.GitVerification{Key: k.Name, Fingerprint: ssh.FingerprintSHA256(pub)}, nil
+	}
+	return nil, fmt.Errorf("SSH signature is not from a trusted key")
+}
+
+type sshSignature struct {
+	Version       uint32
+	PublicKey     []byte
+	Namespace     string
+	Reserved      string
+	HashAlgorithm string
+	Signature     []byte
+}
+
+func parseSSHSignature(armored string) (*sshSignature, error) {
+	armored = strings.TrimSpace(armored)
+	if !strings.HasPrefix(armored, sshSignatureHeader) || !strings.HasSuffix(armored, sshSignatureFooter) {
+		return nil, fmt.Errorf("malformed SSH signature")
+	}
+	body := strings.TrimSuffix(strings.TrimPrefix(armored, sshSignatureHeader), sshSignatureFooter)
+	b, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(body), ""))
+	if err != nil {
+		return nil, fmt.Errorf("malformed SSH signature: %w", err)
+	}
+	if !bytes.HasPrefix(b, []byte("SSHSIG")) {
+		return nil, fmt.Errorf("malformed SSH signature")
+	}
+	sig := &sshSignature{}
+	if err := ssh.Unmarshal(b[len("SSHSIG"):], sig); err != nil {
+		return nil, fmt.Errorf("malformed SSH signature: %w", err)
+	}
+	if sig.Version != 1 {
+		return nil, fmt.Errorf("unsupported SSH signature version %d", sig.Version)
+	}
+	return sig, nil
+}
+
+// encode returns the payload that was signed for a git object.
+func encode(encodeWithoutSignature func(plumbing.EncodedObject) error) ([]byte, error) {
+	o := &plumbing.MemoryObject{}
+	if err := encodeWithoutSignature(o); err != nil {
+		return nil, err
+	}
+	r, err := o.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+func withObject(v *kptfilev1.GitVerification, object, signer string) *kptfilev1.GitVerification {
+	if v != nil {
+		v.Object = object
+		v.Signer = signer
+	}
+	return v
+}
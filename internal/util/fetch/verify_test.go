@@ -0,0 +1,431 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fetch_test
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/GoogleContainerTools/kpt/internal/util/fetch"
+	kptfilev1 "github.com/GoogleContainerTools/kpt/pkg/api/kptfile/v1"
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+)
+
+var testSignature = &object.Signature{
+	Name:  "Release Team",
+	Email: "release@example.com",
+	When:  time.Unix(1650000000, 0),
+}
+
+// signingRepo is a git repo with objects signed with a GPG and an SSH key.
+type signingRepo struct {
+	dir  string
+	repo *gogit.Repository
+
+	gpgKey       *openpgp.Entity
+	gpgPublicKey string
+
+	sshSigner    ssh.Signer
+	sshPublicKey string
+}
+
+func newSigningRepo(t *testing.T) *signingRepo {
+	dir := t.TempDir()
+	repo, err := gogit.PlainInit(dir, false)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	gpgKey, err := openpgp.NewEntity("Release Team", "", "release@example.com", nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	buf := &bytes.Buffer{}
+	w, err := armor.Encode(buf, openpgp.PublicKeyType, nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	if !assert.NoError(t, gpgKey.Serialize(w)) || !assert.NoError(t, w.Close()) {
+		t.FailNow()
+	}
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	sshSigner, err := ssh.NewSignerFromKey(priv)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	return &signingRepo{
+		dir:          dir,
+		repo:         repo,
+		gpgKey:       gpgKey,
+		gpgPublicKey: buf.String(),
+		sshSigner:    sshSigner,
+		sshPublicKey: string(ssh.MarshalAuthorizedKey(sshSigner.PublicKey())),
+	}
+}
+
+// commit creates a commit, signed with the GPG key if gpgSign is true.
+func (r *signingRepo) commit(t *testing.T, content string, gpgSign bool) plumbing.Hash {
+	wt, err := r.repo.Worktree()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	if !assert.NoError(t, os.WriteFile(filepath.Join(r.dir, "cm.yaml"), []byte(content), 0600)) {
+		t.FailNow()
+	}
+	if _, err := wt.Add("cm.yaml"); !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	opts := &gogit.CommitOptions{Author: testSignature}
+	if gpgSign {
+		opts.SignKey = r.gpgKey
+	}
+	h, err := wt.Commit(content, opts)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return h
+}
+
+// sshSignCommit stores a copy of the commit signed with the SSH key.
+func (r *signingRepo) sshSignCommit(t *testing.T, h plumbing.Hash) plumbing.Hash {
+	c, err := r.repo.CommitObject(h)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	payload := &plumbing.MemoryObject{}
+	if !assert.NoError(t, c.EncodeWithoutSignature(payload)) {
+		t.FailNow()
+	}
+	c.PGPSignature = r.sshSign(t, readObject(t, payload))
+	return r.store(t, c.Encode)
+}
+
+// tag creates an annotated tag, with an SSH signature if sshSign is true and
+// a GPG signature if gpgSign is true.
+func (r *signingRepo) tag(t *testing.T, name string, commit plumbing.Hash, gpgSign, sshSign bool) string {
+	tag := &object.Tag{
+		Name:       name,
+		Tagger:     *testSignature,
+		Message:    name + "\n",
+		TargetType: plumbing.CommitObject,
+		Target:     commit,
+	}
+	switch {
+	case gpgSign:
+		payload := &plumbing.MemoryObject{}
+		if !assert.NoError(t, tag.EncodeWithoutSignature(payload)) {
+			t.FailNow()
+		}
+		sig := &bytes.Buffer{}
+		err := openpgp.ArmoredDetachSign(sig, r.gpgKey, bytes.NewReader(readObject(t, payload)), nil)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		tag.PGPSignature = sig.String()
+	case sshSign:
+		payload := &plumbing.MemoryObject{}
+		if !assert.NoError(t, tag.EncodeWithoutSignature(payload)) {
+			t.FailNow()
+		}
+		tag.Message += r.sshSign(t, readObject(t, payload))
+	}
+	return r.store(t, tag.Encode).String()
+}
+
+// sshSign creates an armored SSH signature of the payload in the git
+// namespace.
+func (r *signingRepo) sshSign(t *testing.T, payload []byte) string {
+	h := sha512.Sum512(payload)
+	signed := append([]byte("SSHSIG"), ssh.Marshal(struct {
+		Namespace, Reserved, HashAlgorithm string
+		Hash                               []byte
+	}{"git", "", "sha512", h[:]})...)
+	sig, err := r.sshSigner.Sign(rand.Reader, signed)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	blob := append([]byte("SSHSIG"), ssh.Marshal(struct {
+		Version                            uint32
+		PublicKey                          []byte
+		Namespace, Reserved, HashAlgorithm string
+		Signature                          []byte
+	}{1, r.sshSigner.PublicKey().Marshal(), "git", "", "sha512", ssh.Marshal(sig)})...)
+	return "-----BEGIN SSH SIGNATURE-----\n" + base64.StdEncoding.EncodeToString(blob) +
+		"\n-----END SSH SIGNATURE-----\n"
+}
+
+func (r *signingRepo) store(t *testing.T, encode func(plumbing.EncodedObject) error) plumbing.Hash {
+	o := r.repo.Storer.NewEncodedObject()
+	if !assert.NoError(t, encode(o)) {
+		t.FailNow()
+	}
+	h, err := r.repo.Storer.SetEncodedObject(o)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return h
+}
+
+func readObject(t *testing.T, o *plumbing.MemoryObject) []byte {
+	rd, err := o.Reader()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	b := &bytes.Buffer{}
+	if _, err := b.ReadFrom(rd); !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return b.Bytes()
+}
+
+func TestVerificationPolicy_Verify(t *testing.T) {
+	r := newSigningRepo(t)
+	unsigned := r.commit(t, "unsigned", false)
+	gpgSigned := r.commit(t, "gpg", true)
+	sshSigned := r.sshSignCommit(t, r.commit(t, "ssh", false))
+	gpgTag := r.tag(t, "gpg-tag", unsigned, true, false)
+	sshTag := r.tag(t, "ssh-tag", unsigned, false, true)
+	unsignedTag := r.tag(t, "unsigned-tag", unsigned, false, false)
+
+	testCases := map[string]struct {
+		require     SignatureRequirement
+		ref         string
+		tag         string
+		commit      string
+		expected    *kptfilev1.GitVerification
+		expectedErr string
+	}{
+		"gpg signed tag": {
+			require:  RequireSignedTag,
+			ref:      "gpg-tag",
+			tag:      gpgTag,
+			commit:   gpgTag,
+			expected: &kptfilev1.GitVerification{Object: "tag", Key: "gpg"},
+		},
+		"ssh signed tag": {
+			require:  RequireSignedTag,
+			ref:      "ssh-tag",
+			tag:      sshTag,
+			commit:   sshTag,
+			expected: &kptfilev1.GitVerification{Object: "tag", Key: "ssh"},
+		},
+		"unsigned tag": {
+			require:     RequireSignedTag,
+			ref:         "unsigned-tag",
+			tag:         unsignedTag,
+			commit:      unsignedTag,
+			expectedErr: `tag "unsigned-tag" is not signed`,
+		},
+		"signed tag required for a commit": {
+			require:     RequireSignedTag,
+			commit:      gpgSigned.String(),
+			expectedErr: "ref is not an annotated tag",
+		},
+		"gpg signed commit": {
+			require:  RequireSignedCommit,
+			commit:   gpgSigned.String(),
+			expected: &kptfilev1.GitVerification{Object: "commit", Key: "gpg"},
+		},
+		"ssh signed commit": {
+			require:  RequireSignedCommit,
+			commit:   sshSigned.String(),
+			expected: &kptfilev1.GitVerification{Object: "commit", Key: "ssh"},
+		},
+		"signed commit required for a signed tag": {
+			require:     RequireSignedCommit,
+			ref:         "gpg-tag",
+			tag:         gpgTag,
+			commit:      gpgTag,
+			expectedErr: "is not signed",
+		},
+		"unsigned tag of a signed commit": {
+			require:  RequireSignedTagOrCommit,
+			ref:      "unsigned-tag-2",
+			tag:      r.tag(t, "unsigned-tag-2", gpgSigned, false, false),
+			commit:   gpgSigned.String(),
+			expected: &kptfilev1.GitVerification{Object: "commit", Key: "gpg"},
+		},
+		"signed tag of another name": {
+			require:     RequireSignedTag,
+			ref:         "refs/tags/v2.0.0",
+			tag:         gpgTag,
+			commit:      gpgTag,
+			expectedErr: `tag "v2.0.0" points to the tag object of "gpg-tag"`,
+		},
+		"signed tag of another name on an unsigned commit": {
+			require:     RequireSignedTagOrCommit,
+			ref:         "v2.0.0",
+			tag:         sshTag,
+			commit:      unsigned.String(),
+			expectedErr: "requires a signed tag or commit",
+		},
+		"unsigned commit": {
+			require:     RequireSignedTagOrCommit,
+			commit:      unsigned.String(),
+			expectedErr: "requires a signed tag or commit",
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			p := &VerificationPolicy{
+				Require: tc.require,
+				TrustedKeys: []TrustedKey{
+					{Name: "gpg", GPG: r.gpgPublicKey},
+					{Name: "ssh", SSH: r.sshPublicKey},
+				},
+			}
+			p.APIVersion = VerificationPolicyAPIVersion
+			p.Kind = VerificationPolicyKind
+			if !assert.NoError(t, p.Validate()) {
+				t.FailNow()
+			}
+
+			v, err := p.Verify(r.dir, tc.ref, tc.tag, tc.commit)
+			if tc.expectedErr != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tc.expectedErr)
+				}
+				return
+			}
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, tc.expected.Object, v.Object)
+			assert.Equal(t, tc.expected.Key, v.Key)
+			assert.NotEmpty(t, v.Fingerprint)
+			assert.Equal(t, "Release Team <release@example.com>", v.Signer)
+		})
+	}
+}
+
+func TestVerificationPolicy_Verify_untrustedKey(t *testing.T) {
+	r := newSigningRepo(t)
+	other := newSigningRepo(t)
+	gpgTag := r.tag(t, "gpg-tag", r.commit(t, "unsigned", false), true, false)
+	sshSigned := r.sshSignCommit(t, r.commit(t, "ssh", false))
+
+	p := &VerificationPolicy{
+		TrustedKeys: []TrustedKey{
+			{Name: "gpg", GPG: other.gpgPublicKey},
+			{Name: "ssh", SSH: other.sshPublicKey},
+		},
+	}
+	p.APIVersion = VerificationPolicyAPIVersion
+	p.Kind = VerificationPolicyKind
+	if !assert.NoError(t, p.Validate()) {
+		t.FailNow()
+	}
+
+	_, err := p.Verify(r.dir, "gpg-tag", gpgTag, gpgTag)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "GPG signature is not from a trusted key")
+	}
+	_, err = p.Verify(r.dir, "", "", sshSigned.String())
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "SSH signature is not from a trusted key")
+	}
+}
+
+func TestReadVerificationPolicy(t *testing.T) {
+	testCases := map[string]struct {
+		policy      string
+		expectedErr string
+	}{
+		"valid": {
+			policy: `
+apiVersion: kpt.dev/v1alpha1
+kind: UpstreamVerificationPolicy
+require: tag
+trustedKeys:
+- name: dev
+  ssh: ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIKpBzhLHM0v9V2uJ9B8Zyn6XhC9Zx1VmyzP5AeDC4PjI dev
+`,
+		},
+		"wrong kind": {
+			policy: `
+apiVersion: kpt.dev/v1alpha1
+kind: Kptfile
+`,
+			expectedErr: "expected apiVersion",
+		},
+		"no keys": {
+			policy: `
+apiVersion: kpt.dev/v1alpha1
+kind: UpstreamVerificationPolicy
+`,
+			expectedErr: "at least one trusted key must be provided",
+		},
+		"invalid requirement": {
+			policy: `
+apiVersion: kpt.dev/v1alpha1
+kind: UpstreamVerificationPolicy
+require: branch
+trustedKeys:
+- name: dev
+  ssh: ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIKpBzhLHM0v9V2uJ9B8Zyn6XhC9Zx1VmyzP5AeDC4PjI dev
+`,
+			expectedErr: "require must be one of",
+		},
+		"invalid ssh key": {
+			policy: `
+apiVersion: kpt.dev/v1alpha1
+kind: UpstreamVerificationPolicy
+trustedKeys:
+- name: dev
+  ssh: not-a-key
+`,
+			expectedErr: `unable to parse ssh key "dev"`,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "policy.yaml")
+			if !assert.NoError(t, os.WriteFile(path, []byte(strings.TrimSpace(tc.policy)), 0600)) {
+				t.FailNow()
+			}
+			p, err := ReadVerificationPolicy(path)
+			if tc.expectedErr != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tc.expectedErr)
+				}
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, RequireSignedTag, p.Require)
+			}
+		})
+	}
+}
@@ -40,12 +40,20 @@ import (
 	"sigs.k8s.io/kustomize/kyaml/kio"
 )
 
-// Command fetches a package from a git repository, copies it to a local
-// directory, and expands any remote subpackages.
+// Command fetches a package from a git repository, a local directory or a
+// tarball, copies it to a local directory, and expands any remote subpackages.
 type Command struct {
 	// Git contains information about the git repo to fetch
 	Git *kptfilev1.Git
 
+	// Dir contains information about the local directory to fetch, if Git
+	// isn't set.
+	Dir *kptfilev1.Dir
+
+	// Tarball contains information about the tarball to fetch, if Git and
+	// Dir aren't set.
+	Tarball *kptfilev1.Tarball
+
 	// Destination is the output directory to clone the package to.  Defaults to the name of the package --
 	// either the base repo name, or the base subdirectory name.
 	Destination string
@@ -61,6 +69,10 @@ type Command struct {
 	// Kptfile. This determines how changes will be merged when updating the
 	// package.
 	UpdateStrategy kptfilev1.UpdateStrategyType
+
+	// VerificationPolicy is the policy the signatures of the upstream tags or
+	// commits of the packages are verified against, if set.
+	VerificationPolicy *fetch.VerificationPolicy
 }
 
 // Run runs the Command.
@@ -79,21 +91,29 @@ func (c Command) Run(ctx context.Context) error {
 		return errors.E(op, errors.IO, types.UniquePath(c.Destination), err)
 	}
 
-	// normalize path to a filepath
-	repoDir := c.Git.Directory
-	if !strings.HasSuffix(repoDir, "file://") {
-		// Convert from separator to slash and back.
-		// This ensures all separators are compatible with the local OS.
-		repoDir = filepath.FromSlash(filepath.ToSlash(repoDir))
-	}
-	c.Git.Directory = repoDir
-
 	kf := kptfileutil.DefaultKptfile(c.Name)
 	kf.Upstream = &kptfilev1.Upstream{
-		Type:           kptfilev1.GitOrigin,
-		Git:            c.Git,
 		UpdateStrategy: c.UpdateStrategy,
 	}
+	switch {
+	case c.Git != nil:
+		// normalize path to a filepath
+		repoDir := c.Git.Directory
+		if !strings.HasSuffix(repoDir, "file://") {
+			// Convert from separator to slash and back.
+			// This ensures all separators are compatible with the local OS.
+			repoDir = filepath.FromSlash(filepath.ToSlash(repoDir))
+		}
+		c.Git.Directory = repoDir
+		kf.Upstream.Type = kptfilev1.GitOrigin
+		kf.Upstream.Git = c.Git
+	case c.Dir != nil:
+		kf.Upstream.Type = kptfilev1.DirOrigin
+		kf.Upstream.Dir = c.Dir
+	default:
+		kf.Upstream.Type = kptfilev1.TarballOrigin
+		kf.Upstream.Tarball = c.Tarball
+	}
 
 	err = kptfileutil.WriteFile(c.Destination, kf)
 	if err != nil {
@@ -165,9 +185,10 @@ func (c Command) fetchPackages(ctx context.Context, rootPkg *pkg.Pkg) error {
 		if kf.Upstream != nil && kf.UpstreamLock == nil {
 			packageCount++
 			pr.PrintPackage(p, !(p == rootPkg))
-			pr.Printf("Fetching %s@%s\n", kf.Upstream.Git.Repo, kf.Upstream.Git.Ref)
+			pr.Printf("Fetching %s\n", fetch.UpstreamSource(kf.Upstream))
 			err := (&fetch.Command{
-				Pkg: p,
+				Pkg:                p,
+				VerificationPolicy: c.VerificationPolicy,
 			}).Run(ctx)
 			if err != nil {
 				return errors.E(op, p.UniquePath, err)
@@ -189,22 +210,32 @@ func (c Command) fetchPackages(ctx context.Context, rootPkg *pkg.Pkg) error {
 // DefaultValues sets values to the default values if they were unspecified
 func (c *Command) DefaultValues() error {
 	const op errors.Op = "get.DefaultValues"
-	if c.Git == nil {
+	switch {
+	case c.Git != nil:
+		g := c.Git
+		if len(g.Repo) == 0 {
+			return errors.E(op, errors.MissingParam, fmt.Errorf("must specify repo"))
+		}
+		if len(g.Ref) == 0 {
+			return errors.E(op, errors.MissingParam, fmt.Errorf("must specify ref"))
+		}
+		if len(g.Directory) == 0 {
+			return errors.E(op, errors.MissingParam, fmt.Errorf("must specify directory"))
+		}
+	case c.Dir != nil:
+		if len(c.Dir.Path) == 0 {
+			return errors.E(op, errors.MissingParam, fmt.Errorf("must specify directory path"))
+		}
+	case c.Tarball != nil:
+		if len(c.Tarball.URL) == 0 {
+			return errors.E(op, errors.MissingParam, fmt.Errorf("must specify tarball url"))
+		}
+	default:
 		return errors.E(op, errors.MissingParam, fmt.Errorf("must specify git repo information"))
 	}
-	g := c.Git
-	if len(g.Repo) == 0 {
-		return errors.E(op, errors.MissingParam, fmt.Errorf("must specify repo"))
-	}
-	if len(g.Ref) == 0 {
-		return errors.E(op, errors.MissingParam, fmt.Errorf("must specify ref"))
-	}
 	if len(c.Destination) == 0 {
 		return errors.E(op, errors.MissingParam, fmt.Errorf("must specify destination"))
 	}
-	if len(g.Directory) == 0 {
-		return errors.E(op, errors.MissingParam, fmt.Errorf("must specify directory"))
-	}
 
 	if !filepath.IsAbs(c.Destination) {
 		return errors.E(op, errors.InvalidParam, fmt.Errorf("destination must be an absolute path"))
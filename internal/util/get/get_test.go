@@ -23,6 +23,7 @@ import (
 	"github.com/GoogleContainerTools/kpt/internal/printer/fake"
 	"github.com/GoogleContainerTools/kpt/internal/testutil"
 	"github.com/GoogleContainerTools/kpt/internal/testutil/pkgbuilder"
+	"github.com/GoogleContainerTools/kpt/internal/util/fetch"
 	. "github.com/GoogleContainerTools/kpt/internal/util/get"
 	kptfilev1 "github.com/GoogleContainerTools/kpt/pkg/api/kptfile/v1"
 	"github.com/stretchr/testify/assert"
@@ -791,6 +792,33 @@ func TestCommand_Run_nonexistingParentDir(t *testing.T) {
 	g.AssertEqual(t, filepath.Join(g.DatasetDirectory, testutil.Dataset1), absPath, true)
 }
 
+// TestCommand_Run_dirUpstreamVerificationPolicy verifies that packages from
+// a directory upstream are rejected when a verification policy is set, since
+// they can't be signed.
+func TestCommand_Run_dirUpstreamVerificationPolicy(t *testing.T) {
+	dir := t.TempDir()
+	upstreamDir := filepath.Join(dir, "blueprint")
+	if !assert.NoError(t, os.MkdirAll(upstreamDir, 0700)) {
+		t.FailNow()
+	}
+	err := os.WriteFile(filepath.Join(upstreamDir, "cm.yaml"),
+		[]byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cm\n"), 0600)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	err = Command{
+		Dir:                &kptfilev1.Dir{Path: upstreamDir},
+		Destination:        filepath.Join(dir, "local"),
+		VerificationPolicy: &fetch.VerificationPolicy{},
+	}.Run(fake.CtxWithDefaultPrinter())
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "signature verification is not supported for dir/tarball upstreams")
+	}
+	_, err = os.Stat(filepath.Join(dir, "local", "cm.yaml"))
+	assert.True(t, os.IsNotExist(err))
+}
+
 func TestCommand_Run_failInvalidRepo(t *testing.T) {
 	_, w, clean := testutil.SetupRepoAndWorkspace(t, testutil.Content{
 		Data:   testutil.Dataset1,
@@ -24,6 +24,7 @@ import (
 	"strings"
 
 	"github.com/GoogleContainerTools/kpt/internal/errors"
+	kptfilev1 "github.com/GoogleContainerTools/kpt/pkg/api/kptfile/v1"
 )
 
 // RepoSpec specifies a git repository and a branch and path therein.
@@ -41,6 +42,10 @@ type RepoSpec struct {
 	// Commit is the commit for the version that was added to Dir.
 	Commit string
 
+	// Verification is the signature of the tag or commit that was verified
+	// before the package was added to Dir, if any.
+	Verification *kptfilev1.GitVerification
+
 	// Relative path in the repository, and in the cloneDir,
 	// to a Kustomization.
 	Path string
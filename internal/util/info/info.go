@@ -0,0 +1,295 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package info contains libraries for reporting the metadata and origin of
+// the packages in a directory.
+package info
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/GoogleContainerTools/kpt/internal/errors"
+	"github.com/GoogleContainerTools/kpt/internal/fnruntime"
+	"github.com/GoogleContainerTools/kpt/internal/pkg"
+	"github.com/GoogleContainerTools/kpt/internal/types"
+	"github.com/GoogleContainerTools/kpt/internal/util/pkgutil"
+	kptfilev1 "github.com/GoogleContainerTools/kpt/pkg/api/kptfile/v1"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+const (
+	// OutputText prints the packages as a table
+	OutputText string = "text"
+	// OutputJSON prints the packages as JSON
+	OutputJSON string = "json"
+	// OutputSPDX prints the packages as an SPDX document in JSON
+	OutputSPDX string = "spdx"
+)
+
+// SupportedOutputFormats are the supported values for OutputFormat.
+var SupportedOutputFormats = []string{OutputText, OutputJSON, OutputSPDX}
+
+// PackageMetadata contains the metadata and origin of a package.
+type PackageMetadata struct {
+	// Package is the path of the package relative to the directory.
+	Package string `json:"package" yaml:"package"`
+
+	// Name is the name of the package from the Kptfile.
+	Name string `json:"name" yaml:"name"`
+
+	// Info is the package info from the Kptfile.
+	Info *kptfilev1.PackageInfo `json:"info,omitempty" yaml:"info,omitempty"`
+
+	// Origin is where the package was fetched from. It is not set for local
+	// packages.
+	Origin *Origin `json:"origin,omitempty" yaml:"origin,omitempty"`
+
+	// Functions are the container images of the functions in the pipeline
+	// of the package.
+	Functions []FunctionImage `json:"functions,omitempty" yaml:"functions,omitempty"`
+}
+
+// Origin describes where a package was fetched from. It comes from the
+// upstream lock in the Kptfile, or from the upstream if the package has not
+// been fetched.
+type Origin struct {
+	// Type is the type of the upstream, e.g. git.
+	Type kptfilev1.OriginType `json:"type" yaml:"type"`
+
+	// Repo, Directory, Ref and Commit are set for git upstreams.
+	Repo      string `json:"repo,omitempty" yaml:"repo,omitempty"`
+	Directory string `json:"directory,omitempty" yaml:"directory,omitempty"`
+	Ref       string `json:"ref,omitempty" yaml:"ref,omitempty"`
+	Commit    string `json:"commit,omitempty" yaml:"commit,omitempty"`
+
+	// Path is set for directory upstreams, and URL for tarball upstreams.
+	Path string `json:"path,omitempty" yaml:"path,omitempty"`
+	URL  string `json:"url,omitempty" yaml:"url,omitempty"`
+
+	// Hash is the hash of the content of directory and tarball upstreams.
+	Hash string `json:"hash,omitempty" yaml:"hash,omitempty"`
+}
+
+// FunctionImage is the container image of a function in a pipeline.
+type FunctionImage struct {
+	// Image is the fully qualified image reference.
+	Image string `json:"image" yaml:"image"`
+
+	// Digest is the digest of the image, if the image is pinned to one.
+	Digest string `json:"digest,omitempty" yaml:"digest,omitempty"`
+}
+
+// Command reports the metadata of every package in a directory, including
+// nested subpackages.
+type Command struct {
+	// Path is the path to the directory with the packages.
+	Path string
+
+	// OutputFormat is the format of the report.
+	OutputFormat string
+
+	// Output is where the report is written.
+	Output io.Writer
+
+	// Packages contains the metadata of the packages after Run.
+	Packages []PackageMetadata
+
+	// now returns the creation time of SPDX documents.
+	now func() time.Time
+}
+
+// DefaultValues sets up the default values for the command.
+func (c *Command) DefaultValues() {
+	if c.Output == nil {
+		c.Output = os.Stdout
+	}
+	if c.OutputFormat == "" {
+		c.OutputFormat = OutputText
+	}
+	if c.now == nil {
+		c.now = time.Now
+	}
+}
+
+// Validate makes sure the command is properly configured.
+func (c *Command) Validate() error {
+	const op errors.Op = "info.Validate"
+	switch c.OutputFormat {
+	case "", OutputText, OutputJSON, OutputSPDX:
+	default:
+		return errors.E(op, errors.InvalidParam,
+			fmt.Errorf("invalid output format %q. Supported formats are: %s",
+				c.OutputFormat, strings.Join(SupportedOutputFormats, ", ")))
+	}
+	return nil
+}
+
+// Run reads the Kptfiles of the packages and writes the report.
+func (c *Command) Run(ctx context.Context) error {
+	const op errors.Op = "info.Run"
+	c.DefaultValues()
+	if err := c.Validate(); err != nil {
+		return errors.E(op, err)
+	}
+
+	subPkgPaths, err := pkgutil.FindSubpackagesForPaths(pkg.All, true, c.Path)
+	if err != nil {
+		return errors.E(op, types.UniquePath(c.Path), err)
+	}
+	sort.Strings(subPkgPaths)
+
+	c.Packages = nil
+	for _, p := range append([]string{"."}, subPkgPaths...) {
+		pkgPath := filepath.Join(c.Path, p)
+		kf, err := pkg.ReadKptfile(filesys.FileSystemOrOnDisk{}, pkgPath)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return errors.E(op, types.UniquePath(pkgPath), err)
+		}
+		c.Packages = append(c.Packages, packageMetadata(ctx, filepath.ToSlash(p), kf))
+	}
+
+	switch c.OutputFormat {
+	case OutputJSON:
+		return c.printJSON()
+	case OutputSPDX:
+		return c.printSPDX()
+	default:
+		return c.printTable()
+	}
+}
+
+// packageMetadata collects the metadata of a single package from its
+// Kptfile.
+func packageMetadata(ctx context.Context, relPath string, kf *kptfilev1.KptFile) PackageMetadata {
+	m := PackageMetadata{
+		Package: relPath,
+		Name:    kf.Name,
+		Info:    kf.Info,
+		Origin:  packageOrigin(kf),
+	}
+	if kf.Pipeline == nil {
+		return m
+	}
+	seen := make(map[string]bool)
+	for _, fn := range append(append([]kptfilev1.Function{}, kf.Pipeline.Mutators...), kf.Pipeline.Validators...) {
+		if fn.Image == "" {
+			continue
+		}
+		image := fnruntime.AddDefaultImagePathPrefix(ctx, fn.Image)
+		if seen[image] {
+			continue
+		}
+		seen[image] = true
+		fi := FunctionImage{Image: image}
+		if i := strings.Index(image, "@"); i >= 0 {
+			fi.Digest = image[i+1:]
+		}
+		m.Functions = append(m.Functions, fi)
+	}
+	return m
+}
+
+// packageOrigin returns the origin of a package, or nil if it's a local
+// package.
+func packageOrigin(kf *kptfilev1.KptFile) *Origin {
+	if lock := kf.UpstreamLock; lock != nil {
+		o := &Origin{Type: lock.Type}
+		switch {
+		case lock.Git != nil:
+			o.Repo, o.Directory, o.Ref, o.Commit = lock.Git.Repo, lock.Git.Directory, lock.Git.Ref, lock.Git.Commit
+		case lock.Dir != nil:
+			o.Path, o.Hash = lock.Dir.Path, lock.Dir.Hash
+		case lock.Tarball != nil:
+			o.URL, o.Hash = lock.Tarball.URL, lock.Tarball.Hash
+		}
+		return o
+	}
+	if u := kf.Upstream; u != nil {
+		o := &Origin{Type: u.Type}
+		switch {
+		case u.Git != nil:
+			o.Repo, o.Directory, o.Ref = u.Git.Repo, u.Git.Directory, u.Git.Ref
+		case u.Dir != nil:
+			o.Path = u.Dir.Path
+		case u.Tarball != nil:
+			o.URL = u.Tarball.URL
+		}
+		return o
+	}
+	return nil
+}
+
+func (c *Command) printJSON() error {
+	packages := c.Packages
+	if packages == nil {
+		packages = []PackageMetadata{}
+	}
+	b, err := json.MarshalIndent(packages, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(c.Output, string(b))
+	return err
+}
+
+func (c *Command) printTable() error {
+	w := tabwriter.NewWriter(c.Output, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PACKAGE\tLICENSE\tORIGIN\tVERSION\tFUNCTIONS")
+	for _, p := range c.Packages {
+		license := "-"
+		if p.Info != nil && p.Info.License != "" {
+			license = p.Info.License
+		}
+		origin, version := "<local>", "-"
+		if o := p.Origin; o != nil {
+			switch o.Type {
+			case kptfilev1.GitOrigin:
+				origin = o.Repo + "/" + strings.TrimPrefix(o.Directory, "/")
+				version = o.Ref
+				if o.Commit != "" {
+					version = fmt.Sprintf("%s (%s)", o.Ref, shortCommit(o.Commit))
+				}
+			case kptfilev1.DirOrigin:
+				origin = o.Path
+			case kptfilev1.TarballOrigin:
+				origin = o.URL
+			}
+			if o.Hash != "" {
+				version = o.Hash
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\n", p.Package, license, origin, version, len(p.Functions))
+	}
+	return w.Flush()
+}
+
+// shortCommit returns the short form of a commit.
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
@@ -0,0 +1,160 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package info_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleContainerTools/kpt/internal/printer/fake"
+	. "github.com/GoogleContainerTools/kpt/internal/util/info"
+	kptfilev1 "github.com/GoogleContainerTools/kpt/pkg/api/kptfile/v1"
+	"github.com/GoogleContainerTools/kpt/pkg/kptfile/kptfileutil"
+	"github.com/stretchr/testify/assert"
+)
+
+const digest = "sha256:c347e28606fa1a608e8e02e03541a5a46e4a0152005df4a11e44f6c4ab1edd9a"
+
+// writePackages writes a root package fetched from git with a remote and a
+// local subpackage.
+func writePackages(t *testing.T) string {
+	dir := t.TempDir()
+	root := kptfileutil.DefaultKptfile("root")
+	root.Info = &kptfilev1.PackageInfo{
+		License:  "Apache-2.0",
+		Emails:   []string{"team@example.com"},
+		Keywords: []string{"wordpress"},
+	}
+	root.UpstreamLock = &kptfilev1.UpstreamLock{
+		Type: kptfilev1.GitOrigin,
+		Git: &kptfilev1.GitLock{
+			Repo:      "https://github.com/example/blueprints",
+			Directory: "/wordpress",
+			Ref:       "v1.0.0",
+			Commit:    "9b1d1b5c0b9e7b8b8a1c2e0b6f1a0c3d4e5f6a7b",
+		},
+	}
+	root.Pipeline = &kptfilev1.Pipeline{
+		Mutators: []kptfilev1.Function{
+			{Image: "set-labels:v0.1"},
+			{Image: "gcr.io/kpt-fn/set-namespace@" + digest},
+		},
+		Validators: []kptfilev1.Function{
+			{Image: "set-labels:v0.1"},
+		},
+	}
+
+	remote := kptfileutil.DefaultKptfile("mysql")
+	remote.UpstreamLock = &kptfilev1.UpstreamLock{
+		Type:    kptfilev1.TarballOrigin,
+		Tarball: &kptfilev1.TarballLock{URL: "https://example.com/mysql.tgz", Hash: digest},
+	}
+	local := kptfileutil.DefaultKptfile("local")
+
+	for p, kf := range map[string]*kptfilev1.KptFile{
+		".":                     root,
+		"mysql":                 remote,
+		filepath.Join("a", "b"): local,
+	} {
+		pkgDir := filepath.Join(dir, p)
+		if !assert.NoError(t, os.MkdirAll(pkgDir, 0700)) || !assert.NoError(t, kptfileutil.WriteFile(pkgDir, kf)) {
+			t.FailNow()
+		}
+	}
+	return dir
+}
+
+func TestCommand_Run(t *testing.T) {
+	dir := writePackages(t)
+	out := &bytes.Buffer{}
+	cmd := &Command{
+		Path:         dir,
+		OutputFormat: OutputJSON,
+		Output:       out,
+	}
+	if !assert.NoError(t, cmd.Run(fake.CtxWithDefaultPrinter())) {
+		t.FailNow()
+	}
+
+	if !assert.Len(t, cmd.Packages, 3) {
+		t.FailNow()
+	}
+	root := cmd.Packages[0]
+	assert.Equal(t, ".", root.Package)
+	assert.Equal(t, "Apache-2.0", root.Info.License)
+	assert.Equal(t, "9b1d1b5c0b9e7b8b8a1c2e0b6f1a0c3d4e5f6a7b", root.Origin.Commit)
+	assert.Equal(t, []FunctionImage{
+		{Image: "gcr.io/kpt-fn/set-labels:v0.1"},
+		{Image: "gcr.io/kpt-fn/set-namespace@" + digest, Digest: digest},
+	}, root.Functions)
+	assert.Equal(t, "a/b", cmd.Packages[1].Package)
+	assert.Nil(t, cmd.Packages[1].Origin)
+	assert.Equal(t, "mysql", cmd.Packages[2].Package)
+	assert.Equal(t, digest, cmd.Packages[2].Origin.Hash)
+
+	var printed []PackageMetadata
+	if assert.NoError(t, json.Unmarshal(out.Bytes(), &printed)) {
+		assert.Equal(t, cmd.Packages, printed)
+	}
+}
+
+func TestCommand_SPDX(t *testing.T) {
+	dir := writePackages(t)
+	out := &bytes.Buffer{}
+	cmd := &Command{
+		Path:         dir,
+		OutputFormat: OutputSPDX,
+		Output:       out,
+	}
+	if !assert.NoError(t, cmd.Run(fake.CtxWithDefaultPrinter())) {
+		t.FailNow()
+	}
+
+	var doc SPDXDocument
+	if !assert.NoError(t, json.Unmarshal(out.Bytes(), &doc)) {
+		t.FailNow()
+	}
+	assert.Equal(t, "SPDX-2.3", doc.SPDXVersion)
+	assert.Regexp(t, "^https://kpt.dev/spdx/", doc.DocumentNamespace)
+
+	if !assert.Len(t, doc.Packages, 5) {
+		t.FailNow()
+	}
+	root := doc.Packages[0]
+	assert.Equal(t, "root", root.Name)
+	assert.Equal(t, "Apache-2.0", root.LicenseDeclared)
+	assert.Equal(t, "git+https://github.com/example/blueprints.git@9b1d1b5c0b9e7b8b8a1c2e0b6f1a0c3d4e5f6a7b#wordpress",
+		root.DownloadLocation)
+	assert.Contains(t, root.Comment, "team@example.com")
+
+	image := doc.Packages[2]
+	assert.Equal(t, "gcr.io/kpt-fn/set-namespace", image.Name)
+	assert.Equal(t, []SPDXChecksum{{Algorithm: "SHA256", ChecksumValue: digest[len("sha256:"):]}}, image.Checksums)
+
+	mysql := doc.Packages[4]
+	assert.Equal(t, "https://example.com/mysql.tgz", mysql.DownloadLocation)
+	assert.Equal(t, "NOASSERTION", mysql.LicenseDeclared)
+
+	assert.Equal(t, []SPDXRelationship{
+		{SPDXElementID: "SPDXRef-DOCUMENT", RelationshipType: "DESCRIBES", RelatedSPDXElement: "SPDXRef-Package-0"},
+		{SPDXElementID: "SPDXRef-Image-0", RelationshipType: "BUILD_TOOL_OF", RelatedSPDXElement: "SPDXRef-Package-0"},
+		{SPDXElementID: "SPDXRef-Image-1", RelationshipType: "BUILD_TOOL_OF", RelatedSPDXElement: "SPDXRef-Package-0"},
+		{SPDXElementID: "SPDXRef-Package-0", RelationshipType: "CONTAINS", RelatedSPDXElement: "SPDXRef-Package-1"},
+		{SPDXElementID: "SPDXRef-Package-0", RelationshipType: "CONTAINS", RelatedSPDXElement: "SPDXRef-Package-2"},
+	}, doc.Relationships)
+}
@@ -0,0 +1,286 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package info
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	kptfilev1 "github.com/GoogleContainerTools/kpt/pkg/api/kptfile/v1"
+)
+
+const (
+	spdxVersion     = "SPDX-2.3"
+	spdxDataLicense = "CC0-1.0"
+	spdxDocumentID  = "SPDXRef-DOCUMENT"
+	spdxNoAssertion = "NOASSERTION"
+	spdxCreator     = "Tool: kpt"
+)
+
+// SPDXDocument is an SPDX document describing packages and the function
+// images used to render them. Only the fields used by kpt are defined.
+// See https://spdx.github.io/spdx-spec/v2.3/
+type SPDXDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      SPDXCreationInfo   `json:"creationInfo"`
+	Packages          []SPDXPackage      `json:"packages"`
+	Relationships     []SPDXRelationship `json:"relationships"`
+}
+
+// SPDXCreationInfo describes when and by whom an SPDX document was created.
+type SPDXCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+// SPDXPackage is a kpt package or a function image in an SPDX document.
+type SPDXPackage struct {
+	SPDXID                string            `json:"SPDXID"`
+	Name                  string            `json:"name"`
+	VersionInfo           string            `json:"versionInfo,omitempty"`
+	DownloadLocation      string            `json:"downloadLocation"`
+	FilesAnalyzed         bool              `json:"filesAnalyzed"`
+	Homepage              string            `json:"homepage,omitempty"`
+	SourceInfo            string            `json:"sourceInfo,omitempty"`
+	LicenseConcluded      string            `json:"licenseConcluded"`
+	LicenseDeclared       string            `json:"licenseDeclared"`
+	CopyrightText         string            `json:"copyrightText"`
+	Description           string            `json:"description,omitempty"`
+	Comment               string            `json:"comment,omitempty"`
+	Checksums             []SPDXChecksum    `json:"checksums,omitempty"`
+	ExternalRefs          []SPDXExternalRef `json:"externalRefs,omitempty"`
+	PrimaryPackagePurpose string            `json:"primaryPackagePurpose,omitempty"`
+}
+
+// SPDXChecksum is the checksum of an SPDX package.
+type SPDXChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+// SPDXExternalRef is a reference to a package outside of the SPDX document,
+// e.g. a package URL.
+type SPDXExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+// SPDXRelationship is a relationship between two elements of an SPDX
+// document.
+type SPDXRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+func (c *Command) printSPDX() error {
+	b, err := json.MarshalIndent(c.SPDX(), "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(c.Output, string(b))
+	return err
+}
+
+// SPDX returns an SPDX document for the packages. Every package contains its
+// subpackages, and the function images are build tools of the packages with
+// them in their pipeline.
+func (c *Command) SPDX() *SPDXDocument {
+	now := time.Now
+	if c.now != nil {
+		now = c.now
+	}
+	doc := &SPDXDocument{
+		SPDXVersion: spdxVersion,
+		DataLicense: spdxDataLicense,
+		SPDXID:      spdxDocumentID,
+		Name:        filepath.Base(c.Path),
+		CreationInfo: SPDXCreationInfo{
+			Created:  now().UTC().Format(time.RFC3339),
+			Creators: []string{spdxCreator},
+		},
+		Packages:      []SPDXPackage{},
+		Relationships: []SPDXRelationship{},
+	}
+
+	pkgIDs := make(map[string]string)
+	imageIDs := make(map[string]string)
+	for i, p := range c.Packages {
+		id := fmt.Sprintf("SPDXRef-Package-%d", i)
+		pkgIDs[p.Package] = id
+		doc.Packages = append(doc.Packages, spdxPackage(id, p))
+
+		parentID := spdxDocumentID
+		relationship := "DESCRIBES"
+		if parent, found := parentPackage(p.Package, pkgIDs); found {
+			parentID = parent
+			relationship = "CONTAINS"
+		}
+		doc.Relationships = append(doc.Relationships, SPDXRelationship{
+			SPDXElementID:      parentID,
+			RelationshipType:   relationship,
+			RelatedSPDXElement: id,
+		})
+
+		for _, fn := range p.Functions {
+			imageID, found := imageIDs[fn.Image]
+			if !found {
+				imageID = fmt.Sprintf("SPDXRef-Image-%d", len(imageIDs))
+				imageIDs[fn.Image] = imageID
+				doc.Packages = append(doc.Packages, spdxImage(imageID, fn))
+			}
+			doc.Relationships = append(doc.Relationships, SPDXRelationship{
+				SPDXElementID:      imageID,
+				RelationshipType:   "BUILD_TOOL_OF",
+				RelatedSPDXElement: id,
+			})
+		}
+	}
+
+	// The namespace must be unique for every document, so it includes a
+	// hash of the content and creation time.
+	b, _ := json.Marshal(doc)
+	doc.DocumentNamespace = fmt.Sprintf("https://kpt.dev/spdx/%s-%x", doc.Name, sha256.Sum256(b))
+	return doc
+}
+
+// parentPackage returns the id of the closest package containing the
+// package at relPath.
+func parentPackage(relPath string, pkgIDs map[string]string) (string, bool) {
+	if relPath == "." {
+		return "", false
+	}
+	for dir := path.Dir(relPath); ; dir = path.Dir(dir) {
+		if id, found := pkgIDs[dir]; found {
+			return id, true
+		}
+		if dir == "." {
+			return "", false
+		}
+	}
+}
+
+func spdxPackage(id string, p PackageMetadata) SPDXPackage {
+	sp := SPDXPackage{
+		SPDXID:           id,
+		Name:             p.Name,
+		DownloadLocation: spdxNoAssertion,
+		LicenseConcluded: spdxNoAssertion,
+		LicenseDeclared:  spdxNoAssertion,
+		CopyrightText:    spdxNoAssertion,
+		Comment:          fmt.Sprintf("kpt package at %q", p.Package),
+	}
+	if info := p.Info; info != nil {
+		if info.License != "" {
+			sp.LicenseDeclared = info.License
+		}
+		sp.Homepage = info.Site
+		sp.Description = info.Description
+		if len(info.Emails) > 0 {
+			sp.Comment += fmt.Sprintf("; emails: %s", strings.Join(info.Emails, ", "))
+		}
+		if len(info.Keywords) > 0 {
+			sp.Comment += fmt.Sprintf("; keywords: %s", strings.Join(info.Keywords, ", "))
+		}
+	}
+
+	o := p.Origin
+	if o == nil {
+		return sp
+	}
+	switch o.Type {
+	case kptfilev1.GitOrigin:
+		sp.VersionInfo = o.Ref
+		if o.Commit != "" {
+			sp.VersionInfo = o.Commit
+			sp.DownloadLocation = gitDownloadLocation(o)
+		}
+		sp.SourceInfo = fmt.Sprintf("fetched from git repo %s, directory %s, ref %s", o.Repo, o.Directory, o.Ref)
+	case kptfilev1.DirOrigin:
+		sp.SourceInfo = fmt.Sprintf("fetched from local directory %s", o.Path)
+	case kptfilev1.TarballOrigin:
+		if strings.HasPrefix(o.URL, "http://") || strings.HasPrefix(o.URL, "https://") {
+			sp.DownloadLocation = o.URL
+		}
+		sp.SourceInfo = fmt.Sprintf("fetched from tarball %s", o.URL)
+	}
+	if o.Hash != "" {
+		sp.VersionInfo = o.Hash
+		sp.SourceInfo += fmt.Sprintf(" with content hash %s", o.Hash)
+	}
+	return sp
+}
+
+// gitDownloadLocation returns the SPDX VCS location of a package in a git
+// repo, e.g. git+https://github.com/org/repo.git@<commit>#path/to/pkg.
+func gitDownloadLocation(o *Origin) string {
+	repo := o.Repo
+	if !strings.HasSuffix(repo, ".git") {
+		repo += ".git"
+	}
+	loc := fmt.Sprintf("git+%s@%s", repo, o.Commit)
+	if dir := strings.Trim(o.Directory, "/"); dir != "" {
+		loc += "#" + dir
+	}
+	return loc
+}
+
+func spdxImage(id string, fn FunctionImage) SPDXPackage {
+	name, version := fn.Image, ""
+	if i := strings.Index(name, "@"); i >= 0 {
+		name = name[:i]
+	}
+	// The tag is after the last colon, unless the colon is part of the
+	// registry host:port.
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, version = name[:i], name[i+1:]
+	}
+	sp := SPDXPackage{
+		SPDXID:                id,
+		Name:                  name,
+		VersionInfo:           version,
+		DownloadLocation:      spdxNoAssertion,
+		LicenseConcluded:      spdxNoAssertion,
+		LicenseDeclared:       spdxNoAssertion,
+		CopyrightText:         spdxNoAssertion,
+		Comment:               fmt.Sprintf("kpt function image %s", fn.Image),
+		PrimaryPackagePurpose: "CONTAINER",
+	}
+	if fn.Digest == "" {
+		return sp
+	}
+	if strings.HasPrefix(fn.Digest, "sha256:") {
+		sp.Checksums = []SPDXChecksum{{Algorithm: "SHA256", ChecksumValue: strings.TrimPrefix(fn.Digest, "sha256:")}}
+	}
+	// The package URL of an OCI image is its last path segment, qualified
+	// with the repository it's in.
+	sp.ExternalRefs = []SPDXExternalRef{{
+		ReferenceCategory: "PACKAGE-MANAGER",
+		ReferenceType:     "purl",
+		ReferenceLocator: fmt.Sprintf("pkg:oci/%s@%s?repository_url=%s",
+			path.Base(name), strings.ReplaceAll(fn.Digest, ":", "%3A"), path.Dir(name)),
+	}}
+	return sp
+}
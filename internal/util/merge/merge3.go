@@ -23,9 +23,12 @@ import (
 	"sigs.k8s.io/kustomize/kyaml/kio"
 	"sigs.k8s.io/kustomize/kyaml/kio/filters"
 	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/openapi"
 	"sigs.k8s.io/kustomize/kyaml/pathutil"
 	"sigs.k8s.io/kustomize/kyaml/resid"
 	"sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/kustomize/kyaml/yaml/merge3"
+	"sigs.k8s.io/kustomize/kyaml/yaml/walk"
 )
 
 const (
@@ -48,6 +51,12 @@ type Merge3 struct {
 	MatchFilesGlob     []string
 	MergeOnPath        bool
 	IncludeSubPackages bool
+
+	// Schemas contains the schemas of resource types unknown to kyaml, used
+	// to merge the lists of their resources. The schemas of the CRDs in the
+	// packages are added to them, with the CRDs in the updated package taking
+	// precedence.
+	Schemas *Schemas
 }
 
 func (m Merge3) Merge() error {
@@ -104,7 +113,7 @@ func (m Merge3) Merge() error {
 	})
 
 	rmMatcher := ResourceMergeMatcher{MergeOnPath: m.MergeOnPath}
-	resourceHandler := resourceHandler{}
+	resourceHandler := resourceHandler{schemas: m.Schemas.clone()}
 	kyamlMerge := filters.Merge3{
 		Matcher: &rmMatcher,
 		Handler: &resourceHandler,
@@ -112,7 +121,7 @@ func (m Merge3) Merge() error {
 
 	return kio.Pipeline{
 		Inputs:  inputs,
-		Filters: []kio.Filter{kyamlMerge},
+		Filters: []kio.Filter{renameFilter{matcher: &rmMatcher}, crdSchemaFilter{schemas: resourceHandler.schemas}, kyamlMerge},
 		Outputs: []kio.Writer{dest},
 	}.Execute()
 }
@@ -137,6 +146,27 @@ func (m Merge3) findExclusions() ([]string, error) {
 	return relPaths, nil
 }
 
+// crdSchemaFilter is a kio.Filter that adds the schemas of the CRDs in the
+// original, local and updated packages, in that order, to schemas.
+type crdSchemaFilter struct {
+	schemas *Schemas
+}
+
+func (f crdSchemaFilter) Filter(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+	for _, source := range []string{mergeSourceOriginal, mergeSourceDest, mergeSourceUpdated} {
+		for _, n := range nodes {
+			meta, err := n.GetMeta()
+			if err != nil || !isCRD(meta) || meta.Annotations[mergeSourceAnnotation] != source {
+				continue
+			}
+			// Like merge comments, reading the schemas is best effort, since
+			// merging does not require them.
+			_ = f.schemas.AddCRD(n)
+		}
+	}
+	return nodes, nil
+}
+
 // PruningLocalPackageReader implements the Reader interface. It is similar
 // to the LocalPackageReader but allows for exclusion of subdirectories.
 type PruningLocalPackageReader struct {
@@ -297,6 +327,10 @@ func metadataComment(node *yaml.RNode) string {
 // there is no diff between origin and local.
 type resourceHandler struct {
 	keptResources []*yaml.RNode
+
+	// schemas contains the schemas used to merge resources of types unknown
+	// to kyaml.
+	schemas *Schemas
 }
 
 func (r *resourceHandler) Handle(origin, upstream, local *yaml.RNode) (filters.ResourceMergeStrategy, error) {
@@ -326,12 +360,35 @@ func (r *resourceHandler) Handle(origin, upstream, local *yaml.RNode) (filters.R
 	// Do not re-add if deleted from local.
 	case origin != nil && local == nil:
 		strategy = filters.Skip
+	// Merge with the schema of the resource type if kyaml doesn't know it.
+	case r.schemas.resourceSchema(upstream) != nil:
+		return r.mergeWithSchema(origin, upstream, local, r.schemas.resourceSchema(upstream))
 	default:
 		strategy = filters.Merge
 	}
 	return strategy, nil
 }
 
+// mergeWithSchema merges the resources into local like kyaml does, but using
+// the provided schema, and returns the strategy to use for the result.
+func (*resourceHandler) mergeWithSchema(origin, upstream, local *yaml.RNode,
+	schema *openapi.ResourceSchema) (filters.ResourceMergeStrategy, error) {
+	merged, err := walk.Walker{
+		Schema:             schema,
+		Visitor:            merge3.Visitor{},
+		VisitKeysAsScalars: true,
+		Sources:            []*yaml.RNode{local, origin, upstream},
+	}.Walk()
+	if err != nil {
+		return filters.Skip, err
+	}
+	if merged == nil {
+		return filters.Skip, nil
+	}
+	local.SetYNode(merged.YNode())
+	return filters.KeepDest, nil
+}
+
 func (*resourceHandler) equals(r1, r2 *yaml.RNode) (bool, error) {
 	// We need to create new copies of the resources since we need to
 	// mutate them before comparing them.
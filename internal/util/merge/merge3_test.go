@@ -406,6 +406,188 @@ spec:
   replicas: 4
 `},
 
+		`Publisher renames resource in upstream with the rename-from annotation, consumer customizes it on local,
+fetch upstream changes`: {
+			origin: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+spec:
+  replicas: 3`,
+			update: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  annotations:
+    kpt-rename-from: nginx-deployment
+spec:
+  replicas: 4`,
+			local: `
+apiVersion: apps/v1
+kind: Deployment
+metadata: # kpt-merge: /nginx-deployment
+  name: nginx-deployment
+  namespace: my-space
+spec:
+  replicas: 3
+  paused: true
+`,
+			expected: `
+apiVersion: apps/v1
+kind: Deployment
+metadata: # kpt-merge: /nginx
+  name: nginx
+  namespace: my-space
+  annotations:
+    kpt-rename-from: nginx-deployment
+spec:
+  replicas: 4
+  paused: true
+`},
+
+		`Publisher renames resource in upstream without changing its content, consumer customizes it on local,
+fetch upstream changes`: {
+			origin: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  color: blue
+  size: large
+  shape: round`,
+			update: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app-config
+data:
+  color: blue
+  size: large
+  shape: round`,
+			local: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  color: red
+  size: large
+  shape: round
+`,
+			expected: `
+apiVersion: v1
+kind: ConfigMap
+metadata: # kpt-merge: /app-config
+  name: app-config
+data:
+  color: red
+  size: large
+  shape: round
+`},
+
+		`Publisher replaces a resource in upstream with a different one which has fields in common, consumer
+customizes it on local, fetch upstream changes`: {
+			origin: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  color: blue
+  size: large
+  shape: round`,
+			update: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: other-config
+data:
+  color: blue
+  size: large
+  weight: light`,
+			local: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  color: red
+  size: large
+  shape: round
+`,
+			expected: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  color: red
+  size: large
+  shape: round
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: other-config
+data:
+  color: blue
+  size: large
+  weight: light
+`},
+
+		`Publisher moves a resource to another namespace in upstream without changing its content, consumer
+customizes it on local, fetch upstream changes`: {
+			origin: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  namespace: foo
+data:
+  color: blue
+  size: large`,
+			update: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config-new
+  namespace: bar
+data:
+  color: blue
+  size: large`,
+			local: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  namespace: foo
+data:
+  color: red
+  size: large
+`,
+			expected: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  namespace: foo
+data:
+  color: red
+  size: large
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config-new
+  namespace: bar
+data:
+  color: blue
+  size: large
+`},
+
 		`Publisher changes name multiple times in upstream but maintains original identity, no local customizations,
 fetch upstream changes`: {
 			origin: `
@@ -0,0 +1,267 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const (
+	// RenameFromAnnotation can be set by the publisher on a resource renamed
+	// in upstream to its previous identity, in the format "namespace/name"
+	// or "name" if the namespace did not change. The local resource is then
+	// merged with the renamed resource.
+	RenameFromAnnotation = "kpt-rename-from"
+
+	// renameSimilarityThreshold is the minimum similarity between a resource
+	// deleted from upstream and a resource added in upstream, of the same
+	// kind and in the same namespace, for the added resource to be
+	// considered a rename of the deleted one.
+	renameSimilarityThreshold = 0.8
+)
+
+// renameFilter is a kio.Filter that detects resources renamed in upstream,
+// and sets the merge comment of the original, local and renamed resources to
+// the identity of the renamed resource so that local changes are merged into it
+// instead of the resource being deleted and added again.
+//
+// A resource added in upstream is a rename of a resource deleted from
+// upstream if it has the RenameFromAnnotation with the identity of the
+// deleted resource, or else if they are in the same namespace and their
+// contents are similar enough.
+type renameFilter struct {
+	matcher *ResourceMergeMatcher
+}
+
+func (f renameFilter) Filter(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+	var original, updated, dest []*yaml.RNode
+	for _, n := range nodes {
+		switch n.GetAnnotations()[mergeSourceAnnotation] {
+		case mergeSourceOriginal:
+			original = append(original, n)
+		case mergeSourceUpdated:
+			updated = append(updated, n)
+		case mergeSourceDest:
+			dest = append(dest, n)
+		}
+	}
+
+	renames := f.renames(original, updated)
+	for d, a := range renames {
+		// Find the local resources before changing the identity of the
+		// original resource.
+		var targets []*yaml.RNode
+		for _, n := range dest {
+			if f.matcher.IsSameResource(d, n) {
+				targets = append(targets, n)
+			}
+		}
+		id := resourceID(a)
+		for _, n := range append(targets, d, a) {
+			n.Field(yaml.MetadataField).Key.YNode().LineComment = fmt.Sprintf("%s %s", MergeCommentPrefix, id)
+		}
+	}
+	return nodes, nil
+}
+
+// FindRenames returns the resources of original that were renamed in
+// updated, mapped to the renamed resources, as detected when merging
+// packages. Resources are matched by their merge comments.
+func FindRenames(original, updated []*yaml.RNode) map[*yaml.RNode]*yaml.RNode {
+	return renameFilter{matcher: &ResourceMergeMatcher{}}.renames(original, updated)
+}
+
+// renames returns the resources of original that were renamed in updated,
+// mapped to the renamed resources.
+func (f renameFilter) renames(original, updated []*yaml.RNode) map[*yaml.RNode]*yaml.RNode {
+	renames := make(map[*yaml.RNode]*yaml.RNode)
+	deleted := f.unmatched(original, updated)
+	added := f.unmatched(updated, original)
+	if len(deleted) == 0 || len(added) == 0 {
+		return renames
+	}
+
+	renamed := make(map[*yaml.RNode]bool)
+	for _, a := range added {
+		from := a.GetAnnotations()[RenameFromAnnotation]
+		if from == "" {
+			continue
+		}
+		meta, err := a.GetMeta()
+		if err != nil {
+			continue
+		}
+		ns, name := meta.Namespace, from
+		if i := strings.Index(from, "/"); i >= 0 {
+			ns, name = from[:i], from[i+1:]
+		}
+		for _, d := range deleted {
+			if renames[d] == nil && sameKind(a, d) && resourceID(d) == ns+"/"+name {
+				renames[d] = a
+				renamed[a] = true
+				break
+			}
+		}
+	}
+
+	type candidate struct {
+		deleted, added *yaml.RNode
+		similarity     float64
+	}
+	var candidates []candidate
+	for _, d := range deleted {
+		if renames[d] != nil {
+			continue
+		}
+		for _, a := range added {
+			if renamed[a] || !sameKind(a, d) || namespace(a) != namespace(d) {
+				continue
+			}
+			if s := similarity(d, a); s >= renameSimilarityThreshold {
+				candidates = append(candidates, candidate{deleted: d, added: a, similarity: s})
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].similarity > candidates[j].similarity
+	})
+	for _, c := range candidates {
+		if renames[c.deleted] != nil || renamed[c.added] {
+			continue
+		}
+		renames[c.deleted] = c.added
+		renamed[c.added] = true
+	}
+	return renames
+}
+
+// unmatched returns the nodes that have no matching resource in others.
+func (f renameFilter) unmatched(nodes, others []*yaml.RNode) []*yaml.RNode {
+	var result []*yaml.RNode
+	for _, n := range nodes {
+		if n.Field(yaml.MetadataField).IsNilOrEmpty() {
+			continue
+		}
+		found := false
+		for _, o := range others {
+			if f.matcher.IsSameResource(n, o) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			result = append(result, n)
+		}
+	}
+	return result
+}
+
+// sameKind returns true if the resources have the same group and kind.
+func sameKind(n1, n2 *yaml.RNode) bool {
+	meta1, err := n1.GetMeta()
+	if err != nil {
+		return false
+	}
+	meta2, err := n2.GetMeta()
+	if err != nil {
+		return false
+	}
+	return meta1.Kind == meta2.Kind && resolveGroup(meta1) == resolveGroup(meta2)
+}
+
+// resourceID returns the namespace and name used to merge the resource, in
+// the format of the merge comment.
+func resourceID(n *yaml.RNode) string {
+	meta, err := n.GetMeta()
+	if err != nil {
+		return ""
+	}
+	comment := metadataComment(n)
+	return resolveNamespace(meta, comment) + "/" + resolveName(meta, comment)
+}
+
+// namespace returns the namespace used to merge the resource.
+func namespace(n *yaml.RNode) string {
+	id := resourceID(n)
+	return id[:strings.Index(id+"/", "/")]
+}
+
+// similarity returns the fraction of the fields of the two resources that
+// have the same value, ignoring their identity and the annotations added by
+// kpt and kyaml. It is 0 if the resources have no such fields.
+func similarity(n1, n2 *yaml.RNode) float64 {
+	fields1 := make(map[string]bool)
+	flattenFields("", n1.YNode(), fields1)
+	fields2 := make(map[string]bool)
+	flattenFields("", n2.YNode(), fields2)
+
+	common := 0
+	for f := range fields1 {
+		if fields2[f] {
+			common++
+		}
+	}
+	total := len(fields1) + len(fields2) - common
+	if total == 0 {
+		return 0
+	}
+	return float64(common) / float64(total)
+}
+
+// flattenFields adds a "path=value" entry to fields for each scalar value
+// in the node.
+func flattenFields(path string, n *yaml.Node, fields map[string]bool) {
+	switch path {
+	case "apiVersion", "kind", "metadata.name", "metadata.namespace":
+		return
+	}
+	if strings.HasPrefix(path, "metadata.annotations.") && isInternalAnnotation(strings.TrimPrefix(path, "metadata.annotations.")) {
+		return
+	}
+	switch n.Kind {
+	case yaml.DocumentNode:
+		for _, c := range n.Content {
+			flattenFields(path, c, fields)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key := n.Content[i].Value
+			if path != "" {
+				key = path + "." + key
+			}
+			flattenFields(key, n.Content[i+1], fields)
+		}
+	case yaml.SequenceNode:
+		for i, c := range n.Content {
+			flattenFields(fmt.Sprintf("%s[%d]", path, i), c, fields)
+		}
+	case yaml.AliasNode:
+		if n.Alias != nil {
+			flattenFields(path, n.Alias, fields)
+		}
+	default:
+		fields[path+"="+n.Value] = true
+	}
+}
+
+func isInternalAnnotation(key string) bool {
+	return key == RenameFromAnnotation ||
+		strings.HasPrefix(key, "config.kubernetes.io/") ||
+		strings.HasPrefix(key, "internal.config.kubernetes.io/")
+}
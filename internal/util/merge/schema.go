@@ -0,0 +1,391 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	openapi_v2 "github.com/google/gnostic/openapiv2"
+	"k8s.io/kube-openapi/pkg/validation/spec"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/openapi"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	k8syaml "sigs.k8s.io/yaml"
+)
+
+const (
+	// Extensions used by CRDs to describe how lists are merged.
+	listTypeExtension    = "x-kubernetes-list-type"
+	listMapKeysExtension = "x-kubernetes-list-map-keys"
+
+	// Extensions used by kyaml to merge associative lists.
+	patchStrategyExtension = "x-kubernetes-patch-strategy"
+	patchMergeKeyExtension = "x-kubernetes-patch-merge-key"
+
+	// gvkExtension is the extension containing the group, version and kind of
+	// the resource type of an OpenAPI definition.
+	gvkExtension = "x-kubernetes-group-version-kind"
+
+	// maxRefDepth limits the depth of the references inlined in the schemas
+	// of OpenAPI definitions, to support recursive definitions.
+	maxRefDepth = 10
+)
+
+// Schemas contains the OpenAPI schemas of resource types unknown to kyaml,
+// such as the types defined by CRDs. Merge3 uses them to merge the lists
+// of resources of those types as associative lists based on their
+// x-kubernetes-list-type and x-kubernetes-list-map-keys extensions, instead
+// of replacing them. A nil Schemas is empty.
+type Schemas struct {
+	byType map[yaml.TypeMeta]*spec.Schema
+}
+
+// NewSchemas returns an empty Schemas.
+func NewSchemas() *Schemas {
+	return &Schemas{byType: make(map[yaml.TypeMeta]*spec.Schema)}
+}
+
+// Len returns the number of resource types with a schema.
+func (s *Schemas) Len() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.byType)
+}
+
+// AddCRD adds the schemas of all versions of the resource type defined by
+// the CustomResourceDefinition. Both apiextensions.k8s.io/v1 and v1beta1
+// CRDs are supported.
+func (s *Schemas) AddCRD(crd *yaml.RNode) error {
+	meta, err := crd.GetMeta()
+	if err != nil {
+		return err
+	}
+	if !isCRD(meta) {
+		return fmt.Errorf("%s %q is not a CustomResourceDefinition", meta.Kind, meta.Name)
+	}
+	group, err := crd.Pipe(yaml.Lookup("spec", "group"))
+	if err != nil {
+		return err
+	}
+	kind, err := crd.Pipe(yaml.Lookup("spec", "names", "kind"))
+	if err != nil {
+		return err
+	}
+	if group == nil || kind == nil {
+		return fmt.Errorf("CustomResourceDefinition %q must have a group and a kind", meta.Name)
+	}
+
+	// v1beta1 CRDs may have a single schema for all versions.
+	commonSchema, err := crd.Pipe(yaml.Lookup("spec", "validation", "openAPIV3Schema"))
+	if err != nil {
+		return err
+	}
+	versions := make(map[string]*yaml.RNode)
+	if v, err := crd.Pipe(yaml.Lookup("spec", "version")); err == nil && v != nil {
+		versions[yaml.GetValue(v)] = commonSchema
+	}
+	versionList, err := crd.Pipe(yaml.Lookup("spec", "versions"))
+	if err != nil {
+		return err
+	}
+	if versionList != nil {
+		elements, err := versionList.Elements()
+		if err != nil {
+			return err
+		}
+		for _, e := range elements {
+			name := yaml.GetValue(e.Field("name").Value)
+			schema, err := e.Pipe(yaml.Lookup("schema", "openAPIV3Schema"))
+			if err != nil {
+				return err
+			}
+			if schema == nil {
+				schema = commonSchema
+			}
+			versions[name] = schema
+		}
+	}
+
+	for version, schemaNode := range versions {
+		if version == "" || schemaNode == nil {
+			continue
+		}
+		b, err := schemaNode.MarshalJSON()
+		if err != nil {
+			return err
+		}
+		var schema spec.Schema
+		if err := json.Unmarshal(b, &schema); err != nil {
+			return fmt.Errorf("invalid schema for version %q of CustomResourceDefinition %q: %w", version, meta.Name, err)
+		}
+		s.add(yaml.TypeMeta{
+			APIVersion: yaml.GetValue(group) + "/" + version,
+			Kind:       yaml.GetValue(kind),
+		}, &schema)
+	}
+	return nil
+}
+
+// AddOpenAPI adds the schemas of the resource types defined in an OpenAPI
+// v2 document in JSON or YAML format, such as the /openapi/v2 document of a
+// cluster.
+func (s *Schemas) AddOpenAPI(b []byte) error {
+	b = bytes.TrimSpace(b)
+	if len(b) > 0 && b[0] != '{' {
+		var err error
+		if b, err = k8syaml.YAMLToJSON(b); err != nil {
+			return err
+		}
+	}
+	var swagger spec.Swagger
+	if err := swagger.UnmarshalJSON(b); err != nil {
+		return err
+	}
+	s.AddDefinitions(swagger.Definitions)
+	return nil
+}
+
+// AddOpenAPIDocument adds the schemas of the resource types defined in a
+// parsed OpenAPI v2 document, as returned by the discovery client.
+func (s *Schemas) AddOpenAPIDocument(doc *openapi_v2.Document) error {
+	var swagger spec.Swagger
+	if _, err := swagger.FromGnostic(doc); err != nil {
+		return err
+	}
+	s.AddDefinitions(swagger.Definitions)
+	return nil
+}
+
+// AddDefinitions adds the schemas of the OpenAPI definitions that have the
+// x-kubernetes-group-version-kind extension. References to other
+// definitions are inlined.
+func (s *Schemas) AddDefinitions(definitions spec.Definitions) {
+	for _, d := range definitions {
+		gvks, ok := d.Extensions[gvkExtension].([]interface{})
+		if !ok {
+			continue
+		}
+		for _, gvk := range gvks {
+			m, ok := gvk.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			group, _ := m["group"].(string)
+			version, _ := m["version"].(string)
+			kind, _ := m["kind"].(string)
+			if version == "" || kind == "" {
+				continue
+			}
+			apiVersion := version
+			if group != "" {
+				apiVersion = group + "/" + version
+			}
+			schema := d
+			inlineRefs(&schema, definitions, 0)
+			s.add(yaml.TypeMeta{APIVersion: apiVersion, Kind: kind}, &schema)
+		}
+	}
+}
+
+// AddPath adds the schemas in the file at path, or in all files in the
+// directory at path and its subdirectories. JSON files must contain an
+// OpenAPI v2 document. YAML files may contain either an OpenAPI v2 document
+// or CustomResourceDefinitions, other resources are ignored.
+func (s *Schemas) AddPath(path string) error {
+	return filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		ext := strings.ToLower(filepath.Ext(p))
+		if ext != ".json" && ext != ".yaml" && ext != ".yml" {
+			return nil
+		}
+		b, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		if err := s.addFile(ext, b); err != nil {
+			return fmt.Errorf("failed to read schemas from %q: %w", p, err)
+		}
+		return nil
+	})
+}
+
+func (s *Schemas) addFile(ext string, b []byte) error {
+	if ext == ".json" {
+		return s.AddOpenAPI(b)
+	}
+	nodes, err := (&kio.ByteReader{Reader: bytes.NewReader(b), OmitReaderAnnotations: true}).Read()
+	if err != nil {
+		return err
+	}
+	for _, n := range nodes {
+		if n.Field("swagger") != nil || n.Field("definitions") != nil {
+			return s.AddOpenAPI(b)
+		}
+		meta, err := n.GetMeta()
+		if err != nil || !isCRD(meta) {
+			continue
+		}
+		if err := s.AddCRD(n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resourceSchema returns the schema of the resource type of the node, or
+// nil if there is none.
+func (s *Schemas) resourceSchema(node *yaml.RNode) *openapi.ResourceSchema {
+	if s == nil || node == nil {
+		return nil
+	}
+	meta, err := node.GetMeta()
+	if err != nil {
+		return nil
+	}
+	schema, found := s.byType[meta.TypeMeta]
+	if !found {
+		return nil
+	}
+	return &openapi.ResourceSchema{Schema: schema}
+}
+
+// clone returns a copy of the schemas that can be extended without
+// modifying s.
+func (s *Schemas) clone() *Schemas {
+	c := NewSchemas()
+	if s != nil {
+		for t, schema := range s.byType {
+			c.byType[t] = schema
+		}
+	}
+	return c
+}
+
+func (s *Schemas) add(t yaml.TypeMeta, schema *spec.Schema) {
+	addListMergeStrategies(schema)
+	if s.byType == nil {
+		s.byType = make(map[yaml.TypeMeta]*spec.Schema)
+	}
+	s.byType[t] = schema
+}
+
+func isCRD(meta yaml.ResourceMeta) bool {
+	return meta.Kind == "CustomResourceDefinition" && strings.HasPrefix(meta.APIVersion, "apiextensions.k8s.io/")
+}
+
+// addListMergeStrategies sets the extensions used by kyaml to merge
+// associative lists on the lists of the schema described as maps or sets
+// with the x-kubernetes-list-type extension.
+func addListMergeStrategies(s *spec.Schema) {
+	if s == nil {
+		return
+	}
+	if _, found := s.Extensions[patchStrategyExtension]; !found {
+		listType, _ := s.Extensions.GetString(listTypeExtension)
+		keys, _ := s.Extensions.GetStringSlice(listMapKeysExtension)
+		switch {
+		case listType == "map" && len(keys) > 0:
+			s.AddExtension(patchStrategyExtension, "merge")
+			s.AddExtension(patchMergeKeyExtension, keys[0])
+		case listType == "set":
+			s.AddExtension(patchStrategyExtension, "merge")
+		}
+	}
+
+	for k := range s.Properties {
+		p := s.Properties[k]
+		addListMergeStrategies(&p)
+		s.Properties[k] = p
+	}
+	if s.Items != nil {
+		addListMergeStrategies(s.Items.Schema)
+		for i := range s.Items.Schemas {
+			addListMergeStrategies(&s.Items.Schemas[i])
+		}
+	}
+	if s.AdditionalProperties != nil {
+		addListMergeStrategies(s.AdditionalProperties.Schema)
+	}
+	for i := range s.AllOf {
+		addListMergeStrategies(&s.AllOf[i])
+	}
+}
+
+// inlineRefs replaces the references to other definitions in the schema
+// with copies of the definitions.
+func inlineRefs(s *spec.Schema, definitions spec.Definitions, depth int) {
+	if s == nil {
+		return
+	}
+	if ref := s.Ref.String(); ref != "" {
+		d, found := definitions[strings.TrimPrefix(ref, "#/definitions/")]
+		if !found || depth >= maxRefDepth {
+			return
+		}
+		// Keep the extensions of the referencing schema, such as the patch
+		// strategy of a field.
+		extensions := spec.Extensions{}
+		for k, v := range d.Extensions {
+			extensions[k] = v
+		}
+		for k, v := range s.Extensions {
+			extensions[k] = v
+		}
+		*s = d
+		s.Extensions = extensions
+	}
+	if s.Properties != nil {
+		properties := make(map[string]spec.Schema, len(s.Properties))
+		for k, p := range s.Properties {
+			inlineRefs(&p, definitions, depth+1)
+			properties[k] = p
+		}
+		s.Properties = properties
+	}
+	if s.Items != nil && s.Items.Schema != nil {
+		items := *s.Items
+		schema := *items.Schema
+		inlineRefs(&schema, definitions, depth+1)
+		items.Schema = &schema
+		s.Items = &items
+	}
+	if s.AdditionalProperties != nil && s.AdditionalProperties.Schema != nil {
+		additional := *s.AdditionalProperties
+		schema := *additional.Schema
+		inlineRefs(&schema, definitions, depth+1)
+		additional.Schema = &schema
+		s.AdditionalProperties = &additional
+	}
+	if len(s.AllOf) > 0 {
+		allOf := make([]spec.Schema, len(s.AllOf))
+		for i := range s.AllOf {
+			allOf[i] = s.AllOf[i]
+			inlineRefs(&allOf[i], definitions, depth+1)
+		}
+		s.AllOf = allOf
+	}
+}
@@ -0,0 +1,224 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/GoogleContainerTools/kpt/internal/util/merge"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	gatewayCRD = `
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: gateways.example.com
+spec:
+  group: example.com
+  names:
+    kind: Gateway
+    plural: gateways
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            properties:
+              listeners:
+                type: array
+                x-kubernetes-list-type: map
+                x-kubernetes-list-map-keys:
+                - port
+                items:
+                  type: object
+                  properties:
+                    port:
+                      type: integer
+                    protocol:
+                      type: string
+`
+
+	gatewayOpenAPI = `{
+  "swagger": "2.0",
+  "info": {"title": "test", "version": "v1"},
+  "paths": {},
+  "definitions": {
+    "com.example.v1.Gateway": {
+      "type": "object",
+      "x-kubernetes-group-version-kind": [{"group": "example.com", "kind": "Gateway", "version": "v1"}],
+      "properties": {
+        "spec": {"$ref": "#/definitions/com.example.v1.GatewaySpec"}
+      }
+    },
+    "com.example.v1.GatewaySpec": {
+      "type": "object",
+      "properties": {
+        "listeners": {
+          "type": "array",
+          "x-kubernetes-list-type": "map",
+          "x-kubernetes-list-map-keys": ["port"],
+          "items": {"type": "object"}
+        }
+      }
+    }
+  }
+}`
+
+	gatewayOrigin = `
+apiVersion: example.com/v1
+kind: Gateway
+metadata:
+  name: gateway
+spec:
+  listeners:
+  - port: 80
+    protocol: HTTP
+`
+	gatewayUpdated = `
+apiVersion: example.com/v1
+kind: Gateway
+metadata:
+  name: gateway
+spec:
+  listeners:
+  - port: 80
+    protocol: HTTP2
+`
+	gatewayLocal = `
+apiVersion: example.com/v1
+kind: Gateway
+metadata:
+  name: gateway
+spec:
+  listeners:
+  - port: 80
+    protocol: HTTP
+  - port: 443
+    protocol: HTTPS
+`
+	gatewayMerged = `
+apiVersion: example.com/v1
+kind: Gateway
+metadata:
+  name: gateway
+spec:
+  listeners:
+  - port: 80
+    protocol: HTTP2
+  - port: 443
+    protocol: HTTPS
+`
+	gatewayReplaced = `
+apiVersion: example.com/v1
+kind: Gateway
+metadata:
+  name: gateway
+spec:
+  listeners:
+  - port: 80
+    protocol: HTTP2
+`
+)
+
+func TestMerge3_Schemas(t *testing.T) {
+	testCases := map[string]struct {
+		crdInPackage bool
+		schemaFile   string
+		schema       string
+		expected     string
+	}{
+		"no schema replaces lists": {
+			expected: gatewayReplaced,
+		},
+		"CRD in the package": {
+			crdInPackage: true,
+			expected:     gatewayMerged,
+		},
+		"CRD in a schema file": {
+			schemaFile: "crd.yaml",
+			schema:     gatewayCRD,
+			expected:   gatewayMerged,
+		},
+		"OpenAPI document in a schema file": {
+			schemaFile: "openapi.json",
+			schema:     gatewayOpenAPI,
+			expected:   gatewayMerged,
+		},
+	}
+
+	for tn, tc := range testCases {
+		tc := tc
+		t.Run(tn, func(t *testing.T) {
+			dirs := make(map[string]string)
+			for name, content := range map[string]string{
+				"original": gatewayOrigin,
+				"updated":  gatewayUpdated,
+				"local":    gatewayLocal,
+			} {
+				dir := t.TempDir()
+				dirs[name] = dir
+				writeFile(t, filepath.Join(dir, "gateway.yaml"), content)
+				if tc.crdInPackage {
+					writeFile(t, filepath.Join(dir, "crd.yaml"), gatewayCRD)
+				}
+			}
+
+			var schemas *merge.Schemas
+			if tc.schemaFile != "" {
+				schemaDir := t.TempDir()
+				writeFile(t, filepath.Join(schemaDir, tc.schemaFile), tc.schema)
+				schemas = merge.NewSchemas()
+				if !assert.NoError(t, schemas.AddPath(schemaDir)) {
+					t.FailNow()
+				}
+				assert.Equal(t, 1, schemas.Len())
+			}
+
+			err := merge.Merge3{
+				OriginalPath: dirs["original"],
+				UpdatedPath:  dirs["updated"],
+				DestPath:     dirs["local"],
+				MergeOnPath:  true,
+				Schemas:      schemas,
+			}.Merge()
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+
+			b, err := ioutil.ReadFile(filepath.Join(dirs["local"], "gateway.yaml"))
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, strings.TrimSpace(tc.expected), strings.TrimSpace(string(b)))
+		})
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	if err := ioutil.WriteFile(path, []byte(strings.TrimSpace(content)+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
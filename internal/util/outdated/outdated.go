@@ -0,0 +1,285 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package outdated contains libraries for reporting the packages in a
+// directory that are behind their upstream.
+package outdated
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/GoogleContainerTools/kpt/internal/errors"
+	"github.com/GoogleContainerTools/kpt/internal/gitutil"
+	"github.com/GoogleContainerTools/kpt/internal/pkg"
+	"github.com/GoogleContainerTools/kpt/internal/types"
+	"github.com/GoogleContainerTools/kpt/internal/util/addmergecomment"
+	"github.com/GoogleContainerTools/kpt/internal/util/fetch"
+	"github.com/GoogleContainerTools/kpt/internal/util/git"
+	"github.com/GoogleContainerTools/kpt/internal/util/pkgutil"
+	"github.com/GoogleContainerTools/kpt/internal/util/update"
+	kptfilev1 "github.com/GoogleContainerTools/kpt/pkg/api/kptfile/v1"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+const (
+	// OutputText prints the packages as a table
+	OutputText string = "text"
+	// OutputJSON prints the packages as JSON
+	OutputJSON string = "json"
+)
+
+// SupportedOutputFormats are the supported values for OutputFormat.
+var SupportedOutputFormats = []string{OutputText, OutputJSON}
+
+// PackageStatus describes how a package compares to its upstream.
+type PackageStatus struct {
+	// Package is the path of the package relative to the directory.
+	Package string `json:"package" yaml:"package"`
+
+	// Repo is the upstream git repository of the package.
+	Repo string `json:"repo" yaml:"repo"`
+
+	// Directory is the directory of the package in the upstream repository.
+	Directory string `json:"directory" yaml:"directory"`
+
+	// Ref is the upstream ref from the Kptfile.
+	Ref string `json:"ref" yaml:"ref"`
+
+	// CurrentRef is the ref from the upstream lock in the Kptfile. It is empty
+	// if the package has not been fetched.
+	CurrentRef string `json:"currentRef,omitempty" yaml:"currentRef,omitempty"`
+
+	// CurrentCommit is the commit from the upstream lock in the Kptfile.
+	CurrentCommit string `json:"currentCommit,omitempty" yaml:"currentCommit,omitempty"`
+
+	// LatestRef is the ref that an update of the package would fetch. It
+	// differs from Ref for version ranges and package-specific tags.
+	LatestRef string `json:"latestRef" yaml:"latestRef"`
+
+	// LatestCommit is the commit that an update of the package would fetch.
+	LatestCommit string `json:"latestCommit" yaml:"latestCommit"`
+
+	// NewerTags are the tags with a higher version than CurrentRef, if
+	// CurrentRef is a semantic version tag.
+	NewerTags []string `json:"newerTags,omitempty" yaml:"newerTags,omitempty"`
+
+	// UpToDate is true if the package is at the latest commit.
+	UpToDate bool `json:"upToDate" yaml:"upToDate"`
+
+	// LocalChanges are the files of the package that were modified since it
+	// was fetched.
+	LocalChanges []string `json:"localChanges,omitempty" yaml:"localChanges,omitempty"`
+}
+
+// Command reports the status of every package with an upstream in a
+// directory, including nested subpackages.
+type Command struct {
+	// Path is the path to the directory with the packages.
+	Path string
+
+	// OutputFormat is the format of the report.
+	OutputFormat string
+
+	// Output is where the report is written.
+	Output io.Writer
+
+	// Packages contains the status of the packages after Run.
+	Packages []PackageStatus
+}
+
+// DefaultValues sets up the default values for the command.
+func (c *Command) DefaultValues() {
+	if c.Output == nil {
+		c.Output = os.Stdout
+	}
+	if c.OutputFormat == "" {
+		c.OutputFormat = OutputText
+	}
+}
+
+// Validate makes sure the command is properly configured.
+func (c *Command) Validate() error {
+	const op errors.Op = "outdated.Validate"
+	switch c.OutputFormat {
+	case "", OutputText, OutputJSON:
+	default:
+		return errors.E(op, errors.InvalidParam,
+			fmt.Errorf("invalid output format %q. Supported formats are: %s",
+				c.OutputFormat, strings.Join(SupportedOutputFormats, ", ")))
+	}
+	return nil
+}
+
+// Run finds the packages with an upstream, checks them against the upstream
+// repositories and writes the report.
+func (c *Command) Run(ctx context.Context) error {
+	const op errors.Op = "outdated.Run"
+	c.DefaultValues()
+	if err := c.Validate(); err != nil {
+		return errors.E(op, err)
+	}
+
+	subPkgPaths, err := pkgutil.FindSubpackagesForPaths(pkg.All, true, c.Path)
+	if err != nil {
+		return errors.E(op, types.UniquePath(c.Path), err)
+	}
+
+	// The refs of each upstream repository are only listed once, no matter
+	// how many packages come from it.
+	repos := make(map[string]*gitutil.GitUpstreamRepo)
+	c.Packages = nil
+	for _, p := range append([]string{"."}, subPkgPaths...) {
+		pkgPath := filepath.Join(c.Path, p)
+		kf, err := pkg.ReadKptfile(filesys.FileSystemOrOnDisk{}, pkgPath)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return errors.E(op, types.UniquePath(pkgPath), err)
+		}
+		if kf.Upstream == nil || kf.Upstream.Git == nil {
+			continue
+		}
+
+		status, err := c.packageStatus(ctx, repos, p, pkgPath, kf)
+		if err != nil {
+			return errors.E(op, types.UniquePath(pkgPath), err)
+		}
+		c.Packages = append(c.Packages, status)
+	}
+
+	if c.OutputFormat == OutputJSON {
+		return c.printJSON()
+	}
+	return c.printTable()
+}
+
+// packageStatus compares a single package with its upstream.
+func (c *Command) packageStatus(ctx context.Context, repos map[string]*gitutil.GitUpstreamRepo,
+	relPath, pkgPath string, kf *kptfilev1.KptFile) (PackageStatus, error) {
+	const op errors.Op = "outdated.packageStatus"
+	g := kf.Upstream.Git
+	status := PackageStatus{
+		Package:   relPath,
+		Repo:      g.Repo,
+		Directory: g.Directory,
+		Ref:       g.Ref,
+	}
+
+	repoSpec := &git.RepoSpec{OrgRepo: g.Repo, Path: g.Directory, Ref: g.Ref}
+	upstreamRepo, found := repos[repoSpec.CloneSpec()]
+	if !found {
+		r, err := gitutil.NewGitUpstreamRepo(ctx, repoSpec.CloneSpec())
+		if err != nil {
+			return status, errors.E(op, errors.Git, errors.Repo(repoSpec.CloneSpec()), err)
+		}
+		upstreamRepo = r
+		repos[repoSpec.CloneSpec()] = upstreamRepo
+	}
+
+	latestRef, latestCommit, err := fetch.ResolveRef(upstreamRepo, g.Directory, g.Ref)
+	if err != nil {
+		return status, errors.E(op, errors.Git, errors.Repo(repoSpec.CloneSpec()), err)
+	}
+	status.LatestRef = latestRef
+	status.LatestCommit = latestCommit
+
+	if kf.UpstreamLock == nil || kf.UpstreamLock.Git == nil {
+		return status, nil
+	}
+	gLock := kf.UpstreamLock.Git
+	status.CurrentRef = gLock.Ref
+	status.CurrentCommit = gLock.Commit
+	status.UpToDate = gLock.Commit == latestCommit
+	status.NewerTags = upstreamRepo.NewerTags(gLock.Ref)
+
+	// Fetch the package at the locked commit to find the local changes.
+	origin := &git.RepoSpec{OrgRepo: gLock.Repo, Path: gLock.Directory, Ref: gLock.Commit}
+	if err := fetch.NewCloner(origin, fetch.WithCachedRepo(repos)).Clone(ctx); err != nil {
+		return status, errors.E(op, errors.Git, errors.Repo(origin.CloneSpec()), err)
+	}
+	defer os.RemoveAll(origin.Dir)
+
+	// The local package has merge comments that were added when it was
+	// fetched, so they must not count as local changes.
+	if err := addmergecomment.Process(origin.AbsPath()); err != nil {
+		return status, errors.E(op, types.UniquePath(pkgPath), err)
+	}
+
+	changes, err := update.FindLocalChanges(pkgPath, origin.AbsPath())
+	if err != nil {
+		return status, errors.E(op, types.UniquePath(pkgPath), err)
+	}
+	status.LocalChanges = changes
+	return status, nil
+}
+
+func (c *Command) printJSON() error {
+	packages := c.Packages
+	if packages == nil {
+		packages = []PackageStatus{}
+	}
+	b, err := json.MarshalIndent(packages, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(c.Output, string(b))
+	return err
+}
+
+func (c *Command) printTable() error {
+	w := tabwriter.NewWriter(c.Output, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PACKAGE\tREF\tCURRENT\tLATEST\tNEWER TAGS\tLOCAL CHANGES")
+	for _, p := range c.Packages {
+		current := "<not fetched>"
+		if p.CurrentCommit != "" {
+			current = refAndCommit(p.CurrentRef, p.CurrentCommit)
+		}
+		latest := refAndCommit(p.LatestRef, p.LatestCommit)
+		if p.UpToDate {
+			latest = "<up to date>"
+		}
+		newerTags := strings.Join(p.NewerTags, ",")
+		if newerTags == "" {
+			newerTags = "-"
+		}
+		localChanges := "no"
+		if len(p.LocalChanges) > 0 {
+			localChanges = fmt.Sprintf("yes (%d file(s))", len(p.LocalChanges))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", p.Package, p.Ref, current, latest, newerTags, localChanges)
+	}
+	return w.Flush()
+}
+
+// refAndCommit formats a ref and the commit it resolved to, using the short
+// form of the commit.
+func refAndCommit(ref, commit string) string {
+	short := commit
+	if len(short) > 7 {
+		short = short[:7]
+	}
+	if ref == "" || ref == commit {
+		return short
+	}
+	return fmt.Sprintf("%s (%s)", ref, short)
+}
+
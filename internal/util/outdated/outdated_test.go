@@ -0,0 +1,158 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outdated_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleContainerTools/kpt/internal/printer/fake"
+	"github.com/GoogleContainerTools/kpt/internal/testutil"
+	. "github.com/GoogleContainerTools/kpt/internal/util/outdated"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(testutil.ConfigureTestKptCache(m))
+}
+
+func TestCommand_Run(t *testing.T) {
+	testCases := map[string]struct {
+		ref                  string
+		modifyLocal          bool
+		expectedCurrentRef   string
+		expectedLatestCommit int
+		expectedNewerTags    []string
+		expectedUpToDate     bool
+		expectedLocalChanges []string
+	}{
+		"branch behind upstream": {
+			ref:                  "master",
+			expectedCurrentRef:   "master",
+			expectedLatestCommit: 2,
+			expectedNewerTags:    nil,
+			expectedUpToDate:     false,
+		},
+		"tag with newer tags": {
+			ref:                  "v1.0.0",
+			expectedCurrentRef:   "v1.0.0",
+			expectedLatestCommit: 0,
+			expectedNewerTags:    []string{"v1.1.0", "v2.0.0"},
+			expectedUpToDate:     true,
+		},
+		"version range behind upstream": {
+			ref:                  "~1.0",
+			expectedCurrentRef:   "v1.0.0",
+			expectedLatestCommit: 0,
+			expectedNewerTags:    []string{"v1.1.0", "v2.0.0"},
+			expectedUpToDate:     true,
+		},
+		"local changes": {
+			ref:                  "v1.0.0",
+			modifyLocal:          true,
+			expectedCurrentRef:   "v1.0.0",
+			expectedLatestCommit: 0,
+			expectedNewerTags:    []string{"v1.1.0", "v2.0.0"},
+			expectedUpToDate:     true,
+			expectedLocalChanges: []string{"new-file.txt"},
+		},
+	}
+
+	for tn, tc := range testCases {
+		tc := tc
+		t.Run(tn, func(t *testing.T) {
+			g := &testutil.TestSetupManager{
+				T:      t,
+				GetRef: tc.ref,
+				ReposChanges: map[string][]testutil.Content{
+					testutil.Upstream: {
+						{
+							Data:   testutil.Dataset1,
+							Branch: "master",
+							Tag:    "v1.0.0",
+						},
+						{
+							Data: testutil.Dataset2,
+							Tag:  "v1.1.0",
+						},
+						{
+							Data: testutil.Dataset3,
+							Tag:  "v2.0.0",
+						},
+					},
+				},
+			}
+			defer g.Clean()
+			if !g.Init() {
+				t.FailNow()
+			}
+			upstreamRepo := g.Repos[testutil.Upstream]
+
+			if tc.modifyLocal {
+				err := ioutil.WriteFile(filepath.Join(g.LocalWorkspace.FullPackagePath(), "new-file.txt"),
+					[]byte("local"), 0600)
+				if !assert.NoError(t, err) {
+					t.FailNow()
+				}
+			}
+
+			out := &bytes.Buffer{}
+			cmd := &Command{
+				Path:         g.LocalWorkspace.WorkspaceDirectory,
+				OutputFormat: OutputJSON,
+				Output:       out,
+			}
+			if !assert.NoError(t, cmd.Run(fake.CtxWithDefaultPrinter())) {
+				t.FailNow()
+			}
+
+			if !assert.Len(t, cmd.Packages, 1) {
+				t.FailNow()
+			}
+			p := cmd.Packages[0]
+			assert.Equal(t, g.LocalWorkspace.PackageDir, p.Package)
+			assert.Equal(t, tc.ref, p.Ref)
+			assert.Equal(t, tc.expectedCurrentRef, p.CurrentRef)
+			assert.Equal(t, upstreamRepo.Commits[0], p.CurrentCommit)
+			assert.Equal(t, upstreamRepo.Commits[tc.expectedLatestCommit], p.LatestCommit)
+			assert.Equal(t, tc.expectedNewerTags, p.NewerTags)
+			assert.Equal(t, tc.expectedUpToDate, p.UpToDate)
+			assert.Equal(t, tc.expectedLocalChanges, p.LocalChanges)
+
+			var printed []PackageStatus
+			if assert.NoError(t, json.Unmarshal(out.Bytes(), &printed)) {
+				assert.Equal(t, cmd.Packages, printed)
+			}
+		})
+	}
+}
+
+func TestCommand_Run_noUpstream(t *testing.T) {
+	out := &bytes.Buffer{}
+	cmd := &Command{
+		Path:         t.TempDir(),
+		OutputFormat: OutputJSON,
+		Output:       out,
+	}
+	if !assert.NoError(t, cmd.Run(fake.CtxWithDefaultPrinter())) {
+		t.FailNow()
+	}
+	assert.Empty(t, cmd.Packages)
+	assert.Equal(t, "[]\n", out.String())
+}
@@ -24,6 +24,7 @@ import (
 	"strings"
 
 	"github.com/GoogleContainerTools/kpt/internal/gitutil"
+	"github.com/GoogleContainerTools/kpt/internal/util/fetch"
 	kptfilev1 "github.com/GoogleContainerTools/kpt/pkg/api/kptfile/v1"
 	"sigs.k8s.io/kustomize/kyaml/errors"
 )
@@ -86,6 +87,78 @@ func GitParseArgs(ctx context.Context, args []string) (Target, error) {
 	return g, nil
 }
 
+// LocalTarget is a package in a local directory or tarball, and the local
+// destination to fetch it to.
+type LocalTarget struct {
+	Dir         *kptfilev1.Dir
+	Tarball     *kptfilev1.Tarball
+	Destination string
+}
+
+// IsLocalUpstream returns true if the pkg argument refers to a tarball, or
+// to a local directory with a kpt package rather than a git repo.
+func IsLocalUpstream(arg string) bool {
+	if HasGitSuffix(arg) {
+		return false
+	}
+	if strings.Contains(arg, "://") {
+		return isHTTPURL(arg) && fetch.IsTarball(arg)
+	}
+	if fetch.IsTarball(arg) {
+		return true
+	}
+	_, err := os.Stat(filepath.Join(arg, kptfilev1.KptFileName))
+	return err == nil
+}
+
+// LocalParseArgs parses a local directory or tarball and destination into
+// the upstream of the package and its local destination. Paths are made
+// relative to the destination, so they remain valid if both are moved
+// together.
+func LocalParseArgs(args []string) (LocalTarget, error) {
+	t := LocalTarget{}
+	src := args[0]
+	if !isHTTPURL(src) {
+		abs, err := filepath.Abs(src)
+		if err != nil {
+			return t, err
+		}
+		src = abs
+	}
+	name := path.Base(filepath.ToSlash(src))
+	for _, ext := range []string{".tar.gz", ".tgz", ".tar"} {
+		name = strings.TrimSuffix(name, ext)
+	}
+	destination, err := getDest(args[1], name, "")
+	if err != nil {
+		return t, err
+	}
+	t.Destination = filepath.Clean(destination)
+
+	if isHTTPURL(src) {
+		t.Tarball = &kptfilev1.Tarball{URL: src}
+		return t, nil
+	}
+	absDest, err := filepath.Abs(t.Destination)
+	if err != nil {
+		return t, err
+	}
+	rel, err := filepath.Rel(absDest, src)
+	if err != nil {
+		return t, err
+	}
+	if fetch.IsTarball(src) {
+		t.Tarball = &kptfilev1.Tarball{URL: filepath.ToSlash(rel)}
+	} else {
+		t.Dir = &kptfilev1.Dir{Path: filepath.ToSlash(rel)}
+	}
+	return t, nil
+}
+
+func isHTTPURL(s string) bool {
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+}
+
 // targetFromPkgURL parses a pkg url and destination into kptfile git info and local destination Target
 func targetFromPkgURL(ctx context.Context, pkgURL string, dest string) (Target, error) {
 	g := Target{}
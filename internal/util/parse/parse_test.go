@@ -17,6 +17,8 @@ package parse
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/GoogleContainerTools/kpt/internal/printer"
@@ -267,3 +269,62 @@ func Test_GitParseArgs(t *testing.T) {
 		})
 	}
 }
+
+func Test_LocalParseArgs(t *testing.T) {
+	dir := t.TempDir()
+	blueprint := filepath.Join(dir, "blueprints", "cockroachdb")
+	require.NoError(t, os.MkdirAll(blueprint, 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(blueprint, v1.KptFileName), nil, 0600))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "not-a-package"), 0700))
+	ws := filepath.Join(dir, "ws")
+	require.NoError(t, os.MkdirAll(ws, 0700))
+
+	tests := map[string]struct {
+		arg     string
+		isLocal bool
+		target  LocalTarget
+	}{
+		"directory": {
+			arg:     blueprint,
+			isLocal: true,
+			target: LocalTarget{
+				Dir:         &v1.Dir{Path: "../../blueprints/cockroachdb"},
+				Destination: filepath.Join(ws, "cockroachdb"),
+			},
+		},
+		"local tarball": {
+			arg:     filepath.Join(dir, "cockroachdb-v1.tar.gz"),
+			isLocal: true,
+			target: LocalTarget{
+				Tarball:     &v1.Tarball{URL: "../../cockroachdb-v1.tar.gz"},
+				Destination: filepath.Join(ws, "cockroachdb-v1"),
+			},
+		},
+		"remote tarball": {
+			arg:     "https://example.com/packages/cockroachdb.tgz",
+			isLocal: true,
+			target: LocalTarget{
+				Tarball:     &v1.Tarball{URL: "https://example.com/packages/cockroachdb.tgz"},
+				Destination: filepath.Join(ws, "cockroachdb"),
+			},
+		},
+		"directory without a Kptfile": {
+			arg: filepath.Join(dir, "not-a-package"),
+		},
+		"git repo": {
+			arg: "https://github.com/GoogleContainerTools/kpt.git/package-examples/wordpress",
+		},
+	}
+	for name, test := range tests {
+		test := test // capture range variable
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.isLocal, IsLocalUpstream(test.arg))
+			if !test.isLocal {
+				return
+			}
+			target, err := LocalParseArgs([]string{test.arg, ws})
+			assert.NoError(t, err)
+			assert.Equal(t, test.target, target)
+		})
+	}
+}
@@ -152,6 +152,11 @@ func (e *Renderer) Execute(ctx context.Context) error {
 	return e.saveFnResults(ctx, hctx.fnResults)
 }
 
+// Results returns the results of the functions of the last Execute.
+func (e *Renderer) Results() *fnresult.ResultList {
+	return e.fnResultsList
+}
+
 func (e *Renderer) saveFnResults(ctx context.Context, fnResults *fnresult.ResultList) error {
 	e.fnResultsList = fnResults
 	resultsFile, err := fnruntime.SaveResults(e.FileSystem, e.ResultsDirPath, fnResults)
@@ -0,0 +1,469 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package update
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/GoogleContainerTools/kpt/internal/errors"
+	"github.com/GoogleContainerTools/kpt/internal/pkg"
+	"github.com/GoogleContainerTools/kpt/internal/printer"
+	"github.com/GoogleContainerTools/kpt/internal/types"
+	pkgdiff "github.com/GoogleContainerTools/kpt/internal/util/diff"
+	"github.com/GoogleContainerTools/kpt/internal/util/pkgutil"
+	kptfilev1 "github.com/GoogleContainerTools/kpt/pkg/api/kptfile/v1"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// ConflictResolution defines how conflicts between local and upstream
+// changes are handled when updating a package with the resource-merge
+// strategy.
+type ConflictResolution string
+
+const (
+	// UseUpstream uses the upstream value of conflicting fields, and reports
+	// the conflicts after the update.
+	UseUpstream ConflictResolution = "upstream"
+	// StopOnConflict does not update the package if there are conflicts, and
+	// writes them with conflict markers to ConflictsFileName in the package.
+	StopOnConflict ConflictResolution = "stop"
+	// PromptOnConflict asks whether to use the local or the upstream value
+	// of each conflicting field.
+	PromptOnConflict ConflictResolution = "prompt"
+)
+
+// ConflictResolutionsAsStrings returns the supported conflict resolutions
+// as strings.
+func ConflictResolutionsAsStrings() []string {
+	return []string{string(UseUpstream), string(StopOnConflict), string(PromptOnConflict)}
+}
+
+// ConflictsFileName is the name of the file written to the root of the
+// package when the update is stopped because of conflicts.
+const ConflictsFileName = ".kpt-update-conflicts"
+
+const (
+	// ResolvedLocal is the resolution of a conflict where the local value
+	// is kept.
+	ResolvedLocal = "local"
+	// ResolvedUpstream is the resolution of a conflict where the upstream
+	// value is used.
+	ResolvedUpstream = "upstream"
+)
+
+// Conflict is a field that was changed differently in the local package and
+// in upstream.
+type Conflict struct {
+	// Package is the slash-separated path of the package containing the
+	// resource, relative to the parent directory of the updated package.
+	Package string `json:"package" yaml:"package"`
+
+	// Resolution is either ResolvedLocal or ResolvedUpstream depending on
+	// the value used by the update. It is empty if the package was not
+	// updated.
+	Resolution string `json:"resolution,omitempty" yaml:"resolution,omitempty"`
+
+	pkgdiff.Conflict `json:",inline" yaml:",inline"`
+}
+
+// stopOnConflicts performs the update on a staged copy of the package and,
+// if there are conflicts between local and upstream changes, writes them to
+// ConflictsFileName in the package and returns an error. The package is not
+// updated in that case.
+func (u *Command) stopOnConflicts(ctx context.Context) error {
+	const op errors.Op = "update.stopOnConflicts"
+	pr := printer.FromContextOrDie(ctx)
+
+	stagingDir, err := ioutil.TempDir("", "kpt-update-")
+	if err != nil {
+		return errors.E(op, errors.IO, fmt.Errorf("error creating a temporary directory: %w", err))
+	}
+	defer os.RemoveAll(stagingDir)
+
+	// Conflicts already resolved in the conflicts file written by the
+	// previous update don't stop the update, and are resolved as chosen.
+	conflictsPath := filepath.Join(u.Pkg.UniquePath.String(), ConflictsFileName)
+	u.resolutions, err = readConflictResolutions(conflictsPath)
+	if err != nil {
+		return errors.E(op, errors.IO, u.Pkg.UniquePath, err)
+	}
+
+	// The output of the staged update is discarded since the update is
+	// performed again on the package if there are no conflicts.
+	quietCtx := printer.WithContext(ctx, printer.New(ioutil.Discard, ioutil.Discard))
+	conflicts, err := u.stage(quietCtx, stagingDir)
+	if err != nil {
+		return err
+	}
+	if len(conflicts) == 0 {
+		return nil
+	}
+	unresolved := 0
+	for i := range conflicts {
+		if !u.isResolved(conflicts[i]) {
+			conflicts[i].Resolution = ""
+			unresolved++
+		}
+	}
+	if unresolved == 0 {
+		return nil
+	}
+	u.Conflicts = conflicts
+
+	if err := ioutil.WriteFile(conflictsPath, []byte(conflictMarkers(conflicts)), 0600); err != nil {
+		return errors.E(op, errors.IO, u.Pkg.UniquePath, err)
+	}
+	printConflicts(pr, conflicts)
+	return errors.E(op, u.Pkg.UniquePath,
+		fmt.Errorf("package was not updated because of %d unresolved conflict(s) between local and upstream changes, "+
+			"see %q", unresolved, conflictsPath))
+}
+
+// isResolved returns true if a resolution of the conflict was chosen in
+// ConflictsFileName. Resources deleted on one side and modified on the
+// other can only be kept.
+func (u *Command) isResolved(c Conflict) bool {
+	switch u.resolutions[conflictKey(c)] {
+	case ResolvedLocal:
+		return true
+	case ResolvedUpstream:
+		return c.Path != ""
+	default:
+		return false
+	}
+}
+
+// readConflictResolutions returns the resolutions chosen in the conflicts
+// file at path by conflict key, or nil if there is no such file.
+func readConflictResolutions(path string) (map[string]string, error) {
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	resolutions := make(map[string]string)
+	var key string
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+		case strings.HasPrefix(line, resolutionPrefix):
+			if key != "" {
+				resolutions[key] = strings.TrimSpace(strings.TrimPrefix(line, resolutionPrefix))
+			}
+			key = ""
+		default:
+			key = line
+		}
+	}
+	return resolutions, nil
+}
+
+// resolutionPrefix starts the line with the resolution of a conflict in
+// ConflictsFileName.
+const resolutionPrefix = "resolution:"
+
+// conflictKey identifies the conflict in ConflictsFileName.
+func conflictKey(c Conflict) string {
+	field := c.Path
+	if field == "" {
+		field = "<resource>"
+	}
+	return fmt.Sprintf("%s: %s %s %s %s", path.Join(c.Package, c.File), c.APIVersion, c.Kind, resourceID(c), field)
+}
+
+// conflictMarkers returns the conflicts formatted with conflict markers
+// around the local, original and upstream values, after the resolution
+// chosen for each conflict.
+func conflictMarkers(conflicts []Conflict) string {
+	var b strings.Builder
+	b.WriteString("# Conflicts between local and upstream changes found by kpt pkg update.\n")
+	b.WriteString("# Set the resolution of each conflict to \"local\" to keep the local value, or to\n")
+	b.WriteString("# \"upstream\" to use the upstream value, then run the update again. Resources\n")
+	b.WriteString("# deleted on one side and modified on the other can only be kept with \"local\".\n")
+	b.WriteString("# Conflicts can also be resolved by changing the local package.\n")
+	for _, c := range conflicts {
+		resolution := c.Resolution
+		if resolution == "" {
+			resolution = "unresolved"
+		}
+		fmt.Fprintf(&b, "\n%s\n%s %s\n", conflictKey(c), resolutionPrefix, resolution)
+		fmt.Fprintf(&b, "<<<<<<< local\n%s||||||| original\n%s=======\n%s>>>>>>> upstream\n",
+			markerValue(c.Local), markerValue(c.Original), markerValue(c.Upstream))
+	}
+	return b.String()
+}
+
+// markerValue formats a conflicting value as YAML between conflict markers.
+func markerValue(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v\n", v)
+	}
+	return string(b)
+}
+
+func printConflicts(pr printer.Printer, conflicts []Conflict) {
+	pr.Printf("\nFound %d conflict(s) between local and upstream changes:\n", len(conflicts))
+	for _, c := range conflicts {
+		pr.Printf("  Package %q, %s: %s %s %s\n", c.Package, c.File, c.APIVersion, c.Kind, resourceID(c))
+		used := ""
+		if c.Resolution != "" {
+			used = fmt.Sprintf(" (%s is used)", c.Resolution)
+		}
+		if c.Path == "" {
+			pr.Printf("    resource %s in local and %s in upstream%s\n",
+				resourceState(c.Local), resourceState(c.Upstream), used)
+			continue
+		}
+		pr.Printf("    %s: original=%s, local=%s, upstream=%s%s\n", c.Path,
+			conflictValue(c.Original, "<none>"), conflictValue(c.Local, "<none>"), conflictValue(c.Upstream, "<none>"), used)
+	}
+}
+
+func resourceID(c Conflict) string {
+	if c.Namespace != "" {
+		return c.Namespace + "/" + c.Name
+	}
+	return c.Name
+}
+
+func resourceState(v interface{}) string {
+	if v == nil {
+		return "deleted"
+	}
+	return "modified"
+}
+
+// conflictValue formats a conflicting value on a single line.
+func conflictValue(v interface{}, none string) string {
+	if v == nil {
+		return none
+	}
+	if s, ok := v.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	n, err := yaml.Parse(string(b))
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	n.YNode().Style = yaml.FlowStyle
+	return strings.TrimSpace(n.MustString())
+}
+
+// collectConflicts records the conflicts between the local and updated
+// versions of the package at localPath and its local subpackages. With
+// PromptOnConflict, the value to use for each conflicting field is asked
+// first, and the updated package is changed to use the local values chosen.
+func (u Command) collectConflicts(ctx context.Context, localPath, updatedPath, originPath string) error {
+	const op errors.Op = "update.collectConflicts"
+	subPkgPaths, err := pkgutil.FindSubpackagesForPaths(pkg.Local, true, localPath, updatedPath, originPath)
+	if err != nil {
+		return errors.E(op, types.UniquePath(localPath), err)
+	}
+	for _, subPkgPath := range append([]string{"."}, subPkgPaths...) {
+		paths := []string{
+			filepath.Join(localPath, subPkgPath),
+			filepath.Join(updatedPath, subPkgPath),
+			filepath.Join(originPath, subPkgPath),
+		}
+		allExist := true
+		for _, p := range paths {
+			exists, err := pkgutil.Exists(p)
+			if err != nil {
+				return errors.E(op, types.UniquePath(localPath), err)
+			}
+			allExist = allExist && exists
+		}
+		if !allExist {
+			continue
+		}
+		found, err := pkgdiff.FindConflicts(paths[2], paths[0], paths[1])
+		if err != nil {
+			return errors.E(op, types.UniquePath(localPath), err)
+		}
+		relPath, err := filepath.Rel(filepath.Dir(u.Pkg.UniquePath.String()), paths[0])
+		if err != nil {
+			return errors.E(op, types.UniquePath(localPath), err)
+		}
+		var conflicts []Conflict
+		for _, c := range found {
+			// Merge3 keeps a resource that was deleted on one side and
+			// modified on the other, and uses the upstream value of fields
+			// changed on both sides.
+			resolution := ResolvedUpstream
+			if c.Path == "" {
+				resolution = ResolvedLocal
+			}
+			conflicts = append(conflicts, Conflict{
+				Package:    filepath.ToSlash(relPath),
+				Resolution: resolution,
+				Conflict:   c,
+			})
+		}
+		switch {
+		case u.input != nil:
+			if err := u.promptConflicts(ctx, conflicts); err != nil {
+				return errors.E(op, types.UniquePath(localPath), err)
+			}
+		case u.resolutions != nil:
+			for i := range conflicts {
+				if conflicts[i].Path != "" && u.resolutions[conflictKey(conflicts[i])] == ResolvedLocal {
+					conflicts[i].Resolution = ResolvedLocal
+				}
+			}
+		}
+		if err := useLocalValues(paths[1], conflicts); err != nil {
+			return errors.E(op, types.UniquePath(localPath), err)
+		}
+		*u.conflicts = append(*u.conflicts, conflicts...)
+	}
+	return nil
+}
+
+// promptConflicts asks whether to use the local or the upstream value of
+// each conflicting field, and sets the resolution of the conflicts for which
+// the local value was chosen to ResolvedLocal.
+func (u Command) promptConflicts(ctx context.Context, conflicts []Conflict) error {
+	pr := printer.FromContextOrDie(ctx)
+	for i := range conflicts {
+		c := &conflicts[i]
+		if c.Path == "" {
+			continue
+		}
+		pr.Printf("\nConflict in package %q, %s: %s %s %s\n", c.Package, c.File, c.APIVersion, c.Kind, resourceID(*c))
+		pr.Printf("  %s: original=%s, local=%s, upstream=%s\n", c.Path,
+			conflictValue(c.Original, "<none>"), conflictValue(c.Local, "<none>"), conflictValue(c.Upstream, "<none>"))
+		local, err := u.promptUseLocal(pr)
+		if err != nil {
+			return err
+		}
+		if local {
+			c.Resolution = ResolvedLocal
+		}
+	}
+	return nil
+}
+
+// useLocalValues sets the conflicting fields for which the local value was
+// chosen to that value in the updated package at updatedPath, so the merge
+// keeps it.
+func useLocalValues(updatedPath string, conflicts []Conflict) error {
+	var useLocal []Conflict
+	for _, c := range conflicts {
+		if c.Path != "" && c.Resolution == ResolvedLocal {
+			useLocal = append(useLocal, c)
+		}
+	}
+	if len(useLocal) == 0 {
+		return nil
+	}
+
+	rw := &kio.LocalPackageReadWriter{
+		PackagePath:       updatedPath,
+		PackageFileName:   kptfilev1.KptFileName,
+		PreserveSeqIndent: true,
+		WrapBareSeqNode:   true,
+	}
+	nodes, err := rw.Read()
+	if err != nil {
+		return err
+	}
+	for _, c := range useLocal {
+		for _, n := range nodes {
+			if !isConflictResource(n, c) {
+				continue
+			}
+			// The conflicting field may be a parent of the annotations
+			// which locate the resource in the package, whose values are
+			// not part of the conflict.
+			internal := make(map[string]string)
+			annotations := n.GetAnnotations()
+			for _, k := range []string{kioutil.PathAnnotation, kioutil.IndexAnnotation,
+				kioutil.LegacyPathAnnotation, kioutil.LegacyIndexAnnotation, // nolint:staticcheck
+				kioutil.SeqIndentAnnotation} {
+				if v, found := annotations[k]; found {
+					internal[k] = v
+				}
+			}
+			if err := pkgdiff.SetFieldValue(n, c.Path, c.Local); err != nil {
+				return err
+			}
+			for k, v := range internal {
+				if err := n.PipeE(yaml.SetAnnotation(k, v)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return rw.Write(nodes)
+}
+
+// promptUseLocal reads the answer to the prompt for a conflict, and returns
+// true if the local value should be used.
+func (u Command) promptUseLocal(pr printer.Printer) (bool, error) {
+	for {
+		pr.Printf("Use the [l]ocal or the [u]pstream value? (default: upstream): ")
+		answer, err := u.input.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return false, err
+		}
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "l", "local":
+			return true, nil
+		case "", "u", "upstream":
+			return false, nil
+		}
+		if err == io.EOF {
+			return false, fmt.Errorf("invalid answer %q", strings.TrimSpace(answer))
+		}
+		pr.Printf("Please answer %q or %q.\n", "l", "u")
+	}
+}
+
+// isConflictResource returns true if the node is the resource of the
+// conflict.
+func isConflictResource(n *yaml.RNode, c Conflict) bool {
+	meta, err := n.GetMeta()
+	if err != nil {
+		return false
+	}
+	group := func(apiVersion string) string {
+		if i := strings.Index(apiVersion, "/"); i >= 0 {
+			return apiVersion[:i]
+		}
+		return ""
+	}
+	return meta.Kind == c.Kind && meta.Name == c.Name && meta.Namespace == c.Namespace &&
+		group(meta.APIVersion) == group(c.APIVersion)
+}
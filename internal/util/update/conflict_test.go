@@ -0,0 +1,67 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package update
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestUseLocalValues verifies that the local values of conflicting parent
+// fields replace the whole field, without moving the resource.
+func TestUseLocalValues(t *testing.T) {
+	dir := t.TempDir()
+	cm := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm
+  annotations:
+    foo: bar
+data:
+  foo: bar
+  bar: foo
+`
+	if !assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "cm.yaml"), []byte(cm), 0600)) {
+		t.FailNow()
+	}
+	conflict := func(path string, local interface{}) Conflict {
+		c := Conflict{Resolution: ResolvedLocal}
+		c.APIVersion, c.Kind, c.Name, c.File = "v1", "ConfigMap", "cm", "cm.yaml"
+		c.Path, c.Local = path, local
+		return c
+	}
+	err := useLocalValues(dir, []Conflict{
+		conflict("data", map[string]interface{}{"foo": "baz"}),
+		conflict("metadata.annotations", nil),
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "cm.yaml"))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm
+data:
+  foo: baz
+`, string(b))
+}
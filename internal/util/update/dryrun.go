@@ -0,0 +1,189 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package update
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/GoogleContainerTools/kpt/internal/errors"
+	"github.com/GoogleContainerTools/kpt/internal/pkg"
+	"github.com/GoogleContainerTools/kpt/internal/printer"
+	"github.com/GoogleContainerTools/kpt/internal/util/addmergecomment"
+	"github.com/GoogleContainerTools/kpt/internal/util/pkgutil"
+	"github.com/pmezard/go-difflib/difflib"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+// dryRun performs the update on a staged copy of the package, and prints
+// the diff between the local package and the result of the update, as well
+// as the conflicts between local and upstream changes. The local package is
+// not modified.
+func (u *Command) dryRun(ctx context.Context) error {
+	const op errors.Op = "update.dryRun"
+	pr := printer.FromContextOrDie(ctx)
+
+	stagingDir, err := ioutil.TempDir("", "kpt-update-")
+	if err != nil {
+		return errors.E(op, errors.IO, fmt.Errorf("error creating a temporary directory: %w", err))
+	}
+	defer os.RemoveAll(stagingDir)
+
+	conflicts, err := u.stage(ctx, stagingDir)
+	if err != nil {
+		return err
+	}
+	u.Conflicts = conflicts
+
+	name := filepath.Base(u.Pkg.UniquePath.String())
+	pr.Printf("\nDry run: package %q was not modified. Changes that the update would make:\n", u.Pkg.DisplayPath)
+	if err := printPkgDiff(stagingDir, filepath.Join("local", name), filepath.Join("updated", name), pr); err != nil {
+		return errors.E(op, u.Pkg.UniquePath, err)
+	}
+	if len(conflicts) == 0 {
+		pr.Printf("\nNo conflicts between local and upstream changes.\n")
+		return nil
+	}
+	printConflicts(pr, conflicts)
+	return nil
+}
+
+// stage performs the update on a copy of the package in stagingDir/updated,
+// next to an unmodified copy in stagingDir/local, and returns the conflicts
+// between local and upstream changes. The local package is not modified.
+func (u *Command) stage(ctx context.Context, stagingDir string) ([]Conflict, error) {
+	const op errors.Op = "update.stage"
+
+	// The staged packages have the same name as the local package, so
+	// progress messages and the diff refer to the same package name.
+	name := filepath.Base(u.Pkg.UniquePath.String())
+	localPath := filepath.Join(stagingDir, "local", name)
+	updatedPath := filepath.Join(stagingDir, "updated", name)
+	for _, p := range []string{localPath, updatedPath} {
+		if err := os.MkdirAll(p, 0700); err != nil {
+			return nil, errors.E(op, errors.IO, err)
+		}
+		if err := pkgutil.CopyPackage(u.Pkg.UniquePath.String(), p, true, pkg.All); err != nil {
+			return nil, errors.E(op, u.Pkg.UniquePath, err)
+		}
+	}
+	// The update adds merge comments to all resources, so add them to the
+	// local copy as well to keep them out of the diff.
+	if err := addmergecomment.Process(localPath); err != nil {
+		return nil, errors.E(op, u.Pkg.UniquePath, err)
+	}
+
+	p, err := pkg.New(filesys.FileSystemOrOnDisk{}, updatedPath)
+	if err != nil {
+		return nil, errors.E(op, u.Pkg.UniquePath, err)
+	}
+	var conflicts []Conflict
+	staged := &Command{
+		Pkg:                 p,
+		Ref:                 u.Ref,
+		Strategy:            u.Strategy,
+		Schemas:             u.Schemas,
+		VerificationPolicy:  u.VerificationPolicy,
+		resolutions:         u.resolutions,
+		stagedFrom:          u.Pkg.UniquePath.String(),
+		cachedUpstreamRepos: u.cachedUpstreamRepos,
+		conflicts:           &conflicts,
+	}
+	if err := staged.Run(ctx); err != nil {
+		return nil, err
+	}
+	u.cachedUpstreamRepos = staged.cachedUpstreamRepos
+	return conflicts, nil
+}
+
+// printPkgDiff prints the unified diff between two packages in dir, like
+// `diff -ruN` but without depending on the diff command.
+func printPkgDiff(dir, pkg1, pkg2 string, pr printer.Printer) error {
+	return writePkgDiff(pr.OutStream(), dir, pkg1, pkg2)
+}
+
+func writePkgDiff(w io.Writer, dir, pkg1, pkg2 string) error {
+	files := sets.NewString()
+	for _, p := range []string{pkg1, pkg2} {
+		root := filepath.Join(dir, p)
+		err := filepath.Walk(root, func(fp string, info os.FileInfo, err error) error {
+			if err != nil || !info.Mode().IsRegular() {
+				return err
+			}
+			rel, err := filepath.Rel(root, fp)
+			if err != nil {
+				return err
+			}
+			files.Insert(filepath.ToSlash(rel))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, f := range files.List() {
+		// Missing files are diffed as empty files, like `diff -N`.
+		a, err := readFileIfExists(filepath.Join(dir, pkg1, filepath.FromSlash(f)))
+		if err != nil {
+			return err
+		}
+		b, err := readFileIfExists(filepath.Join(dir, pkg2, filepath.FromSlash(f)))
+		if err != nil {
+			return err
+		}
+		if a == b {
+			continue
+		}
+		err = difflib.WriteUnifiedDiff(w, difflib.UnifiedDiff{
+			A:        splitLines(a),
+			B:        splitLines(b),
+			FromFile: path.Join(filepath.ToSlash(pkg1), f),
+			ToFile:   path.Join(filepath.ToSlash(pkg2), f),
+			Context:  3,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readFileIfExists(p string) (string, error) {
+	b, err := ioutil.ReadFile(p)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	return string(b), err
+}
+
+// splitLines splits s into lines which all end with a newline, as expected
+// by difflib.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if last := len(lines) - 1; lines[last] == "" {
+		lines = lines[:last]
+	} else {
+		lines[last] += "\n"
+	}
+	return lines
+}
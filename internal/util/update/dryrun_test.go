@@ -0,0 +1,66 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package update
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWritePkgDiff(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"local/pkg/unchanged.yaml":   "a: 1\n",
+		"updated/pkg/unchanged.yaml": "a: 1\n",
+		"local/pkg/changed.yaml":     "a: 1\nb: 2\n",
+		"updated/pkg/changed.yaml":   "a: 1\nb: 3\n",
+		"local/pkg/deleted.yaml":     "c: 1\n",
+		"updated/pkg/sub/added.yaml": "d: 1",
+	}
+	for p, content := range files {
+		p = filepath.Join(dir, filepath.FromSlash(p))
+		if !assert.NoError(t, os.MkdirAll(filepath.Dir(p), 0700)) {
+			t.FailNow()
+		}
+		if !assert.NoError(t, ioutil.WriteFile(p, []byte(content), 0600)) {
+			t.FailNow()
+		}
+	}
+
+	var out bytes.Buffer
+	if !assert.NoError(t, writePkgDiff(&out, dir, "local/pkg", "updated/pkg")) {
+		t.FailNow()
+	}
+	assert.Equal(t, `--- local/pkg/changed.yaml
++++ updated/pkg/changed.yaml
+@@ -1,2 +1,2 @@
+ a: 1
+-b: 2
++b: 3
+--- local/pkg/deleted.yaml
++++ updated/pkg/deleted.yaml
+@@ -1 +0,0 @@
+-c: 1
+--- local/pkg/sub/added.yaml
++++ updated/pkg/sub/added.yaml
+@@ -0,0 +1 @@
++d: 1
+`, out.String())
+}
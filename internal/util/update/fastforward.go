@@ -59,17 +59,34 @@ func (u FastForwardUpdater) Update(options Options) error {
 
 func (u FastForwardUpdater) checkForLocalChanges(localPath, originalPath string) error {
 	const op errors.Op = "update.checkForLocalChanges"
-	found, err := pkgutil.Exists(originalPath)
+	changes, err := FindLocalChanges(localPath, originalPath)
 	if err != nil {
 		return errors.E(op, types.UniquePath(localPath), err)
 	}
+	if len(changes) > 0 {
+		return errors.E(op, types.UniquePath(localPath), fmt.Sprintf(
+			"local package files have been modified: %v.\n  use a different update --strategy.",
+			changes))
+	}
+	return nil
+}
+
+// FindLocalChanges returns the files in the package at localPath, and its
+// local subpackages, that differ from the original package at originalPath.
+// Added or deleted subpackages are listed as "<path> (Package)".
+func FindLocalChanges(localPath, originalPath string) ([]string, error) {
+	const op errors.Op = "update.FindLocalChanges"
+	found, err := pkgutil.Exists(originalPath)
+	if err != nil {
+		return nil, errors.E(op, types.UniquePath(localPath), err)
+	}
 	if !found {
-		return nil
+		return nil, nil
 	}
 
 	subPkgPaths, err := pkgutil.FindSubpackagesForPaths(pkg.Local, true, localPath, originalPath)
 	if err != nil {
-		return errors.E(op, types.UniquePath(localPath), err)
+		return nil, errors.E(op, types.UniquePath(localPath), err)
 	}
 	aggDiff := sets.String{}
 	for _, subPkgPath := range append([]string{"."}, subPkgPaths...) {
@@ -78,19 +95,19 @@ func (u FastForwardUpdater) checkForLocalChanges(localPath, originalPath string)
 
 		localExists, err := pkgutil.Exists(localSubPkgPath)
 		if err != nil {
-			return errors.E(op, types.UniquePath(localSubPkgPath), err)
+			return nil, errors.E(op, types.UniquePath(localSubPkgPath), err)
 		}
 		originalExists, err := pkgutil.Exists(originalSubPkgPath)
 		if err != nil {
-			return errors.E(op, types.UniquePath(localSubPkgPath), err)
+			return nil, errors.E(op, types.UniquePath(localSubPkgPath), err)
 		}
 		if !originalExists || !localExists {
-			aggDiff.Insert("%s (Package)", subPkgPath)
+			aggDiff.Insert(fmt.Sprintf("%s (Package)", subPkgPath))
 			continue
 		}
 		d, err := pkgdiff.PkgDiff(localSubPkgPath, originalSubPkgPath)
 		if err != nil {
-			return errors.E(op, types.UniquePath(localSubPkgPath), err)
+			return nil, errors.E(op, types.UniquePath(localSubPkgPath), err)
 		}
 		// If the original package didn't have a Kptfile, one was created
 		// in local, but we don't consider that a change unless the user
@@ -98,7 +115,7 @@ func (u FastForwardUpdater) checkForLocalChanges(localPath, originalPath string)
 		if d.Has(kptfilev1.KptFileName) && subPkgPath == "." {
 			hasDiff, err := hasKfDiff(localSubPkgPath, originalSubPkgPath)
 			if err != nil {
-				return errors.E(op, types.UniquePath(localSubPkgPath), err)
+				return nil, errors.E(op, types.UniquePath(localSubPkgPath), err)
 			}
 			if !hasDiff {
 				d = d.Difference(kptfileSet)
@@ -107,12 +124,7 @@ func (u FastForwardUpdater) checkForLocalChanges(localPath, originalPath string)
 
 		aggDiff.Insert(d.List()...)
 	}
-	if aggDiff.Len() > 0 {
-		return errors.E(op, types.UniquePath(localPath), fmt.Sprintf(
-			"local package files have been modified: %v.\n  use a different update --strategy.",
-			aggDiff.List()))
-	}
-	return nil
+	return aggDiff.List(), nil
 }
 
 func hasKfDiff(localPath, orgPath string) (bool, error) {
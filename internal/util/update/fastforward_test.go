@@ -187,3 +187,28 @@ func TestUpdate_FastForward(t *testing.T) {
 		})
 	}
 }
+
+func TestFindLocalChanges(t *testing.T) {
+	repos := testutil.EmptyReposInfo
+	origin := pkgbuilder.NewRootPkg().
+		WithResource(pkgbuilder.DeploymentResource).
+		WithSubPackages(
+			pkgbuilder.NewSubPkg("removed").
+				WithKptfile().
+				WithResource(pkgbuilder.ConfigMapResource),
+		).ExpandPkg(t, repos)
+	local := pkgbuilder.NewRootPkg().
+		WithResource(pkgbuilder.DeploymentResource,
+			pkgbuilder.SetFieldPath("5", "spec", "replicas")).
+		WithSubPackages(
+			pkgbuilder.NewSubPkg("added").
+				WithKptfile().
+				WithResource(pkgbuilder.ConfigMapResource),
+		).ExpandPkg(t, repos)
+
+	changes, err := FindLocalChanges(local, origin)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.ElementsMatch(t, []string{"added (Package)", "deployment.yaml", "removed (Package)"}, changes)
+}
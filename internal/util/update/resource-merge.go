@@ -37,10 +37,14 @@ import (
 
 // ResourceMergeUpdater updates a package by fetching the original and updated source
 // packages, and performing a 3-way merge of the Resources.
-type ResourceMergeUpdater struct{}
+type ResourceMergeUpdater struct {
+	// schemas are the additional schemas from the options of the update.
+	schemas *merge.Schemas
+}
 
 func (u ResourceMergeUpdater) Update(options Options) error {
 	const op errors.Op = "update.Update"
+	u.schemas = options.Schemas
 	if !options.IsRoot {
 		hasChanges, err := PkgHasUpdatedUpstream(options.LocalPath, options.OriginPath)
 		if err != nil {
@@ -159,6 +163,7 @@ func (u ResourceMergeUpdater) mergePackage(localPath, updatedPath, originalPath,
 		// TODO: Write a test to ensure this is set
 		MergeOnPath:        true,
 		IncludeSubPackages: false,
+		Schemas:            u.schemas,
 	}.Merge()
 	if err != nil {
 		return errors.E(op, types.UniquePath(localPath), err)
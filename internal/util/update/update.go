@@ -16,8 +16,10 @@
 package update
 
 import (
+	"bufio"
 	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path"
@@ -32,6 +34,7 @@ import (
 	"github.com/GoogleContainerTools/kpt/internal/util/addmergecomment"
 	"github.com/GoogleContainerTools/kpt/internal/util/fetch"
 	"github.com/GoogleContainerTools/kpt/internal/util/git"
+	"github.com/GoogleContainerTools/kpt/internal/util/merge"
 	"github.com/GoogleContainerTools/kpt/internal/util/pkgutil"
 	"github.com/GoogleContainerTools/kpt/internal/util/stack"
 	kptfilev1 "github.com/GoogleContainerTools/kpt/pkg/api/kptfile/v1"
@@ -80,6 +83,10 @@ type Options struct {
 	// updated and origin were fetched based on the information in the
 	// Kptfile from this package.
 	IsRoot bool
+
+	// Schemas contains additional schemas used by the resource-merge
+	// strategy to merge the lists of resources of types unknown to kyaml.
+	Schemas *merge.Schemas
 }
 
 // Updater updates a local package
@@ -104,6 +111,48 @@ type Command struct {
 	// Strategy is the update strategy to use
 	Strategy kptfilev1.UpdateStrategyType
 
+	// Schemas contains additional schemas used by the resource-merge
+	// strategy to merge the lists of resources of types unknown to kyaml,
+	// besides the schemas of the CRDs in the package.
+	Schemas *merge.Schemas
+
+	// DryRun performs the update on a staged copy of the package and prints
+	// the resulting changes and conflicts, without modifying the package.
+	DryRun bool
+
+	// VerificationPolicy is the policy the signature of the upstream tag or
+	// commit is verified against before the package is updated, if set.
+	VerificationPolicy *fetch.VerificationPolicy
+
+	// ConflictResolution defines how conflicts between local and upstream
+	// changes are handled with the resource-merge strategy. Defaults to
+	// UseUpstream.
+	ConflictResolution ConflictResolution
+
+	// Input is read for the answers to prompts with PromptOnConflict.
+	// Defaults to os.Stdin.
+	Input io.Reader
+
+	// Conflicts is set to the fields that were changed differently in the
+	// local package and in upstream.
+	Conflicts []Conflict
+
+	// conflicts collects the conflicts found while merging packages.
+	conflicts *[]Conflict
+
+	// input reads the answers to prompts if conflicts are resolved
+	// interactively.
+	input *bufio.Reader
+
+	// resolutions are the resolutions of conflicts chosen in
+	// ConflictsFileName with StopOnConflict, by conflict key.
+	resolutions map[string]string
+
+	// stagedFrom is the path of the local package when Pkg is a staged copy
+	// of it, so relative paths of directory and tarball upstreams are
+	// resolved against the local package.
+	stagedFrom string
+
 	// cachedUpstreamRepos is an upstream repo already fetched for a given repoSpec CloneRef
 	cachedUpstreamRepos map[string]*gitutil.GitUpstreamRepo
 }
@@ -117,18 +166,56 @@ func (u *Command) Run(ctx context.Context) error {
 		return errors.E(op, errors.MissingParam, "pkg must be provided")
 	}
 
+	if u.DryRun {
+		return u.dryRun(ctx)
+	}
+
+	switch u.ConflictResolution {
+	case "", UseUpstream:
+	case StopOnConflict:
+		if err := u.stopOnConflicts(ctx); err != nil {
+			return err
+		}
+	case PromptOnConflict:
+		in := u.Input
+		if in == nil {
+			in = os.Stdin
+		}
+		u.input = bufio.NewReader(in)
+		defer func() { u.input = nil }()
+	default:
+		return errors.E(op, errors.InvalidParam,
+			fmt.Errorf("unknown conflict resolution %q, must be one of: %s",
+				u.ConflictResolution, strings.Join(ConflictResolutionsAsStrings(), ",")))
+	}
+
+	// Staged updates collect the conflicts for the command that started
+	// them, which reports them.
+	reportConflicts := u.conflicts == nil
+	if reportConflicts {
+		u.Conflicts = nil
+		u.conflicts = &u.Conflicts
+		defer func() { u.conflicts = nil }()
+	}
+
 	rootKf, err := u.Pkg.Kptfile()
 	if err != nil {
 		return errors.E(op, u.Pkg.UniquePath, err)
 	}
 
-	if rootKf.Upstream == nil || rootKf.Upstream.Git == nil {
+	if !hasUpstream(rootKf) {
 		return errors.E(op, u.Pkg.UniquePath,
 			fmt.Errorf("package must have an upstream reference"))
 	}
-	originalRootKfRef := rootKf.Upstream.Git.Ref
-	if u.Ref != "" {
-		rootKf.Upstream.Git.Ref = u.Ref
+	var originalRootKfRef string
+	if rootKf.Upstream.Git != nil {
+		originalRootKfRef = rootKf.Upstream.Git.Ref
+		if u.Ref != "" {
+			rootKf.Upstream.Git.Ref = u.Ref
+		}
+	} else if u.Ref != "" {
+		return errors.E(op, u.Pkg.UniquePath, errors.InvalidParam,
+			fmt.Errorf("a version can only be given for packages with a git upstream"))
 	}
 	if u.Strategy != "" {
 		rootKf.Upstream.UpdateStrategy = u.Strategy
@@ -165,7 +252,7 @@ func (u *Command) Run(ctx context.Context) error {
 				return errors.E(op, p.UniquePath, err)
 			}
 
-			if subKf.Upstream != nil && subKf.Upstream.Git != nil {
+			if hasUpstream(subKf) {
 				// update subpackage kf ref/strategy if current pkg is a subpkg of root pkg or is root pkg
 				// and if original root pkg ref matches the subpkg ref
 				if shouldUpdateSubPkgRef(subKf, rootKf, originalRootKfRef) {
@@ -180,11 +267,22 @@ func (u *Command) Run(ctx context.Context) error {
 		}
 	}
 	pr.Printf("\nUpdated %d package(s).\n", packageCount)
+	if reportConflicts && len(u.Conflicts) > 0 {
+		printConflicts(pr, u.Conflicts)
+	}
 
 	// finally, make sure that the merge comments are added to all resources in the updated package
 	if err := addmergecomment.Process(string(u.Pkg.UniquePath)); err != nil {
 		return errors.E(op, u.Pkg.UniquePath, err)
 	}
+
+	// the conflicts written by a previous update were resolved.
+	if u.ConflictResolution == StopOnConflict {
+		err := os.Remove(filepath.Join(u.Pkg.UniquePath.String(), ConflictsFileName))
+		if err != nil && !os.IsNotExist(err) {
+			return errors.E(op, errors.IO, u.Pkg.UniquePath, err)
+		}
+	}
 	return nil
 }
 
@@ -193,6 +291,13 @@ func (u Command) GetCachedUpstreamRepos() map[string]*gitutil.GitUpstreamRepo {
 	return u.cachedUpstreamRepos
 }
 
+// hasUpstream returns true if the package has a git, directory or tarball
+// upstream.
+func hasUpstream(kf *kptfilev1.KptFile) bool {
+	return kf.Upstream != nil &&
+		(kf.Upstream.Git != nil || kf.Upstream.Dir != nil || kf.Upstream.Tarball != nil)
+}
+
 // updateSubKf updates subpackage with given ref and update strategy
 func updateSubKf(subKf *kptfilev1.KptFile, ref string, strategy kptfilev1.UpdateStrategyType) {
 	// check if explicit ref provided
@@ -207,6 +312,9 @@ func updateSubKf(subKf *kptfilev1.KptFile, ref string, strategy kptfilev1.Update
 // shouldUpdateSubPkgRef checks if subpkg ref should be updated.
 // This is true if pkg has the same upstream repo, upstream directory is within or equal to root pkg directory and original root pkg ref matches the subpkg ref.
 func shouldUpdateSubPkgRef(subKf, rootKf *kptfilev1.KptFile, originalRootKfRef string) bool {
+	if subKf.Upstream.Git == nil || rootKf.Upstream.Git == nil {
+		return false
+	}
 	return subKf.Upstream.Git.Repo == rootKf.Upstream.Git.Repo &&
 		subKf.Upstream.Git.Ref == originalRootKfRef &&
 		strings.HasPrefix(path.Clean(subKf.Upstream.Git.Directory), path.Clean(rootKf.Upstream.Git.Directory))
@@ -258,11 +366,17 @@ func (u Command) updateRootPackage(ctx context.Context, p *pkg.Pkg) error {
 	pr := printer.FromContextOrDie(ctx)
 	pr.PrintPackage(p, !(p == u.Pkg))
 
+	switch kf.Upstream.Type {
+	case kptfilev1.DirOrigin, kptfilev1.TarballOrigin:
+		return u.updateFromSnapshot(ctx, p, kf)
+	}
+
 	g := kf.Upstream.Git
 	updated := &git.RepoSpec{OrgRepo: g.Repo, Path: g.Directory, Ref: g.Ref}
 	pr.Printf("Fetching upstream from %s@%s\n", kf.Upstream.Git.Repo, kf.Upstream.Git.Ref)
-	cloner := fetch.NewCloner(updated, fetch.WithCachedRepo(u.cachedUpstreamRepos))
-	if err := cloner.ClonerUsingGitExec(ctx); err != nil {
+	cloner := fetch.NewCloner(updated, fetch.WithCachedRepo(u.cachedUpstreamRepos),
+		fetch.WithVerificationPolicy(u.VerificationPolicy))
+	if err := cloner.Clone(ctx); err != nil {
 		return errors.E(op, p.UniquePath, err)
 	}
 	defer os.RemoveAll(updated.AbsPath())
@@ -272,7 +386,7 @@ func (u Command) updateRootPackage(ctx context.Context, p *pkg.Pkg) error {
 		gLock := kf.UpstreamLock.Git
 		originRepoSpec := &git.RepoSpec{OrgRepo: gLock.Repo, Path: gLock.Directory, Ref: gLock.Commit}
 		pr.Printf("Fetching origin from %s@%s\n", kf.Upstream.Git.Repo, kf.Upstream.Git.Ref)
-		if err := fetch.NewCloner(originRepoSpec, fetch.WithCachedRepo(u.cachedUpstreamRepos)).ClonerUsingGitExec(ctx); err != nil {
+		if err := fetch.NewCloner(originRepoSpec, fetch.WithCachedRepo(u.cachedUpstreamRepos)).Clone(ctx); err != nil {
 			return errors.E(op, p.UniquePath, err)
 		}
 		origin = originRepoSpec
@@ -284,14 +398,83 @@ func (u Command) updateRootPackage(ctx context.Context, p *pkg.Pkg) error {
 	}
 	defer os.RemoveAll(origin.AbsPath())
 
+	if err := u.updatePackages(ctx, p, updated.AbsPath(), origin.AbsPath()); err != nil {
+		return errors.E(op, p.UniquePath, err)
+	}
+
+	if err := kptfileutil.UpdateUpstreamLockFromGit(p.UniquePath.String(), updated); err != nil {
+		return errors.E(op, p.UniquePath, err)
+	}
+	return nil
+}
+
+// updateFromSnapshot updates a local package from a directory or tarball
+// upstream. The snapshot of the package with the content hash in the
+// upstream lock is the origin.
+func (u Command) updateFromSnapshot(ctx context.Context, p *pkg.Pkg, kf *kptfilev1.KptFile) error {
+	const op errors.Op = "update.updateFromSnapshot"
+	if err := fetch.VerifySnapshotUpstream(u.VerificationPolicy); err != nil {
+		return errors.E(op, p.UniquePath, err)
+	}
+	pr := printer.FromContextOrDie(ctx)
+	sourceDir := u.sourceDir(p)
+
+	pr.Printf("Fetching upstream from %s\n", fetch.UpstreamSource(kf.Upstream))
+	updated, err := fetch.TakeSnapshot(ctx, sourceDir, kf.Upstream)
+	if err != nil {
+		return errors.E(op, p.UniquePath, err)
+	}
+	defer os.RemoveAll(updated.AbsPath())
+
+	var origin repoClone
+	if kf.UpstreamLock != nil {
+		pr.Printf("Fetching origin from %s\n", fetch.UpstreamSource(kf.Upstream))
+		origin, err = fetch.OriginSnapshot(ctx, sourceDir, kf.UpstreamLock)
+	} else {
+		origin, err = newNilRepoClone()
+	}
+	if err != nil {
+		return errors.E(op, p.UniquePath, err)
+	}
+	defer os.RemoveAll(origin.AbsPath())
+
+	if err := u.updatePackages(ctx, p, updated.AbsPath(), origin.AbsPath()); err != nil {
+		return errors.E(op, p.UniquePath, err)
+	}
+
+	if err := kptfileutil.UpdateUpstreamLock(p.UniquePath.String(), updated.Lock); err != nil {
+		return errors.E(op, p.UniquePath, err)
+	}
+	return nil
+}
+
+// sourceDir returns the directory that relative paths of directory and
+// tarball upstreams of the package are resolved against. For staged updates
+// this is where the package is in the local package rather than the staging
+// directory.
+func (u Command) sourceDir(p *pkg.Pkg) string {
+	if u.stagedFrom == "" {
+		return p.UniquePath.String()
+	}
+	rel, err := filepath.Rel(u.Pkg.UniquePath.String(), p.UniquePath.String())
+	if err != nil {
+		return p.UniquePath.String()
+	}
+	return filepath.Join(u.stagedFrom, rel)
+}
+
+// updatePackages updates the package p and its subpackages from the
+// packages in updatedDir and originDir.
+func (u Command) updatePackages(ctx context.Context, p *pkg.Pkg, updatedDir, originDir string) error {
+	const op errors.Op = "update.updatePackages"
 	s := stack.New()
 	s.Push(".")
 
 	for s.Len() > 0 {
 		relPath := s.Pop()
 		localPath := filepath.Join(p.UniquePath.String(), relPath)
-		updatedPath := filepath.Join(updated.AbsPath(), relPath)
-		originPath := filepath.Join(origin.AbsPath(), relPath)
+		updatedPath := filepath.Join(updatedDir, relPath)
+		originPath := filepath.Join(originDir, relPath)
 
 		isRoot := false
 		if relPath == "." {
@@ -311,10 +494,6 @@ func (u Command) updateRootPackage(ctx context.Context, p *pkg.Pkg) error {
 			s.Push(filepath.Join(relPath, path))
 		}
 	}
-
-	if err := kptfileutil.UpdateUpstreamLockFromGit(p.UniquePath.String(), updated); err != nil {
-		return errors.E(op, p.UniquePath, err)
-	}
 	return nil
 }
 
@@ -463,6 +642,11 @@ func (u Command) mergePackage(ctx context.Context, localPath, updatedPath, origi
 		return errors.E(op, types.UniquePath(localPath),
 			fmt.Errorf("unrecognized update strategy %s", u.Strategy))
 	}
+	if pkgKf.Upstream.UpdateStrategy == kptfilev1.ResourceMerge {
+		if err := u.collectConflicts(ctx, localPath, updatedPath, originPath); err != nil {
+			return errors.E(op, types.UniquePath(localPath), err)
+		}
+	}
 	pr.Printf("Updating package %q with strategy %q.\n", packageName(localPath), pkgKf.Upstream.UpdateStrategy)
 	if err := updater().Update(Options{
 		RelPackagePath: relPath,
@@ -470,6 +654,7 @@ func (u Command) mergePackage(ctx context.Context, localPath, updatedPath, origi
 		UpdatedPath:    updatedPath,
 		OriginPath:     originPath,
 		IsRoot:         isRootPkg,
+		Schemas:        u.Schemas,
 	}); err != nil {
 		return errors.E(op, types.UniquePath(localPath), err)
 	}
@@ -21,13 +21,16 @@ import (
 	"path"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/GoogleContainerTools/kpt/internal/gitutil"
 	"github.com/GoogleContainerTools/kpt/internal/pkg"
 	pkgtest "github.com/GoogleContainerTools/kpt/internal/pkg/testing"
 	"github.com/GoogleContainerTools/kpt/internal/printer/fake"
 	"github.com/GoogleContainerTools/kpt/internal/testutil"
 	"github.com/GoogleContainerTools/kpt/internal/testutil/pkgbuilder"
+	"github.com/GoogleContainerTools/kpt/internal/util/fetch"
 	. "github.com/GoogleContainerTools/kpt/internal/util/update"
 	kptfilev1 "github.com/GoogleContainerTools/kpt/pkg/api/kptfile/v1"
 	"github.com/GoogleContainerTools/kpt/pkg/kptfile/kptfileutil"
@@ -36,6 +39,7 @@ import (
 	"sigs.k8s.io/kustomize/kyaml/filesys"
 	"sigs.k8s.io/kustomize/kyaml/kio"
 	"sigs.k8s.io/kustomize/kyaml/kio/filters"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
 )
 
 const (
@@ -414,6 +418,286 @@ func TestCommand_Run_localPackageChanges(t *testing.T) {
 	}
 }
 
+// TestCommand_Run_dryRun verifies that a dry run reports the conflicts
+// between local and upstream changes without modifying the local package.
+func TestCommand_Run_dryRun(t *testing.T) {
+	g := &testutil.TestSetupManager{
+		T: t,
+		ReposChanges: map[string][]testutil.Content{
+			testutil.Upstream: {
+				{
+					Pkg: pkgbuilder.NewRootPkg().
+						WithResource(pkgbuilder.DeploymentResource),
+					Branch: masterBranch,
+				},
+				{
+					Pkg: pkgbuilder.NewRootPkg().
+						WithResource(pkgbuilder.DeploymentResource,
+							pkgbuilder.SetFieldPath("42", "spec", "replicas")),
+				},
+			},
+		},
+	}
+	defer g.Clean()
+	localPkg := pkgbuilder.NewRootPkg().
+		WithResource(pkgbuilder.DeploymentResource,
+			pkgbuilder.SetFieldPath("21", "spec", "replicas"))
+	g.LocalChanges = []testutil.Content{{Pkg: localPkg}}
+	if !g.Init() {
+		t.FailNow()
+	}
+	// copy the local package to verify that it is not modified.
+	localCopy := t.TempDir()
+	if !assert.NoError(t, copyutil.CopyDir(g.LocalWorkspace.FullPackagePath(), localCopy)) {
+		t.FailNow()
+	}
+
+	cmd := &Command{
+		Pkg:      pkgtest.CreatePkgOrFail(t, g.LocalWorkspace.FullPackagePath()),
+		Ref:      masterBranch,
+		Strategy: kptfilev1.ResourceMerge,
+		DryRun:   true,
+	}
+	if !assert.NoError(t, cmd.Run(fake.CtxWithDefaultPrinter())) {
+		t.FailNow()
+	}
+
+	if assert.Len(t, cmd.Conflicts, 1) {
+		c := cmd.Conflicts[0]
+		assert.Equal(t, "spec.replicas", c.Path)
+		assert.Equal(t, "Deployment", c.Kind)
+		assert.Equal(t, 21, c.Local)
+		assert.Equal(t, 42, c.Upstream)
+	}
+
+	// the local package must not be modified.
+	diff, err := copyutil.Diff(localCopy, g.LocalWorkspace.FullPackagePath())
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Empty(t, diff.List())
+}
+
+func TestCommand_Run_conflictResolution(t *testing.T) {
+	testCases := map[string]struct {
+		resolution       ConflictResolution
+		input            string
+		expectedErr      bool
+		expectedReplicas string
+		expectedResolved string
+	}{
+		"upstream": {
+			resolution:       UseUpstream,
+			expectedReplicas: "42",
+			expectedResolved: ResolvedUpstream,
+		},
+		"stop": {
+			resolution:       StopOnConflict,
+			expectedErr:      true,
+			expectedReplicas: "21",
+		},
+		"prompt local": {
+			resolution:       PromptOnConflict,
+			input:            "x\nl\n",
+			expectedReplicas: "21",
+			expectedResolved: ResolvedLocal,
+		},
+		"prompt upstream": {
+			resolution:       PromptOnConflict,
+			input:            "\n",
+			expectedReplicas: "42",
+			expectedResolved: ResolvedUpstream,
+		},
+	}
+
+	for tn, tc := range testCases {
+		tc := tc
+		t.Run(tn, func(t *testing.T) {
+			g := &testutil.TestSetupManager{
+				T: t,
+				ReposChanges: map[string][]testutil.Content{
+					testutil.Upstream: {
+						{
+							Pkg: pkgbuilder.NewRootPkg().
+								WithResource(pkgbuilder.DeploymentResource),
+							Branch: masterBranch,
+						},
+						{
+							Pkg: pkgbuilder.NewRootPkg().
+								WithResource(pkgbuilder.DeploymentResource,
+									pkgbuilder.SetFieldPath("42", "spec", "replicas")),
+						},
+					},
+				},
+			}
+			defer g.Clean()
+			localPkg := pkgbuilder.NewRootPkg().
+				WithResource(pkgbuilder.DeploymentResource,
+					pkgbuilder.SetFieldPath("21", "spec", "replicas"))
+			g.LocalChanges = []testutil.Content{{Pkg: localPkg}}
+			if !g.Init() {
+				t.FailNow()
+			}
+			pkgPath := g.LocalWorkspace.FullPackagePath()
+
+			cmd := &Command{
+				Pkg:                pkgtest.CreatePkgOrFail(t, pkgPath),
+				Ref:                masterBranch,
+				Strategy:           kptfilev1.ResourceMerge,
+				ConflictResolution: tc.resolution,
+				Input:              strings.NewReader(tc.input),
+			}
+			err := cmd.Run(fake.CtxWithDefaultPrinter())
+			if tc.expectedErr {
+				assert.Error(t, err)
+			} else if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+
+			if assert.Len(t, cmd.Conflicts, 1) {
+				assert.Equal(t, "spec.replicas", cmd.Conflicts[0].Path)
+				assert.Equal(t, tc.expectedResolved, cmd.Conflicts[0].Resolution)
+			}
+
+			node, err := yaml.ReadFile(filepath.Join(pkgPath, "deployment.yaml"))
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			replicas, err := node.Pipe(yaml.Lookup("spec", "replicas"))
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, tc.expectedReplicas, replicas.YNode().Value)
+
+			markers, err := ioutil.ReadFile(filepath.Join(pkgPath, ConflictsFileName))
+			if tc.resolution != StopOnConflict {
+				assert.True(t, os.IsNotExist(err))
+				return
+			}
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Contains(t, string(markers), `<<<<<<< local
+21
+||||||| original
+3
+=======
+42
+>>>>>>> upstream
+`)
+		})
+	}
+}
+
+// TestCommand_Run_resolvedConflictsFile verifies that the conflicts resolved
+// in the conflicts file written by an update stopped because of conflicts
+// are resolved as chosen when the update is run again.
+func TestCommand_Run_resolvedConflictsFile(t *testing.T) {
+	testCases := map[string]struct {
+		resolution       string
+		expectedErr      bool
+		expectedReplicas string
+	}{
+		"unresolved": {
+			resolution:       "unresolved",
+			expectedErr:      true,
+			expectedReplicas: "21",
+		},
+		"local": {
+			resolution:       ResolvedLocal,
+			expectedReplicas: "21",
+		},
+		"upstream": {
+			resolution:       ResolvedUpstream,
+			expectedReplicas: "42",
+		},
+	}
+
+	for tn, tc := range testCases {
+		tc := tc
+		t.Run(tn, func(t *testing.T) {
+			g := &testutil.TestSetupManager{
+				T: t,
+				ReposChanges: map[string][]testutil.Content{
+					testutil.Upstream: {
+						{
+							Pkg: pkgbuilder.NewRootPkg().
+								WithResource(pkgbuilder.DeploymentResource),
+							Branch: masterBranch,
+						},
+						{
+							Pkg: pkgbuilder.NewRootPkg().
+								WithResource(pkgbuilder.DeploymentResource,
+									pkgbuilder.SetFieldPath("42", "spec", "replicas")),
+						},
+					},
+				},
+			}
+			defer g.Clean()
+			localPkg := pkgbuilder.NewRootPkg().
+				WithResource(pkgbuilder.DeploymentResource,
+					pkgbuilder.SetFieldPath("21", "spec", "replicas"))
+			g.LocalChanges = []testutil.Content{{Pkg: localPkg}}
+			if !g.Init() {
+				t.FailNow()
+			}
+			pkgPath := g.LocalWorkspace.FullPackagePath()
+			conflictsPath := filepath.Join(pkgPath, ConflictsFileName)
+
+			newCommand := func() *Command {
+				return &Command{
+					Pkg:                pkgtest.CreatePkgOrFail(t, pkgPath),
+					Ref:                masterBranch,
+					Strategy:           kptfilev1.ResourceMerge,
+					ConflictResolution: StopOnConflict,
+				}
+			}
+			if !assert.Error(t, newCommand().Run(fake.CtxWithDefaultPrinter())) {
+				t.FailNow()
+			}
+			markers, err := ioutil.ReadFile(conflictsPath)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			if !assert.Contains(t, string(markers), "spec.replicas\nresolution: unresolved\n") {
+				t.FailNow()
+			}
+			resolved := strings.Replace(string(markers), "resolution: unresolved", "resolution: "+tc.resolution, 1)
+			if !assert.NoError(t, ioutil.WriteFile(conflictsPath, []byte(resolved), 0600)) {
+				t.FailNow()
+			}
+
+			cmd := newCommand()
+			err = cmd.Run(fake.CtxWithDefaultPrinter())
+			if tc.expectedErr {
+				assert.Error(t, err)
+			} else if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+
+			node, err := yaml.ReadFile(filepath.Join(pkgPath, "deployment.yaml"))
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			replicas, err := node.Pipe(yaml.Lookup("spec", "replicas"))
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, tc.expectedReplicas, replicas.YNode().Value)
+
+			_, err = os.Stat(conflictsPath)
+			if tc.expectedErr {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, os.IsNotExist(err))
+			if assert.Len(t, cmd.Conflicts, 1) {
+				assert.Equal(t, tc.resolution, cmd.Conflicts[0].Resolution)
+			}
+		})
+	}
+}
+
 // TestCommand_Run_toBranchRef verifies the package contents are set to the contents of the branch
 // it was updated to.
 func TestCommand_Run_toBranchRef(t *testing.T) {
@@ -964,6 +1248,87 @@ func TestCommand_Run_noUpstreamReference(t *testing.T) {
 	assert.Contains(t, err.Error(), "must have an upstream reference")
 }
 
+// TestCommand_Run_dirUpstream updates a package from a local directory,
+// merging the upstream changes with the local changes.
+func TestCommand_Run_dirUpstream(t *testing.T) {
+	t.Setenv(gitutil.RepoCacheDirEnv, t.TempDir())
+	dir := t.TempDir()
+	upstreamCm := filepath.Join(dir, "blueprint", "cm.yaml")
+	localPath := filepath.Join(dir, "local")
+	writeFile := func(path, content string) {
+		if !assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0700)) ||
+			!assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0600)) {
+			t.FailNow()
+		}
+	}
+	writeFile(upstreamCm, "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cm\ndata:\n  a: \"1\"\n  b: \"1\"\n")
+
+	kf := kptfileutil.DefaultKptfile("local")
+	kf.Upstream = &kptfilev1.Upstream{
+		Type:           kptfilev1.DirOrigin,
+		Dir:            &kptfilev1.Dir{Path: "../blueprint"},
+		UpdateStrategy: kptfilev1.ResourceMerge,
+	}
+	if !assert.NoError(t, os.MkdirAll(localPath, 0700)) ||
+		!assert.NoError(t, kptfileutil.WriteFile(localPath, kf)) {
+		t.FailNow()
+	}
+	err := fetch.Command{
+		Pkg: pkgtest.CreatePkgOrFail(t, localPath),
+	}.Run(fake.CtxWithDefaultPrinter())
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	originKf, err := pkg.ReadKptfile(filesys.FileSystemOrOnDisk{}, localPath)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	writeFile(filepath.Join(localPath, "cm.yaml"), "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cm\ndata:\n  a: \"local\"\n  b: \"1\"\n")
+	writeFile(upstreamCm, "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cm\ndata:\n  a: \"1\"\n  b: \"2\"\n")
+
+	err = (&Command{
+		Pkg: pkgtest.CreatePkgOrFail(t, localPath),
+	}).Run(fake.CtxWithDefaultPrinter())
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(localPath, "cm.yaml"))
+	if assert.NoError(t, err) {
+		assert.Contains(t, string(b), `a: "local"`)
+		assert.Contains(t, string(b), `b: "2"`)
+	}
+	updatedKf, err := pkg.ReadKptfile(filesys.FileSystemOrOnDisk{}, localPath)
+	if assert.NoError(t, err) {
+		assert.NotEqual(t, originKf.UpstreamLock.Dir.Hash, updatedKf.UpstreamLock.Dir.Hash)
+	}
+
+	// Only packages with a git upstream can be updated to a version.
+	err = (&Command{
+		Pkg: pkgtest.CreatePkgOrFail(t, localPath),
+		Ref: "v2",
+	}).Run(fake.CtxWithDefaultPrinter())
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "a version can only be given for packages with a git upstream")
+	}
+
+	// Packages from a directory upstream can't be signed, so they are rejected
+	// when a verification policy is set.
+	writeFile(upstreamCm, "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cm\ndata:\n  a: \"1\"\n  b: \"3\"\n")
+	err = (&Command{
+		Pkg:                pkgtest.CreatePkgOrFail(t, localPath),
+		VerificationPolicy: &fetch.VerificationPolicy{},
+	}).Run(fake.CtxWithDefaultPrinter())
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "signature verification is not supported for dir/tarball upstreams")
+	}
+	b, err = ioutil.ReadFile(filepath.Join(localPath, "cm.yaml"))
+	if assert.NoError(t, err) {
+		assert.Contains(t, string(b), `b: "2"`)
+	}
+}
+
 // TestCommand_Run_failInvalidPath verifies Run fails if the path is invalid
 func TestCommand_Run_failInvalidPath(t *testing.T) {
 	for i := range kptfilev1.UpdateStrategies {
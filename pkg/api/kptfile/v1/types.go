@@ -57,6 +57,9 @@ type KptFile struct {
 
 	// Inventory contains parameters for the inventory object used in apply.
 	Inventory *Inventory `yaml:"inventory,omitempty" json:"inventory,omitempty"`
+
+	// Status records the conditions reported on the package.
+	Status *Status `yaml:"status,omitempty" json:"status,omitempty"`
 }
 
 // OriginType defines the type of origin for a package.
@@ -65,6 +68,14 @@ type OriginType string
 const (
 	// GitOrigin specifies a package as having been cloned from a git repository.
 	GitOrigin OriginType = "git"
+
+	// DirOrigin specifies a package as having been copied from a local
+	// directory.
+	DirOrigin OriginType = "dir"
+
+	// TarballOrigin specifies a package as having been extracted from a
+	// tarball.
+	TarballOrigin OriginType = "tarball"
 )
 
 // UpdateStrategyType defines the strategy for updating a package from upstream.
@@ -121,6 +132,12 @@ type Upstream struct {
 	// Git is the locator for a package stored on Git.
 	Git *Git `yaml:"git,omitempty" json:"git,omitempty"`
 
+	// Dir is the locator for a package in a local directory.
+	Dir *Dir `yaml:"dir,omitempty" json:"dir,omitempty"`
+
+	// Tarball is the locator for a package in a tarball.
+	Tarball *Tarball `yaml:"tarball,omitempty" json:"tarball,omitempty"`
+
 	// UpdateStrategy declares how a package will be updated from upstream.
 	UpdateStrategy UpdateStrategyType `yaml:"updateStrategy,omitempty" json:"updateStrategy,omitempty"`
 }
@@ -135,10 +152,28 @@ type Git struct {
 	// e.g. 'staging/cockroachdb'
 	Directory string `yaml:"directory,omitempty" json:"directory,omitempty"`
 
-	// Ref can be a Git branch, tag, or a commit SHA-1.
+	// Ref can be a Git branch, tag, or a commit SHA-1. It can also be a
+	// semantic version range, e.g. '~1.4', which resolves to the highest
+	// matching tag.
 	Ref string `yaml:"ref,omitempty" json:"ref,omitempty"`
 }
 
+// Dir is the user-specified locator for a package in a local directory.
+type Dir struct {
+	// Path is the directory with the package. A relative path is relative
+	// to the directory of the package.
+	// e.g. '../blueprints/cockroachdb'
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
+}
+
+// Tarball is the user-specified locator for a package in a tarball.
+type Tarball struct {
+	// URL is the http(s) URL or the path of a .tar, .tar.gz or .tgz file with
+	// the package. A relative path is relative to the directory of the package.
+	// e.g. 'https://example.com/packages/cockroachdb-v1.tgz'
+	URL string `yaml:"url,omitempty" json:"url,omitempty"`
+}
+
 // UpstreamLock is a resolved locator for the last fetch of the package.
 type UpstreamLock struct {
 	// Type is the type of origin.
@@ -146,6 +181,12 @@ type UpstreamLock struct {
 
 	// Git is the resolved locator for a package on Git.
 	Git *GitLock `yaml:"git,omitempty" json:"git,omitempty"`
+
+	// Dir is the resolved locator for a package in a local directory.
+	Dir *DirLock `yaml:"dir,omitempty" json:"dir,omitempty"`
+
+	// Tarball is the resolved locator for a package in a tarball.
+	Tarball *TarballLock `yaml:"tarball,omitempty" json:"tarball,omitempty"`
 }
 
 // GitLock is the resolved locator for a package on Git.
@@ -159,12 +200,57 @@ type GitLock struct {
 	Directory string `yaml:"directory,omitempty" json:"directory,omitempty"`
 
 	// Ref can be a Git branch, tag, or a commit SHA-1 that was fetched.
+	// If the upstream ref is a semantic version range, this is the tag it
+	// was resolved to.
 	// e.g. 'master'
 	Ref string `yaml:"ref,omitempty" json:"ref,omitempty"`
 
 	// Commit is the SHA-1 for the last fetch of the package.
 	// This is set by kpt for bookkeeping purposes.
 	Commit string `yaml:"commit,omitempty" json:"commit,omitempty"`
+
+	// Verification is the signature that was verified when the package was
+	// fetched with an upstream verification policy.
+	Verification *GitVerification `yaml:"verification,omitempty" json:"verification,omitempty"`
+}
+
+// DirLock is the resolved locator for a package in a local directory.
+type DirLock struct {
+	// Path is the directory that was copied.
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
+
+	// Hash is the hash of the content of the package that was copied, e.g.
+	// 'sha256:2c26b46b...'. It identifies the snapshot of the package in the
+	// kpt cache that is used as the origin when the package is updated.
+	Hash string `yaml:"hash,omitempty" json:"hash,omitempty"`
+}
+
+// TarballLock is the resolved locator for a package in a tarball.
+type TarballLock struct {
+	// URL is the tarball that was extracted.
+	URL string `yaml:"url,omitempty" json:"url,omitempty"`
+
+	// Hash is the hash of the content of the package that was extracted, e.g.
+	// 'sha256:2c26b46b...'. It identifies the snapshot of the package in the
+	// kpt cache that is used as the origin when the package is updated.
+	Hash string `yaml:"hash,omitempty" json:"hash,omitempty"`
+}
+
+// GitVerification records the signature of the upstream tag or commit that
+// was verified against a trusted key.
+type GitVerification struct {
+	// Object is the git object with the verified signature, either 'tag' or
+	// 'commit'.
+	Object string `yaml:"object,omitempty" json:"object,omitempty"`
+
+	// Key is the name of the trusted key in the verification policy.
+	Key string `yaml:"key,omitempty" json:"key,omitempty"`
+
+	// Fingerprint is the fingerprint of the key that made the signature.
+	Fingerprint string `yaml:"fingerprint,omitempty" json:"fingerprint,omitempty"`
+
+	// Signer is the identity of the signer, i.e. the tagger or committer.
+	Signer string `yaml:"signer,omitempty" json:"signer,omitempty"`
 }
 
 // PackageInfo contains optional information about the package such as license, documentation, etc.
@@ -192,8 +278,42 @@ type PackageInfo struct {
 
 	// Man is the path to documentation about the package
 	Man string `yaml:"man,omitempty" json:"man,omitempty"`
+
+	// ReadinessGates lists the conditions that must be true before the
+	// package can be published.
+	ReadinessGates []ReadinessGate `yaml:"readinessGates,omitempty" json:"readinessGates,omitempty"`
+}
+
+// ReadinessGate names a condition that must be true for the package to be ready.
+type ReadinessGate struct {
+	ConditionType string `yaml:"conditionType" json:"conditionType"`
+}
+
+// Status contains the conditions reported on the package.
+type Status struct {
+	Conditions []Condition `yaml:"conditions,omitempty" json:"conditions,omitempty"`
 }
 
+// Condition is an observation about the package, typically set by a
+// controller or validator.
+type Condition struct {
+	Type string `yaml:"type" json:"type"`
+
+	Status ConditionStatus `yaml:"status" json:"status"`
+
+	Reason string `yaml:"reason,omitempty" json:"reason,omitempty"`
+
+	Message string `yaml:"message,omitempty" json:"message,omitempty"`
+}
+
+type ConditionStatus string
+
+const (
+	ConditionTrue    ConditionStatus = "True"
+	ConditionFalse   ConditionStatus = "False"
+	ConditionUnknown ConditionStatus = "Unknown"
+)
+
 // Subpackages declares a local or remote subpackage.
 type Subpackage struct {
 	// Name of the immediate subdirectory relative to this Kptfile where the suppackage
@@ -17,6 +17,7 @@ package fn
 import (
 	"context"
 
+	fnresult "github.com/GoogleContainerTools/kpt/pkg/api/fnresult/v1"
 	"sigs.k8s.io/kustomize/kyaml/filesys"
 )
 
@@ -26,5 +27,7 @@ type RenderOptions struct {
 }
 
 type Renderer interface {
-	Render(ctx context.Context, pkg filesys.FileSystem, opts RenderOptions) error
+	// Render renders the package and returns the results of the functions
+	// of the pipeline. The results are returned even if the render fails.
+	Render(ctx context.Context, pkg filesys.FileSystem, opts RenderOptions) (*fnresult.ResultList, error)
 }
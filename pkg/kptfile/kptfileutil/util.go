@@ -212,10 +212,11 @@ func UpdateUpstreamLockFromGit(path string, spec *git.RepoSpec) error {
 	kpgfile.UpstreamLock = &kptfilev1.UpstreamLock{
 		Type: kptfilev1.GitOrigin,
 		Git: &kptfilev1.GitLock{
-			Repo:      spec.OrgRepo,
-			Directory: spec.Path,
-			Ref:       spec.Ref,
-			Commit:    spec.Commit,
+			Repo:         spec.OrgRepo,
+			Directory:    spec.Path,
+			Ref:          spec.Ref,
+			Commit:       spec.Commit,
+			Verification: spec.Verification,
 		},
 	}
 	err = WriteFile(path, kpgfile)
@@ -225,6 +226,21 @@ func UpdateUpstreamLockFromGit(path string, spec *git.RepoSpec) error {
 	return nil
 }
 
+// UpdateUpstreamLock sets the upstreamLock of the package specified by path
+// to lock.
+func UpdateUpstreamLock(path string, lock *kptfilev1.UpstreamLock) error {
+	const op errors.Op = "kptfileutil.UpdateUpstreamLock"
+	kf, err := pkg.ReadKptfile(filesys.FileSystemOrOnDisk{}, path)
+	if err != nil {
+		return errors.E(op, types.UniquePath(path), err)
+	}
+	kf.UpstreamLock = lock
+	if err := WriteFile(path, kf); err != nil {
+		return errors.E(op, types.UniquePath(path), err)
+	}
+	return nil
+}
+
 // merge merges the Kptfiles from various sources and updates localKf with output
 // please refer to https://github.com/GoogleContainerTools/kpt/blob/main/docs/design-docs/03-pipeline-merge.md
 // for related design
@@ -0,0 +1,130 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kptpkg
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/GoogleContainerTools/kpt/internal/util/diff"
+)
+
+// DiffType is the type of comparison performed by Diff.
+type DiffType = diff.Type
+
+const (
+	// DiffLocal compares the local package with upstream at the version
+	// it was fetched at.
+	DiffLocal = diff.TypeLocal
+	// DiffRemote compares upstream at the version the package was fetched
+	// at with upstream at the target version.
+	DiffRemote = diff.TypeRemote
+	// DiffCombined compares the local package with upstream at the target
+	// version.
+	DiffCombined = diff.TypeCombined
+	// Diff3Way compares the local package and upstream at the target
+	// version with upstream at the version the package was fetched at.
+	Diff3Way = diff.Type3Way
+)
+
+// PackageDiff contains the resource-level changes between versions of a
+// package.
+type PackageDiff = diff.PackageDiff
+
+// ResourceChange describes how a single resource changed.
+type ResourceChange = diff.ResourceChange
+
+// FieldChange describes how a single field of a resource changed.
+type FieldChange = diff.FieldChange
+
+// DiffOptions contains the options for comparing a package with upstream.
+type DiffOptions struct {
+	// Path is the directory of the package to compare. It must have a git
+	// upstream.
+	Path string
+
+	// Ref is the target git ref to compare against. Defaults to the default
+	// branch of the upstream repo.
+	Ref string
+
+	// Type is the type of comparison. Defaults to DiffLocal, or to
+	// DiffCombined if Ref is set.
+	Type DiffType
+
+	// Offline only uses the upstream repos in the kpt cache.
+	Offline bool
+
+	// Output is where the progress of the operation is written. Defaults
+	// to discarding it.
+	Output io.Writer
+}
+
+// Diff returns the resource-level changes between the package and its
+// upstream.
+func Diff(ctx context.Context, opts DiffOptions) (*PackageDiff, error) {
+	const op = "diff"
+	ctx = newContext(ctx, opts.Offline, opts.Output)
+
+	kf, err := readPackage(op, opts.Path)
+	if err != nil {
+		return nil, err
+	}
+	if kf.Upstream == nil || kf.Upstream.Git == nil {
+		return nil, &Error{Op: op, Path: opts.Path, Kind: ErrNoUpstream,
+			Err: fmt.Errorf("package at %q must have a git upstream to be diffed", opts.Path)}
+	}
+
+	diffType := opts.Type
+	if diffType == "" {
+		diffType = DiffLocal
+		if opts.Ref != "" {
+			diffType = DiffCombined
+		}
+	}
+	differ := &capturingPkgDiffer{diffType: diffType}
+	cmd := diff.Command{
+		Path:         opts.Path,
+		Ref:          opts.Ref,
+		DiffType:     diffType,
+		OutputFormat: diff.OutputJSON,
+		Output:       ioutil.Discard,
+		PkgDiffer:    differ,
+	}
+	if err := cmd.Validate(); err != nil {
+		return nil, &Error{Op: op, Path: opts.Path, Kind: ErrInvalidArgument, Err: err}
+	}
+	if err := cmd.Run(ctx); err != nil {
+		return nil, wrapError(op, opts.Path, err)
+	}
+	return differ.result, nil
+}
+
+// capturingPkgDiffer keeps the resource-level diff of the staged packages
+// instead of printing it.
+type capturingPkgDiffer struct {
+	diffType DiffType
+	result   *PackageDiff
+}
+
+func (d *capturingPkgDiffer) Diff(pkgs ...string) error {
+	pd, err := diff.DiffResources(d.diffType, pkgs...)
+	if err != nil {
+		return err
+	}
+	d.result = pd
+	return nil
+}
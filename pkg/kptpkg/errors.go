@@ -0,0 +1,100 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kptpkg
+
+import (
+	goerrors "errors"
+
+	"github.com/GoogleContainerTools/kpt/internal/errors"
+)
+
+// The kinds of errors returned by Get, Update, Diff and Walk. They can be
+// checked with errors.Is.
+var (
+	// ErrExist is returned if the destination of a package already exists.
+	ErrExist = goerrors.New("already exists")
+	// ErrInvalidArgument is returned if the options are missing a required
+	// value or have an invalid one.
+	ErrInvalidArgument = goerrors.New("invalid argument")
+	// ErrNotPackage is returned if the path doesn't contain a Kptfile.
+	ErrNotPackage = goerrors.New("not a kpt package")
+	// ErrNoUpstream is returned if the package doesn't have the upstream
+	// required by the operation.
+	ErrNoUpstream = goerrors.New("package has no upstream")
+	// ErrConflict is returned if an update was stopped because of conflicts
+	// between local and upstream changes.
+	ErrConflict = goerrors.New("conflicting local and upstream changes")
+	// ErrGit is returned if the upstream git repo can't be fetched or read.
+	ErrGit = goerrors.New("git error")
+	// ErrIO is returned if reading or writing files fails.
+	ErrIO = goerrors.New("IO error")
+)
+
+// Error is the type of the errors returned by Get, Update, Diff and Walk.
+type Error struct {
+	// Op is the operation that failed, e.g. get.
+	Op string
+
+	// Path is the path of the package the operation failed for.
+	Path string
+
+	// Kind is one of the Err* kinds, or nil if the error isn't classified.
+	Kind error
+
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is the kind of the error.
+func (e *Error) Is(target error) bool {
+	return e.Kind != nil && e.Kind == target
+}
+
+// wrapError returns err as an *Error with the kind derived from the class
+// of the kpt errors it wraps.
+func wrapError(op, path string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Op: op, Path: path, Kind: errorKind(err), Err: err}
+}
+
+func errorKind(err error) error {
+	for ; err != nil; err = goerrors.Unwrap(err) {
+		kptErr, ok := err.(*errors.Error)
+		if !ok || kptErr.Class == errors.Other {
+			continue
+		}
+		switch kptErr.Class {
+		case errors.Exist:
+			return ErrExist
+		case errors.InvalidParam, errors.MissingParam:
+			return ErrInvalidArgument
+		case errors.Git:
+			return ErrGit
+		case errors.IO:
+			return ErrIO
+		}
+	}
+	return nil
+}
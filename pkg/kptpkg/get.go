@@ -0,0 +1,132 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kptpkg
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/GoogleContainerTools/kpt/internal/gitutil"
+	"github.com/GoogleContainerTools/kpt/internal/printer"
+	"github.com/GoogleContainerTools/kpt/internal/util/fetch"
+	"github.com/GoogleContainerTools/kpt/internal/util/get"
+	kptfilev1 "github.com/GoogleContainerTools/kpt/pkg/api/kptfile/v1"
+)
+
+// GetOptions contains the options for fetching a package. Exactly one of
+// Git, Dir and Tarball must be set.
+type GetOptions struct {
+	// Git is the git repo, directory and ref to fetch the package from.
+	// The directory defaults to the root of the repo, and the ref to the
+	// default branch of the repo.
+	Git *kptfilev1.Git
+
+	// Dir is the local directory to fetch the package from. A relative
+	// path is relative to Destination.
+	Dir *kptfilev1.Dir
+
+	// Tarball is the tarball to fetch the package from. A relative path is
+	// relative to Destination.
+	Tarball *kptfilev1.Tarball
+
+	// Destination is the directory the package is written to. It must not
+	// exist.
+	Destination string
+
+	// Name is the name of the package. Defaults to the base name of
+	// Destination.
+	Name string
+
+	// UpdateStrategy is the update strategy recorded in the Kptfile.
+	// Defaults to resource-merge.
+	UpdateStrategy kptfilev1.UpdateStrategyType
+
+	// IsDeploymentInstance marks the package as a deployment instance of
+	// the upstream package.
+	IsDeploymentInstance bool
+
+	// VerificationPolicy is the path of an UpstreamVerificationPolicy file
+	// the signatures of the upstream tags or commits are verified against.
+	// Defaults to $KPT_VERIFICATION_POLICY.
+	VerificationPolicy string
+
+	// Offline only uses the upstream repos in the kpt cache.
+	Offline bool
+
+	// Output is where the progress of the operation is written. Defaults
+	// to discarding it.
+	Output io.Writer
+}
+
+// Get fetches a package and its subpackages from upstream.
+func Get(ctx context.Context, opts GetOptions) error {
+	const op = "get"
+	ctx = newContext(ctx, opts.Offline, opts.Output)
+
+	dest := opts.Destination
+	if dest != "" {
+		abs, err := filepath.Abs(dest)
+		if err != nil {
+			return &Error{Op: op, Path: dest, Kind: ErrInvalidArgument, Err: err}
+		}
+		dest = abs
+	}
+
+	git := opts.Git
+	if git != nil {
+		g := *git
+		if g.Directory == "" {
+			g.Directory = "/"
+		}
+		if g.Ref == "" && g.Repo != "" {
+			gur, err := gitutil.NewGitUpstreamRepo(ctx, g.Repo)
+			if err != nil {
+				return wrapError(op, dest, err)
+			}
+			if g.Ref, err = gur.GetDefaultBranch(ctx); err != nil {
+				return &Error{Op: op, Path: dest, Kind: ErrGit, Err: err}
+			}
+		}
+		git = &g
+	}
+
+	policy, err := fetch.LoadVerificationPolicy(opts.VerificationPolicy)
+	if err != nil {
+		return wrapError(op, dest, err)
+	}
+
+	return wrapError(op, dest, get.Command{
+		Git:                  git,
+		Dir:                  opts.Dir,
+		Tarball:              opts.Tarball,
+		Destination:          dest,
+		Name:                 opts.Name,
+		IsDeploymentInstance: opts.IsDeploymentInstance,
+		UpdateStrategy:       opts.UpdateStrategy,
+		VerificationPolicy:   policy,
+	}.Run(ctx))
+}
+
+// newContext returns a context for running the kpt commands, which print
+// their progress to out.
+func newContext(ctx context.Context, offline bool, out io.Writer) context.Context {
+	if out == nil {
+		out = ioutil.Discard
+	}
+	ctx = printer.WithContext(ctx, printer.New(out, out))
+	return gitutil.ContextWithOffline(ctx, offline)
+}
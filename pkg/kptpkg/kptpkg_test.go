@@ -0,0 +1,107 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kptpkg_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleContainerTools/kpt/internal/gitutil"
+	kptfilev1 "github.com/GoogleContainerTools/kpt/pkg/api/kptfile/v1"
+	"github.com/GoogleContainerTools/kpt/pkg/kptfile/kptfileutil"
+	"github.com/GoogleContainerTools/kpt/pkg/kptpkg"
+	"github.com/stretchr/testify/assert"
+)
+
+const configMap = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm
+data:
+  foo: %s
+`
+
+func writePkg(t *testing.T, dir, name, value string) {
+	if !assert.NoError(t, os.MkdirAll(dir, 0700)) ||
+		!assert.NoError(t, kptfileutil.WriteFile(dir, kptfileutil.DefaultKptfile(name))) ||
+		!assert.NoError(t, os.WriteFile(filepath.Join(dir, "cm.yaml"), []byte(fmt.Sprintf(configMap, value)), 0600)) {
+		t.FailNow()
+	}
+}
+
+func TestGetUpdateWalk(t *testing.T) {
+	t.Setenv(gitutil.RepoCacheDirEnv, t.TempDir())
+	ctx := context.Background()
+	dir := t.TempDir()
+	upstream := filepath.Join(dir, "blueprint")
+	writePkg(t, upstream, "blueprint", "a")
+	writePkg(t, filepath.Join(upstream, "sub"), "sub", "a")
+	local := filepath.Join(dir, "local")
+
+	err := kptpkg.Get(ctx, kptpkg.GetOptions{
+		Dir:         &kptfilev1.Dir{Path: "../blueprint"},
+		Destination: local,
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	err = kptpkg.Get(ctx, kptpkg.GetOptions{
+		Dir:         &kptfilev1.Dir{Path: "../blueprint"},
+		Destination: local,
+	})
+	assert.True(t, errors.Is(err, kptpkg.ErrExist), "unexpected error: %v", err)
+
+	writePkg(t, upstream, "blueprint", "b")
+	res, err := kptpkg.Update(ctx, kptpkg.UpdateOptions{Path: local})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Empty(t, res.Conflicts)
+	b, err := os.ReadFile(filepath.Join(local, "cm.yaml"))
+	if assert.NoError(t, err) {
+		assert.Contains(t, string(b), "foo: b")
+	}
+
+	var paths []string
+	err = kptpkg.Walk(local, func(info kptpkg.PackageInfo) error {
+		paths = append(paths, info.Path)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{".", "sub"}, paths)
+
+	_, err = kptpkg.Diff(ctx, kptpkg.DiffOptions{Path: local})
+	assert.True(t, errors.Is(err, kptpkg.ErrNoUpstream), "unexpected error: %v", err)
+}
+
+func TestUpdate_errors(t *testing.T) {
+	dir := t.TempDir()
+	_, err := kptpkg.Update(context.Background(), kptpkg.UpdateOptions{Path: dir})
+	assert.True(t, errors.Is(err, kptpkg.ErrNotPackage), "unexpected error: %v", err)
+
+	writePkg(t, dir, "local", "a")
+	_, err = kptpkg.Update(context.Background(), kptpkg.UpdateOptions{Path: dir})
+	assert.True(t, errors.Is(err, kptpkg.ErrNoUpstream), "unexpected error: %v", err)
+	var kptpkgErr *kptpkg.Error
+	if assert.True(t, errors.As(err, &kptpkgErr)) {
+		assert.Equal(t, "update", kptpkgErr.Op)
+		assert.Equal(t, dir, kptpkgErr.Path)
+	}
+}
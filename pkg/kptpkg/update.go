@@ -0,0 +1,160 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kptpkg
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/GoogleContainerTools/kpt/internal/pkg"
+	"github.com/GoogleContainerTools/kpt/internal/util/fetch"
+	"github.com/GoogleContainerTools/kpt/internal/util/update"
+	kptfilev1 "github.com/GoogleContainerTools/kpt/pkg/api/kptfile/v1"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+// ConflictResolution defines how conflicts between local and upstream
+// changes are handled by the resource-merge strategy.
+type ConflictResolution = update.ConflictResolution
+
+const (
+	// UseUpstream uses the upstream values of conflicting fields.
+	UseUpstream = update.UseUpstream
+	// StopOnConflict doesn't update the package if there are conflicts.
+	StopOnConflict = update.StopOnConflict
+	// PromptOnConflict asks which value to use for every conflict.
+	PromptOnConflict = update.PromptOnConflict
+)
+
+// Conflict is a field that was changed differently in the local package and
+// in upstream.
+type Conflict = update.Conflict
+
+// UpdateOptions contains the options for updating a package.
+type UpdateOptions struct {
+	// Path is the directory of the package to update.
+	Path string
+
+	// Ref is the git ref to update the package to. Defaults to the ref in
+	// the Kptfile.
+	Ref string
+
+	// Strategy is the strategy used to merge upstream changes into the
+	// package. Defaults to resource-merge.
+	Strategy kptfilev1.UpdateStrategyType
+
+	// ConflictResolution defines how conflicts are handled. Defaults to
+	// UseUpstream.
+	ConflictResolution ConflictResolution
+
+	// Input is read for the answers to the prompts of PromptOnConflict.
+	Input io.Reader
+
+	// DryRun reports the changes and conflicts of the update without
+	// modifying the package.
+	DryRun bool
+
+	// VerificationPolicy is the path of an UpstreamVerificationPolicy file
+	// the signature of the upstream tag or commit is verified against.
+	// Defaults to $KPT_VERIFICATION_POLICY.
+	VerificationPolicy string
+
+	// Offline only uses the upstream repos in the kpt cache.
+	Offline bool
+
+	// Output is where the progress of the operation is written. Defaults
+	// to discarding it.
+	Output io.Writer
+}
+
+// UpdateResult is the result of updating a package.
+type UpdateResult struct {
+	// Conflicts are the fields that were changed differently in the local
+	// package and in upstream.
+	Conflicts []Conflict
+}
+
+// Update merges the upstream changes into a package and its subpackages.
+// If the update is stopped because of conflicts, the result is returned
+// along with an error of kind ErrConflict.
+func Update(ctx context.Context, opts UpdateOptions) (*UpdateResult, error) {
+	const op = "update"
+	ctx = newContext(ctx, opts.Offline, opts.Output)
+
+	kf, err := readPackage(op, opts.Path)
+	if err != nil {
+		return nil, err
+	}
+	if kf.Upstream == nil {
+		return nil, &Error{Op: op, Path: opts.Path, Kind: ErrNoUpstream,
+			Err: fmt.Errorf("package at %q doesn't have an upstream", opts.Path)}
+	}
+
+	absPath, err := filepath.Abs(opts.Path)
+	if err != nil {
+		return nil, &Error{Op: op, Path: opts.Path, Kind: ErrInvalidArgument, Err: err}
+	}
+	p, err := pkg.New(filesys.FileSystemOrOnDisk{}, absPath)
+	if err != nil {
+		return nil, wrapError(op, opts.Path, err)
+	}
+	policy, err := fetch.LoadVerificationPolicy(opts.VerificationPolicy)
+	if err != nil {
+		return nil, wrapError(op, opts.Path, err)
+	}
+
+	strategy := opts.Strategy
+	if strategy == "" {
+		strategy = kptfilev1.ResourceMerge
+	}
+	cmd := update.Command{
+		Pkg:                p,
+		Ref:                opts.Ref,
+		Strategy:           strategy,
+		ConflictResolution: opts.ConflictResolution,
+		Input:              opts.Input,
+		DryRun:             opts.DryRun,
+		VerificationPolicy: policy,
+	}
+	err = cmd.Run(ctx)
+	result := &UpdateResult{Conflicts: cmd.Conflicts}
+	if err != nil && opts.ConflictResolution == StopOnConflict && len(cmd.Conflicts) > 0 {
+		return result, &Error{Op: op, Path: opts.Path, Kind: ErrConflict, Err: err}
+	}
+	if err != nil {
+		return nil, wrapError(op, opts.Path, err)
+	}
+	return result, nil
+}
+
+// readPackage reads the Kptfile of the package at path.
+func readPackage(op, path string) (*kptfilev1.KptFile, error) {
+	fsys := filesys.FileSystemOrOnDisk{}
+	isPkg, err := pkg.IsPackageDir(fsys, path)
+	if err != nil {
+		return nil, wrapError(op, path, err)
+	}
+	if !isPkg {
+		return nil, &Error{Op: op, Path: path, Kind: ErrNotPackage,
+			Err: fmt.Errorf("%q doesn't contain a %s", path, kptfilev1.KptFileName)}
+	}
+	kf, err := pkg.ReadKptfile(fsys, path)
+	if err != nil {
+		return nil, wrapError(op, path, err)
+	}
+	return kf, nil
+}
@@ -0,0 +1,101 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kptpkg
+
+import (
+	goerrors "errors"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/GoogleContainerTools/kpt/internal/pkg"
+	kptfilev1 "github.com/GoogleContainerTools/kpt/pkg/api/kptfile/v1"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+// SkipPackage is returned by a WalkFunc to skip the subpackages of the
+// package.
+var SkipPackage = goerrors.New("skip this package")
+
+// PackageInfo describes a package visited by Walk.
+type PackageInfo struct {
+	// Path is the path of the package relative to the root of the walk.
+	// It is "." for the root package.
+	Path string
+
+	// Kptfile is the Kptfile of the package.
+	Kptfile *kptfilev1.KptFile
+}
+
+// WalkFunc is called by Walk for every package. If it returns SkipPackage,
+// the subpackages of the package are skipped. Any other error stops the
+// walk and is returned by Walk.
+type WalkFunc func(info PackageInfo) error
+
+// Walk calls fn for the package at root and all its subpackages, local and
+// remote, in lexical order of their paths.
+func Walk(root string, fn WalkFunc) error {
+	const op = "walk"
+	fsys := filesys.FileSystemOrOnDisk{}
+
+	kf, err := readPackage(op, root)
+	if err != nil {
+		return err
+	}
+	paths, err := pkg.Subpackages(fsys, root, pkg.All, true)
+	if err != nil {
+		return wrapError(op, root, err)
+	}
+	sort.Strings(paths)
+
+	var skipped []string
+	visit := func(path string, kf *kptfilev1.KptFile) error {
+		err := fn(PackageInfo{Path: path, Kptfile: kf})
+		if err == SkipPackage {
+			skipped = append(skipped, path)
+			return nil
+		}
+		return err
+	}
+	if err := visit(".", kf); err != nil {
+		return err
+	}
+	if len(skipped) > 0 {
+		return nil
+	}
+	for _, path := range paths {
+		if isSkipped(path, skipped) {
+			continue
+		}
+		kf, err := pkg.ReadKptfile(fsys, filepath.Join(root, path))
+		if err != nil {
+			return wrapError(op, filepath.Join(root, path), err)
+		}
+		if err := visit(path, kf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isSkipped returns true if path is in one of the skipped packages.
+func isSkipped(path string, skipped []string) bool {
+	for _, s := range skipped {
+		if strings.HasPrefix(path, s+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
@@ -19,6 +19,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/GoogleContainerTools/kpt/internal/types"
 	"sigs.k8s.io/kustomize/kyaml/yaml"
@@ -82,6 +83,33 @@ type TestCaseConfig struct {
 	// AllowExec determines if `fn render` needs to be invoked with `--allow-exec` flag
 	AllowExec bool `json:"allowExec,omitempty" yaml:"allowExec,omitempty"`
 
+	// ExecOnly means the test case only runs exec functions, so it doesn't need
+	// a container runtime and is never skipped because of Runtimes. It implies
+	// AllowExec. Default: false
+	ExecOnly bool `json:"execOnly,omitempty" yaml:"execOnly,omitempty"`
+
+	// NotIdempotent means the commands are not expected to be idempotent, so
+	// they are only run once. Default: false
+	NotIdempotent bool `json:"notIdempotent,omitempty" yaml:"notIdempotent,omitempty"`
+
+	// Commands is the sequence of kpt commands run by a 'commands' test, e.g.
+	// `pkg update @v2` followed by `fn render`. Each command is split into
+	// arguments like a shell would and run in the package directory. The
+	// sequence stops at the first command that fails.
+	Commands []string `json:"commands,omitempty" yaml:"commands,omitempty"`
+
+	// Env is the environment variables set for the setup steps, the kpt
+	// commands and the teardown steps, in addition to the current environment.
+	Env map[string]string `json:"env,omitempty" yaml:"env,omitempty"`
+
+	// Setup is a list of bash commands run in the package directory before
+	// the kpt commands, after setup.sh if it exists.
+	Setup []string `json:"setup,omitempty" yaml:"setup,omitempty"`
+
+	// Teardown is a list of bash commands run in the package directory after
+	// the kpt commands and result comparison, after teardown.sh if it exists.
+	Teardown []string `json:"teardown,omitempty" yaml:"teardown,omitempty"`
+
 	// Skip means should this test case be skipped. Default: false
 	Skip bool `json:"skip,omitempty" yaml:"skip,omitempty"`
 
@@ -91,10 +119,18 @@ type TestCaseConfig struct {
 	//    after test.
 	Debug bool `json:"debug,omitempty" yaml:"debug,omitempty"`
 
-	// TestType is the type of the test case. Possible value: ['render', 'eval']
-	// Default: 'render'
+	// TestType is the type of the test case. Possible value: ['render', 'eval', 'commands']
+	// Default: 'commands' if Commands is set, otherwise 'render'
 	TestType string `json:"testType,omitempty" yaml:"testType,omitempty"`
 
+	// ResultsComparison controls how the actual results are compared with
+	// the expected results. Possible value: ['exact', 'structural']
+	// 'exact' compares the text of the results file. 'structural' compares
+	// the parsed function results, so formatting, field order and comments
+	// are ignored.
+	// Default: 'exact'
+	ResultsComparison string `json:"resultsComparison,omitempty" yaml:"resultsComparison,omitempty"`
+
 	// DisableOutputTruncate indicates should error output be truncated
 	DisableOutputTruncate bool `json:"disableOutputTruncate,omitempty" yaml:"disableOutputTruncate,omitempty"`
 
@@ -103,16 +139,34 @@ type TestCaseConfig struct {
 }
 
 func (c *TestCaseConfig) RunCount() int {
+	if c.NotIdempotent {
+		return 1
+	}
 	return 2
 }
 
+// MatchesRuntime returns true if the test case can be run with the
+// given container runtime.
+func (c *TestCaseConfig) MatchesRuntime(runtime string) bool {
+	if c.ExecOnly || len(c.Runtimes) == 0 {
+		return true
+	}
+	for _, rt := range c.Runtimes {
+		if strings.EqualFold(runtime, rt) {
+			return true
+		}
+	}
+	return false
+}
+
 func newTestCaseConfig(path string) (TestCaseConfig, error) {
 	configPath := filepath.Join(path, expectedDir, expectedConfigFile)
 	b, err := ioutil.ReadFile(configPath)
 	if os.IsNotExist(err) {
 		// return default config
 		return TestCaseConfig{
-			TestType: CommandFnRender,
+			TestType:          CommandFnRender,
+			ResultsComparison: ResultsComparisonExact,
 		}, nil
 	}
 	if err != nil {
@@ -125,8 +179,23 @@ func newTestCaseConfig(path string) (TestCaseConfig, error) {
 		return config, fmt.Errorf("failed to unmarshal config file: %s\n: %w", string(b), err)
 	}
 	if config.TestType == "" {
-		// by default we test pipeline
-		config.TestType = CommandFnRender
+		if len(config.Commands) > 0 {
+			config.TestType = CommandSequence
+		} else {
+			// by default we test pipeline
+			config.TestType = CommandFnRender
+		}
+	}
+	if config.TestType == CommandSequence && len(config.Commands) == 0 {
+		return config, fmt.Errorf("test type %q requires at least one command", CommandSequence)
+	}
+	switch config.ResultsComparison {
+	case "":
+		config.ResultsComparison = ResultsComparisonExact
+	case ResultsComparisonExact, ResultsComparisonStructural:
+	default:
+		return config, fmt.Errorf("invalid resultsComparison %q, must be one of %q or %q",
+			config.ResultsComparison, ResultsComparisonExact, ResultsComparisonStructural)
 	}
 	if config.EvalConfig != nil {
 		config.EvalConfig.fnConfigUniquePath, err = fromSlashPath(filepath.Join(path, expectedDir), config.EvalConfig.FnConfig)
@@ -194,7 +263,7 @@ func ScanTestCases(path string) (*TestCases, error) {
 		return nil
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to scan test cases in %s", path)
+		return nil, fmt.Errorf("failed to scan test cases in %s: %w", path, err)
 	}
 	return &cases, nil
 }
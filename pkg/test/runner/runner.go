@@ -21,11 +21,15 @@ import (
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"testing"
 
 	"github.com/GoogleContainerTools/kpt/internal/fnruntime"
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/shlex"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
 )
 
 // Runner runs an e2e test
@@ -36,6 +40,21 @@ type Runner struct {
 	t             *testing.T
 	initialCommit string
 	kptBin        string
+	// update makes the runner rewrite the expected artifacts instead of
+	// comparing against them.
+	update bool
+}
+
+// RunnerOption configures a Runner.
+type RunnerOption func(*Runner)
+
+// WithUpdateExpected makes the runner rewrite the expected artifacts of the
+// test case instead of comparing against them, like setting
+// KPT_E2E_UPDATE_EXPECTED to "true".
+func WithUpdateExpected(update bool) RunnerOption {
+	return func(r *Runner) {
+		r.update = update
+	}
 }
 
 func getKptBin() (string, error) {
@@ -47,9 +66,9 @@ func getKptBin() (string, error) {
 }
 
 const (
-	// If this env is set to "true", this e2e test framework will update the
-	// expected diff and results if they already exist. If will not change
-	// config.yaml.
+	// If this env is set to "true", this e2e test framework will rewrite the
+	// expected artifacts in .expected instead of comparing against them.
+	// See updateExpected for the details.
 	updateExpectedEnv string = "KPT_E2E_UPDATE_EXPECTED"
 
 	expectedDir         string = ".expected"
@@ -62,10 +81,20 @@ const (
 	execScript          string = "exec.sh"
 	CommandFnEval       string = "eval"
 	CommandFnRender     string = "render"
+	CommandSequence     string = "commands"
+
+	ResultsComparisonExact      string = "exact"
+	ResultsComparisonStructural string = "structural"
 )
 
+// shouldUpdateExpected returns true if the runner should rewrite the expected
+// artifacts instead of comparing against them.
+func (r *Runner) shouldUpdateExpected() bool {
+	return r.update || strings.ToLower(os.Getenv(updateExpectedEnv)) == "true"
+}
+
 // NewRunner returns a new runner for pkg
-func NewRunner(t *testing.T, testCase TestCase, c string) (*Runner, error) {
+func NewRunner(t *testing.T, testCase TestCase, c string, opts ...RunnerOption) (*Runner, error) {
 	info, err := os.Stat(testCase.Path)
 	if err != nil {
 		return nil, fmt.Errorf("cannot open path %s: %w", testCase.Path, err)
@@ -80,13 +109,17 @@ func NewRunner(t *testing.T, testCase TestCase, c string) (*Runner, error) {
 	if kptBin != "" {
 		t.Logf("Using kpt binary: %s", kptBin)
 	}
-	return &Runner{
+	r := &Runner{
 		pkgName:  filepath.Base(testCase.Path),
 		testCase: testCase,
 		cmd:      c,
 		t:        t,
 		kptBin:   kptBin,
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r, nil
 }
 
 // Run runs the test.
@@ -96,6 +129,8 @@ func (r *Runner) Run() error {
 		return r.runFnEval()
 	case CommandFnRender:
 		return r.runFnRender()
+	case CommandSequence:
+		return r.runCommands()
 	default:
 		return fmt.Errorf("invalid command %s", r.cmd)
 	}
@@ -107,13 +142,19 @@ func (r *Runner) runSetupScript(pkgPath string) error {
 	if err != nil {
 		return err
 	}
-	if _, err := os.Stat(p); os.IsNotExist(err) {
-		return nil
+	if _, err := os.Stat(p); err == nil {
+		cmd := r.getCommand(pkgPath, "bash", []string{p})
+		r.t.Logf("running setup script: %q", cmd.String())
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to run setup script %q.\nOutput: %q\n: %w", p, string(output), err)
+		}
 	}
-	cmd := getCommand(pkgPath, "bash", []string{p})
-	r.t.Logf("running setup script: %q", cmd.String())
-	if output, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("failed to run setup script %q.\nOutput: %q\n: %w", p, string(output), err)
+	for _, step := range r.testCase.Config.Setup {
+		cmd := r.getCommand(pkgPath, "bash", []string{"-c", step})
+		r.t.Logf("running setup step: %q", cmd.String())
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to run setup step %q.\nOutput: %q\n: %w", step, string(output), err)
+		}
 	}
 	return nil
 }
@@ -124,17 +165,41 @@ func (r *Runner) runTearDownScript(pkgPath string) error {
 	if err != nil {
 		return err
 	}
-	if _, err := os.Stat(p); os.IsNotExist(err) {
-		return nil
+	if _, err := os.Stat(p); err == nil {
+		cmd := r.getCommand(pkgPath, "bash", []string{p})
+		r.t.Logf("running teardown script: %q", cmd.String())
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to run teardown script %q.\nOutput: %q\n: %w", p, string(output), err)
+		}
 	}
-	cmd := getCommand(pkgPath, "bash", []string{p})
-	r.t.Logf("running teardown script: %q", cmd.String())
-	if output, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("failed to run teardown script %q.\nOutput: %q\n: %w", p, string(output), err)
+	for _, step := range r.testCase.Config.Teardown {
+		cmd := r.getCommand(pkgPath, "bash", []string{"-c", step})
+		r.t.Logf("running teardown step: %q", cmd.String())
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to run teardown step %q.\nOutput: %q\n: %w", step, string(output), err)
+		}
 	}
 	return nil
 }
 
+// getCommand returns a command with the environment variables of the
+// test case set.
+func (r *Runner) getCommand(pwd, name string, arg []string) *exec.Cmd {
+	cmd := getCommand(pwd, name, arg)
+	if len(r.testCase.Config.Env) > 0 {
+		var keys []string
+		for k := range r.testCase.Config.Env {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		cmd.Env = os.Environ()
+		for _, k := range keys {
+			cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", k, r.testCase.Config.Env[k]))
+		}
+	}
+	return cmd
+}
+
 func (r *Runner) runFnEval() error {
 	r.t.Logf("Running test against package %s\n", r.pkgName)
 	tmpDir, err := ioutil.TempDir("", "kpt-fn-e2e-*")
@@ -186,7 +251,7 @@ func (r *Runner) runFnEval() error {
 		}
 
 		if _, err := os.Stat(execScriptPath); err == nil {
-			cmd = getCommand(pkgPath, "bash", []string{execScriptPath})
+			cmd = r.getCommand(pkgPath, "bash", []string{execScriptPath})
 		} else {
 			kptArgs := []string{"fn", "eval", pkgPath}
 
@@ -220,16 +285,17 @@ func (r *Runner) runFnEval() error {
 					kptArgs = append(kptArgs, fmt.Sprintf("%s=%s", k, v))
 				}
 			}
-			cmd = getCommand("", r.kptBin, kptArgs)
+			cmd = r.getCommand("", r.kptBin, kptArgs)
 		}
 		r.t.Logf("running command: %v=%v %v", fnruntime.ContainerRuntimeEnv, os.Getenv(fnruntime.ContainerRuntimeEnv), cmd.String())
 		stdout, stderr, fnErr := runCommand(cmd)
 		if fnErr != nil {
 			r.t.Logf("kpt error, stdout: %s; stderr: %s", stdout, stderr)
 		}
-		// Update the diff file or results file if updateExpectedEnv is set.
-		if strings.ToLower(os.Getenv(updateExpectedEnv)) == "true" {
-			return r.updateExpected(pkgPath, resultsDir, filepath.Join(r.testCase.Path, expectedDir))
+		// Update the expected artifacts if update mode is enabled.
+		if r.shouldUpdateExpected() {
+			return r.updateExpected(fnErr, stdout, sanitizeTimestamps(stderr), pkgPath, resultsDir,
+				filepath.Join(r.testCase.Path, expectedDir))
 		}
 
 		// compare results
@@ -344,7 +410,7 @@ func (r *Runner) runFnRender() error {
 		}
 
 		if _, err := os.Stat(execScriptPath); err == nil {
-			cmd = getCommand(pkgPath, "bash", []string{execScriptPath})
+			cmd = r.getCommand(pkgPath, "bash", []string{execScriptPath})
 		} else {
 			kptArgs := []string{"fn", "render", pkgPath}
 
@@ -360,20 +426,21 @@ func (r *Runner) runFnRender() error {
 				kptArgs = append(kptArgs, "--image-pull-policy", r.testCase.Config.ImagePullPolicy)
 			}
 
-			if r.testCase.Config.AllowExec {
+			if r.testCase.Config.AllowExec || r.testCase.Config.ExecOnly {
 				kptArgs = append(kptArgs, "--allow-exec")
 			}
 
 			if r.testCase.Config.DisableOutputTruncate {
 				kptArgs = append(kptArgs, "--truncate-output=false")
 			}
-			cmd = getCommand("", r.kptBin, kptArgs)
+			cmd = r.getCommand("", r.kptBin, kptArgs)
 		}
 		r.t.Logf("running command: %v=%v %v", fnruntime.ContainerRuntimeEnv, os.Getenv(fnruntime.ContainerRuntimeEnv), cmd.String())
 		stdout, stderr, fnErr := runCommand(cmd)
-		// Update the diff file or results file if updateExpectedEnv is set.
-		if strings.ToLower(os.Getenv(updateExpectedEnv)) == "true" {
-			return r.updateExpected(pkgPath, resultsDir, filepath.Join(r.testCase.Path, expectedDir))
+		// Update the expected artifacts if update mode is enabled.
+		if r.shouldUpdateExpected() {
+			return r.updateExpected(fnErr, stdout, sanitizeTimestamps(stderr), pkgPath, resultsDir,
+				filepath.Join(r.testCase.Path, expectedDir))
 		}
 
 		if fnErr != nil {
@@ -397,6 +464,90 @@ func (r *Runner) runFnRender() error {
 	return nil
 }
 
+func (r *Runner) runCommands() error {
+	r.t.Logf("Running test against package %s\n", r.pkgName)
+	tmpDir, err := ioutil.TempDir("", "kpt-commands-e2e-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary dir: %w", err)
+	}
+	pkgPath := filepath.Join(tmpDir, r.pkgName)
+
+	if r.testCase.Config.Debug {
+		fmt.Printf("Running test against package %s in dir %s \n", r.pkgName, pkgPath)
+	}
+	if !r.testCase.Config.Debug {
+		// if debug is true, keep the test directory around for debugging
+		defer os.RemoveAll(tmpDir)
+	}
+
+	// copy package to temp directory
+	err = copyDir(r.testCase.Path, pkgPath)
+	if err != nil {
+		return fmt.Errorf("failed to copy package: %w", err)
+	}
+
+	// init and commit package files
+	err = r.preparePackage(pkgPath)
+	if err != nil {
+		return fmt.Errorf("failed to prepare package: %w", err)
+	}
+
+	for i := 0; i < r.testCase.Config.RunCount(); i++ {
+		err = r.runSetupScript(pkgPath)
+		if err != nil {
+			return err
+		}
+
+		stdout, stderr, cmdErr := r.runCommandSequence(pkgPath)
+		if cmdErr != nil {
+			r.t.Logf("kpt error, stdout: %s; stderr: %s", stdout, stderr)
+		}
+		// Update the expected artifacts if update mode is enabled.
+		if r.shouldUpdateExpected() {
+			return r.updateExpected(cmdErr, stdout, sanitizeTimestamps(stderr), pkgPath, "",
+				filepath.Join(r.testCase.Path, expectedDir))
+		}
+
+		// compare results
+		err = r.compareResult(i, cmdErr, stdout, sanitizeTimestamps(stderr), pkgPath, "")
+		if err != nil {
+			return err
+		}
+		// we passed result check, now we should run teardown script and break
+		// if the command error is expected
+		err = r.runTearDownScript(pkgPath)
+		if err != nil {
+			return err
+		}
+		if cmdErr != nil {
+			break
+		}
+	}
+	return nil
+}
+
+// runCommandSequence runs the kpt commands of the test case in pkgPath and
+// returns the combined stdout and stderr. It stops at the first command
+// that fails and returns its error.
+func (r *Runner) runCommandSequence(pkgPath string) (string, string, error) {
+	var stdout, stderr strings.Builder
+	for _, c := range r.testCase.Config.Commands {
+		args, err := shlex.Split(c)
+		if err != nil {
+			return stdout.String(), stderr.String(), fmt.Errorf("failed to parse command %q: %w", c, err)
+		}
+		cmd := r.getCommand(pkgPath, r.kptBin, args)
+		r.t.Logf("running command: %v=%v %v", fnruntime.ContainerRuntimeEnv, os.Getenv(fnruntime.ContainerRuntimeEnv), cmd.String())
+		o, e, err := runCommand(cmd)
+		stdout.WriteString(o)
+		stderr.WriteString(e)
+		if err != nil {
+			return stdout.String(), stderr.String(), err
+		}
+	}
+	return stdout.String(), stderr.String(), nil
+}
+
 func (r *Runner) preparePackage(pkgPath string) error {
 	err := gitInit(pkgPath)
 	if err != nil {
@@ -422,12 +573,9 @@ func (r *Runner) compareResult(cnt int, exitErr error, stdout string, stderr str
 	if err != nil {
 		return err
 	}
-	// get exit code
-	exitCode := 0
-	if e, ok := exitErr.(*exec.ExitError); ok {
-		exitCode = e.ExitCode()
-	} else if exitErr != nil {
-		return fmt.Errorf("cannot get exit code, received error '%w'", exitErr)
+	exitCode, err := getExitCode(exitErr)
+	if err != nil {
+		return err
 	}
 
 	if exitCode != r.testCase.Config.ExitCode {
@@ -447,13 +595,24 @@ func (r *Runner) compareResult(cnt int, exitErr error, stdout string, stderr str
 		if err != nil {
 			return fmt.Errorf("failed to read actual results: %w", err)
 		}
-		diffOfResult, err := diffStrings(actual, expected.Results)
-		if err != nil {
-			return fmt.Errorf("error when run diff of results: %w: %s", err, diffOfResult)
-		}
-		if actual != expected.Results {
-			return fmt.Errorf("actual results doesn't match expected\nActual\n===\n%s\nDiff of Results\n===\n%s",
-				actual, diffOfResult)
+		if r.testCase.Config.ResultsComparison == ResultsComparisonStructural {
+			diffOfResult, err := diffResultsStructurally(actual, expected.Results)
+			if err != nil {
+				return fmt.Errorf("error when run structural diff of results: %w", err)
+			}
+			if diffOfResult != "" {
+				return fmt.Errorf("actual results doesn't match expected\nActual\n===\n%s\nDiff of Results (-expected, +actual)\n===\n%s",
+					actual, diffOfResult)
+			}
+		} else {
+			diffOfResult, err := diffStrings(actual, expected.Results)
+			if err != nil {
+				return fmt.Errorf("error when run diff of results: %w: %s", err, diffOfResult)
+			}
+			if actual != expected.Results {
+				return fmt.Errorf("actual results doesn't match expected\nActual\n===\n%s\nDiff of Results\n===\n%s",
+					actual, diffOfResult)
+			}
 		}
 	}
 
@@ -576,7 +735,16 @@ func newExpected(path string) (expected, error) {
 	return e, nil
 }
 
-func (r *Runner) updateExpected(tmpPkgPath, resultsPath, sourceOfTruthPath string) error {
+// updateExpected rewrites the expected artifacts in sourceOfTruthPath with
+// the actual output of the command:
+//   - results.yaml is rewritten if it already exists.
+//   - diff.patch is rewritten, or removed if the command made no changes.
+//   - exitCode, stdErr and stdOut in config.yaml are rewritten only if they no
+//     longer match the actual output, so that partial expectations are kept.
+func (r *Runner) updateExpected(exitErr error, stdout, stderr, tmpPkgPath, resultsPath, sourceOfTruthPath string) error {
+	if err := r.updateExpectedConfig(exitErr, stdout, stderr, sourceOfTruthPath); err != nil {
+		return err
+	}
 	if resultsPath != "" {
 		// We update results directory only when a result file already exists.
 		l, err := ioutil.ReadDir(resultsPath)
@@ -599,11 +767,73 @@ func (r *Runner) updateExpected(tmpPkgPath, resultsPath, sourceOfTruthPath strin
 	if err != nil {
 		return err
 	}
-	if actualDiff != "" {
-		if err := ioutil.WriteFile(filepath.Join(sourceOfTruthPath, expectedDiffFile), []byte(actualDiff+"\n"), 0666); err != nil {
+	diffPath := filepath.Join(sourceOfTruthPath, expectedDiffFile)
+	if actualDiff == "" {
+		if err := os.Remove(diffPath); err != nil && !os.IsNotExist(err) {
 			return err
 		}
+		return nil
 	}
+	return ioutil.WriteFile(diffPath, []byte(actualDiff+"\n"), 0666)
+}
 
-	return nil
+// updateExpectedConfig rewrites the fields in config.yaml that don't match
+// the actual exit code, stderr and stdout. Comments and all other fields
+// are preserved.
+func (r *Runner) updateExpectedConfig(exitErr error, stdout, stderr, sourceOfTruthPath string) error {
+	exitCode, err := getExitCode(exitErr)
+	if err != nil {
+		return err
+	}
+	config := r.testCase.Config
+	var setters []yaml.Filter
+	if exitCode != config.ExitCode {
+		exitCodeNode := yaml.NewScalarRNode(strconv.Itoa(exitCode))
+		exitCodeNode.YNode().Tag = yaml.NodeTagInt
+		setters = append(setters, yaml.SetField("exitCode", exitCodeNode))
+	}
+	if config.StdErr != "" && !strings.Contains(stderr, config.StdErr) {
+		setters = append(setters, yaml.SetField("stdErr", yaml.NewStringRNode(strings.TrimSpace(stderr))))
+	}
+	if config.StdOut != "" && !strings.Contains(stdout, config.StdOut) {
+		setters = append(setters, yaml.SetField("stdOut", yaml.NewStringRNode(strings.TrimSpace(stdout))))
+	}
+	if len(setters) == 0 {
+		return nil
+	}
+
+	configPath := filepath.Join(sourceOfTruthPath, expectedConfigFile)
+	node := yaml.NewMapRNode(nil)
+	b, err := ioutil.ReadFile(configPath)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return fmt.Errorf("failed to read test config file: %w", err)
+	default:
+		node, err = yaml.Parse(string(b))
+		if err != nil {
+			return fmt.Errorf("failed to parse test config file: %w", err)
+		}
+	}
+	for _, s := range setters {
+		if _, err := node.Pipe(s); err != nil {
+			return fmt.Errorf("failed to update test config file: %w", err)
+		}
+	}
+	out, err := node.String()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(configPath, []byte(out), 0666)
+}
+
+// getExitCode returns the exit code of the command from the error returned
+// by running it.
+func getExitCode(exitErr error) (int, error) {
+	if e, ok := exitErr.(*exec.ExitError); ok {
+		return e.ExitCode(), nil
+	} else if exitErr != nil {
+		return 0, fmt.Errorf("cannot get exit code, received error '%w'", exitErr)
+	}
+	return 0, nil
 }
@@ -0,0 +1,160 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDiffResultsStructurally(t *testing.T) {
+	expected := `apiVersion: kpt.dev/v1
+kind: FunctionResultList
+metadata:
+  name: fnresults
+exitCode: 1
+items:
+  - image: gcr.io/kpt-fn/kubeval:v0.1
+    exitCode: 1
+    results:
+      - message: 'Invalid type. Expected: integer, given: string'
+        severity: error
+        field:
+          path: spec.replicas
+`
+	grid := []struct {
+		Name     string
+		Actual   string
+		WantDiff bool
+	}{
+		{
+			Name: "formatting, field order and comments are ignored",
+			Actual: `# comment
+apiVersion: kpt.dev/v1
+kind: FunctionResultList
+metadata: {name: fnresults}
+items:
+- exitCode: 1
+  image: "gcr.io/kpt-fn/kubeval:v0.1"
+  results:
+  - severity: error
+    message: "Invalid type. Expected: integer, given: string"
+    field: {path: spec.replicas}
+exitCode: 1
+`,
+		},
+		{
+			Name: "different values are reported",
+			Actual: `apiVersion: kpt.dev/v1
+kind: FunctionResultList
+metadata:
+  name: fnresults
+exitCode: 1
+items:
+  - image: gcr.io/kpt-fn/kubeval:v0.1
+    exitCode: 1
+    results:
+      - message: 'Invalid type. Expected: integer, given: string'
+        severity: warning
+        field:
+          path: spec.replicas
+`,
+			WantDiff: true,
+		},
+		{
+			Name:     "missing results are reported",
+			Actual:   "",
+			WantDiff: true,
+		},
+	}
+
+	for _, g := range grid {
+		g := g // Avoid range go-tcha
+		t.Run(g.Name, func(t *testing.T) {
+			diff, err := diffResultsStructurally(g.Actual, expected)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := diff != ""; got != g.WantDiff {
+				t.Errorf("got diff %v, want diff %v: %s", got, g.WantDiff, diff)
+			}
+		})
+	}
+}
+
+func TestUpdateExpectedConfig(t *testing.T) {
+	grid := []struct {
+		Name     string
+		Config   TestCaseConfig
+		Original string
+		ExitCode int
+		Stderr   string
+		Want     string
+	}{
+		{
+			Name:     "matching expectations are kept",
+			Config:   TestCaseConfig{ExitCode: 1, StdErr: "failed"},
+			Original: "# comment\nexitCode: 1\nstdErr: failed\n",
+			ExitCode: 1,
+			Stderr:   "the function failed\n",
+			Want:     "# comment\nexitCode: 1\nstdErr: failed\n",
+		},
+		{
+			Name:     "mismatching expectations are rewritten",
+			Config:   TestCaseConfig{ExitCode: 1, StdErr: "failed"},
+			Original: "# comment\nexitCode: 1\nstdErr: failed\ndebug: true\n",
+			ExitCode: 0,
+			Stderr:   "[PASS] \"foo\" in 0s\nSuccessfully executed 1 function(s)\n",
+			Want:     "# comment\nexitCode: 0\nstdErr: |-\n  [PASS] \"foo\" in 0s\n  Successfully executed 1 function(s)\ndebug: true\n",
+		},
+		{
+			Name:     "config file is created for unexpected exit code",
+			ExitCode: 1,
+			Want:     "exitCode: 1\n",
+		},
+	}
+
+	for _, g := range grid {
+		g := g // Avoid range go-tcha
+		t.Run(g.Name, func(t *testing.T) {
+			dir := t.TempDir()
+			configPath := filepath.Join(dir, expectedConfigFile)
+			if g.Original != "" {
+				if err := ioutil.WriteFile(configPath, []byte(g.Original), 0666); err != nil {
+					t.Fatal(err)
+				}
+			}
+			var exitErr error
+			if g.ExitCode != 0 {
+				exitErr = exec.Command("bash", "-c", "exit 1").Run()
+			}
+			r := &Runner{testCase: TestCase{Config: g.Config}, t: t}
+			if err := r.updateExpectedConfig(exitErr, "", g.Stderr, dir); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got, err := ioutil.ReadFile(configPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(g.Want, string(got)); diff != "" {
+				t.Errorf("unexpected config (-want, +got): %s", diff)
+			}
+		})
+	}
+}
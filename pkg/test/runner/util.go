@@ -21,6 +21,10 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+
+	fnresult "github.com/GoogleContainerTools/kpt/pkg/api/fnresult/v1"
+	"github.com/google/go-cmp/cmp"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
 )
 
 func runCommand(cmd *exec.Cmd) (string, string, error) {
@@ -109,3 +113,29 @@ func diffStrings(actual, expected string) (string, error) {
 	output, _, _ := runCommand(getCommand(tmpDir, "diff", []string{"-u", expectedPath, actualPath}))
 	return output, nil
 }
+
+// diffResultsStructurally parses the actual and expected function results
+// and returns a diff of the parsed values, or an empty string if they are
+// equal.
+func diffResultsStructurally(actual, expected string) (string, error) {
+	actualResults, err := parseResultList(actual)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse actual results: %w", err)
+	}
+	expectedResults, err := parseResultList(expected)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse expected results: %w", err)
+	}
+	return cmp.Diff(expectedResults, actualResults), nil
+}
+
+func parseResultList(s string) (*fnresult.ResultList, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var rl fnresult.ResultList
+	if err := yaml.Unmarshal([]byte(s), &rl); err != nil {
+		return nil, err
+	}
+	return &rl, nil
+}
@@ -74,6 +74,15 @@ type PackageRevisionSpec struct {
 	Lifecycle PackageRevisionLifecycle `json:"lifecycle,omitempty"`
 
 	Tasks []Task `json:"tasks,omitempty"`
+
+	// ReadinessGates lists the condition types that must be true in the status
+	// before the package revision can be approved.
+	ReadinessGates []ReadinessGate `json:"readinessGates,omitempty"`
+}
+
+// ReadinessGate names a condition type that gates the approval of a package revision.
+type ReadinessGate struct {
+	ConditionType string `json:"conditionType"`
 }
 
 // ParentReference is a reference to a parent package
@@ -87,6 +96,66 @@ type ParentReference struct {
 // PackageRevisionStatus defines the observed state of PackageRevision
 type PackageRevisionStatus struct {
 	UpstreamLock *UpstreamLock
+
+	// RepositoryFunctionResults are the results of the mutators and validators of the repository,
+	// evaluated on the last change to the package revision.
+	RepositoryFunctionResults []FunctionResult `json:"repositoryFunctionResults,omitempty"`
+
+	// Conditions describe whether the package revision rendered and passed validation, and
+	// whether it is up to date with its upstream.
+	Conditions []Condition `json:"conditions,omitempty"`
+
+	// RenderResults are the results of the functions of the last render of the package revision.
+	RenderResults *ResultList `json:"renderResults,omitempty"`
+
+	// PublishedBy is the identity of the user who approved the package revision.
+	PublishedBy string `json:"publishedBy,omitempty"`
+
+	// PublishTimestamp is the time when the package revision was approved.
+	PublishTimestamp metav1.Time `json:"publishTimestamp,omitempty"`
+}
+
+// Condition describes an aspect of the state of a package revision.
+type Condition struct {
+	// Type of the condition, for example Rendered.
+	Type string `json:"type"`
+	// Status of the condition, one of True, False or Unknown.
+	Status ConditionStatus `json:"status"`
+	// Reason is a one-word, CamelCase reason for the status.
+	Reason string `json:"reason,omitempty"`
+	// Message is a human readable description of the status.
+	Message string `json:"message,omitempty"`
+}
+
+type ConditionStatus string
+
+const (
+	ConditionTrue    ConditionStatus = "True"
+	ConditionFalse   ConditionStatus = "False"
+	ConditionUnknown ConditionStatus = "Unknown"
+)
+
+// Types of the conditions of a package revision:
+const (
+	// ConditionTypeRendered is true if the last render of the package revision succeeded.
+	ConditionTypeRendered = "Rendered"
+	// ConditionTypeValidated is true if the validators of the repository passed on the package revision.
+	ConditionTypeValidated = "Validated"
+	// ConditionTypeUpToDateWithUpstream is true if the package revision was cloned from the latest
+	// revision of its upstream package.
+	ConditionTypeUpToDateWithUpstream = "UpToDateWithUpstream"
+)
+
+// FunctionResult is the result of evaluating a function on a package revision.
+type FunctionResult struct {
+	// Image is the image of the function.
+	Image string `json:"image"`
+	// FunctionType is the type of the function, either mutator or validator.
+	FunctionType FunctionType `json:"functionType"`
+	// Passed is true if the function evaluated successfully.
+	Passed bool `json:"passed"`
+	// Message is the error of the function if it failed.
+	Message string `json:"message,omitempty"`
 }
 
 type TaskType string
@@ -121,8 +190,10 @@ type PackageInitTaskSpec struct {
 }
 
 type PackageCloneTaskSpec struct {
-	// // `Subpackage` is a path to a directory where to clone the upstream package.
-	// Subpackage string `json:"subpackage,omitempty"`
+	// `Subpackage` is a path to a directory where to clone the upstream package. If unspecified,
+	// the upstream package is cloned into the root of a new package. If specified, the upstream
+	// package is cloned into the directory of an existing package and recorded as its subpackage.
+	Subpackage string `json:"subpackage,omitempty"`
 
 	// `Upstream` is the reference to the upstream package to clone.
 	Upstream UpstreamPackage `json:"upstreamRef,omitempty"`
@@ -264,6 +335,71 @@ type Selector struct {
 	Namespace string `json:"namespace,omitempty"`
 }
 
+// The following types (ResultList, Result, ResultItem, ResourceIdentifier, Field and File) are duplicates
+// of the function results from the kpt library. The current and proposed values of fields are omitted,
+// because they can't be represented in the API.
+
+// ResultList contains aggregated results from multiple functions.
+type ResultList struct {
+	// ExitCode is the exit code of the render.
+	ExitCode int `json:"exitCode"`
+	// Items contain a list of function results.
+	Items []Result `json:"items,omitempty"`
+}
+
+// Result contains the structured result from an individual function.
+type Result struct {
+	// Image is the full name of the image that generates this result.
+	Image string `json:"image,omitempty"`
+	// ExecPath is the path of the executable that generates this result.
+	ExecPath string `json:"exec,omitempty"`
+	// Stderr is the content in function stderr.
+	Stderr string `json:"stderr,omitempty"`
+	// ExitCode is the exit code from running the function.
+	ExitCode int `json:"exitCode"`
+	// Results is the list of results for the function.
+	Results []ResultItem `json:"results,omitempty"`
+}
+
+// ResultItem is a single result reported by a function.
+type ResultItem struct {
+	// Message is a human readable message.
+	Message string `json:"message,omitempty"`
+	// Severity is the severity of the result, one of error, warning or info.
+	Severity string `json:"severity,omitempty"`
+	// ResourceRef is a reference to the resource the result refers to.
+	ResourceRef *ResourceIdentifier `json:"resourceRef,omitempty"`
+	// Field is a reference to the field in the resource the result refers to.
+	Field *Field `json:"field,omitempty"`
+	// File references the file containing the resource the result refers to.
+	File *File `json:"file,omitempty"`
+	// Tags is an unstructured key value map stored with the result.
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+// ResourceIdentifier identifies a resource.
+type ResourceIdentifier struct {
+	APIVersion string `json:"apiVersion,omitempty"`
+	Kind       string `json:"kind,omitempty"`
+	Name       string `json:"name,omitempty"`
+	Namespace  string `json:"namespace,omitempty"`
+}
+
+// Field references a field in a resource.
+type Field struct {
+	// Path is the field path.
+	Path string `json:"path,omitempty"`
+}
+
+// File references a file containing a resource.
+type File struct {
+	// Path is relative path to the file containing the resource.
+	Path string `json:"path,omitempty"`
+	// Index is the index into the file containing the resource
+	// (i.e. if there are multiple resources in a single file).
+	Index int `json:"index,omitempty"`
+}
+
 // The following types (UpstreamLock, OriginType, and GitLock) are duplicates from the kpt library.
 // We are repeating them here to avoid cyclic dependencies, but these duplicate type should be removed when
 // https://github.com/GoogleContainerTools/kpt/issues/3297 is resolved.
@@ -34,6 +34,36 @@ func init() {
 // RegisterConversions adds conversion functions to the given scheme.
 // Public to allow building arbitrary schemes.
 func RegisterConversions(s *runtime.Scheme) error {
+	if err := s.AddGeneratedConversionFunc((*Condition)(nil), (*porch.Condition)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_Condition_To_porch_Condition(a.(*Condition), b.(*porch.Condition), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*porch.Condition)(nil), (*Condition)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_porch_Condition_To_v1alpha1_Condition(a.(*porch.Condition), b.(*Condition), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*Field)(nil), (*porch.Field)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_Field_To_porch_Field(a.(*Field), b.(*porch.Field), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*porch.Field)(nil), (*Field)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_porch_Field_To_v1alpha1_Field(a.(*porch.Field), b.(*Field), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*File)(nil), (*porch.File)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_File_To_porch_File(a.(*File), b.(*porch.File), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*porch.File)(nil), (*File)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_porch_File_To_v1alpha1_File(a.(*porch.File), b.(*File), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*Function)(nil), (*porch.Function)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha1_Function_To_porch_Function(a.(*Function), b.(*porch.Function), scope)
 	}); err != nil {
@@ -84,6 +114,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*FunctionResult)(nil), (*porch.FunctionResult)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_FunctionResult_To_porch_FunctionResult(a.(*FunctionResult), b.(*porch.FunctionResult), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*porch.FunctionResult)(nil), (*FunctionResult)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_porch_FunctionResult_To_v1alpha1_FunctionResult(a.(*porch.FunctionResult), b.(*FunctionResult), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*FunctionSpec)(nil), (*porch.FunctionSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha1_FunctionSpec_To_porch_FunctionSpec(a.(*FunctionSpec), b.(*porch.FunctionSpec), scope)
 	}); err != nil {
@@ -264,6 +304,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*ReadinessGate)(nil), (*porch.ReadinessGate)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ReadinessGate_To_porch_ReadinessGate(a.(*ReadinessGate), b.(*porch.ReadinessGate), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*porch.ReadinessGate)(nil), (*ReadinessGate)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_porch_ReadinessGate_To_v1alpha1_ReadinessGate(a.(*porch.ReadinessGate), b.(*ReadinessGate), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*RepositoryRef)(nil), (*porch.RepositoryRef)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha1_RepositoryRef_To_porch_RepositoryRef(a.(*RepositoryRef), b.(*porch.RepositoryRef), scope)
 	}); err != nil {
@@ -274,6 +324,46 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*ResourceIdentifier)(nil), (*porch.ResourceIdentifier)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ResourceIdentifier_To_porch_ResourceIdentifier(a.(*ResourceIdentifier), b.(*porch.ResourceIdentifier), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*porch.ResourceIdentifier)(nil), (*ResourceIdentifier)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_porch_ResourceIdentifier_To_v1alpha1_ResourceIdentifier(a.(*porch.ResourceIdentifier), b.(*ResourceIdentifier), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*Result)(nil), (*porch.Result)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_Result_To_porch_Result(a.(*Result), b.(*porch.Result), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*porch.Result)(nil), (*Result)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_porch_Result_To_v1alpha1_Result(a.(*porch.Result), b.(*Result), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ResultItem)(nil), (*porch.ResultItem)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ResultItem_To_porch_ResultItem(a.(*ResultItem), b.(*porch.ResultItem), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*porch.ResultItem)(nil), (*ResultItem)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_porch_ResultItem_To_v1alpha1_ResultItem(a.(*porch.ResultItem), b.(*ResultItem), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ResultList)(nil), (*porch.ResultList)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ResultList_To_porch_ResultList(a.(*ResultList), b.(*porch.ResultList), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*porch.ResultList)(nil), (*ResultList)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_porch_ResultList_To_v1alpha1_ResultList(a.(*porch.ResultList), b.(*ResultList), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*SecretRef)(nil), (*porch.SecretRef)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha1_SecretRef_To_porch_SecretRef(a.(*SecretRef), b.(*porch.SecretRef), scope)
 	}); err != nil {
@@ -317,6 +407,74 @@ func RegisterConversions(s *runtime.Scheme) error {
 	return nil
 }
 
+func autoConvert_v1alpha1_Condition_To_porch_Condition(in *Condition, out *porch.Condition, s conversion.Scope) error {
+	out.Type = in.Type
+	out.Status = porch.ConditionStatus(in.Status)
+	out.Reason = in.Reason
+	out.Message = in.Message
+	return nil
+}
+
+// Convert_v1alpha1_Condition_To_porch_Condition is an autogenerated conversion function.
+func Convert_v1alpha1_Condition_To_porch_Condition(in *Condition, out *porch.Condition, s conversion.Scope) error {
+	return autoConvert_v1alpha1_Condition_To_porch_Condition(in, out, s)
+}
+
+func autoConvert_porch_Condition_To_v1alpha1_Condition(in *porch.Condition, out *Condition, s conversion.Scope) error {
+	out.Type = in.Type
+	out.Status = ConditionStatus(in.Status)
+	out.Reason = in.Reason
+	out.Message = in.Message
+	return nil
+}
+
+// Convert_porch_Condition_To_v1alpha1_Condition is an autogenerated conversion function.
+func Convert_porch_Condition_To_v1alpha1_Condition(in *porch.Condition, out *Condition, s conversion.Scope) error {
+	return autoConvert_porch_Condition_To_v1alpha1_Condition(in, out, s)
+}
+
+func autoConvert_v1alpha1_Field_To_porch_Field(in *Field, out *porch.Field, s conversion.Scope) error {
+	out.Path = in.Path
+	return nil
+}
+
+// Convert_v1alpha1_Field_To_porch_Field is an autogenerated conversion function.
+func Convert_v1alpha1_Field_To_porch_Field(in *Field, out *porch.Field, s conversion.Scope) error {
+	return autoConvert_v1alpha1_Field_To_porch_Field(in, out, s)
+}
+
+func autoConvert_porch_Field_To_v1alpha1_Field(in *porch.Field, out *Field, s conversion.Scope) error {
+	out.Path = in.Path
+	return nil
+}
+
+// Convert_porch_Field_To_v1alpha1_Field is an autogenerated conversion function.
+func Convert_porch_Field_To_v1alpha1_Field(in *porch.Field, out *Field, s conversion.Scope) error {
+	return autoConvert_porch_Field_To_v1alpha1_Field(in, out, s)
+}
+
+func autoConvert_v1alpha1_File_To_porch_File(in *File, out *porch.File, s conversion.Scope) error {
+	out.Path = in.Path
+	out.Index = in.Index
+	return nil
+}
+
+// Convert_v1alpha1_File_To_porch_File is an autogenerated conversion function.
+func Convert_v1alpha1_File_To_porch_File(in *File, out *porch.File, s conversion.Scope) error {
+	return autoConvert_v1alpha1_File_To_porch_File(in, out, s)
+}
+
+func autoConvert_porch_File_To_v1alpha1_File(in *porch.File, out *File, s conversion.Scope) error {
+	out.Path = in.Path
+	out.Index = in.Index
+	return nil
+}
+
+// Convert_porch_File_To_v1alpha1_File is an autogenerated conversion function.
+func Convert_porch_File_To_v1alpha1_File(in *porch.File, out *File, s conversion.Scope) error {
+	return autoConvert_porch_File_To_v1alpha1_File(in, out, s)
+}
+
 func autoConvert_v1alpha1_Function_To_porch_Function(in *Function, out *porch.Function, s conversion.Scope) error {
 	out.ObjectMeta = in.ObjectMeta
 	if err := Convert_v1alpha1_FunctionSpec_To_porch_FunctionSpec(&in.Spec, &out.Spec, s); err != nil {
@@ -449,6 +607,32 @@ func Convert_porch_FunctionRef_To_v1alpha1_FunctionRef(in *porch.FunctionRef, ou
 	return autoConvert_porch_FunctionRef_To_v1alpha1_FunctionRef(in, out, s)
 }
 
+func autoConvert_v1alpha1_FunctionResult_To_porch_FunctionResult(in *FunctionResult, out *porch.FunctionResult, s conversion.Scope) error {
+	out.Image = in.Image
+	out.FunctionType = porch.FunctionType(in.FunctionType)
+	out.Passed = in.Passed
+	out.Message = in.Message
+	return nil
+}
+
+// Convert_v1alpha1_FunctionResult_To_porch_FunctionResult is an autogenerated conversion function.
+func Convert_v1alpha1_FunctionResult_To_porch_FunctionResult(in *FunctionResult, out *porch.FunctionResult, s conversion.Scope) error {
+	return autoConvert_v1alpha1_FunctionResult_To_porch_FunctionResult(in, out, s)
+}
+
+func autoConvert_porch_FunctionResult_To_v1alpha1_FunctionResult(in *porch.FunctionResult, out *FunctionResult, s conversion.Scope) error {
+	out.Image = in.Image
+	out.FunctionType = FunctionType(in.FunctionType)
+	out.Passed = in.Passed
+	out.Message = in.Message
+	return nil
+}
+
+// Convert_porch_FunctionResult_To_v1alpha1_FunctionResult is an autogenerated conversion function.
+func Convert_porch_FunctionResult_To_v1alpha1_FunctionResult(in *porch.FunctionResult, out *FunctionResult, s conversion.Scope) error {
+	return autoConvert_porch_FunctionResult_To_v1alpha1_FunctionResult(in, out, s)
+}
+
 func autoConvert_v1alpha1_FunctionSpec_To_porch_FunctionSpec(in *FunctionSpec, out *porch.FunctionSpec, s conversion.Scope) error {
 	out.Image = in.Image
 	if err := Convert_v1alpha1_RepositoryRef_To_porch_RepositoryRef(&in.RepositoryRef, &out.RepositoryRef, s); err != nil {
@@ -554,6 +738,7 @@ func Convert_porch_OciPackage_To_v1alpha1_OciPackage(in *porch.OciPackage, out *
 }
 
 func autoConvert_v1alpha1_PackageCloneTaskSpec_To_porch_PackageCloneTaskSpec(in *PackageCloneTaskSpec, out *porch.PackageCloneTaskSpec, s conversion.Scope) error {
+	out.Subpackage = in.Subpackage
 	if err := Convert_v1alpha1_UpstreamPackage_To_porch_UpstreamPackage(&in.Upstream, &out.Upstream, s); err != nil {
 		return err
 	}
@@ -567,6 +752,7 @@ func Convert_v1alpha1_PackageCloneTaskSpec_To_porch_PackageCloneTaskSpec(in *Pac
 }
 
 func autoConvert_porch_PackageCloneTaskSpec_To_v1alpha1_PackageCloneTaskSpec(in *porch.PackageCloneTaskSpec, out *PackageCloneTaskSpec, s conversion.Scope) error {
+	out.Subpackage = in.Subpackage
 	if err := Convert_porch_UpstreamPackage_To_v1alpha1_UpstreamPackage(&in.Upstream, &out.Upstream, s); err != nil {
 		return err
 	}
@@ -679,7 +865,17 @@ func Convert_porch_PackageRevision_To_v1alpha1_PackageRevision(in *porch.Package
 
 func autoConvert_v1alpha1_PackageRevisionList_To_porch_PackageRevisionList(in *PackageRevisionList, out *porch.PackageRevisionList, s conversion.Scope) error {
 	out.ListMeta = in.ListMeta
-	out.Items = *(*[]porch.PackageRevision)(unsafe.Pointer(&in.Items))
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]porch.PackageRevision, len(*in))
+		for i := range *in {
+			if err := Convert_v1alpha1_PackageRevision_To_porch_PackageRevision(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.Items = nil
+	}
 	return nil
 }
 
@@ -690,7 +886,17 @@ func Convert_v1alpha1_PackageRevisionList_To_porch_PackageRevisionList(in *Packa
 
 func autoConvert_porch_PackageRevisionList_To_v1alpha1_PackageRevisionList(in *porch.PackageRevisionList, out *PackageRevisionList, s conversion.Scope) error {
 	out.ListMeta = in.ListMeta
-	out.Items = *(*[]PackageRevision)(unsafe.Pointer(&in.Items))
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]PackageRevision, len(*in))
+		for i := range *in {
+			if err := Convert_porch_PackageRevision_To_v1alpha1_PackageRevision(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.Items = nil
+	}
 	return nil
 }
 
@@ -800,6 +1006,7 @@ func autoConvert_v1alpha1_PackageRevisionSpec_To_porch_PackageRevisionSpec(in *P
 	out.Parent = (*porch.ParentReference)(unsafe.Pointer(in.Parent))
 	out.Lifecycle = porch.PackageRevisionLifecycle(in.Lifecycle)
 	out.Tasks = *(*[]porch.Task)(unsafe.Pointer(&in.Tasks))
+	out.ReadinessGates = *(*[]porch.ReadinessGate)(unsafe.Pointer(&in.ReadinessGates))
 	return nil
 }
 
@@ -815,6 +1022,7 @@ func autoConvert_porch_PackageRevisionSpec_To_v1alpha1_PackageRevisionSpec(in *p
 	out.Parent = (*ParentReference)(unsafe.Pointer(in.Parent))
 	out.Lifecycle = PackageRevisionLifecycle(in.Lifecycle)
 	out.Tasks = *(*[]Task)(unsafe.Pointer(&in.Tasks))
+	out.ReadinessGates = *(*[]ReadinessGate)(unsafe.Pointer(&in.ReadinessGates))
 	return nil
 }
 
@@ -824,6 +1032,11 @@ func Convert_porch_PackageRevisionSpec_To_v1alpha1_PackageRevisionSpec(in *porch
 }
 
 func autoConvert_v1alpha1_PackageRevisionStatus_To_porch_PackageRevisionStatus(in *PackageRevisionStatus, out *porch.PackageRevisionStatus, s conversion.Scope) error {
+	out.RepositoryFunctionResults = *(*[]porch.FunctionResult)(unsafe.Pointer(&in.RepositoryFunctionResults))
+	out.Conditions = *(*[]porch.Condition)(unsafe.Pointer(&in.Conditions))
+	out.RenderResults = (*porch.ResultList)(unsafe.Pointer(in.RenderResults))
+	out.PublishedBy = in.PublishedBy
+	out.PublishTimestamp = in.PublishTimestamp
 	return nil
 }
 
@@ -833,6 +1046,11 @@ func Convert_v1alpha1_PackageRevisionStatus_To_porch_PackageRevisionStatus(in *P
 }
 
 func autoConvert_porch_PackageRevisionStatus_To_v1alpha1_PackageRevisionStatus(in *porch.PackageRevisionStatus, out *PackageRevisionStatus, s conversion.Scope) error {
+	out.RepositoryFunctionResults = *(*[]FunctionResult)(unsafe.Pointer(&in.RepositoryFunctionResults))
+	out.Conditions = *(*[]Condition)(unsafe.Pointer(&in.Conditions))
+	out.RenderResults = (*ResultList)(unsafe.Pointer(in.RenderResults))
+	out.PublishedBy = in.PublishedBy
+	out.PublishTimestamp = in.PublishTimestamp
 	return nil
 }
 
@@ -885,6 +1103,26 @@ func Convert_porch_PatchSpec_To_v1alpha1_PatchSpec(in *porch.PatchSpec, out *Pat
 	return autoConvert_porch_PatchSpec_To_v1alpha1_PatchSpec(in, out, s)
 }
 
+func autoConvert_v1alpha1_ReadinessGate_To_porch_ReadinessGate(in *ReadinessGate, out *porch.ReadinessGate, s conversion.Scope) error {
+	out.ConditionType = in.ConditionType
+	return nil
+}
+
+// Convert_v1alpha1_ReadinessGate_To_porch_ReadinessGate is an autogenerated conversion function.
+func Convert_v1alpha1_ReadinessGate_To_porch_ReadinessGate(in *ReadinessGate, out *porch.ReadinessGate, s conversion.Scope) error {
+	return autoConvert_v1alpha1_ReadinessGate_To_porch_ReadinessGate(in, out, s)
+}
+
+func autoConvert_porch_ReadinessGate_To_v1alpha1_ReadinessGate(in *porch.ReadinessGate, out *ReadinessGate, s conversion.Scope) error {
+	out.ConditionType = in.ConditionType
+	return nil
+}
+
+// Convert_porch_ReadinessGate_To_v1alpha1_ReadinessGate is an autogenerated conversion function.
+func Convert_porch_ReadinessGate_To_v1alpha1_ReadinessGate(in *porch.ReadinessGate, out *ReadinessGate, s conversion.Scope) error {
+	return autoConvert_porch_ReadinessGate_To_v1alpha1_ReadinessGate(in, out, s)
+}
+
 func autoConvert_v1alpha1_RepositoryRef_To_porch_RepositoryRef(in *RepositoryRef, out *porch.RepositoryRef, s conversion.Scope) error {
 	out.Name = in.Name
 	return nil
@@ -905,6 +1143,112 @@ func Convert_porch_RepositoryRef_To_v1alpha1_RepositoryRef(in *porch.RepositoryR
 	return autoConvert_porch_RepositoryRef_To_v1alpha1_RepositoryRef(in, out, s)
 }
 
+func autoConvert_v1alpha1_ResourceIdentifier_To_porch_ResourceIdentifier(in *ResourceIdentifier, out *porch.ResourceIdentifier, s conversion.Scope) error {
+	out.APIVersion = in.APIVersion
+	out.Kind = in.Kind
+	out.Name = in.Name
+	out.Namespace = in.Namespace
+	return nil
+}
+
+// Convert_v1alpha1_ResourceIdentifier_To_porch_ResourceIdentifier is an autogenerated conversion function.
+func Convert_v1alpha1_ResourceIdentifier_To_porch_ResourceIdentifier(in *ResourceIdentifier, out *porch.ResourceIdentifier, s conversion.Scope) error {
+	return autoConvert_v1alpha1_ResourceIdentifier_To_porch_ResourceIdentifier(in, out, s)
+}
+
+func autoConvert_porch_ResourceIdentifier_To_v1alpha1_ResourceIdentifier(in *porch.ResourceIdentifier, out *ResourceIdentifier, s conversion.Scope) error {
+	out.APIVersion = in.APIVersion
+	out.Kind = in.Kind
+	out.Name = in.Name
+	out.Namespace = in.Namespace
+	return nil
+}
+
+// Convert_porch_ResourceIdentifier_To_v1alpha1_ResourceIdentifier is an autogenerated conversion function.
+func Convert_porch_ResourceIdentifier_To_v1alpha1_ResourceIdentifier(in *porch.ResourceIdentifier, out *ResourceIdentifier, s conversion.Scope) error {
+	return autoConvert_porch_ResourceIdentifier_To_v1alpha1_ResourceIdentifier(in, out, s)
+}
+
+func autoConvert_v1alpha1_Result_To_porch_Result(in *Result, out *porch.Result, s conversion.Scope) error {
+	out.Image = in.Image
+	out.ExecPath = in.ExecPath
+	out.Stderr = in.Stderr
+	out.ExitCode = in.ExitCode
+	out.Results = *(*[]porch.ResultItem)(unsafe.Pointer(&in.Results))
+	return nil
+}
+
+// Convert_v1alpha1_Result_To_porch_Result is an autogenerated conversion function.
+func Convert_v1alpha1_Result_To_porch_Result(in *Result, out *porch.Result, s conversion.Scope) error {
+	return autoConvert_v1alpha1_Result_To_porch_Result(in, out, s)
+}
+
+func autoConvert_porch_Result_To_v1alpha1_Result(in *porch.Result, out *Result, s conversion.Scope) error {
+	out.Image = in.Image
+	out.ExecPath = in.ExecPath
+	out.Stderr = in.Stderr
+	out.ExitCode = in.ExitCode
+	out.Results = *(*[]ResultItem)(unsafe.Pointer(&in.Results))
+	return nil
+}
+
+// Convert_porch_Result_To_v1alpha1_Result is an autogenerated conversion function.
+func Convert_porch_Result_To_v1alpha1_Result(in *porch.Result, out *Result, s conversion.Scope) error {
+	return autoConvert_porch_Result_To_v1alpha1_Result(in, out, s)
+}
+
+func autoConvert_v1alpha1_ResultItem_To_porch_ResultItem(in *ResultItem, out *porch.ResultItem, s conversion.Scope) error {
+	out.Message = in.Message
+	out.Severity = in.Severity
+	out.ResourceRef = (*porch.ResourceIdentifier)(unsafe.Pointer(in.ResourceRef))
+	out.Field = (*porch.Field)(unsafe.Pointer(in.Field))
+	out.File = (*porch.File)(unsafe.Pointer(in.File))
+	out.Tags = *(*map[string]string)(unsafe.Pointer(&in.Tags))
+	return nil
+}
+
+// Convert_v1alpha1_ResultItem_To_porch_ResultItem is an autogenerated conversion function.
+func Convert_v1alpha1_ResultItem_To_porch_ResultItem(in *ResultItem, out *porch.ResultItem, s conversion.Scope) error {
+	return autoConvert_v1alpha1_ResultItem_To_porch_ResultItem(in, out, s)
+}
+
+func autoConvert_porch_ResultItem_To_v1alpha1_ResultItem(in *porch.ResultItem, out *ResultItem, s conversion.Scope) error {
+	out.Message = in.Message
+	out.Severity = in.Severity
+	out.ResourceRef = (*ResourceIdentifier)(unsafe.Pointer(in.ResourceRef))
+	out.Field = (*Field)(unsafe.Pointer(in.Field))
+	out.File = (*File)(unsafe.Pointer(in.File))
+	out.Tags = *(*map[string]string)(unsafe.Pointer(&in.Tags))
+	return nil
+}
+
+// Convert_porch_ResultItem_To_v1alpha1_ResultItem is an autogenerated conversion function.
+func Convert_porch_ResultItem_To_v1alpha1_ResultItem(in *porch.ResultItem, out *ResultItem, s conversion.Scope) error {
+	return autoConvert_porch_ResultItem_To_v1alpha1_ResultItem(in, out, s)
+}
+
+func autoConvert_v1alpha1_ResultList_To_porch_ResultList(in *ResultList, out *porch.ResultList, s conversion.Scope) error {
+	out.ExitCode = in.ExitCode
+	out.Items = *(*[]porch.Result)(unsafe.Pointer(&in.Items))
+	return nil
+}
+
+// Convert_v1alpha1_ResultList_To_porch_ResultList is an autogenerated conversion function.
+func Convert_v1alpha1_ResultList_To_porch_ResultList(in *ResultList, out *porch.ResultList, s conversion.Scope) error {
+	return autoConvert_v1alpha1_ResultList_To_porch_ResultList(in, out, s)
+}
+
+func autoConvert_porch_ResultList_To_v1alpha1_ResultList(in *porch.ResultList, out *ResultList, s conversion.Scope) error {
+	out.ExitCode = in.ExitCode
+	out.Items = *(*[]Result)(unsafe.Pointer(&in.Items))
+	return nil
+}
+
+// Convert_porch_ResultList_To_v1alpha1_ResultList is an autogenerated conversion function.
+func Convert_porch_ResultList_To_v1alpha1_ResultList(in *porch.ResultList, out *ResultList, s conversion.Scope) error {
+	return autoConvert_porch_ResultList_To_v1alpha1_ResultList(in, out, s)
+}
+
 func autoConvert_v1alpha1_SecretRef_To_porch_SecretRef(in *SecretRef, out *porch.SecretRef, s conversion.Scope) error {
 	out.Name = in.Name
 	return nil
@@ -84,6 +84,11 @@ type RepositorySpec struct {
 	// Based on the Kubernetest Admission Controllers (https://kubernetes.io/docs/reference/access-authn-authz/admission-controllers/). The functions will be evaluated
 	// in the order specified in the list.
 	Validators []FunctionEval `json:"validators,omitempty"`
+
+	// `SyncInterval` is how often Porch polls the repository for changes made outside of Porch.
+	// If unspecified, defaults to 1 minute. Changes can also be picked up immediately by sending
+	// push notifications to the Porch webhook.
+	SyncInterval *metav1.Duration `json:"syncInterval,omitempty"`
 }
 
 // GitRepository describes a Git repository.
@@ -162,6 +167,10 @@ const (
 type RepositoryStatus struct {
 	// Conditions describes the reconciliation state of the object.
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// LastSyncTime is the time the repository was last synchronized.
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+	// LastSyncError is the error encountered by the last synchronization of the repository, if any.
+	LastSyncError string `json:"lastSyncError,omitempty"`
 }
 
 //+kubebuilder:object:root=true
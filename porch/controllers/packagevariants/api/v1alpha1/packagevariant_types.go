@@ -0,0 +1,134 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ConfigInjectionAnnotation marks a resource of the upstream package as a
+	// point where an in-cluster object is injected. The value is either
+	// "required" or "optional".
+	ConfigInjectionAnnotation = "kpt.dev/config-injection"
+	// InjectedResourceNameAnnotation records the name of the in-cluster
+	// object which was injected into a resource of the downstream package.
+	InjectedResourceNameAnnotation = "kpt.dev/injected-resource-name"
+
+	ConfigInjectionRequired = "required"
+	ConfigInjectionOptional = "optional"
+)
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Upstream",type=string,JSONPath=`.status.upstream`
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=='Ready')].status`
+//+kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.status.conditions[?(@.type=='Ready')].reason`
+
+// PackageVariant declares a downstream package which is cloned from an
+// upstream package and kept up to date with its published revisions.
+//
+// When a new revision of the upstream package is published, the controller
+// creates (or reuses) a draft of the downstream package, updates it to the
+// new upstream revision, injects the selected in-cluster objects and
+// proposes it. Approving the proposal is left to the users.
+type PackageVariant struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PackageVariantSpec   `json:"spec,omitempty"`
+	Status PackageVariantStatus `json:"status,omitempty"`
+}
+
+// PackageVariantSpec defines the desired state of PackageVariant
+type PackageVariantSpec struct {
+	Upstream   *Upstream   `json:"upstream,omitempty"`
+	Downstream *Downstream `json:"downstream,omitempty"`
+
+	// Injectors select the in-cluster objects which are injected into the
+	// resources of the downstream package marked with the
+	// kpt.dev/config-injection annotation.
+	Injectors []InjectionSelector `json:"injectors,omitempty"`
+
+	// PackageContext sets data in the package context ConfigMap
+	// (package-context.yaml) of the downstream package.
+	PackageContext *PackageContext `json:"packageContext,omitempty"`
+}
+
+// Upstream identifies the upstream package in a registered repository.
+type Upstream struct {
+	Repo    string `json:"repo,omitempty"`
+	Package string `json:"package,omitempty"`
+	// Revision of the upstream package; the latest published revision if empty.
+	Revision string `json:"revision,omitempty"`
+}
+
+// Downstream identifies the downstream package in a registered repository.
+type Downstream struct {
+	Repo    string `json:"repo,omitempty"`
+	Package string `json:"package,omitempty"`
+}
+
+// InjectionSelector selects the in-cluster object, in the namespace of the
+// PackageVariant, to inject into the matching resources of the package. The
+// group, version and kind default to those of the resource of the package.
+// Only ConfigMaps, and the kinds allowed by the operator of the controller,
+// can be injected.
+type InjectionSelector struct {
+	Group   *string `json:"group,omitempty"`
+	Version *string `json:"version,omitempty"`
+	Kind    *string `json:"kind,omitempty"`
+	Name    string  `json:"name"`
+}
+
+// PackageContext is the data set in the package context of the downstream
+// package. The "name" key is reserved for the name of the package.
+type PackageContext struct {
+	Data map[string]string `json:"data,omitempty"`
+}
+
+// PackageVariantStatus defines the observed state of PackageVariant
+type PackageVariantStatus struct {
+	// Upstream is the name of the upstream package revision the downstream
+	// package is kept up to date with.
+	Upstream string `json:"upstream,omitempty"`
+
+	// DownstreamTargets are the downstream package revisions created or
+	// updated by the controller.
+	DownstreamTargets []DownstreamTarget `json:"downstreamTargets,omitempty"`
+
+	// Conditions describes the reconciliation state of the object.
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// DownstreamTarget is a package revision of the downstream package.
+type DownstreamTarget struct {
+	Name      string `json:"name,omitempty"`
+	Revision  string `json:"revision,omitempty"`
+	Lifecycle string `json:"lifecycle,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// PackageVariantList contains a list of PackageVariant
+type PackageVariantList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PackageVariant `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PackageVariant{}, &PackageVariantList{})
+}
@@ -0,0 +1,154 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PackageVariantSetLabel labels the PackageVariants generated by a
+// PackageVariantSet with the name of the set.
+const PackageVariantSetLabel = "config.porch.kpt.dev/packagevariantset"
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=='Ready')].status`
+//+kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.status.conditions[?(@.type=='Ready')].reason`
+
+// PackageVariantSet generates a PackageVariant for each of its targets, so
+// one upstream package is cloned into many downstream repositories.
+type PackageVariantSet struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PackageVariantSetSpec   `json:"spec,omitempty"`
+	Status PackageVariantSetStatus `json:"status,omitempty"`
+}
+
+// PackageVariantSetSpec defines the desired state of PackageVariantSet
+type PackageVariantSetSpec struct {
+	Upstream *Upstream `json:"upstream,omitempty"`
+
+	// Targets select the downstream packages; each target generates a
+	// PackageVariant per downstream package.
+	Targets []Target `json:"targets,omitempty"`
+
+	// AdoptionPolicy controls whether existing PackageVariants of the
+	// downstream packages are taken over by the set.
+	AdoptionPolicy AdoptionPolicy `json:"adoptionPolicy,omitempty"`
+
+	// DeletionPolicy controls what happens to the generated PackageVariants
+	// when their target goes away, or the set is deleted.
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=adoptNone;adoptExisting
+type AdoptionPolicy string
+
+const (
+	// AdoptionPolicyAdoptNone leaves existing PackageVariants alone; the
+	// conflicting variants are reported in the status. This is the default.
+	AdoptionPolicyAdoptNone AdoptionPolicy = "adoptNone"
+	// AdoptionPolicyAdoptExisting takes over the existing PackageVariants
+	// which aren't controlled by another object.
+	AdoptionPolicyAdoptExisting AdoptionPolicy = "adoptExisting"
+)
+
+// +kubebuilder:validation:Enum=delete;orphan
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyDelete deletes the PackageVariants. This is the default.
+	DeletionPolicyDelete DeletionPolicy = "delete"
+	// DeletionPolicyOrphan releases the PackageVariants, leaving them in place.
+	DeletionPolicyOrphan DeletionPolicy = "orphan"
+)
+
+// Target selects downstream packages in one of three ways: a list of
+// repositories, a label selector over Repository objects, or a label
+// selector over arbitrary objects, which generates a variant per object.
+type Target struct {
+	Repositories       []RepositoryTarget    `json:"repositories,omitempty"`
+	RepositorySelector *metav1.LabelSelector `json:"repositorySelector,omitempty"`
+	ObjectSelector     *ObjectSelector       `json:"objectSelector,omitempty"`
+
+	// Template customizes the PackageVariants generated for the target.
+	Template *PackageVariantTemplate `json:"template,omitempty"`
+}
+
+// RepositoryTarget is a downstream repository.
+type RepositoryTarget struct {
+	Name string `json:"name"`
+	// PackageNames are the names of the downstream packages in the
+	// repository; the name of the upstream package if empty.
+	PackageNames []string `json:"packageNames,omitempty"`
+}
+
+// ObjectSelector selects objects of the kind, in the namespace of the
+// PackageVariantSet. Only ConfigMaps, and the kinds allowed by the operator of
+// the controller, can be selected. The template of the target must set the
+// downstream repository.
+type ObjectSelector struct {
+	APIVersion string                `json:"apiVersion"`
+	Kind       string                `json:"kind"`
+	Selector   *metav1.LabelSelector `json:"selector,omitempty"`
+}
+
+// PackageVariantTemplate customizes the generated PackageVariants. The
+// string values are Go templates, evaluated with:
+//
+//	.upstream    the upstream package (repo, package and revision)
+//	.repository  the target Repository object, for repository targets
+//	.target      the target object; the Repository for repository targets
+//	.packageName the default name of the downstream package
+type PackageVariantTemplate struct {
+	Downstream     *Downstream         `json:"downstream,omitempty"`
+	Injectors      []InjectionSelector `json:"injectors,omitempty"`
+	PackageContext *PackageContext     `json:"packageContext,omitempty"`
+	Labels         map[string]string   `json:"labels,omitempty"`
+	Annotations    map[string]string   `json:"annotations,omitempty"`
+}
+
+// PackageVariantSetStatus defines the observed state of PackageVariantSet
+type PackageVariantSetStatus struct {
+	// Variants are the PackageVariants of the targets of the set.
+	Variants []VariantStatus `json:"variants,omitempty"`
+
+	// Conditions describes the reconciliation state of the object.
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// VariantStatus is the status of the PackageVariant of a downstream package.
+type VariantStatus struct {
+	Name    string                 `json:"name,omitempty"`
+	Repo    string                 `json:"repo,omitempty"`
+	Package string                 `json:"package,omitempty"`
+	Ready   metav1.ConditionStatus `json:"ready,omitempty"`
+	Reason  string                 `json:"reason,omitempty"`
+	Message string                 `json:"message,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// PackageVariantSetList contains a list of PackageVariantSet
+type PackageVariantSetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PackageVariantSet `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PackageVariantSet{}, &PackageVariantSetList{})
+}
@@ -0,0 +1,183 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package packagevariant
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	api "github.com/GoogleContainerTools/kpt/porch/controllers/packagevariants/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// objectGetter returns the in-cluster object with the given kind and name,
+// or nil if the object doesn't exist.
+type objectGetter func(gvk schema.GroupVersionKind, name string) (*unstructured.Unstructured, error)
+
+// injectResources replaces the content of the package resources marked with
+// the config injection annotation with the content of the in-cluster objects
+// selected by the injectors. It returns the files which were changed.
+func injectResources(resources map[string]string, injectors []api.InjectionSelector, get objectGetter) (map[string]string, error) {
+	// Visit the files in a stable order, so errors are reported consistently.
+	var files []string
+	for file := range resources {
+		switch strings.ToLower(path.Ext(file)) {
+		case ".yaml", ".yml":
+			files = append(files, file)
+		}
+	}
+	sort.Strings(files)
+
+	changed := map[string]string{}
+	for _, file := range files {
+		contents := resources[file]
+		nodes, err := (&kio.ByteReader{
+			Reader:            strings.NewReader(contents),
+			PreserveSeqIndent: true,
+		}).Read()
+		if err != nil {
+			// Not all yaml files are resources; skip them.
+			continue
+		}
+
+		fileChanged := false
+		for _, node := range nodes {
+			injected, err := injectResource(node, injectors, get)
+			if err != nil {
+				return nil, fmt.Errorf("cannot inject into %s: %w", file, err)
+			}
+			fileChanged = fileChanged || injected
+		}
+		if !fileChanged {
+			continue
+		}
+
+		var out strings.Builder
+		if err := (kio.ByteWriter{Writer: &out}).Write(nodes); err != nil {
+			return nil, fmt.Errorf("cannot write %s: %w", file, err)
+		}
+		if out.String() != contents {
+			changed[file] = out.String()
+		}
+	}
+	return changed, nil
+}
+
+// injectResource injects the selected in-cluster object into the resource if
+// the resource is an injection point. It returns true if the resource was
+// injected.
+func injectResource(node *yaml.RNode, injectors []api.InjectionSelector, get objectGetter) (bool, error) {
+	mode, found := node.GetAnnotations()[api.ConfigInjectionAnnotation]
+	if !found {
+		return false, nil
+	}
+	if mode != api.ConfigInjectionRequired && mode != api.ConfigInjectionOptional {
+		return false, fmt.Errorf("invalid value %q of annotation %s on %s %s", mode, api.ConfigInjectionAnnotation, node.GetKind(), node.GetName())
+	}
+	required := mode == api.ConfigInjectionRequired
+
+	gv, err := schema.ParseGroupVersion(node.GetApiVersion())
+	if err != nil {
+		return false, err
+	}
+	gvk := gv.WithKind(node.GetKind())
+
+	injector := findInjector(gvk, injectors)
+	if injector == nil {
+		if required {
+			return false, fmt.Errorf("no injector selects %s %s, which requires injection", gvk.Kind, node.GetName())
+		}
+		return false, nil
+	}
+	if injector.Version != nil {
+		gvk.Version = *injector.Version
+	}
+
+	obj, err := get(gvk, injector.Name)
+	if err != nil {
+		return false, err
+	}
+	if obj == nil {
+		if required {
+			return false, fmt.Errorf("%s %s to inject into %s does not exist", gvk.Kind, injector.Name, node.GetName())
+		}
+		return false, nil
+	}
+
+	// The resource keeps its identity; everything else comes from the object.
+	fields, err := node.Fields()
+	if err != nil {
+		return false, err
+	}
+	for _, field := range fields {
+		if isIdentityField(field) {
+			continue
+		}
+		if err := node.PipeE(yaml.Clear(field)); err != nil {
+			return false, err
+		}
+	}
+	var objFields []string
+	for field := range obj.Object {
+		if !isIdentityField(field) && field != "status" {
+			objFields = append(objFields, field)
+		}
+	}
+	sort.Strings(objFields)
+	for _, field := range objFields {
+		valueNode, err := yaml.FromMap(map[string]interface{}{field: obj.Object[field]})
+		if err != nil {
+			return false, err
+		}
+		if err := node.PipeE(yaml.SetField(field, valueNode.Field(field).Value)); err != nil {
+			return false, err
+		}
+	}
+	if err := node.PipeE(
+		yaml.LookupCreate(yaml.MappingNode, yaml.MetadataField, yaml.AnnotationsField),
+		yaml.SetField(api.InjectedResourceNameAnnotation, yaml.NewScalarRNode(injector.Name)),
+	); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// findInjector returns the first injector selecting objects of the kind.
+func findInjector(gvk schema.GroupVersionKind, injectors []api.InjectionSelector) *api.InjectionSelector {
+	for i := range injectors {
+		injector := &injectors[i]
+		if injector.Group != nil && *injector.Group != gvk.Group {
+			continue
+		}
+		if injector.Kind != nil && *injector.Kind != gvk.Kind {
+			continue
+		}
+		return injector
+	}
+	return nil
+}
+
+func isIdentityField(field string) bool {
+	switch field {
+	case yaml.APIVersionField, yaml.KindField, yaml.MetadataField:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,154 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package packagevariant
+
+import (
+	"strings"
+	"testing"
+
+	porchapi "github.com/GoogleContainerTools/kpt/porch/api/porch/v1alpha1"
+	api "github.com/GoogleContainerTools/kpt/porch/controllers/packagevariants/api/v1alpha1"
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestInjectResources(t *testing.T) {
+	objects := map[string]*unstructured.Unstructured{
+		"team-a": {
+			Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata": map[string]interface{}{
+					"name":      "team-a",
+					"namespace": "default",
+				},
+				"data": map[string]interface{}{
+					"team": "team-a",
+				},
+			},
+		},
+	}
+	get := func(gvk schema.GroupVersionKind, name string) (*unstructured.Unstructured, error) {
+		if gvk.Kind != "ConfigMap" {
+			return nil, nil
+		}
+		return objects[name], nil
+	}
+
+	configMap := func(mode string) string {
+		return `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  annotations:
+    kpt.dev/config-injection: ` + mode + `
+data:
+  team: placeholder
+`
+	}
+
+	for _, tc := range []struct {
+		name      string
+		resources map[string]string
+		injectors []api.InjectionSelector
+		want      map[string]string
+		wantErr   string
+	}{
+		{
+			name: "required",
+			resources: map[string]string{
+				"Kptfile":     "apiVersion: kpt.dev/v1\nkind: Kptfile\nmetadata:\n  name: example\n",
+				"config.yaml": configMap("required"),
+				"README.md":   "# example\n",
+			},
+			injectors: []api.InjectionSelector{{Name: "team-a"}},
+			want: map[string]string{
+				"config.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  annotations:
+    kpt.dev/config-injection: required
+    kpt.dev/injected-resource-name: team-a
+data:
+  team: team-a
+`,
+			},
+		},
+		{
+			name:      "optional without injector",
+			resources: map[string]string{"config.yaml": configMap("optional")},
+			want:      map[string]string{},
+		},
+		{
+			name:      "optional without object",
+			resources: map[string]string{"config.yaml": configMap("optional")},
+			injectors: []api.InjectionSelector{{Name: "team-b"}},
+			want:      map[string]string{},
+		},
+		{
+			name:      "required without injector",
+			resources: map[string]string{"config.yaml": configMap("required")},
+			wantErr:   "no injector selects ConfigMap config",
+		},
+		{
+			name:      "required without object",
+			resources: map[string]string{"config.yaml": configMap("required")},
+			injectors: []api.InjectionSelector{{Name: "team-b"}},
+			wantErr:   "ConfigMap team-b to inject into config does not exist",
+		},
+		{
+			name:      "invalid annotation",
+			resources: map[string]string{"config.yaml": configMap("always")},
+			wantErr:   `invalid value "always"`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := injectResources(tc.resources, tc.injectors, get)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("injectResources() error = %v, want error containing %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("injectResources() failed: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("injectResources() returned unexpected changes (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestNextRevision(t *testing.T) {
+	for _, tc := range []struct {
+		revisions []string
+		want      string
+	}{
+		{nil, "v1"},
+		{[]string{"v1"}, "v2"},
+		{[]string{"v1", "v3", "draft"}, "v4"},
+	} {
+		var downstreams []porchapi.PackageRevision
+		for _, r := range tc.revisions {
+			downstreams = append(downstreams, porchapi.PackageRevision{Spec: porchapi.PackageRevisionSpec{Revision: r}})
+		}
+		if got := nextRevision(downstreams); got != tc.want {
+			t.Errorf("nextRevision(%v) = %q, want %q", tc.revisions, got, tc.want)
+		}
+	}
+}
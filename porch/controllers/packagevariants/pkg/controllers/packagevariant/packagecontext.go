@@ -0,0 +1,73 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package packagevariant
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/GoogleContainerTools/kpt/internal/builtins"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+)
+
+// packageContextNameKey is the key of the package context data which holds
+// the name of the package. It is maintained by the package context generator.
+const packageContextNameKey = "name"
+
+// setPackageContext sets the data in the package context ConfigMap of the
+// package, creating package-context.yaml if the package has none. It returns
+// the files which were changed.
+func setPackageContext(resources map[string]string, packageName string, data map[string]string) (map[string]string, error) {
+	if len(data) == 0 {
+		return map[string]string{}, nil
+	}
+
+	contents, found := resources[builtins.PkgContextFile]
+	if !found {
+		contents = builtins.AbstractPkgContext()
+	}
+	nodes, err := (&kio.ByteReader{
+		Reader:            strings.NewReader(contents),
+		PreserveSeqIndent: true,
+	}).Read()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", builtins.PkgContextFile, err)
+	}
+	if len(nodes) != 1 || nodes[0].GetKind() != "ConfigMap" {
+		return nil, fmt.Errorf("%s must contain a single ConfigMap", builtins.PkgContextFile)
+	}
+	cm := nodes[0]
+
+	cmData := cm.GetDataMap()
+	if cmData == nil {
+		cmData = map[string]string{}
+	}
+	if !found {
+		cmData[packageContextNameKey] = packageName
+	}
+	for key, value := range data {
+		cmData[key] = value
+	}
+	cm.SetDataMap(cmData)
+
+	var out strings.Builder
+	if err := (kio.ByteWriter{Writer: &out}).Write(nodes); err != nil {
+		return nil, fmt.Errorf("cannot write %s: %w", builtins.PkgContextFile, err)
+	}
+	if out.String() == contents && found {
+		return map[string]string{}, nil
+	}
+	return map[string]string{builtins.PkgContextFile: out.String()}, nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package packagevariant
+
+import (
+	"testing"
+
+	"github.com/GoogleContainerTools/kpt/internal/builtins"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSetPackageContext(t *testing.T) {
+	const existing = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: kptfile.kpt.dev
+  annotations:
+    config.kubernetes.io/local-config: "true"
+data:
+  name: team-a
+`
+
+	for _, tc := range []struct {
+		name      string
+		resources map[string]string
+		data      map[string]string
+		want      map[string]string
+	}{
+		{
+			name:      "no data",
+			resources: map[string]string{builtins.PkgContextFile: existing},
+			want:      map[string]string{},
+		},
+		{
+			name:      "existing",
+			resources: map[string]string{builtins.PkgContextFile: existing},
+			data:      map[string]string{"cluster": "us-east"},
+			want: map[string]string{
+				builtins.PkgContextFile: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: kptfile.kpt.dev
+  annotations:
+    config.kubernetes.io/local-config: "true"
+data:
+  cluster: us-east
+  name: team-a
+`,
+			},
+		},
+		{
+			name: "unchanged",
+			resources: map[string]string{builtins.PkgContextFile: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: kptfile.kpt.dev
+  annotations:
+    config.kubernetes.io/local-config: "true"
+data:
+  cluster: us-east
+  name: team-a
+`},
+			data: map[string]string{"cluster": "us-east"},
+			want: map[string]string{},
+		},
+		{
+			name:      "missing",
+			resources: map[string]string{},
+			data:      map[string]string{"cluster": "us-east"},
+			want: map[string]string{
+				builtins.PkgContextFile: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: kptfile.kpt.dev
+  annotations:
+    config.kubernetes.io/local-config: "true"
+data:
+  cluster: us-east
+  name: team-a
+`,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := setPackageContext(tc.resources, "team-a", tc.data)
+			if err != nil {
+				t.Fatalf("setPackageContext() failed: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("setPackageContext() returned unexpected changes (-want, +got): %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,482 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package packagevariant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	porchapi "github.com/GoogleContainerTools/kpt/porch/api/porch/v1alpha1"
+	api "github.com/GoogleContainerTools/kpt/porch/controllers/packagevariants/api/v1alpha1"
+	"github.com/GoogleContainerTools/kpt/porch/controllers/packagevariants/pkg/kinds"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	ConditionTypeStalled = "Stalled" // The package variant cannot be reconciled until it, or the packages, change.
+	ConditionTypeReady   = "Ready"   // The downstream package is up to date with the upstream package.
+)
+
+// PackageVariantReconciler reconciles PackageVariant objects
+type PackageVariantReconciler struct {
+	client.Client
+
+	// apiReader reads package revisions directly from the porch server, so the
+	// reconciler always sees the package revisions it has just changed.
+	apiReader client.Reader
+
+	// AllowedKinds are the kinds of in-cluster objects, besides ConfigMaps,
+	// which may be injected into packages.
+	AllowedKinds kinds.Allowed
+}
+
+//+kubebuilder:rbac:groups=config.porch.kpt.dev,resources=packagevariants,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=config.porch.kpt.dev,resources=packagevariants/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=porch.kpt.dev,resources=packagerevisions,verbs=get;list;watch;create;update;patch
+//+kubebuilder:rbac:groups=porch.kpt.dev,resources=packagerevisionresources,verbs=get;update;patch
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get
+
+// stalledError is an error which retrying the reconciliation won't fix.
+type stalledError struct {
+	reason  string
+	message string
+}
+
+func (e *stalledError) Error() string {
+	return e.message
+}
+
+func stalled(reason, format string, args ...interface{}) error {
+	return &stalledError{reason: reason, message: fmt.Sprintf(format, args...)}
+}
+
+// Reconcile implements the main kubernetes reconciliation loop.
+func (r *PackageVariantReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var pv api.PackageVariant
+	if err := r.Get(ctx, req.NamespacedName, &pv); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !pv.ObjectMeta.DeletionTimestamp.IsZero() {
+		// The downstream package revisions are left in place.
+		return ctrl.Result{}, nil
+	}
+
+	downstream, err := r.reconcile(ctx, &pv)
+
+	var stalledErr *stalledError
+	switch {
+	case errors.As(err, &stalledErr):
+		setCondition(&pv, ConditionTypeStalled, metav1.ConditionTrue, stalledErr.reason, stalledErr.message)
+		setCondition(&pv, ConditionTypeReady, metav1.ConditionFalse, stalledErr.reason, stalledErr.message)
+		// Changes to the package variant or the package revisions trigger
+		// the next reconciliation.
+		err = nil
+	case err != nil:
+		setCondition(&pv, ConditionTypeStalled, metav1.ConditionFalse, "Reconciling", "")
+		setCondition(&pv, ConditionTypeReady, metav1.ConditionFalse, "Error", err.Error())
+	default:
+		setCondition(&pv, ConditionTypeStalled, metav1.ConditionFalse, "Reconciled", "")
+		setCondition(&pv, ConditionTypeReady, metav1.ConditionTrue, "UpToDate",
+			fmt.Sprintf("%s (%s) is up to date with upstream %s", downstream.Name, downstream.Spec.Lifecycle, pv.Status.Upstream))
+	}
+
+	if statusErr := r.Status().Update(ctx, &pv); statusErr != nil {
+		klog.Errorf("failed to update status of PackageVariant %s: %v", req.NamespacedName, statusErr)
+		if err == nil {
+			err = statusErr
+		}
+	}
+	return ctrl.Result{}, err
+}
+
+func setCondition(pv *api.PackageVariant, conditionType string, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&pv.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: pv.Generation,
+	})
+}
+
+// reconcile makes sure the latest revision of the downstream package is
+// cloned from the upstream package revision, creating and proposing a new
+// revision if it isn't. It returns the latest downstream revision.
+func (r *PackageVariantReconciler) reconcile(ctx context.Context, pv *api.PackageVariant) (*porchapi.PackageRevision, error) {
+	if err := validatePackageVariant(pv); err != nil {
+		return nil, err
+	}
+
+	var prList porchapi.PackageRevisionList
+	if err := r.apiReader.List(ctx, &prList, client.InNamespace(pv.Namespace)); err != nil {
+		return nil, fmt.Errorf("cannot list package revisions: %w", err)
+	}
+
+	upstream := findUpstream(prList.Items, pv.Spec.Upstream)
+	if upstream == nil {
+		return nil, stalled("UpstreamNotFound", "no published revision %q of package %s in repository %s",
+			pv.Spec.Upstream.Revision, pv.Spec.Upstream.Package, pv.Spec.Upstream.Repo)
+	}
+	pv.Status.Upstream = upstream.Name
+
+	downstreams := findDownstreams(prList.Items, pv.Spec.Downstream)
+	defer func() {
+		pv.Status.DownstreamTargets = downstreamTargets(downstreams)
+	}()
+
+	// The changes are made to a single draft (or proposed) revision.
+	draft := findDraft(downstreams)
+	changed := false
+	if draft == nil {
+		published := findLatestPublished(downstreams)
+		if published != nil && clonedFrom(published) == upstream.Name {
+			return published, nil
+		}
+
+		draft = &porchapi.PackageRevision{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: pv.Namespace,
+			},
+			Spec: porchapi.PackageRevisionSpec{
+				PackageName:    pv.Spec.Downstream.Package,
+				Revision:       nextRevision(downstreams),
+				RepositoryName: pv.Spec.Downstream.Repo,
+				Lifecycle:      porchapi.PackageRevisionLifecycleDraft,
+			},
+		}
+		if published == nil {
+			draft.Spec.Tasks = []porchapi.Task{{
+				Type: porchapi.TaskTypeClone,
+				Clone: &porchapi.PackageCloneTaskSpec{
+					Upstream: porchapi.UpstreamPackage{
+						UpstreamRef: &porchapi.PackageRevisionRef{Name: upstream.Name},
+					},
+				},
+			}}
+		} else {
+			// Copy the latest revision rather than replaying its history. The
+			// clone task records the upstream the copy was cloned from, and is
+			// updated to the new upstream revision below.
+			clone := lastCloneTask(published)
+			if clone == nil || clone.Upstream.UpstreamRef == nil {
+				return nil, stalled("DownstreamNotCloned", "package revision %s was not cloned from a registered upstream package", published.Name)
+			}
+			draft.Spec.Tasks = []porchapi.Task{
+				{
+					Type:  porchapi.TaskTypeClone,
+					Clone: clone.DeepCopy(),
+				},
+				{
+					Type: porchapi.TaskTypeEdit,
+					Edit: &porchapi.PackageEditTaskSpec{
+						Source: &porchapi.PackageRevisionRef{Name: published.Name},
+					},
+				},
+			}
+		}
+		if err := r.Create(ctx, draft); err != nil {
+			return nil, fmt.Errorf("cannot create revision %s of package %s: %w", draft.Spec.Revision, draft.Spec.PackageName, err)
+		}
+		klog.Infof("PackageVariant %s/%s created package revision %s", pv.Namespace, pv.Name, draft.Name)
+		downstreams = append(downstreams, *draft)
+		changed = true
+	}
+
+	if clonedFrom(draft) != upstream.Name {
+		if draft.Spec.Lifecycle == porchapi.PackageRevisionLifecycleProposed {
+			// Only drafts can be updated; withdraw the proposal first.
+			draft.Spec.Lifecycle = porchapi.PackageRevisionLifecycleDraft
+			if err := r.updateDownstream(ctx, draft, downstreams); err != nil {
+				return nil, err
+			}
+		}
+
+		clone := lastCloneTask(draft)
+		if clone == nil || clone.Upstream.UpstreamRef == nil {
+			return nil, stalled("DownstreamNotCloned", "package revision %s was not cloned from a registered upstream package", draft.Name)
+		}
+		// The engine merges the changes between the upstream revisions into the draft.
+		clone.Upstream.UpstreamRef.Name = upstream.Name
+		if err := r.updateDownstream(ctx, draft, downstreams); err != nil {
+			return nil, err
+		}
+		changed = true
+	}
+
+	if draft.Spec.Lifecycle == porchapi.PackageRevisionLifecycleDraft {
+		injected, err := r.inject(ctx, pv, draft)
+		if err != nil {
+			return nil, err
+		}
+		if injected {
+			// Updating the resources adds a task to the package revision.
+			if err := r.apiReader.Get(ctx, client.ObjectKeyFromObject(draft), draft); err != nil {
+				return nil, fmt.Errorf("cannot get package revision %s: %w", draft.Name, err)
+			}
+		}
+
+		// Drafts which weren't changed may have been rejected; they are
+		// proposed again only when the upstream changes.
+		if changed || injected {
+			draft.Spec.Lifecycle = porchapi.PackageRevisionLifecycleProposed
+			if err := r.updateDownstream(ctx, draft, downstreams); err != nil {
+				return nil, err
+			}
+			klog.Infof("PackageVariant %s/%s proposed package revision %s", pv.Namespace, pv.Name, draft.Name)
+		}
+	}
+	return draft, nil
+}
+
+// updateDownstream updates the downstream package revision, and the copy of it in downstreams.
+func (r *PackageVariantReconciler) updateDownstream(ctx context.Context, pr *porchapi.PackageRevision, downstreams []porchapi.PackageRevision) error {
+	if err := r.Update(ctx, pr); err != nil {
+		return fmt.Errorf("cannot update package revision %s: %w", pr.Name, err)
+	}
+	for i := range downstreams {
+		if downstreams[i].Name == pr.Name {
+			downstreams[i] = *pr
+		}
+	}
+	return nil
+}
+
+// inject injects the in-cluster objects selected by the injectors, and the
+// package context, of the package variant into the package revision. It
+// returns true if the resources of the package revision changed.
+func (r *PackageVariantReconciler) inject(ctx context.Context, pv *api.PackageVariant, pr *porchapi.PackageRevision) (bool, error) {
+	var prr porchapi.PackageRevisionResources
+	if err := r.apiReader.Get(ctx, client.ObjectKeyFromObject(pr), &prr); err != nil {
+		return false, fmt.Errorf("cannot get resources of package revision %s: %w", pr.Name, err)
+	}
+
+	changed, err := injectResources(prr.Spec.Resources, pv.Spec.Injectors, func(gvk schema.GroupVersionKind, name string) (*unstructured.Unstructured, error) {
+		if !r.AllowedKinds.Allows(gvk.GroupKind()) {
+			return nil, stalled("InjectionNotAllowed", "injection of %s objects is not allowed", gvk.GroupKind())
+		}
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(gvk)
+		if err := r.Get(ctx, types.NamespacedName{Namespace: pv.Namespace, Name: name}, obj); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("cannot get %s %s: %w", gvk.Kind, name, err)
+		}
+		return obj, nil
+	})
+	var stalledErr *stalledError
+	switch {
+	case errors.As(err, &stalledErr):
+		return false, stalled(stalledErr.reason, "%v", err)
+	case err != nil:
+		return false, stalled("InjectionFailed", "%v", err)
+	}
+	if pv.Spec.PackageContext != nil {
+		contextChanged, err := setPackageContext(prr.Spec.Resources, pv.Spec.Downstream.Package, pv.Spec.PackageContext.Data)
+		if err != nil {
+			return false, stalled("InjectionFailed", "%v", err)
+		}
+		for file, contents := range contextChanged {
+			changed[file] = contents
+		}
+	}
+	if len(changed) == 0 {
+		return false, nil
+	}
+
+	for file, contents := range changed {
+		prr.Spec.Resources[file] = contents
+	}
+	if err := r.Update(ctx, &prr); err != nil {
+		return false, fmt.Errorf("cannot update resources of package revision %s: %w", pr.Name, err)
+	}
+	return true, nil
+}
+
+func validatePackageVariant(pv *api.PackageVariant) error {
+	switch upstream := pv.Spec.Upstream; {
+	case upstream == nil:
+		return stalled("InvalidSpec", "spec.upstream is required")
+	case upstream.Repo == "":
+		return stalled("InvalidSpec", "spec.upstream.repo is required")
+	case upstream.Package == "":
+		return stalled("InvalidSpec", "spec.upstream.package is required")
+	}
+	switch downstream := pv.Spec.Downstream; {
+	case downstream == nil:
+		return stalled("InvalidSpec", "spec.downstream is required")
+	case downstream.Repo == "":
+		return stalled("InvalidSpec", "spec.downstream.repo is required")
+	case downstream.Package == "":
+		return stalled("InvalidSpec", "spec.downstream.package is required")
+	}
+	for i, injector := range pv.Spec.Injectors {
+		if injector.Name == "" {
+			return stalled("InvalidSpec", "spec.injectors[%d].name is required", i)
+		}
+	}
+	if pv.Spec.PackageContext != nil {
+		if _, found := pv.Spec.PackageContext.Data[packageContextNameKey]; found {
+			return stalled("InvalidSpec", "spec.packageContext.data.%s is reserved for the name of the package", packageContextNameKey)
+		}
+	}
+	return nil
+}
+
+// findUpstream returns the upstream package revision; the latest published
+// revision unless the revision is specified.
+func findUpstream(prs []porchapi.PackageRevision, upstream *api.Upstream) *porchapi.PackageRevision {
+	for i := range prs {
+		pr := &prs[i]
+		if pr.Spec.RepositoryName != upstream.Repo || pr.Spec.PackageName != upstream.Package {
+			continue
+		}
+		if pr.Spec.Lifecycle != porchapi.PackageRevisionLifecyclePublished {
+			continue
+		}
+		if upstream.Revision == "" {
+			if pr.Labels[porchapi.LatestPackageRevisionKey] == porchapi.LatestPackageRevisionValue {
+				return pr
+			}
+		} else if pr.Spec.Revision == upstream.Revision {
+			return pr
+		}
+	}
+	return nil
+}
+
+// findDownstreams returns the revisions of the downstream package, sorted by name.
+func findDownstreams(prs []porchapi.PackageRevision, downstream *api.Downstream) []porchapi.PackageRevision {
+	var downstreams []porchapi.PackageRevision
+	for _, pr := range prs {
+		if pr.Spec.RepositoryName == downstream.Repo && pr.Spec.PackageName == downstream.Package {
+			downstreams = append(downstreams, pr)
+		}
+	}
+	sort.Slice(downstreams, func(i, j int) bool {
+		return downstreams[i].Name < downstreams[j].Name
+	})
+	return downstreams
+}
+
+func findDraft(downstreams []porchapi.PackageRevision) *porchapi.PackageRevision {
+	for i := range downstreams {
+		if downstreams[i].Spec.Lifecycle != porchapi.PackageRevisionLifecyclePublished {
+			return downstreams[i].DeepCopy()
+		}
+	}
+	return nil
+}
+
+func findLatestPublished(downstreams []porchapi.PackageRevision) *porchapi.PackageRevision {
+	for i := range downstreams {
+		if downstreams[i].Labels[porchapi.LatestPackageRevisionKey] == porchapi.LatestPackageRevisionValue {
+			return &downstreams[i]
+		}
+	}
+	return nil
+}
+
+// nextRevision returns the revision following the latest vN revision of the
+// downstream package.
+func nextRevision(downstreams []porchapi.PackageRevision) string {
+	latest := 0
+	for _, pr := range downstreams {
+		var n int
+		if _, err := fmt.Sscanf(pr.Spec.Revision, "v%d", &n); err == nil && n > latest {
+			latest = n
+		}
+	}
+	return fmt.Sprintf("v%d", latest+1)
+}
+
+// lastCloneTask returns the last clone task of the root package of the
+// package revision; clone tasks of subpackages have their own upstreams.
+func lastCloneTask(pr *porchapi.PackageRevision) *porchapi.PackageCloneTaskSpec {
+	for i := len(pr.Spec.Tasks) - 1; i >= 0; i-- {
+		if task := &pr.Spec.Tasks[i]; task.Type == porchapi.TaskTypeClone && task.Clone != nil && task.Clone.Subpackage == "" {
+			return task.Clone
+		}
+	}
+	return nil
+}
+
+// clonedFrom returns the name of the upstream package revision the package
+// revision was cloned from, or "" if the upstream isn't a registered package.
+func clonedFrom(pr *porchapi.PackageRevision) string {
+	if clone := lastCloneTask(pr); clone != nil && clone.Upstream.UpstreamRef != nil {
+		return clone.Upstream.UpstreamRef.Name
+	}
+	return ""
+}
+
+func downstreamTargets(downstreams []porchapi.PackageRevision) []api.DownstreamTarget {
+	var targets []api.DownstreamTarget
+	for _, pr := range downstreams {
+		targets = append(targets, api.DownstreamTarget{
+			Name:      pr.Name,
+			Revision:  pr.Spec.Revision,
+			Lifecycle: string(pr.Spec.Lifecycle),
+		})
+	}
+	return targets
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *PackageVariantReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.apiReader = mgr.GetAPIReader()
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&api.PackageVariant{}).
+		Watches(&source.Kind{Type: &porchapi.PackageRevision{}}, handler.EnqueueRequestsFromMapFunc(r.findPackageVariants)).
+		Complete(r)
+}
+
+// findPackageVariants returns the package variants whose upstream or
+// downstream package is the package of the package revision.
+func (r *PackageVariantReconciler) findPackageVariants(obj client.Object) []ctrl.Request {
+	pr, ok := obj.(*porchapi.PackageRevision)
+	if !ok {
+		return nil
+	}
+
+	var pvList api.PackageVariantList
+	if err := r.List(context.Background(), &pvList, client.InNamespace(pr.Namespace)); err != nil {
+		klog.Errorf("cannot list PackageVariants in namespace %s: %v", pr.Namespace, err)
+		return nil
+	}
+
+	var requests []ctrl.Request
+	for _, pv := range pvList.Items {
+		upstream, downstream := pv.Spec.Upstream, pv.Spec.Downstream
+		if (upstream != nil && upstream.Repo == pr.Spec.RepositoryName && upstream.Package == pr.Spec.PackageName) ||
+			(downstream != nil && downstream.Repo == pr.Spec.RepositoryName && downstream.Package == pr.Spec.PackageName) {
+			requests = append(requests, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&pv)})
+		}
+	}
+	return requests
+}
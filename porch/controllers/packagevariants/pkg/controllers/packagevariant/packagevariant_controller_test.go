@@ -0,0 +1,353 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package packagevariant
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	porchapi "github.com/GoogleContainerTools/kpt/porch/api/porch/v1alpha1"
+	api "github.com/GoogleContainerTools/kpt/porch/controllers/packagevariants/api/v1alpha1"
+	"github.com/GoogleContainerTools/kpt/porch/controllers/packagevariants/pkg/kinds"
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// fakePorch names the package revisions it creates, and creates their
+// resources, like the porch server does. It records the updates of package
+// revisions as "<name> <lifecycle> <upstream>".
+type fakePorch struct {
+	client.Client
+	updates []string
+}
+
+func (c *fakePorch) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	pr, ok := obj.(*porchapi.PackageRevision)
+	if !ok {
+		return c.Client.Create(ctx, obj, opts...)
+	}
+	pr.Name = fmt.Sprintf("%s-%s-%s", pr.Spec.RepositoryName, pr.Spec.PackageName, pr.Spec.Revision)
+	if err := c.Client.Create(ctx, pr, opts...); err != nil {
+		return err
+	}
+	return c.Client.Create(ctx, packageRevisionResources(pr))
+}
+
+func (c *fakePorch) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	if pr, ok := obj.(*porchapi.PackageRevision); ok {
+		c.updates = append(c.updates, fmt.Sprintf("%s %s %s", pr.Name, pr.Spec.Lifecycle, clonedFrom(pr)))
+	}
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func packageRevisionResources(pr *porchapi.PackageRevision) *porchapi.PackageRevisionResources {
+	return &porchapi.PackageRevisionResources{
+		ObjectMeta: metav1.ObjectMeta{Namespace: pr.Namespace, Name: pr.Name},
+		Spec: porchapi.PackageRevisionResourcesSpec{
+			PackageName:    pr.Spec.PackageName,
+			Revision:       pr.Spec.Revision,
+			RepositoryName: pr.Spec.RepositoryName,
+			Resources: map[string]string{
+				"Kptfile": "apiVersion: kpt.dev/v1\nkind: Kptfile\nmetadata:\n  name: " + pr.Spec.PackageName + "\n",
+			},
+		},
+	}
+}
+
+func packageRevision(repo, pkg, revision string, lifecycle porchapi.PackageRevisionLifecycle, latest bool, tasks ...porchapi.Task) *porchapi.PackageRevision {
+	pr := &porchapi.PackageRevision{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      fmt.Sprintf("%s-%s-%s", repo, pkg, revision),
+		},
+		Spec: porchapi.PackageRevisionSpec{
+			PackageName:    pkg,
+			Revision:       revision,
+			RepositoryName: repo,
+			Lifecycle:      lifecycle,
+			Tasks:          tasks,
+		},
+	}
+	if latest {
+		pr.Labels = map[string]string{porchapi.LatestPackageRevisionKey: porchapi.LatestPackageRevisionValue}
+	}
+	return pr
+}
+
+func cloneTask(upstream string) porchapi.Task {
+	return porchapi.Task{
+		Type: porchapi.TaskTypeClone,
+		Clone: &porchapi.PackageCloneTaskSpec{
+			Upstream: porchapi.UpstreamPackage{
+				UpstreamRef: &porchapi.PackageRevisionRef{Name: upstream},
+			},
+		},
+	}
+}
+
+func editTask(source string) porchapi.Task {
+	return porchapi.Task{
+		Type: porchapi.TaskTypeEdit,
+		Edit: &porchapi.PackageEditTaskSpec{
+			Source: &porchapi.PackageRevisionRef{Name: source},
+		},
+	}
+}
+
+func evalTask(image string) porchapi.Task {
+	return porchapi.Task{
+		Type: porchapi.TaskTypeEval,
+		Eval: &porchapi.FunctionEvalTaskSpec{Image: image},
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	const (
+		published = porchapi.PackageRevisionLifecyclePublished
+		proposed  = porchapi.PackageRevisionLifecycleProposed
+		draft     = porchapi.PackageRevisionLifecycleDraft
+	)
+	upstreamV1 := packageRevision("blueprints", "basens", "v1", published, false, evalTask("set-labels"))
+	upstreamV2 := packageRevision("blueprints", "basens", "v2", published, true, evalTask("set-labels"), evalTask("set-namespace"))
+
+	for _, tc := range []struct {
+		name        string
+		revisions   []*porchapi.PackageRevision
+		want        []*porchapi.PackageRevision
+		wantUpdates []string
+	}{
+		{
+			name:      "create",
+			revisions: []*porchapi.PackageRevision{upstreamV2},
+			want: []*porchapi.PackageRevision{
+				packageRevision("deployments", "team-a", "v1", proposed, false, cloneTask("blueprints-basens-v2")),
+			},
+			wantUpdates: []string{"deployments-team-a-v1 Proposed blueprints-basens-v2"},
+		},
+		{
+			name: "up to date",
+			revisions: []*porchapi.PackageRevision{
+				upstreamV2,
+				packageRevision("deployments", "team-a", "v1", published, true, cloneTask("blueprints-basens-v2")),
+			},
+			want: []*porchapi.PackageRevision{
+				packageRevision("deployments", "team-a", "v1", published, true, cloneTask("blueprints-basens-v2")),
+			},
+		},
+		{
+			name: "update to new upstream",
+			revisions: []*porchapi.PackageRevision{
+				upstreamV1,
+				upstreamV2,
+				packageRevision("deployments", "team-a", "v1", published, true,
+					cloneTask("blueprints-basens-v1"), evalTask("set-labels"), cloneTask("blueprints-basens-v1")),
+			},
+			want: []*porchapi.PackageRevision{
+				packageRevision("deployments", "team-a", "v1", published, true,
+					cloneTask("blueprints-basens-v1"), evalTask("set-labels"), cloneTask("blueprints-basens-v1")),
+				// The new revision is a copy of the latest one, not a replay of its history.
+				packageRevision("deployments", "team-a", "v2", proposed, false,
+					cloneTask("blueprints-basens-v2"), editTask("deployments-team-a-v1")),
+			},
+			wantUpdates: []string{
+				"deployments-team-a-v2 Draft blueprints-basens-v2",
+				"deployments-team-a-v2 Proposed blueprints-basens-v2",
+			},
+		},
+		{
+			name: "re-propose draft when upstream changes",
+			revisions: []*porchapi.PackageRevision{
+				upstreamV1,
+				upstreamV2,
+				packageRevision("deployments", "team-a", "v1", draft, false, cloneTask("blueprints-basens-v1")),
+			},
+			want: []*porchapi.PackageRevision{
+				packageRevision("deployments", "team-a", "v1", proposed, false, cloneTask("blueprints-basens-v2")),
+			},
+			wantUpdates: []string{
+				"deployments-team-a-v1 Draft blueprints-basens-v2",
+				"deployments-team-a-v1 Proposed blueprints-basens-v2",
+			},
+		},
+		{
+			name: "don't re-propose rejected draft",
+			revisions: []*porchapi.PackageRevision{
+				upstreamV2,
+				packageRevision("deployments", "team-a", "v1", draft, false, cloneTask("blueprints-basens-v2")),
+			},
+			want: []*porchapi.PackageRevision{
+				packageRevision("deployments", "team-a", "v1", draft, false, cloneTask("blueprints-basens-v2")),
+			},
+		},
+		{
+			name: "withdraw proposal",
+			revisions: []*porchapi.PackageRevision{
+				upstreamV1,
+				upstreamV2,
+				packageRevision("deployments", "team-a", "v1", proposed, false, cloneTask("blueprints-basens-v1")),
+			},
+			want: []*porchapi.PackageRevision{
+				packageRevision("deployments", "team-a", "v1", proposed, false, cloneTask("blueprints-basens-v2")),
+			},
+			wantUpdates: []string{
+				"deployments-team-a-v1 Draft blueprints-basens-v1",
+				"deployments-team-a-v1 Draft blueprints-basens-v2",
+				"deployments-team-a-v1 Proposed blueprints-basens-v2",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			scheme := runtime.NewScheme()
+			if err := porchapi.AddToScheme(scheme); err != nil {
+				t.Fatalf("Failed to build scheme: %v", err)
+			}
+			if err := api.AddToScheme(scheme); err != nil {
+				t.Fatalf("Failed to build scheme: %v", err)
+			}
+			pv := &api.PackageVariant{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "team-a"},
+				Spec: api.PackageVariantSpec{
+					Upstream:   &api.Upstream{Repo: "blueprints", Package: "basens"},
+					Downstream: &api.Downstream{Repo: "deployments", Package: "team-a"},
+				},
+			}
+			builder := fake.NewClientBuilder().WithScheme(scheme).WithObjects(pv)
+			for _, pr := range tc.revisions {
+				builder = builder.WithObjects(pr.DeepCopy(), packageRevisionResources(pr))
+			}
+			c := &fakePorch{Client: builder.Build()}
+			r := &PackageVariantReconciler{Client: c, apiReader: c}
+
+			if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(pv)}); err != nil {
+				t.Fatalf("Reconcile failed: %v", err)
+			}
+
+			var prList porchapi.PackageRevisionList
+			if err := c.List(ctx, &prList); err != nil {
+				t.Fatalf("List failed: %v", err)
+			}
+			var got []*porchapi.PackageRevision
+			for _, pr := range findDownstreams(prList.Items, pv.Spec.Downstream) {
+				got = append(got, packageRevision(pr.Spec.RepositoryName, pr.Spec.PackageName, pr.Spec.Revision, pr.Spec.Lifecycle,
+					pr.Labels[porchapi.LatestPackageRevisionKey] == porchapi.LatestPackageRevisionValue, pr.Spec.Tasks...))
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("downstream package revisions (-want,+got): %s", diff)
+			}
+			if diff := cmp.Diff(tc.wantUpdates, c.updates); diff != "" {
+				t.Errorf("package revision updates (-want,+got): %s", diff)
+			}
+
+			if err := c.Get(ctx, client.ObjectKeyFromObject(pv), pv); err != nil {
+				t.Fatalf("Get failed: %v", err)
+			}
+			if !meta.IsStatusConditionTrue(pv.Status.Conditions, ConditionTypeReady) {
+				t.Errorf("PackageVariant is not ready: %v", pv.Status.Conditions)
+			}
+			if got, want := pv.Status.Upstream, upstreamV2.Name; got != want {
+				t.Errorf("status.upstream = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestReconcileInjection(t *testing.T) {
+	const secret = `apiVersion: v1
+kind: Secret
+metadata:
+  name: credentials
+  annotations:
+    kpt.dev/config-injection: required
+data:
+  token: cGxhY2Vob2xkZXI=
+`
+	for _, tc := range []struct {
+		name          string
+		allowedKinds  kinds.Allowed
+		wantCondition string
+		wantInjected  bool
+	}{
+		{
+			name:          "kind not allowed",
+			wantCondition: "InjectionNotAllowed",
+		},
+		{
+			name:          "kind allowed",
+			allowedKinds:  kinds.Allowed{{Kind: "Secret"}},
+			wantCondition: "Reconciled",
+			wantInjected:  true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			scheme := runtime.NewScheme()
+			if err := corev1.AddToScheme(scheme); err != nil {
+				t.Fatalf("Failed to build scheme: %v", err)
+			}
+			if err := porchapi.AddToScheme(scheme); err != nil {
+				t.Fatalf("Failed to build scheme: %v", err)
+			}
+			if err := api.AddToScheme(scheme); err != nil {
+				t.Fatalf("Failed to build scheme: %v", err)
+			}
+			pv := &api.PackageVariant{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "team-a"},
+				Spec: api.PackageVariantSpec{
+					Upstream:   &api.Upstream{Repo: "blueprints", Package: "basens"},
+					Downstream: &api.Downstream{Repo: "deployments", Package: "team-a"},
+					Injectors:  []api.InjectionSelector{{Name: "team-a-credentials"}},
+				},
+			}
+			upstream := packageRevision("blueprints", "basens", "v1", porchapi.PackageRevisionLifecyclePublished, true)
+			downstream := packageRevision("deployments", "team-a", "v1", porchapi.PackageRevisionLifecycleDraft, false, cloneTask(upstream.Name))
+			prr := packageRevisionResources(downstream)
+			prr.Spec.Resources["secret.yaml"] = secret
+			c := &fakePorch{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				pv, upstream, packageRevisionResources(upstream), downstream, prr,
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "team-a-credentials"},
+					Data:       map[string][]byte{"token": []byte("team-a")},
+				},
+			).Build()}
+			r := &PackageVariantReconciler{Client: c, apiReader: c, AllowedKinds: tc.allowedKinds}
+
+			if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(pv)}); err != nil {
+				t.Fatalf("Reconcile failed: %v", err)
+			}
+
+			if err := c.Get(ctx, client.ObjectKeyFromObject(pv), pv); err != nil {
+				t.Fatalf("Get failed: %v", err)
+			}
+			if got := meta.FindStatusCondition(pv.Status.Conditions, ConditionTypeStalled); got == nil || got.Reason != tc.wantCondition {
+				t.Errorf("Stalled condition = %v, want reason %s", got, tc.wantCondition)
+			}
+			if err := c.Get(ctx, client.ObjectKeyFromObject(prr), prr); err != nil {
+				t.Fatalf("Get failed: %v", err)
+			}
+			if got := strings.Contains(prr.Spec.Resources["secret.yaml"], "dGVhbS1h"); got != tc.wantInjected {
+				t.Errorf("secret injected = %v, want %v; secret.yaml:\n%s", got, tc.wantInjected, prr.Spec.Resources["secret.yaml"])
+			}
+		})
+	}
+}
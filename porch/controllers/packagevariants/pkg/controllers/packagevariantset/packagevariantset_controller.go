@@ -0,0 +1,587 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package packagevariantset
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	configapi "github.com/GoogleContainerTools/kpt/porch/api/porchconfig/v1alpha1"
+	api "github.com/GoogleContainerTools/kpt/porch/controllers/packagevariants/api/v1alpha1"
+	"github.com/GoogleContainerTools/kpt/porch/controllers/packagevariants/pkg/kinds"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	ConditionTypeStalled = "Stalled" // The package variant set cannot be reconciled until it changes.
+	ConditionTypeReady   = "Ready"   // All package variants of the set are ready.
+
+	// finalizer makes sure the package variants are released according to the
+	// deletion policy before the set is deleted.
+	finalizer = "config.porch.kpt.dev/packagevariantset"
+
+	// objectSelectorResyncPeriod is how often sets with object selectors are
+	// reconciled; the selected objects aren't watched.
+	objectSelectorResyncPeriod = time.Minute
+)
+
+// PackageVariantSetReconciler reconciles PackageVariantSet objects
+type PackageVariantSetReconciler struct {
+	client.Client
+
+	// AllowedKinds are the kinds of in-cluster objects, besides ConfigMaps,
+	// which object selectors may select.
+	AllowedKinds kinds.Allowed
+}
+
+//+kubebuilder:rbac:groups=config.porch.kpt.dev,resources=packagevariantsets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=config.porch.kpt.dev,resources=packagevariantsets/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=config.porch.kpt.dev,resources=packagevariantsets/finalizers,verbs=update
+//+kubebuilder:rbac:groups=config.porch.kpt.dev,resources=packagevariants,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=config.porch.kpt.dev,resources=repositories,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=list
+
+// stalledError is an error which retrying the reconciliation won't fix.
+type stalledError struct {
+	reason  string
+	message string
+}
+
+func (e *stalledError) Error() string {
+	return e.message
+}
+
+func stalled(reason, format string, args ...interface{}) error {
+	return &stalledError{reason: reason, message: fmt.Sprintf(format, args...)}
+}
+
+// Reconcile implements the main kubernetes reconciliation loop.
+func (r *PackageVariantSetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var pvs api.PackageVariantSet
+	if err := r.Get(ctx, req.NamespacedName, &pvs); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !pvs.ObjectMeta.DeletionTimestamp.IsZero() {
+		if !controllerutil.ContainsFinalizer(&pvs, finalizer) {
+			return ctrl.Result{}, nil
+		}
+		if pvs.Spec.DeletionPolicy == api.DeletionPolicyOrphan {
+			owned, err := r.ownedPackageVariants(ctx, &pvs)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			for i := range owned {
+				if err := r.release(ctx, &owned[i]); err != nil {
+					return ctrl.Result{}, err
+				}
+			}
+		}
+		// The package variants which weren't released are garbage collected.
+		controllerutil.RemoveFinalizer(&pvs, finalizer)
+		return ctrl.Result{}, r.Update(ctx, &pvs)
+	}
+	if !controllerutil.ContainsFinalizer(&pvs, finalizer) {
+		controllerutil.AddFinalizer(&pvs, finalizer)
+		if err := r.Update(ctx, &pvs); err != nil {
+			return ctrl.Result{}, fmt.Errorf("cannot add finalizer: %w", err)
+		}
+	}
+
+	err := r.reconcile(ctx, &pvs)
+
+	var stalledErr *stalledError
+	switch {
+	case errors.As(err, &stalledErr):
+		setCondition(&pvs, ConditionTypeStalled, metav1.ConditionTrue, stalledErr.reason, stalledErr.message)
+		setCondition(&pvs, ConditionTypeReady, metav1.ConditionFalse, stalledErr.reason, stalledErr.message)
+		err = nil
+	case err != nil:
+		setCondition(&pvs, ConditionTypeStalled, metav1.ConditionFalse, "Reconciling", "")
+		setCondition(&pvs, ConditionTypeReady, metav1.ConditionFalse, "Error", err.Error())
+	default:
+		setCondition(&pvs, ConditionTypeStalled, metav1.ConditionFalse, "Reconciled", "")
+		if notReady := countNotReady(pvs.Status.Variants); notReady > 0 {
+			setCondition(&pvs, ConditionTypeReady, metav1.ConditionFalse, "VariantsNotReady",
+				fmt.Sprintf("%d of %d package variants are not ready", notReady, len(pvs.Status.Variants)))
+		} else {
+			setCondition(&pvs, ConditionTypeReady, metav1.ConditionTrue, "VariantsReady",
+				fmt.Sprintf("%d package variants are ready", len(pvs.Status.Variants)))
+		}
+	}
+
+	if statusErr := r.Status().Update(ctx, &pvs); statusErr != nil {
+		klog.Errorf("failed to update status of PackageVariantSet %s: %v", req.NamespacedName, statusErr)
+		if err == nil {
+			err = statusErr
+		}
+	}
+
+	var result ctrl.Result
+	for _, target := range pvs.Spec.Targets {
+		if target.ObjectSelector != nil {
+			result.RequeueAfter = objectSelectorResyncPeriod
+		}
+	}
+	return result, err
+}
+
+func setCondition(pvs *api.PackageVariantSet, conditionType string, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&pvs.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: pvs.Generation,
+	})
+}
+
+func countNotReady(variants []api.VariantStatus) int {
+	count := 0
+	for _, v := range variants {
+		if v.Ready != metav1.ConditionTrue {
+			count++
+		}
+	}
+	return count
+}
+
+// reconcile makes the package variants of the set match its targets, and
+// records their status in the status of the set.
+func (r *PackageVariantSetReconciler) reconcile(ctx context.Context, pvs *api.PackageVariantSet) error {
+	if err := validatePackageVariantSet(pvs); err != nil {
+		return err
+	}
+
+	variants, statuses, err := r.generateVariants(ctx, pvs)
+	if err != nil {
+		return err
+	}
+
+	var pvList api.PackageVariantList
+	if err := r.List(ctx, &pvList, client.InNamespace(pvs.Namespace)); err != nil {
+		return fmt.Errorf("cannot list package variants: %w", err)
+	}
+	owned := map[string]*api.PackageVariant{}
+	others := map[string]*api.PackageVariant{}
+	for i := range pvList.Items {
+		pv := &pvList.Items[i]
+		if pv.Spec.Downstream == nil {
+			continue
+		}
+		key := variantKey(pv.Spec.Downstream.Repo, pv.Spec.Downstream.Package)
+		if metav1.IsControlledBy(pv, pvs) {
+			owned[key] = pv
+		} else {
+			others[key] = pv
+		}
+	}
+
+	for _, v := range variants {
+		status, err := r.applyVariant(ctx, pvs, v, owned[v.key()], others[v.key()])
+		if err != nil {
+			return err
+		}
+		statuses = append(statuses, *status)
+		delete(owned, v.key())
+	}
+
+	// The remaining package variants no longer have a target.
+	for _, pv := range owned {
+		if pvs.Spec.DeletionPolicy == api.DeletionPolicyOrphan {
+			if err := r.release(ctx, pv); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := r.Delete(ctx, pv); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("cannot delete package variant %s: %w", pv.Name, err)
+		}
+		klog.Infof("PackageVariantSet %s/%s deleted PackageVariant %s", pvs.Namespace, pvs.Name, pv.Name)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return variantKey(statuses[i].Repo, statuses[i].Package) < variantKey(statuses[j].Repo, statuses[j].Package)
+	})
+	pvs.Status.Variants = statuses
+	return nil
+}
+
+// applyVariant creates or updates the package variant of a downstream package,
+// adopting an existing package variant if the adoption policy allows it.
+func (r *PackageVariantSetReconciler) applyVariant(ctx context.Context, pvs *api.PackageVariantSet, v *variant, owned, other *api.PackageVariant) (*api.VariantStatus, error) {
+	status := &api.VariantStatus{
+		Repo:    v.spec.Downstream.Repo,
+		Package: v.spec.Downstream.Package,
+	}
+
+	pv := owned
+	if pv == nil && other != nil {
+		status.Name = other.Name
+		status.Ready = metav1.ConditionFalse
+		if controller := metav1.GetControllerOf(other); controller != nil {
+			status.Reason = "Conflict"
+			status.Message = fmt.Sprintf("PackageVariant %s is controlled by %s %s", other.Name, controller.Kind, controller.Name)
+			return status, nil
+		}
+		if pvs.Spec.AdoptionPolicy != api.AdoptionPolicyAdoptExisting {
+			status.Reason = "NotAdopted"
+			status.Message = fmt.Sprintf("PackageVariant %s already exists and the adoption policy is %s", other.Name, api.AdoptionPolicyAdoptNone)
+			return status, nil
+		}
+		pv = other
+	}
+
+	if pv == nil {
+		pv = &api.PackageVariant{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        variantName(pvs.Name, v.spec.Downstream.Repo, v.spec.Downstream.Package),
+				Namespace:   pvs.Namespace,
+				Labels:      v.labels,
+				Annotations: v.annotations,
+			},
+			Spec: v.spec,
+		}
+		pv.Labels[api.PackageVariantSetLabel] = pvs.Name
+		if err := controllerutil.SetControllerReference(pvs, pv, r.Scheme()); err != nil {
+			return nil, err
+		}
+		if err := r.Create(ctx, pv); err != nil {
+			return nil, fmt.Errorf("cannot create package variant %s: %w", pv.Name, err)
+		}
+		klog.Infof("PackageVariantSet %s/%s created PackageVariant %s", pvs.Namespace, pvs.Name, pv.Name)
+	} else {
+		updated := pv.DeepCopy()
+		updated.Spec = v.spec
+		if updated.Labels == nil {
+			updated.Labels = map[string]string{}
+		}
+		for key, value := range v.labels {
+			updated.Labels[key] = value
+		}
+		updated.Labels[api.PackageVariantSetLabel] = pvs.Name
+		if updated.Annotations == nil && len(v.annotations) > 0 {
+			updated.Annotations = map[string]string{}
+		}
+		for key, value := range v.annotations {
+			updated.Annotations[key] = value
+		}
+		if owned == nil {
+			if err := controllerutil.SetControllerReference(pvs, updated, r.Scheme()); err != nil {
+				return nil, err
+			}
+		}
+		if !equality.Semantic.DeepEqual(pv, updated) {
+			if err := r.Update(ctx, updated); err != nil {
+				return nil, fmt.Errorf("cannot update package variant %s: %w", pv.Name, err)
+			}
+			if owned == nil {
+				klog.Infof("PackageVariantSet %s/%s adopted PackageVariant %s", pvs.Namespace, pvs.Name, pv.Name)
+			}
+		}
+		pv = updated
+	}
+
+	status.Name = pv.Name
+	status.Ready = metav1.ConditionUnknown
+	status.Reason = "Reconciling"
+	// The condition is stale if the spec of the package variant just changed.
+	if ready := meta.FindStatusCondition(pv.Status.Conditions, ConditionTypeReady); ready != nil && ready.ObservedGeneration == pv.Generation {
+		status.Ready = ready.Status
+		status.Reason = ready.Reason
+		status.Message = ready.Message
+	}
+	return status, nil
+}
+
+// release removes the package variant from the set, leaving it in place.
+func (r *PackageVariantSetReconciler) release(ctx context.Context, pv *api.PackageVariant) error {
+	var refs []metav1.OwnerReference
+	for _, ref := range pv.OwnerReferences {
+		if ref.Controller == nil || !*ref.Controller {
+			refs = append(refs, ref)
+		}
+	}
+	pv.OwnerReferences = refs
+	delete(pv.Labels, api.PackageVariantSetLabel)
+	if err := r.Update(ctx, pv); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("cannot release package variant %s: %w", pv.Name, err)
+	}
+	return nil
+}
+
+func (r *PackageVariantSetReconciler) ownedPackageVariants(ctx context.Context, pvs *api.PackageVariantSet) ([]api.PackageVariant, error) {
+	var pvList api.PackageVariantList
+	if err := r.List(ctx, &pvList, client.InNamespace(pvs.Namespace), client.MatchingLabels{api.PackageVariantSetLabel: pvs.Name}); err != nil {
+		return nil, fmt.Errorf("cannot list package variants: %w", err)
+	}
+	var owned []api.PackageVariant
+	for _, pv := range pvList.Items {
+		if metav1.IsControlledBy(&pv, pvs) {
+			owned = append(owned, pv)
+		}
+	}
+	return owned, nil
+}
+
+// generateVariants generates the package variants of the targets of the set.
+// It also returns the status of the targets for which no package variant
+// could be generated.
+func (r *PackageVariantSetReconciler) generateVariants(ctx context.Context, pvs *api.PackageVariantSet) ([]*variant, []api.VariantStatus, error) {
+	var variants []*variant
+	var statuses []api.VariantStatus
+	seen := map[string]bool{}
+	add := func(i int, in templateInput) error {
+		v, err := generateVariant(pvs.Spec.Upstream, pvs.Spec.Targets[i].Template, in)
+		if err != nil {
+			return stalled("InvalidTemplate", "spec.targets[%d]: %v", i, err)
+		}
+		if seen[v.key()] {
+			return stalled("InvalidSpec", "spec.targets[%d]: package %s in repository %s is targeted more than once",
+				i, v.spec.Downstream.Package, v.spec.Downstream.Repo)
+		}
+		seen[v.key()] = true
+		variants = append(variants, v)
+		return nil
+	}
+
+	var repositories []configapi.Repository
+	listRepositories := func(selector labels.Selector) ([]configapi.Repository, error) {
+		if repositories == nil {
+			var repoList configapi.RepositoryList
+			if err := r.List(ctx, &repoList, client.InNamespace(pvs.Namespace)); err != nil {
+				return nil, fmt.Errorf("cannot list repositories: %w", err)
+			}
+			repositories = repoList.Items
+		}
+		var selected []configapi.Repository
+		for _, repo := range repositories {
+			if selector.Matches(labels.Set(repo.Labels)) {
+				selected = append(selected, repo)
+			}
+		}
+		return selected, nil
+	}
+
+	for i, target := range pvs.Spec.Targets {
+		switch {
+		case target.Repositories != nil:
+			all, err := listRepositories(labels.Everything())
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, repoTarget := range target.Repositories {
+				packageNames := repoTarget.PackageNames
+				if len(packageNames) == 0 {
+					packageNames = []string{pvs.Spec.Upstream.Package}
+				}
+				repo := findRepository(all, repoTarget.Name)
+				for _, packageName := range packageNames {
+					if repo == nil {
+						statuses = append(statuses, api.VariantStatus{
+							Repo:    repoTarget.Name,
+							Package: packageName,
+							Ready:   metav1.ConditionFalse,
+							Reason:  "RepositoryNotFound",
+							Message: fmt.Sprintf("repository %s is not registered", repoTarget.Name),
+						})
+						continue
+					}
+					in, err := repositoryInput(repo, packageName)
+					if err != nil {
+						return nil, nil, err
+					}
+					if err := add(i, in); err != nil {
+						return nil, nil, err
+					}
+				}
+			}
+
+		case target.RepositorySelector != nil:
+			selector, err := metav1.LabelSelectorAsSelector(target.RepositorySelector)
+			if err != nil {
+				return nil, nil, stalled("InvalidSpec", "spec.targets[%d].repositorySelector: %v", i, err)
+			}
+			selected, err := listRepositories(selector)
+			if err != nil {
+				return nil, nil, err
+			}
+			for j := range selected {
+				in, err := repositoryInput(&selected[j], pvs.Spec.Upstream.Package)
+				if err != nil {
+					return nil, nil, err
+				}
+				if err := add(i, in); err != nil {
+					return nil, nil, err
+				}
+			}
+
+		case target.ObjectSelector != nil:
+			objects, err := r.selectObjects(ctx, pvs.Namespace, target.ObjectSelector)
+			if err != nil {
+				var stalledErr *stalledError
+				if errors.As(err, &stalledErr) {
+					return nil, nil, stalled(stalledErr.reason, "spec.targets[%d].objectSelector: %s", i, stalledErr.message)
+				}
+				return nil, nil, err
+			}
+			for _, obj := range objects {
+				if err := add(i, templateInput{target: obj.Object, packageName: pvs.Spec.Upstream.Package}); err != nil {
+					return nil, nil, err
+				}
+			}
+		}
+	}
+	return variants, statuses, nil
+}
+
+func (r *PackageVariantSetReconciler) selectObjects(ctx context.Context, namespace string, objectSelector *api.ObjectSelector) ([]unstructured.Unstructured, error) {
+	gv, err := schema.ParseGroupVersion(objectSelector.APIVersion)
+	if err != nil {
+		return nil, stalled("InvalidSpec", "%v", err)
+	}
+	if gk := gv.WithKind(objectSelector.Kind).GroupKind(); !r.AllowedKinds.Allows(gk) {
+		return nil, stalled("SelectionNotAllowed", "selection of %s objects is not allowed", gk)
+	}
+	opts := []client.ListOption{client.InNamespace(namespace)}
+	if objectSelector.Selector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(objectSelector.Selector)
+		if err != nil {
+			return nil, stalled("InvalidSpec", "%v", err)
+		}
+		opts = append(opts, client.MatchingLabelsSelector{Selector: selector})
+	}
+
+	var list unstructured.UnstructuredList
+	list.SetGroupVersionKind(gv.WithKind(objectSelector.Kind + "List"))
+	if err := r.List(ctx, &list, opts...); err != nil {
+		if meta.IsNoMatchError(err) {
+			return nil, stalled("InvalidSpec", "%v", err)
+		}
+		return nil, fmt.Errorf("cannot list %s: %w", objectSelector.Kind, err)
+	}
+	return list.Items, nil
+}
+
+func repositoryInput(repo *configapi.Repository, packageName string) (templateInput, error) {
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(repo)
+	if err != nil {
+		return templateInput{}, fmt.Errorf("cannot convert repository %s: %w", repo.Name, err)
+	}
+	return templateInput{
+		repository:  obj,
+		target:      obj,
+		repo:        repo.Name,
+		packageName: packageName,
+	}, nil
+}
+
+func findRepository(repositories []configapi.Repository, name string) *configapi.Repository {
+	for i := range repositories {
+		if repositories[i].Name == name {
+			return &repositories[i]
+		}
+	}
+	return nil
+}
+
+func validatePackageVariantSet(pvs *api.PackageVariantSet) error {
+	switch upstream := pvs.Spec.Upstream; {
+	case upstream == nil:
+		return stalled("InvalidSpec", "spec.upstream is required")
+	case upstream.Repo == "":
+		return stalled("InvalidSpec", "spec.upstream.repo is required")
+	case upstream.Package == "":
+		return stalled("InvalidSpec", "spec.upstream.package is required")
+	}
+	for i, target := range pvs.Spec.Targets {
+		count := 0
+		if target.Repositories != nil {
+			count++
+		}
+		if target.RepositorySelector != nil {
+			count++
+		}
+		if target.ObjectSelector != nil {
+			count++
+		}
+		if count != 1 {
+			return stalled("InvalidSpec", "spec.targets[%d] must have exactly one of repositories, repositorySelector and objectSelector", i)
+		}
+		for j, repo := range target.Repositories {
+			if repo.Name == "" {
+				return stalled("InvalidSpec", "spec.targets[%d].repositories[%d].name is required", i, j)
+			}
+		}
+		if s := target.ObjectSelector; s != nil && (s.APIVersion == "" || s.Kind == "") {
+			return stalled("InvalidSpec", "spec.targets[%d].objectSelector must have apiVersion and kind", i)
+		}
+	}
+	return nil
+}
+
+// variantName returns the name of the package variant generated for the
+// downstream package. The name is stable, and valid whatever the names of the
+// repository and package are.
+func variantName(pvsName, repo, pkg string) string {
+	hash := sha256.Sum256([]byte(variantKey(repo, pkg)))
+	return fmt.Sprintf("%s-%x", pvsName, hash[:5])
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *PackageVariantSetReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&api.PackageVariantSet{}).
+		Owns(&api.PackageVariant{}).
+		// Targets depend only on the spec and labels of repositories, so
+		// ignore status updates such as the sync status written by porch.
+		Watches(&source.Kind{Type: &configapi.Repository{}}, handler.EnqueueRequestsFromMapFunc(r.findPackageVariantSets),
+			builder.WithPredicates(predicate.Or(predicate.GenerationChangedPredicate{}, predicate.LabelChangedPredicate{}))).
+		Complete(r)
+}
+
+// findPackageVariantSets returns the package variant sets in the namespace of
+// the repository, any of which may target it.
+func (r *PackageVariantSetReconciler) findPackageVariantSets(obj client.Object) []ctrl.Request {
+	var pvsList api.PackageVariantSetList
+	if err := r.List(context.Background(), &pvsList, client.InNamespace(obj.GetNamespace())); err != nil {
+		klog.Errorf("cannot list PackageVariantSets in namespace %s: %v", obj.GetNamespace(), err)
+		return nil
+	}
+
+	var requests []ctrl.Request
+	for _, pvs := range pvsList.Items {
+		requests = append(requests, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&pvs)})
+	}
+	return requests
+}
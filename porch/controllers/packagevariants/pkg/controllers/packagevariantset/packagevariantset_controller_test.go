@@ -0,0 +1,298 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package packagevariantset
+
+import (
+	"context"
+	"testing"
+
+	configapi "github.com/GoogleContainerTools/kpt/porch/api/porchconfig/v1alpha1"
+	api "github.com/GoogleContainerTools/kpt/porch/controllers/packagevariants/api/v1alpha1"
+	"github.com/GoogleContainerTools/kpt/porch/controllers/packagevariants/pkg/kinds"
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+func newTestReconciler(t *testing.T, objs ...client.Object) *PackageVariantSetReconciler {
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build scheme: %v", err)
+	}
+	if err := configapi.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build scheme: %v", err)
+	}
+	if err := api.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build scheme: %v", err)
+	}
+	for _, name := range []string{"cluster-a", "cluster-b", "cluster-c"} {
+		objs = append(objs, &configapi.Repository{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+		})
+	}
+	return &PackageVariantSetReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build(),
+	}
+}
+
+// packageVariantSet returns a set of variants of the basens package in the
+// target repositories.
+func packageVariantSet(repos ...string) *api.PackageVariantSet {
+	var targets []api.RepositoryTarget
+	for _, repo := range repos {
+		targets = append(targets, api.RepositoryTarget{Name: repo})
+	}
+	return &api.PackageVariantSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "basens", UID: "basens-uid"},
+		Spec: api.PackageVariantSetSpec{
+			Upstream: &api.Upstream{Repo: "blueprints", Package: "basens"},
+			Targets:  []api.Target{{Repositories: targets}},
+		},
+	}
+}
+
+// packageVariant returns a package variant of the basens package in the
+// repository, controlled by pvs unless pvs is nil.
+func packageVariant(t *testing.T, name, repo string, pvs *api.PackageVariantSet) *api.PackageVariant {
+	pv := &api.PackageVariant{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+		Spec: api.PackageVariantSpec{
+			Upstream:   &api.Upstream{Repo: "blueprints", Package: "basens"},
+			Downstream: &api.Downstream{Repo: repo, Package: "basens"},
+		},
+	}
+	if pvs != nil {
+		pv.Labels = map[string]string{api.PackageVariantSetLabel: pvs.Name}
+		scheme := runtime.NewScheme()
+		if err := api.AddToScheme(scheme); err != nil {
+			t.Fatalf("Failed to build scheme: %v", err)
+		}
+		if err := controllerutil.SetControllerReference(pvs, pv, scheme); err != nil {
+			t.Fatalf("SetControllerReference failed: %v", err)
+		}
+	}
+	return pv
+}
+
+func reconcile(t *testing.T, r *PackageVariantSetReconciler, pvs *api.PackageVariantSet) {
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(pvs)}); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+}
+
+// controllerOf returns the controller of the package variant, or "" if it
+// has no controller.
+func controllerOf(t *testing.T, r *PackageVariantSetReconciler, name string) string {
+	var pv api.PackageVariant
+	if err := r.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: name}, &pv); err != nil {
+		t.Fatalf("Get of package variant %s failed: %v", name, err)
+	}
+	if controller := metav1.GetControllerOf(&pv); controller != nil {
+		return controller.Kind + "/" + controller.Name
+	}
+	return ""
+}
+
+func exists(t *testing.T, r client.Reader, obj client.Object) bool {
+	if err := r.Get(context.Background(), client.ObjectKeyFromObject(obj), obj); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false
+		}
+		t.Fatalf("Get failed: %v", err)
+	}
+	return true
+}
+
+func TestReconcileAdoption(t *testing.T) {
+	for _, tc := range []struct {
+		policy         api.AdoptionPolicy
+		wantController string
+		wantStatus     api.VariantStatus
+	}{
+		{
+			policy:     api.AdoptionPolicyAdoptNone,
+			wantStatus: api.VariantStatus{Name: "existing", Repo: "cluster-a", Package: "basens", Ready: metav1.ConditionFalse, Reason: "NotAdopted", Message: "PackageVariant existing already exists and the adoption policy is adoptNone"},
+		},
+		{
+			policy:         api.AdoptionPolicyAdoptExisting,
+			wantController: "PackageVariantSet/basens",
+			wantStatus:     api.VariantStatus{Name: "existing", Repo: "cluster-a", Package: "basens", Ready: metav1.ConditionUnknown, Reason: "Reconciling"},
+		},
+	} {
+		t.Run(string(tc.policy), func(t *testing.T) {
+			pvs := packageVariantSet("cluster-a", "cluster-b")
+			pvs.Spec.AdoptionPolicy = tc.policy
+			r := newTestReconciler(t, pvs, packageVariant(t, "existing", "cluster-a", nil))
+
+			reconcile(t, r, pvs)
+
+			if got, want := controllerOf(t, r, "existing"), tc.wantController; got != want {
+				t.Errorf("controller of existing package variant = %q, want %q", got, want)
+			}
+			created := variantName(pvs.Name, "cluster-b", "basens")
+			if got, want := controllerOf(t, r, created), "PackageVariantSet/basens"; got != want {
+				t.Errorf("controller of package variant %s = %q, want %q", created, got, want)
+			}
+
+			if !exists(t, r, pvs) {
+				t.Fatalf("PackageVariantSet was deleted")
+			}
+			want := []api.VariantStatus{
+				tc.wantStatus,
+				{Name: created, Repo: "cluster-b", Package: "basens", Ready: metav1.ConditionUnknown, Reason: "Reconciling"},
+			}
+			if diff := cmp.Diff(want, pvs.Status.Variants); diff != "" {
+				t.Errorf("status.variants (-want,+got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestReconcileRemovedTarget(t *testing.T) {
+	for _, tc := range []struct {
+		policy      api.DeletionPolicy
+		wantDeleted bool
+	}{
+		{policy: api.DeletionPolicyDelete, wantDeleted: true},
+		{policy: api.DeletionPolicyOrphan},
+	} {
+		t.Run(string(tc.policy), func(t *testing.T) {
+			pvs := packageVariantSet("cluster-a")
+			pvs.Spec.DeletionPolicy = tc.policy
+			kept := packageVariant(t, variantName(pvs.Name, "cluster-a", "basens"), "cluster-a", pvs)
+			removed := packageVariant(t, variantName(pvs.Name, "cluster-c", "basens"), "cluster-c", pvs)
+			r := newTestReconciler(t, pvs, kept, removed)
+
+			reconcile(t, r, pvs)
+
+			if got, want := controllerOf(t, r, kept.Name), "PackageVariantSet/basens"; got != want {
+				t.Errorf("controller of targeted package variant = %q, want %q", got, want)
+			}
+			if got := exists(t, r, removed); got == tc.wantDeleted {
+				t.Fatalf("untargeted package variant exists = %v, want %v", got, !tc.wantDeleted)
+			}
+			if !tc.wantDeleted {
+				if controller := metav1.GetControllerOf(removed); controller != nil {
+					t.Errorf("orphaned package variant is controlled by %s %s", controller.Kind, controller.Name)
+				}
+				if label, found := removed.Labels[api.PackageVariantSetLabel]; found {
+					t.Errorf("orphaned package variant has label %s=%s", api.PackageVariantSetLabel, label)
+				}
+			}
+		})
+	}
+}
+
+func TestReconcileFinalizer(t *testing.T) {
+	for _, tc := range []struct {
+		policy       api.DeletionPolicy
+		wantReleased bool
+	}{
+		// The controlled package variants are left to the garbage collector.
+		{policy: api.DeletionPolicyDelete},
+		{policy: api.DeletionPolicyOrphan, wantReleased: true},
+	} {
+		t.Run(string(tc.policy), func(t *testing.T) {
+			ctx := context.Background()
+			pvs := packageVariantSet("cluster-a")
+			pvs.Spec.DeletionPolicy = tc.policy
+			r := newTestReconciler(t, pvs)
+
+			reconcile(t, r, pvs)
+			if !exists(t, r, pvs) {
+				t.Fatalf("PackageVariantSet was deleted")
+			}
+			if !controllerutil.ContainsFinalizer(pvs, finalizer) {
+				t.Fatalf("finalizers = %v, want %s", pvs.Finalizers, finalizer)
+			}
+			name := variantName(pvs.Name, "cluster-a", "basens")
+			if got, want := controllerOf(t, r, name), "PackageVariantSet/basens"; got != want {
+				t.Fatalf("controller of package variant = %q, want %q", got, want)
+			}
+
+			// The finalizer keeps the set until the package variants are released.
+			if err := r.Delete(ctx, pvs); err != nil {
+				t.Fatalf("Delete failed: %v", err)
+			}
+			if !exists(t, r, pvs) {
+				t.Fatalf("PackageVariantSet was deleted before it was finalized")
+			}
+
+			reconcile(t, r, pvs)
+			if exists(t, r, pvs) {
+				t.Errorf("PackageVariantSet still exists after it was finalized; finalizers: %v", pvs.Finalizers)
+			}
+			wantController := "PackageVariantSet/basens"
+			if tc.wantReleased {
+				wantController = ""
+			}
+			if got := controllerOf(t, r, name); got != wantController {
+				t.Errorf("controller of package variant = %q, want %q", got, wantController)
+			}
+		})
+	}
+}
+
+func TestReconcileObjectSelector(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		kind          string
+		allowedKinds  kinds.Allowed
+		wantCondition string
+		wantVariants  int
+	}{
+		{name: "ConfigMap", kind: "ConfigMap", wantCondition: "Reconciled", wantVariants: 1},
+		{name: "kind not allowed", kind: "Secret", wantCondition: "SelectionNotAllowed"},
+		{name: "kind allowed", kind: "Secret", allowedKinds: kinds.Allowed{{Kind: "Secret"}}, wantCondition: "Reconciled", wantVariants: 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			pvs := packageVariantSet()
+			pvs.Spec.Targets = []api.Target{{
+				ObjectSelector: &api.ObjectSelector{APIVersion: "v1", Kind: tc.kind},
+				Template: &api.PackageVariantTemplate{
+					Downstream: &api.Downstream{Repo: "cluster-a", Package: "{{.target.metadata.name}}"},
+				},
+			}}
+			r := newTestReconciler(t, pvs,
+				&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "team-a"}},
+				&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "team-b"}},
+			)
+			r.AllowedKinds = tc.allowedKinds
+
+			reconcile(t, r, pvs)
+
+			if !exists(t, r, pvs) {
+				t.Fatalf("PackageVariantSet was deleted")
+			}
+			if got := meta.FindStatusCondition(pvs.Status.Conditions, ConditionTypeStalled); got == nil || got.Reason != tc.wantCondition {
+				t.Errorf("Stalled condition = %v, want reason %s", got, tc.wantCondition)
+			}
+			var pvList api.PackageVariantList
+			if err := r.List(context.Background(), &pvList); err != nil {
+				t.Fatalf("List failed: %v", err)
+			}
+			if got := len(pvList.Items); got != tc.wantVariants {
+				t.Errorf("got %d package variants, want %d", got, tc.wantVariants)
+			}
+		})
+	}
+}
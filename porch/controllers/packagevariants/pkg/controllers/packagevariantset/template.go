@@ -0,0 +1,136 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package packagevariantset
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	api "github.com/GoogleContainerTools/kpt/porch/controllers/packagevariants/api/v1alpha1"
+)
+
+// variant is a package variant generated for a downstream package of a target.
+type variant struct {
+	spec        api.PackageVariantSpec
+	labels      map[string]string
+	annotations map[string]string
+}
+
+func (v *variant) key() string {
+	return variantKey(v.spec.Downstream.Repo, v.spec.Downstream.Package)
+}
+
+func variantKey(repo, pkg string) string {
+	return repo + "/" + pkg
+}
+
+// templateInput is the input of the templates generating a package variant.
+// The repository is nil for targets which aren't repositories.
+type templateInput struct {
+	repository  map[string]interface{}
+	target      map[string]interface{}
+	repo        string
+	packageName string
+}
+
+// generateVariant generates the package variant of the upstream package for
+// the target, customized by the template.
+func generateVariant(upstream *api.Upstream, tmpl *api.PackageVariantTemplate, in templateInput) (*variant, error) {
+	data := map[string]interface{}{
+		"upstream": map[string]interface{}{
+			"repo":     upstream.Repo,
+			"package":  upstream.Package,
+			"revision": upstream.Revision,
+		},
+		"target":      in.target,
+		"packageName": in.packageName,
+	}
+	if in.repository != nil {
+		data["repository"] = in.repository
+	}
+	render := func(field, text string) (string, error) {
+		t, err := template.New(field).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return "", fmt.Errorf("invalid template %s: %w", field, err)
+		}
+		var out strings.Builder
+		if err := t.Execute(&out, data); err != nil {
+			return "", fmt.Errorf("cannot evaluate template %s: %w", field, err)
+		}
+		return out.String(), nil
+	}
+
+	v := &variant{
+		spec: api.PackageVariantSpec{
+			Upstream: upstream.DeepCopy(),
+			Downstream: &api.Downstream{
+				Repo:    in.repo,
+				Package: in.packageName,
+			},
+		},
+		labels:      map[string]string{},
+		annotations: map[string]string{},
+	}
+	if tmpl == nil {
+		tmpl = &api.PackageVariantTemplate{}
+	}
+
+	var err error
+	if tmpl.Downstream != nil {
+		if tmpl.Downstream.Repo != "" {
+			if v.spec.Downstream.Repo, err = render("downstream.repo", tmpl.Downstream.Repo); err != nil {
+				return nil, err
+			}
+		}
+		if tmpl.Downstream.Package != "" {
+			if v.spec.Downstream.Package, err = render("downstream.package", tmpl.Downstream.Package); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if v.spec.Downstream.Repo == "" {
+		return nil, fmt.Errorf("the template must set the downstream repository of targets which aren't repositories")
+	}
+
+	for i, injector := range tmpl.Injectors {
+		injector := *injector.DeepCopy()
+		if injector.Name, err = render(fmt.Sprintf("injectors[%d].name", i), injector.Name); err != nil {
+			return nil, err
+		}
+		v.spec.Injectors = append(v.spec.Injectors, injector)
+	}
+
+	if tmpl.PackageContext != nil {
+		v.spec.PackageContext = &api.PackageContext{Data: map[string]string{}}
+		for key, value := range tmpl.PackageContext.Data {
+			if v.spec.PackageContext.Data[key], err = render("packageContext.data."+key, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for key, value := range tmpl.Labels {
+		if v.labels[key], err = render("labels."+key, value); err != nil {
+			return nil, err
+		}
+	}
+	for key, value := range tmpl.Annotations {
+		if v.annotations[key], err = render("annotations."+key, value); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
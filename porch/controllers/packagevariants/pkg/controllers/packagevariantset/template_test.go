@@ -0,0 +1,148 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package packagevariantset
+
+import (
+	"strings"
+	"testing"
+
+	api "github.com/GoogleContainerTools/kpt/porch/controllers/packagevariants/api/v1alpha1"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGenerateVariant(t *testing.T) {
+	upstream := &api.Upstream{Repo: "blueprints", Package: "basens"}
+	repository := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name": "cluster-1",
+			"labels": map[string]interface{}{
+				"example.com/cluster": "us-east",
+			},
+		},
+	}
+	cluster := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name": "us-west",
+		},
+	}
+
+	for _, tc := range []struct {
+		name     string
+		template *api.PackageVariantTemplate
+		input    templateInput
+		want     *variant
+		wantErr  string
+	}{
+		{
+			name:  "repository without template",
+			input: templateInput{repository: repository, target: repository, repo: "cluster-1", packageName: "basens"},
+			want: &variant{
+				spec: api.PackageVariantSpec{
+					Upstream:   upstream,
+					Downstream: &api.Downstream{Repo: "cluster-1", Package: "basens"},
+				},
+				labels:      map[string]string{},
+				annotations: map[string]string{},
+			},
+		},
+		{
+			name: "repository with template",
+			template: &api.PackageVariantTemplate{
+				Downstream: &api.Downstream{Package: "{{.upstream.package}}-{{.target.metadata.name}}"},
+				Injectors:  []api.InjectionSelector{{Name: "{{.repository.metadata.name}}-config"}},
+				PackageContext: &api.PackageContext{Data: map[string]string{
+					"cluster": `{{index .repository.metadata.labels "example.com/cluster"}}`,
+				}},
+				Labels: map[string]string{"cluster": "{{.repository.metadata.name}}"},
+			},
+			input: templateInput{repository: repository, target: repository, repo: "cluster-1", packageName: "basens"},
+			want: &variant{
+				spec: api.PackageVariantSpec{
+					Upstream:       upstream,
+					Downstream:     &api.Downstream{Repo: "cluster-1", Package: "basens-cluster-1"},
+					Injectors:      []api.InjectionSelector{{Name: "cluster-1-config"}},
+					PackageContext: &api.PackageContext{Data: map[string]string{"cluster": "us-east"}},
+				},
+				labels:      map[string]string{"cluster": "cluster-1"},
+				annotations: map[string]string{},
+			},
+		},
+		{
+			name: "object",
+			template: &api.PackageVariantTemplate{
+				Downstream: &api.Downstream{Repo: "deployments", Package: "{{.target.metadata.name}}/{{.packageName}}"},
+			},
+			input: templateInput{target: cluster, packageName: "basens"},
+			want: &variant{
+				spec: api.PackageVariantSpec{
+					Upstream:   upstream,
+					Downstream: &api.Downstream{Repo: "deployments", Package: "us-west/basens"},
+				},
+				labels:      map[string]string{},
+				annotations: map[string]string{},
+			},
+		},
+		{
+			name:    "object without repository",
+			input:   templateInput{target: cluster, packageName: "basens"},
+			wantErr: "must set the downstream repository",
+		},
+		{
+			name: "missing key",
+			template: &api.PackageVariantTemplate{
+				Downstream: &api.Downstream{Repo: "{{.repository.metadata.name}}"},
+			},
+			input:   templateInput{target: cluster, packageName: "basens"},
+			wantErr: "cannot evaluate template downstream.repo",
+		},
+		{
+			name: "invalid template",
+			template: &api.PackageVariantTemplate{
+				Labels: map[string]string{"cluster": "{{.target"},
+			},
+			input:   templateInput{repository: repository, target: repository, repo: "cluster-1", packageName: "basens"},
+			wantErr: "invalid template labels.cluster",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := generateVariant(upstream, tc.template, tc.input)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("generateVariant() error = %v, want error containing %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("generateVariant() failed: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(variant{})); diff != "" {
+				t.Errorf("generateVariant() returned unexpected variant (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestVariantName(t *testing.T) {
+	name := variantName("basens", "cluster-1", "basens")
+	if got := variantName("basens", "cluster-1", "basens"); got != name {
+		t.Errorf("variantName() is not stable: %q != %q", got, name)
+	}
+	if other := variantName("basens", "cluster-2", "basens"); other == name {
+		t.Errorf("variantName() returned %q for different downstream packages", name)
+	}
+	if !strings.HasPrefix(name, "basens-") {
+		t.Errorf("variantName() = %q, want prefix %q", name, "basens-")
+	}
+}
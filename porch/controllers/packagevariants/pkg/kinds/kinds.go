@@ -0,0 +1,69 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kinds
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ConfigMap is the kind of in-cluster object the controllers may always read.
+var ConfigMap = schema.GroupKind{Kind: "ConfigMap"}
+
+// Allowed is the list of kinds of in-cluster objects, besides ConfigMaps, the
+// package variant controllers may read on behalf of their users. The role of
+// the controllers must also grant access to them.
+//
+// Allowed implements flag.Value; the value is a comma-separated list of kinds
+// in the form kind.group, e.g. "Namespace,Deployment.apps".
+type Allowed []schema.GroupKind
+
+// Allows returns true if objects of the kind may be read.
+func (a Allowed) Allows(gk schema.GroupKind) bool {
+	if gk == ConfigMap {
+		return true
+	}
+	for _, allowed := range a {
+		if allowed == gk {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *Allowed) String() string {
+	var kinds []string
+	for _, gk := range *a {
+		kinds = append(kinds, gk.String())
+	}
+	return strings.Join(kinds, ",")
+}
+
+func (a *Allowed) Set(value string) error {
+	for _, kind := range strings.Split(value, ",") {
+		kind = strings.TrimSpace(kind)
+		if kind == "" {
+			continue
+		}
+		gk := schema.ParseGroupKind(kind)
+		if gk.Kind == "" {
+			return fmt.Errorf("invalid kind %q", kind)
+		}
+		*a = append(*a, gk)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kinds
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestAllowed(t *testing.T) {
+	var allowed Allowed
+	if err := allowed.Set("Namespace, Deployment.apps"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if diff := cmp.Diff(Allowed{{Kind: "Namespace"}, {Group: "apps", Kind: "Deployment"}}, allowed); diff != "" {
+		t.Errorf("allowed kinds (-want,+got): %s", diff)
+	}
+	if err := allowed.Set(".apps"); err == nil {
+		t.Errorf("Set of a kind without a name succeeded")
+	}
+
+	for gk, want := range map[schema.GroupKind]bool{
+		ConfigMap:                           true,
+		{Kind: "Namespace"}:                 true,
+		{Group: "apps", Kind: "Deployment"}: true,
+		{Kind: "Secret"}:                    false,
+		{Kind: "Deployment"}:                false,
+	} {
+		if got := allowed.Allows(gk); got != want {
+			t.Errorf("Allows(%s) = %v, want %v", gk, got, want)
+		}
+	}
+}
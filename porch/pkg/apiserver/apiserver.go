@@ -17,13 +17,16 @@ package apiserver
 import (
 	"context"
 	"fmt"
+	"net/http"
 
+	"github.com/GoogleContainerTools/kpt/internal/util/merge"
 	"github.com/GoogleContainerTools/kpt/porch/api/porch/install"
 	configapi "github.com/GoogleContainerTools/kpt/porch/api/porchconfig/v1alpha1"
 	"github.com/GoogleContainerTools/kpt/porch/pkg/cache"
 	"github.com/GoogleContainerTools/kpt/porch/pkg/engine"
 	"github.com/GoogleContainerTools/kpt/porch/pkg/kpt"
 	"github.com/GoogleContainerTools/kpt/porch/pkg/registry/porch"
+	"github.com/GoogleContainerTools/kpt/porch/pkg/webhook"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -33,6 +36,7 @@ import (
 	genericapiserver "k8s.io/apiserver/pkg/server"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -67,6 +71,13 @@ type ExtraConfig struct {
 	CoreAPIKubeconfigPath string
 	CacheDirectory        string
 	FunctionRunnerAddress string
+	UpdateSchemaDirectory string
+	// WebhookAddress is the address on which the server receives push
+	// notifications; the webhook is disabled if empty.
+	WebhookAddress string
+	// WebhookSecret authenticates the push notifications; all of them are
+	// rejected if it is empty.
+	WebhookSecret []byte
 }
 
 // Config defines the config for the apiserver
@@ -80,6 +91,8 @@ type PorchServer struct {
 	GenericAPIServer *genericapiserver.GenericAPIServer
 	coreClient       client.WithWatch
 	cache            *cache.Cache
+	syncStatus       *porch.RepoSyncStatusUpdater
+	webhookServer    *http.Server
 }
 
 type completedConfig struct {
@@ -168,10 +181,19 @@ func (c completedConfig) New() (*PorchServer, error) {
 
 	renderer := kpt.NewRenderer()
 
+	syncStatus := porch.NewRepoSyncStatusUpdater(coreClient)
 	cache := cache.NewCache(c.ExtraConfig.CacheDirectory, cache.CacheOptions{
 		CredentialResolver: credentialResolver,
 		UserInfoProvider:   userInfoProvider,
+		RepoSyncNotifier:   syncStatus,
 	})
+	updateSchemas := merge.NewSchemas()
+	if c.ExtraConfig.UpdateSchemaDirectory != "" {
+		if err := updateSchemas.AddPath(c.ExtraConfig.UpdateSchemaDirectory); err != nil {
+			return nil, fmt.Errorf("failed to load update schemas: %w", err)
+		}
+	}
+
 	cad, err := engine.NewCaDEngine(
 		engine.WithCache(cache),
 		// The order of registering the function runtimes matters here. When
@@ -183,6 +205,7 @@ func (c completedConfig) New() (*PorchServer, error) {
 		engine.WithRenderer(renderer),
 		engine.WithReferenceResolver(referenceResolver),
 		engine.WithUserInfoProvider(userInfoProvider),
+		engine.WithUpdateSchemas(updateSchemas),
 	)
 	if err != nil {
 		return nil, err
@@ -197,6 +220,18 @@ func (c completedConfig) New() (*PorchServer, error) {
 		GenericAPIServer: genericServer,
 		coreClient:       coreClient,
 		cache:            cache,
+		syncStatus:       syncStatus,
+	}
+
+	if c.ExtraConfig.WebhookAddress != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/webhook", webhook.NewHandler(coreClient, cache, webhook.Options{
+			Secret: c.ExtraConfig.WebhookSecret,
+		}))
+		s.webhookServer = &http.Server{
+			Addr:    c.ExtraConfig.WebhookAddress,
+			Handler: mux,
+		}
 	}
 
 	// Install the groups.
@@ -208,6 +243,24 @@ func (c completedConfig) New() (*PorchServer, error) {
 }
 
 func (s *PorchServer) Run(ctx context.Context) error {
-	porch.RunBackground(ctx, s.coreClient, s.cache)
+	porch.RunBackground(ctx, s.coreClient, s.cache, s.syncStatus)
+	if s.webhookServer != nil {
+		go s.runWebhookServer(ctx)
+	}
 	return s.GenericAPIServer.PrepareRun().Run(ctx.Done())
 }
+
+// runWebhookServer serves the push notifications until ctx is done.
+func (s *PorchServer) runWebhookServer(ctx context.Context) {
+	go func() {
+		<-ctx.Done()
+		if err := s.webhookServer.Shutdown(context.Background()); err != nil {
+			klog.Warningf("Failed to shut down webhook server: %v", err)
+		}
+	}()
+
+	klog.Infof("Serving push notifications on %s", s.webhookServer.Addr)
+	if err := s.webhookServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		klog.Errorf("Webhook server failed: %v", err)
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"path/filepath"
 	"sync"
+	"time"
 
 	configapi "github.com/GoogleContainerTools/kpt/porch/api/porchconfig/v1alpha1"
 	"github.com/GoogleContainerTools/kpt/porch/pkg/git"
@@ -37,18 +38,28 @@ import (
 // * Caches oci images with further hierarchy underneath
 // * We Cache image layers in <cacheDir>/oci/layers/ (this might be obsolete with the flattened Cache)
 // * We Cache flattened tar files in <cacheDir>/oci/ (so we don't need to pull to read resources)
-// * We poll the repositories (every minute) and Cache the discovered images in memory.
+// * We poll the repositories (every `syncInterval`, or on push notification) and Cache the discovered images in memory.
 type Cache struct {
 	mutex              sync.Mutex
 	repositories       map[string]*cachedRepository
 	cacheDir           string
 	credentialResolver repository.CredentialResolver
 	userInfoProvider   repository.UserInfoProvider
+	repoSyncNotifier   RepoSyncNotifier
+	watcherManager     *watcherManager
+}
+
+// RepoSyncNotifier is notified after each synchronization of a cached repository.
+// It is called on the refresh path, possibly with the context of a user request,
+// so implementations must not block.
+type RepoSyncNotifier interface {
+	NotifyRepoSynced(ctx context.Context, repositorySpec *configapi.Repository, syncTime time.Time, syncErr error)
 }
 
 type CacheOptions struct {
 	CredentialResolver repository.CredentialResolver
 	UserInfoProvider   repository.UserInfoProvider
+	RepoSyncNotifier   RepoSyncNotifier
 }
 
 func NewCache(cacheDir string, opts CacheOptions) *Cache {
@@ -57,6 +68,8 @@ func NewCache(cacheDir string, opts CacheOptions) *Cache {
 		cacheDir:           cacheDir,
 		credentialResolver: opts.CredentialResolver,
 		userInfoProvider:   opts.UserInfoProvider,
+		repoSyncNotifier:   opts.RepoSyncNotifier,
+		watcherManager:     newWatcherManager(),
 	}
 }
 
@@ -81,8 +94,10 @@ func (c *Cache) OpenRepository(ctx context.Context, repositorySpec *configapi.Re
 			if err != nil {
 				return nil, err
 			}
-			cr = newRepository(key, r)
+			cr = newRepository(key, r, repositorySpec, c.watcherManager, c.repoSyncNotifier)
 			c.repositories[key] = cr
+		} else {
+			cr.setRepositorySpec(repositorySpec)
 		}
 		return cr, nil
 
@@ -110,10 +125,11 @@ func (c *Cache) OpenRepository(ctx context.Context, repositorySpec *configapi.Re
 			}); err != nil {
 				return nil, err
 			} else {
-				cr = newRepository(key, r)
+				cr = newRepository(key, r, repositorySpec, c.watcherManager, c.repoSyncNotifier)
 				c.repositories[key] = cr
 			}
 		} else {
+			cr.setRepositorySpec(repositorySpec)
 			// If there is an error from the background refresh goroutine, return it.
 			if err := cr.getRefreshError(); err != nil {
 				return nil, err
@@ -130,26 +146,53 @@ func isPackageContent(content configapi.RepositoryContent) bool {
 	return content == configapi.RepositoryContentPackage
 }
 
-func (c *Cache) CloseRepository(repositorySpec *configapi.Repository) error {
-	var key string
-
+// repositoryKey returns the key of the repository in the cache.
+func repositoryKey(repositorySpec *configapi.Repository) (string, error) {
 	switch repositorySpec.Spec.Type {
 	case configapi.RepositoryTypeOCI:
 		oci := repositorySpec.Spec.Oci
 		if oci == nil {
-			return fmt.Errorf("oci not configured for %s:%s", repositorySpec.ObjectMeta.Namespace, repositorySpec.ObjectMeta.Name)
+			return "", fmt.Errorf("oci not configured for %s:%s", repositorySpec.ObjectMeta.Namespace, repositorySpec.ObjectMeta.Name)
 		}
-		key = "oci://" + oci.Registry
+		return "oci://" + oci.Registry, nil
 
 	case configapi.RepositoryTypeGit:
 		git := repositorySpec.Spec.Git
 		if git == nil {
-			return fmt.Errorf("git not configured for %s:%s", repositorySpec.ObjectMeta.Namespace, repositorySpec.ObjectMeta.Name)
+			return "", fmt.Errorf("git not configured for %s:%s", repositorySpec.ObjectMeta.Namespace, repositorySpec.ObjectMeta.Name)
 		}
-		key = "git://" + git.Repo
+		return "git://" + git.Repo, nil
 
 	default:
-		return fmt.Errorf("unknown repository type: %q", repositorySpec.Spec.Type)
+		return "", fmt.Errorf("unknown repository type: %q", repositorySpec.Spec.Type)
+	}
+}
+
+// RefreshRepository asks the cached repository to synchronize with the
+// underlying repository as soon as possible, for example after a push
+// notification. The refresh is asynchronous; it returns false if the
+// repository isn't cached.
+func (c *Cache) RefreshRepository(repositorySpec *configapi.Repository) (bool, error) {
+	key, err := repositoryKey(repositorySpec)
+	if err != nil {
+		return false, err
+	}
+
+	c.mutex.Lock()
+	cr := c.repositories[key]
+	c.mutex.Unlock()
+
+	if cr == nil {
+		return false, nil
+	}
+	cr.requestRefresh()
+	return true, nil
+}
+
+func (c *Cache) CloseRepository(repositorySpec *configapi.Repository) error {
+	key, err := repositoryKey(repositorySpec)
+	if err != nil {
+		return err
 	}
 
 	// TODO: Multiple Repository resources can point to the same underlying repository
@@ -23,6 +23,9 @@ import (
 type cachedDraft struct {
 	repository.PackageDraft
 	cache *cachedRepository
+	// created is true if the draft is a new package revision, rather than an
+	// update of an existing one.
+	created bool
 }
 
 var _ repository.PackageDraft = &cachedDraft{}
@@ -31,6 +34,6 @@ func (cd *cachedDraft) Close(ctx context.Context) (repository.PackageRevision, e
 	if closed, err := cd.PackageDraft.Close(ctx); err != nil {
 		return nil, err
 	} else {
-		return cd.cache.update(closed), nil
+		return cd.cache.update(closed, cd.created), nil
 	}
 }
@@ -22,20 +22,35 @@ import (
 	"time"
 
 	"github.com/GoogleContainerTools/kpt/porch/api/porch/v1alpha1"
+	configapi "github.com/GoogleContainerTools/kpt/porch/api/porchconfig/v1alpha1"
 	"github.com/GoogleContainerTools/kpt/porch/pkg/repository"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/mod/semver"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/klog/v2"
 )
 
 var tracer = otel.Tracer("cache")
 
+// defaultSyncInterval is how often repositories are polled for changes
+// unless the Repository specifies its own sync interval.
+const defaultSyncInterval = 1 * time.Minute
+
 type cachedRepository struct {
 	id     string
 	repo   repository.Repository
 	cancel context.CancelFunc
 
+	// repositorySpec is the Repository the cached repository was last opened with.
+	repositorySpec *configapi.Repository
+	// syncNotifier, if set, is notified after each synchronization.
+	syncNotifier RepoSyncNotifier
+	// refreshRequests triggers an immediate synchronization of the repository.
+	refreshRequests chan struct{}
+	// syncIntervalChanged reschedules the next synchronization of the repository.
+	syncIntervalChanged chan struct{}
+
 	mutex          sync.Mutex
 	cachedPackages []*cachedPackageRevision
 	// TODO: Currently we support repositories with homogenous content (only packages xor functions). Model this more optimally?
@@ -43,6 +58,14 @@ type cachedRepository struct {
 	// Error encountered on repository refresh by the refresh goroutine.
 	// This is returned back by the cache to the background goroutine when it calls periodicall to resync repositories.
 	refreshError error
+	// refreshing is the refresh of the cached packages in flight, if any.
+	refreshing *packagesRefresh
+
+	// watcherManager is notified of the changes to the cached packages.
+	watcherManager *watcherManager
+	// lastPackages are the packages last known to the watchers, kept when
+	// the cached packages are dropped because of a refresh error.
+	lastPackages map[string]revisionState
 }
 
 // We take advantage of the cache having a global view of all the packages
@@ -68,12 +91,17 @@ func (c *cachedPackageRevision) GetPackageRevision() *v1alpha1.PackageRevision {
 
 var _ repository.PackageRevision = &cachedPackageRevision{}
 
-func newRepository(id string, repo repository.Repository) *cachedRepository {
+func newRepository(id string, repo repository.Repository, repositorySpec *configapi.Repository, watcherManager *watcherManager, syncNotifier RepoSyncNotifier) *cachedRepository {
 	ctx, cancel := context.WithCancel(context.Background())
 	r := &cachedRepository{
-		id:     id,
-		repo:   repo,
-		cancel: cancel,
+		id:                  id,
+		repo:                repo,
+		cancel:              cancel,
+		repositorySpec:      repositorySpec.DeepCopy(),
+		syncNotifier:        syncNotifier,
+		refreshRequests:     make(chan struct{}, 1),
+		syncIntervalChanged: make(chan struct{}, 1),
+		watcherManager:      watcherManager,
 	}
 
 	go r.pollForever(ctx)
@@ -118,17 +146,7 @@ func (r *cachedRepository) getPackages(ctx context.Context, filter repository.Li
 	}
 
 	if packages == nil {
-		// TODO: Avoid simultaneous fetches?
-		// TODO: Push-down partial refresh?
-		p, err := r.repo.ListPackageRevisions(ctx, repository.ListPackageRevisionFilter{})
-		if err == nil {
-			packages = toCachedPackageRevisionSlice(p)
-		}
-
-		r.mutex.Lock()
-		r.cachedPackages = packages
-		r.refreshError = err
-		r.mutex.Unlock()
+		packages, err = r.refreshPackages(ctx, forceRefresh)
 	}
 
 	if err != nil {
@@ -138,6 +156,66 @@ func (r *cachedRepository) getPackages(ctx context.Context, filter repository.Li
 	return toPackageRevisionSlice(packages, filter), nil
 }
 
+// packagesRefresh is a refresh of the cached packages, whose outcome is
+// shared by all the callers which requested the refresh while it ran.
+type packagesRefresh struct {
+	done     chan struct{}
+	packages []*cachedPackageRevision
+	err      error
+}
+
+// refreshPackages reloads the cached packages from the repository, which
+// only loads the package revisions that changed since the last refresh.
+// Concurrent refreshes are coalesced: callers join the refresh in flight,
+// except for forced refreshes, which must observe the changes made before
+// they were requested, and so wait for the refresh in flight and join the
+// next one.
+func (r *cachedRepository) refreshPackages(ctx context.Context, force bool) ([]*cachedPackageRevision, error) {
+	r.mutex.Lock()
+	if inflight := r.refreshing; inflight != nil {
+		r.mutex.Unlock()
+		select {
+		case <-inflight.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if !force {
+			return inflight.packages, inflight.err
+		}
+		// Any refresh now in flight started after this one was requested.
+		return r.refreshPackages(ctx, false)
+	}
+	refresh := &packagesRefresh{done: make(chan struct{})}
+	r.refreshing = refresh
+	r.mutex.Unlock()
+
+	var packages []*cachedPackageRevision
+	p, err := r.repo.ListPackageRevisions(ctx, repository.ListPackageRevisionFilter{})
+	if err == nil {
+		packages = toCachedPackageRevisionSlice(p)
+	}
+
+	r.mutex.Lock()
+	before := r.lastPackages
+	if r.cachedPackages != nil {
+		before = snapshotRevisions(r.cachedPackages)
+	}
+	r.cachedPackages = packages
+	r.refreshError = err
+	r.notifyChanges(before)
+	repositorySpec := r.repositorySpec
+	r.refreshing = nil
+	r.mutex.Unlock()
+
+	refresh.packages, refresh.err = packages, err
+	close(refresh.done)
+
+	if r.syncNotifier != nil {
+		r.syncNotifier.NotifyRepoSynced(ctx, repositorySpec, time.Now(), err)
+	}
+	return packages, err
+}
+
 func (r *cachedRepository) getFunctions(ctx context.Context, force bool) ([]repository.Function, error) {
 	var functions []repository.Function
 
@@ -176,6 +254,7 @@ func (r *cachedRepository) CreatePackageRevision(ctx context.Context, obj *v1alp
 	return &cachedDraft{
 		PackageDraft: created,
 		cache:        r,
+		created:      true,
 	}, nil
 }
 
@@ -193,14 +272,29 @@ func (r *cachedRepository) UpdatePackage(ctx context.Context, old repository.Pac
 	}, nil
 }
 
-func (r *cachedRepository) update(closed repository.PackageRevision) *cachedPackageRevision {
+func (r *cachedRepository) update(closed repository.PackageRevision, created bool) *cachedPackageRevision {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
 	cached := &cachedPackageRevision{PackageRevision: closed}
+	if r.cachedPackages == nil {
+		// The packages will be loaded from the repository when listed, so we
+		// can't compare with the cached revision, and rely on the draft to
+		// tell whether the revision is new.
+		if r.watcherManager != nil {
+			eventType := watch.Modified
+			if created {
+				eventType = watch.Added
+			}
+			r.watcherManager.notify(eventType, cached)
+		}
+		return cached
+	}
+	before := snapshotRevisions(r.cachedPackages)
 	r.cachedPackages = updateOrAppend(r.cachedPackages, cached)
 	// Recompute latest package revisions.
 	identifyLatestRevisions(r.cachedPackages)
+	r.notifyChanges(before)
 	return cached
 }
 
@@ -223,31 +317,110 @@ func (r *cachedRepository) DeletePackageRevision(ctx context.Context, old reposi
 	}
 
 	r.mutex.Lock()
-	// TODO: Do something more efficient than a full cache flush
-	r.cachedPackages = nil
-	r.mutex.Unlock()
+	defer r.mutex.Unlock()
+	if r.cachedPackages == nil {
+		if r.watcherManager != nil {
+			r.watcherManager.notify(watch.Deleted, old)
+		}
+		return nil
+	}
+	before := snapshotRevisions(r.cachedPackages)
+	r.cachedPackages = remove(r.cachedPackages, old.KubeObjectName())
+	identifyLatestRevisions(r.cachedPackages)
+	r.notifyChanges(before)
 
 	return nil
 }
 
+func remove(revisions []*cachedPackageRevision, kubeObjectName string) []*cachedPackageRevision {
+	result := make([]*cachedPackageRevision, 0, len(revisions))
+	for _, cached := range revisions {
+		if cached.KubeObjectName() != kubeObjectName {
+			result = append(result, cached)
+		}
+	}
+	return result
+}
+
 func (r *cachedRepository) Close() error {
 	r.cancel()
 	return nil
 }
 
+// setRepositorySpec records the Repository the cached repository was opened
+// with, and reschedules the next synchronization if the sync interval changed.
+func (r *cachedRepository) setRepositorySpec(repositorySpec *configapi.Repository) {
+	r.mutex.Lock()
+	changed := syncInterval(r.repositorySpec) != syncInterval(repositorySpec)
+	r.repositorySpec = repositorySpec.DeepCopy()
+	r.mutex.Unlock()
+
+	if changed {
+		signal(r.syncIntervalChanged)
+	}
+}
+
+func (r *cachedRepository) getSyncInterval() time.Duration {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return syncInterval(r.repositorySpec)
+}
+
+// requestRefresh asks the poller to synchronize the repository immediately.
+// Requests made while a synchronization is pending are coalesced.
+func (r *cachedRepository) requestRefresh() {
+	signal(r.refreshRequests)
+}
+
+// syncInterval returns how often the repository should be polled for changes.
+func syncInterval(repositorySpec *configapi.Repository) time.Duration {
+	if repositorySpec == nil || repositorySpec.Spec.SyncInterval == nil || repositorySpec.Spec.SyncInterval.Duration <= 0 {
+		return defaultSyncInterval
+	}
+	return repositorySpec.Spec.SyncInterval.Duration
+}
+
+// signal sends to the buffered channel without blocking; if a signal is
+// already pending, the new one is dropped.
+func signal(ch chan<- struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
 // pollForever will continue polling until signal channel is closed or ctx is done.
 func (r *cachedRepository) pollForever(ctx context.Context) {
-	ticker := time.NewTicker(1 * time.Minute)
+	timer := time.NewTimer(r.getSyncInterval())
+	defer timer.Stop()
 
 	for {
 		select {
-		case <-ticker.C:
+		case <-timer.C:
+			r.pollOnce(ctx)
+
+		case <-r.refreshRequests:
+			stopTimer(timer)
 			r.pollOnce(ctx)
 
+		case <-r.syncIntervalChanged:
+			stopTimer(timer)
+
 		case <-ctx.Done():
 			klog.V(2).Infof("exiting repository poller, because context is done: %v", ctx.Err())
 			return
 		}
+		timer.Reset(r.getSyncInterval())
+	}
+}
+
+// stopTimer stops the timer and drains its channel so it can be reset.
+func stopTimer(timer *time.Timer) {
+	if !timer.Stop() {
+		select {
+		case <-timer.C:
+		default:
+		}
 	}
 }
 
@@ -0,0 +1,153 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/GoogleContainerTools/kpt/porch/api/porchconfig/v1alpha1"
+	"github.com/GoogleContainerTools/kpt/porch/pkg/git"
+	"github.com/GoogleContainerTools/kpt/porch/pkg/repository"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type syncEvent struct {
+	name string
+	err  error
+}
+
+type fakeSyncNotifier chan syncEvent
+
+func (n fakeSyncNotifier) NotifyRepoSynced(ctx context.Context, repositorySpec *v1alpha1.Repository, syncTime time.Time, syncErr error) {
+	n <- syncEvent{name: repositorySpec.Name, err: syncErr}
+}
+
+func TestRefreshRepository(t *testing.T) {
+	ctx := context.Background()
+	tarfile := filepath.Join("..", "git", "testdata", "nested-repository.tar")
+	_, address := git.ServeGitRepository(t, tarfile, t.TempDir())
+
+	notifier := make(fakeSyncNotifier, 10)
+	cache := NewCache(t.TempDir(), CacheOptions{RepoSyncNotifier: notifier})
+	spec := &v1alpha1.Repository{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "refresh",
+			Namespace: "default",
+		},
+		Spec: v1alpha1.RepositorySpec{
+			Type:    v1alpha1.RepositoryTypeGit,
+			Content: v1alpha1.RepositoryContentPackage,
+			Git: &v1alpha1.GitRepository{
+				Repo: address,
+			},
+			// Long enough for the repository not to be polled during the test.
+			SyncInterval: &metav1.Duration{Duration: time.Hour},
+		},
+	}
+
+	if refreshed, err := cache.RefreshRepository(spec); err != nil || refreshed {
+		t.Errorf("RefreshRepository of repository which isn't cached = %t, %v; want false, nil", refreshed, err)
+	}
+
+	cached, err := cache.OpenRepository(ctx, spec)
+	if err != nil {
+		t.Fatalf("OpenRepository failed: %v", err)
+	}
+	if _, err := cached.ListPackageRevisions(ctx, repository.ListPackageRevisionFilter{}); err != nil {
+		t.Fatalf("ListPackageRevisions failed: %v", err)
+	}
+	waitForSync(t, notifier, "refresh")
+
+	if refreshed, err := cache.RefreshRepository(spec); err != nil || !refreshed {
+		t.Fatalf("RefreshRepository = %t, %v; want true, nil", refreshed, err)
+	}
+	waitForSync(t, notifier, "refresh")
+}
+
+// blockingRepository lists no packages, once each listing is released.
+type blockingRepository struct {
+	repository.Repository
+
+	calls   int32
+	started chan struct{}
+	release chan struct{}
+}
+
+func (r *blockingRepository) ListPackageRevisions(ctx context.Context, filter repository.ListPackageRevisionFilter) ([]repository.PackageRevision, error) {
+	atomic.AddInt32(&r.calls, 1)
+	r.started <- struct{}{}
+	<-r.release
+	return nil, nil
+}
+
+func TestCoalesceRefreshes(t *testing.T) {
+	ctx := context.Background()
+	repo := &blockingRepository{
+		started: make(chan struct{}, 2),
+		release: make(chan struct{}),
+	}
+	cached := &cachedRepository{id: "coalesce", repo: repo}
+
+	var wg sync.WaitGroup
+	getPackages := func(force bool) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := cached.getPackages(ctx, repository.ListPackageRevisionFilter{}, force); err != nil {
+				t.Errorf("getPackages failed: %v", err)
+			}
+		}()
+	}
+
+	getPackages(true)
+	<-repo.started
+
+	// Refreshes requested while the first one is in flight join it, except
+	// for the forced refresh which waits for another listing.
+	getPackages(false)
+	getPackages(false)
+	getPackages(true)
+	time.Sleep(100 * time.Millisecond)
+
+	repo.release <- struct{}{}
+	<-repo.started
+	repo.release <- struct{}{}
+	wg.Wait()
+
+	if got, want := atomic.LoadInt32(&repo.calls), int32(2); got != want {
+		t.Errorf("Repository was listed %d times, want %d", got, want)
+	}
+}
+
+func waitForSync(t *testing.T, notifier fakeSyncNotifier, name string) {
+	t.Helper()
+
+	select {
+	case event := <-notifier:
+		if event.name != name {
+			t.Errorf("Synchronized repository %q, want %q", event.name, name)
+		}
+		if event.err != nil {
+			t.Errorf("Synchronization failed: %v", event.err)
+		}
+	case <-time.After(30 * time.Second):
+		t.Fatalf("Timed out waiting for synchronization of repository %q", name)
+	}
+}
@@ -0,0 +1,249 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/GoogleContainerTools/kpt/porch/pkg/repository"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// watchWindow is the number of events kept for resuming watches.
+const watchWindow = 1000
+
+// ErrResourceVersionExpired is returned when a watch can't be resumed from a
+// resource version because it's older than the events kept by the cache.
+var ErrResourceVersionExpired = errors.New("resource version is too old")
+
+// PackageRevisionWatcher is called for every change to the package revisions
+// in the cache, with the resource version of the change. The watcher is
+// called in the order of the changes and must not block. Returning false
+// stops the watch.
+type PackageRevisionWatcher func(eventType watch.EventType, rev repository.PackageRevision, resourceVersion string) bool
+
+// packageRevisionEvent is a change to a package revision, recorded to
+// resume watches.
+type packageRevisionEvent struct {
+	seq       uint64
+	eventType watch.EventType
+	revision  repository.PackageRevision
+	// objectResourceVersion is the resource version of the package revision
+	// object, which clients may resume watches from instead of seq.
+	objectResourceVersion string
+}
+
+// watcherManager records the changes to the package revisions of all
+// repositories in the cache and sends them to the registered watchers.
+type watcherManager struct {
+	mutex    sync.Mutex
+	seq      uint64
+	events   []packageRevisionEvent
+	watchers map[*registeredWatcher]bool
+}
+
+type registeredWatcher struct {
+	watcher PackageRevisionWatcher
+}
+
+func newWatcherManager() *watcherManager {
+	return &watcherManager{
+		watchers: make(map[*registeredWatcher]bool),
+	}
+}
+
+// resourceVersion returns the resource version of the latest change.
+func (m *watcherManager) resourceVersion() string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return strconv.FormatUint(m.seq, 10)
+}
+
+// notify records a change and sends it to the watchers.
+func (m *watcherManager) notify(eventType watch.EventType, rev repository.PackageRevision) {
+	objectResourceVersion := rev.GetPackageRevision().ResourceVersion
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.seq++
+	m.events = append(m.events, packageRevisionEvent{
+		seq:                   m.seq,
+		eventType:             eventType,
+		revision:              rev,
+		objectResourceVersion: objectResourceVersion,
+	})
+	if len(m.events) > watchWindow {
+		m.events = m.events[len(m.events)-watchWindow:]
+	}
+
+	resourceVersion := strconv.FormatUint(m.seq, 10)
+	for w := range m.watchers {
+		if !w.watcher(eventType, rev, resourceVersion) {
+			delete(m.watchers, w)
+		}
+	}
+}
+
+// watch registers the watcher, after sending it the changes since
+// resourceVersion. An empty resourceVersion only sends new changes.
+func (m *watcherManager) watch(ctx context.Context, resourceVersion string, watcher PackageRevisionWatcher) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	replay, err := m.eventsSince(resourceVersion)
+	if err != nil {
+		return err
+	}
+	for _, e := range replay {
+		if !watcher(e.eventType, e.revision, strconv.FormatUint(e.seq, 10)) {
+			return nil
+		}
+	}
+
+	w := &registeredWatcher{watcher: watcher}
+	m.watchers[w] = true
+	go func() {
+		<-ctx.Done()
+		m.mutex.Lock()
+		delete(m.watchers, w)
+		m.mutex.Unlock()
+	}()
+	return nil
+}
+
+// eventsSince returns the recorded events after resourceVersion, which is
+// either the resource version of a change, or the resource version of a
+// package revision object in one of the recorded events.
+func (m *watcherManager) eventsSince(resourceVersion string) ([]packageRevisionEvent, error) {
+	if resourceVersion == "" {
+		return nil, nil
+	}
+
+	if seq, err := strconv.ParseUint(resourceVersion, 10, 64); err == nil && seq <= m.seq {
+		if seq == m.seq {
+			return nil, nil
+		}
+		if len(m.events) == 0 || seq+1 < m.events[0].seq {
+			return nil, fmt.Errorf("%w: %s", ErrResourceVersionExpired, resourceVersion)
+		}
+		return m.copyEvents(int(seq + 1 - m.events[0].seq)), nil
+	}
+
+	for i, e := range m.events {
+		if e.objectResourceVersion == resourceVersion {
+			return m.copyEvents(i + 1), nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrResourceVersionExpired, resourceVersion)
+}
+
+func (m *watcherManager) copyEvents(from int) []packageRevisionEvent {
+	return append([]packageRevisionEvent{}, m.events[from:]...)
+}
+
+// WatchPackageRevisions calls watcher for the changes to package revisions
+// after resourceVersion until ctx is done. The resource version is either
+// one returned by ResourceVersion or passed to a watcher, or the resource
+// version of a package revision changed recently. ErrResourceVersionExpired
+// is returned if the changes since resourceVersion are no longer known.
+func (c *Cache) WatchPackageRevisions(ctx context.Context, resourceVersion string, watcher PackageRevisionWatcher) error {
+	return c.watcherManager.watch(ctx, resourceVersion, watcher)
+}
+
+// ResourceVersion returns the resource version of the latest change to the
+// package revisions in the cache.
+func (c *Cache) ResourceVersion() string {
+	return c.watcherManager.resourceVersion()
+}
+
+// revisionState is the state of a cached package revision, used to find
+// the changes made to the cache.
+type revisionState struct {
+	revision *cachedPackageRevision
+	latest   bool
+}
+
+func snapshotRevisions(revisions []*cachedPackageRevision) map[string]revisionState {
+	if revisions == nil {
+		return nil
+	}
+	states := make(map[string]revisionState, len(revisions))
+	for _, r := range revisions {
+		states[r.KubeObjectName()] = revisionState{revision: r, latest: r.isLatestRevision}
+	}
+	return states
+}
+
+// notifyChanges sends the changes to the cached package revisions since
+// the before snapshot to the watchers. The caller must hold r.mutex.
+func (r *cachedRepository) notifyChanges(before map[string]revisionState) {
+	if r.cachedPackages == nil {
+		if before != nil {
+			r.lastPackages = before
+		}
+		return
+	}
+	after := snapshotRevisions(r.cachedPackages)
+	r.lastPackages = nil
+	if r.watcherManager == nil || before == nil {
+		return
+	}
+	var deleted []string
+	for name := range before {
+		if _, found := after[name]; !found {
+			deleted = append(deleted, name)
+		}
+	}
+	sort.Strings(deleted)
+	for _, name := range deleted {
+		r.watcherManager.notify(watch.Deleted, before[name].snapshot())
+	}
+	// The cached packages are visited in order for a stable order of events.
+	for _, rev := range r.cachedPackages {
+		name := rev.KubeObjectName()
+		current := after[name]
+		old, found := before[name]
+		switch {
+		case !found:
+			r.watcherManager.notify(watch.Added, current.snapshot())
+		case old.revision != current.revision && isModified(old.revision, current.revision):
+			r.watcherManager.notify(watch.Modified, current.snapshot())
+		case old.latest != current.latest:
+			r.watcherManager.notify(watch.Modified, current.snapshot())
+		}
+	}
+}
+
+// snapshot returns a copy of the cached package revision, which isn't
+// changed when the latest revisions are recomputed.
+func (s revisionState) snapshot() *cachedPackageRevision {
+	return &cachedPackageRevision{
+		PackageRevision:  s.revision.PackageRevision,
+		isLatestRevision: s.latest,
+	}
+}
+
+func isModified(old, current *cachedPackageRevision) bool {
+	if old.Lifecycle() != current.Lifecycle() {
+		return true
+	}
+	return old.GetPackageRevision().ResourceVersion != current.GetPackageRevision().ResourceVersion
+}
@@ -0,0 +1,177 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	api "github.com/GoogleContainerTools/kpt/porch/api/porch/v1alpha1"
+	"github.com/GoogleContainerTools/kpt/porch/pkg/repository"
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+type recordedEvent struct {
+	EventType       watch.EventType
+	Name            string
+	Lifecycle       api.PackageRevisionLifecycle
+	ResourceVersion string
+}
+
+type eventRecorder struct {
+	events []recordedEvent
+}
+
+func (r *eventRecorder) watcher(eventType watch.EventType, rev repository.PackageRevision, resourceVersion string) bool {
+	r.events = append(r.events, recordedEvent{
+		EventType:       eventType,
+		Name:            rev.KubeObjectName(),
+		Lifecycle:       rev.Lifecycle(),
+		ResourceVersion: resourceVersion,
+	})
+	return true
+}
+
+func TestWatchPackageRevisions(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tarfile := filepath.Join("..", "git", "testdata", "nested-repository.tar")
+	_, cached := openRepositoryFromArchive(t, ctx, tarfile, "watch-test")
+
+	findRevision := func(pkg, revision string) repository.PackageRevision {
+		t.Helper()
+		revisions, err := cached.ListPackageRevisions(ctx, repository.ListPackageRevisionFilter{Package: pkg, Revision: revision})
+		if err != nil {
+			t.Fatalf("ListPackageRevisions failed: %v", err)
+		}
+		if got, want := len(revisions), 1; got != want {
+			t.Fatalf("ListPackageRevisions returned %d packages; want %d", got, want)
+		}
+		return revisions[0]
+	}
+
+	bucket := findRevision("catalog/gcp/bucket", "v2")
+	bucketV1 := findRevision("catalog/gcp/bucket", "v1")
+	empty := findRevision("catalog/empty", "v1")
+	startRV := cached.watcherManager.resourceVersion()
+
+	recorder := &eventRecorder{}
+	if err := cached.watcherManager.watch(ctx, "", recorder.watcher); err != nil {
+		t.Fatalf("watch failed: %v", err)
+	}
+
+	update, err := cached.UpdatePackage(ctx, bucket)
+	if err != nil {
+		t.Fatalf("UpdatePackage(%s) failed: %v", bucket.Key(), err)
+	}
+	if err := update.UpdateLifecycle(ctx, api.PackageRevisionLifecyclePublished); err != nil {
+		t.Fatalf("UpdateLifecycle failed; %v", err)
+	}
+	if _, err := update.Close(ctx); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if err := cached.DeletePackageRevision(ctx, empty); err != nil {
+		t.Fatalf("DeletePackageRevision(%s) failed: %v", empty.Key(), err)
+	}
+
+	want := []recordedEvent{
+		{EventType: watch.Modified, Name: bucketV1.KubeObjectName(), Lifecycle: api.PackageRevisionLifecyclePublished},
+		{EventType: watch.Modified, Name: bucket.KubeObjectName(), Lifecycle: api.PackageRevisionLifecyclePublished},
+		{EventType: watch.Deleted, Name: empty.KubeObjectName(), Lifecycle: api.PackageRevisionLifecyclePublished},
+	}
+	ignoreRV := func(events []recordedEvent) []recordedEvent {
+		var result []recordedEvent
+		for _, e := range events {
+			e.ResourceVersion = ""
+			result = append(result, e)
+		}
+		return result
+	}
+	if diff := cmp.Diff(want, ignoreRV(recorder.events)); diff != "" {
+		t.Fatalf("Unexpected events (-want,+got): %s", diff)
+	}
+
+	// Resuming from the resource version of an event replays the later events.
+	resumed := &eventRecorder{}
+	if err := cached.watcherManager.watch(ctx, recorder.events[0].ResourceVersion, resumed.watcher); err != nil {
+		t.Fatalf("watch failed: %v", err)
+	}
+	if diff := cmp.Diff(recorder.events[1:], resumed.events); diff != "" {
+		t.Errorf("Unexpected resumed events (-want,+got): %s", diff)
+	}
+
+	// Resuming from the resource version before the changes replays all of them.
+	resumed = &eventRecorder{}
+	if err := cached.watcherManager.watch(ctx, startRV, resumed.watcher); err != nil {
+		t.Fatalf("watch failed: %v", err)
+	}
+	if diff := cmp.Diff(recorder.events, resumed.events); diff != "" {
+		t.Errorf("Unexpected resumed events (-want,+got): %s", diff)
+	}
+
+	if err := cached.watcherManager.watch(ctx, "unknown", (&eventRecorder{}).watcher); !errors.Is(err, ErrResourceVersionExpired) {
+		t.Errorf("watch from unknown resource version: got %v, want %v", err, ErrResourceVersionExpired)
+	}
+}
+
+func TestWatchPackageRevisions_unloadedCache(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tarfile := filepath.Join("..", "git", "testdata", "nested-repository.tar")
+	_, cached := openRepositoryFromArchive(t, ctx, tarfile, "watch-unloaded-test")
+
+	revisions, err := cached.ListPackageRevisions(ctx, repository.ListPackageRevisionFilter{Package: "catalog/gcp/bucket", Revision: "v2"})
+	if err != nil {
+		t.Fatalf("ListPackageRevisions failed: %v", err)
+	}
+	if got, want := len(revisions), 1; got != want {
+		t.Fatalf("ListPackageRevisions returned %d packages; want %d", got, want)
+	}
+	bucket := revisions[0]
+
+	// Drop the cached packages, as if they hadn't been listed yet.
+	cached.mutex.Lock()
+	cached.cachedPackages = nil
+	cached.mutex.Unlock()
+
+	recorder := &eventRecorder{}
+	if err := cached.watcherManager.watch(ctx, "", recorder.watcher); err != nil {
+		t.Fatalf("watch failed: %v", err)
+	}
+	update, err := cached.UpdatePackage(ctx, bucket)
+	if err != nil {
+		t.Fatalf("UpdatePackage(%s) failed: %v", bucket.Key(), err)
+	}
+	if err := update.UpdateLifecycle(ctx, api.PackageRevisionLifecyclePublished); err != nil {
+		t.Fatalf("UpdateLifecycle failed; %v", err)
+	}
+	if _, err := update.Close(ctx); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// The existing revision is modified, not added.
+	if got, want := len(recorder.events), 1; got != want {
+		t.Fatalf("got %d events; want %d", got, want)
+	}
+	if got, want := recorder.events[0].EventType, watch.Modified; got != want {
+		t.Errorf("got event %s; want %s", got, want)
+	}
+	if got, want := recorder.events[0].Name, bucket.KubeObjectName(); got != want {
+		t.Errorf("got event for %s; want %s", got, want)
+	}
+}
@@ -15,6 +15,7 @@
 package server
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -50,6 +51,9 @@ type PorchServerOptions struct {
 	CacheDirectory           string
 	CoreAPIKubeconfigPath    string
 	FunctionRunnerAddress    string
+	UpdateSchemaDirectory    string
+	WebhookAddress           string
+	WebhookSecretFile        string
 
 	SharedInformerFactory informers.SharedInformerFactory
 	StdOut                io.Writer
@@ -110,6 +114,10 @@ func NewCommandStartPorchServer(ctx context.Context, defaults *PorchServerOption
 func (o PorchServerOptions) Validate(args []string) error {
 	errors := []error{}
 	errors = append(errors, o.RecommendedOptions.Validate()...)
+	// Anyone who can reach the webhook could otherwise trigger refreshes.
+	if o.WebhookAddress != "" && o.WebhookSecretFile == "" {
+		errors = append(errors, fmt.Errorf("--webhook-secret-file is required when --webhook-address is set"))
+	}
 	return utilerrors.NewAggregate(errors)
 }
 
@@ -182,8 +190,23 @@ func (o *PorchServerOptions) Config() (*apiserver.Config, error) {
 			CoreAPIKubeconfigPath: o.CoreAPIKubeconfigPath,
 			CacheDirectory:        o.CacheDirectory,
 			FunctionRunnerAddress: o.FunctionRunnerAddress,
+			UpdateSchemaDirectory: o.UpdateSchemaDirectory,
+			WebhookAddress:        o.WebhookAddress,
 		},
 	}
+
+	if o.WebhookSecretFile != "" {
+		// The secret is mounted from an optional Secret, so the server still
+		// starts without it; the webhook rejects all notifications then.
+		secret, err := os.ReadFile(o.WebhookSecretFile)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("error reading webhook secret: %w", err)
+		}
+		config.ExtraConfig.WebhookSecret = bytes.TrimSpace(secret)
+		if len(config.ExtraConfig.WebhookSecret) == 0 && o.WebhookAddress != "" {
+			klog.Warningf("Webhook secret %s is missing or empty; all push notifications will be rejected", o.WebhookSecretFile)
+		}
+	}
 	return config, nil
 }
 
@@ -226,4 +249,7 @@ func (o *PorchServerOptions) AddFlags(fs *pflag.FlagSet) {
 
 	fs.StringVar(&o.FunctionRunnerAddress, "function-runner", "", "Address of the function runner gRPC service.")
 	fs.StringVar(&o.CacheDirectory, "cache-directory", "", "Directory where Porch server stores repository and package caches.")
+	fs.StringVar(&o.UpdateSchemaDirectory, "update-schema-directory", "", "Directory with CRDs or OpenAPI documents used to merge lists of custom resources when updating packages.")
+	fs.StringVar(&o.WebhookAddress, "webhook-address", "", "Address on which Porch server receives repository push notifications at /webhook, for example :8080. The webhook is disabled if empty.")
+	fs.StringVar(&o.WebhookSecretFile, "webhook-secret-file", "", "File containing the secret which authenticates repository push notifications. Required if --webhook-address is set; notifications are rejected while the file is missing or empty.")
 }
@@ -16,17 +16,12 @@ package engine
 
 import (
 	"context"
-	"errors"
 	"fmt"
-	"io/ioutil"
-	"os"
+	"path"
 	"strings"
 
 	"github.com/GoogleContainerTools/kpt/internal/fnruntime"
-	v1 "github.com/GoogleContainerTools/kpt/pkg/api/kptfile/v1"
 	api "github.com/GoogleContainerTools/kpt/porch/api/porch/v1alpha1"
-	configapi "github.com/GoogleContainerTools/kpt/porch/api/porchconfig/v1alpha1"
-	"github.com/GoogleContainerTools/kpt/porch/pkg/git"
 	"github.com/GoogleContainerTools/kpt/porch/pkg/kpt"
 	"github.com/GoogleContainerTools/kpt/porch/pkg/repository"
 	"go.opentelemetry.io/otel/trace"
@@ -47,22 +42,28 @@ func (m *clonePackageMutation) Apply(ctx context.Context, resources repository.P
 	ctx, span := tracer.Start(ctx, "clonePackageMutation::Apply", trace.WithAttributes())
 	defer span.End()
 
-	var cloned repository.PackageResources
-	var err error
-
-	if ref := m.task.Clone.Upstream.UpstreamRef; ref != nil {
-		cloned, err = m.cloneFromRegisteredRepository(ctx, ref)
-	} else if git := m.task.Clone.Upstream.Git; git != nil {
-		cloned, err = m.cloneFromGit(ctx, git)
-	} else if oci := m.task.Clone.Upstream.Oci; oci != nil {
-		cloned, err = m.cloneFromOci(ctx, oci)
-	} else {
-		err = errors.New("invalid clone source (neither of git, oci, nor upstream were specified)")
+	if subpackage := m.task.Clone.Subpackage; subpackage != "" {
+		return m.cloneSubpackage(ctx, resources, subpackage)
 	}
 
+	fetched, err := (&PackageFetcher{
+		cad:                m.cad,
+		credentialResolver: m.credentialResolver,
+		referenceResolver:  m.referenceResolver,
+	}).fetchUpstream(ctx, &m.task.Clone.Upstream, m.namespace)
 	if err != nil {
 		return repository.PackageResources{}, nil, err
 	}
+	cloned := fetched.resources
+
+	// Update Kptfile
+	if fetched.upstream != nil {
+		if err := kpt.UpdateKptfileUpstream(m.name, cloned.Contents, *fetched.upstream, *fetched.lock); err != nil {
+			return repository.PackageResources{}, nil, fmt.Errorf("failed to apply upstream lock to package %q: %w", upstreamName(&m.task.Clone.Upstream), err)
+		}
+	} else if err := kpt.UpdateKptfileName(m.name, cloned.Contents); err != nil {
+		return repository.PackageResources{}, nil, fmt.Errorf("failed to clone package %q: %w", upstreamName(&m.task.Clone.Upstream), err)
+	}
 
 	// Add any pre-existing parts of the config that have not been overwritten by the clone operation.
 	for k, v := range resources.Contents {
@@ -93,102 +94,49 @@ func (m *clonePackageMutation) Apply(ctx context.Context, resources repository.P
 		klog.Infof("failed to add merge-key to resources %v", err)
 	}
 
-	return result, m.task, nil
+	return result, pinUpstreamImage(m.task, fetched), nil
 }
 
-func (m *clonePackageMutation) cloneFromRegisteredRepository(ctx context.Context, ref *api.PackageRevisionRef) (repository.PackageResources, error) {
-	if ref.Name == "" {
-		return repository.PackageResources{}, fmt.Errorf("upstreamRef.name is required")
-	}
-
-	upstreamRevision, err := (&PackageFetcher{
-		cad:               m.cad,
-		referenceResolver: m.referenceResolver,
-	}).FetchRevision(ctx, ref, m.namespace)
-	if err != nil {
-		return repository.PackageResources{}, fmt.Errorf("failed to fetch package revision %q: %w", ref.Name, err)
-	}
-
-	resources, err := upstreamRevision.GetResources(ctx)
-	if err != nil {
-		return repository.PackageResources{}, fmt.Errorf("cannot read contents of package %q: %w", ref.Name, err)
-	}
-
-	upstream, lock, err := upstreamRevision.GetLock()
-	if err != nil {
-		return repository.PackageResources{}, fmt.Errorf("cannot determine upstream lock for package %q: %w", ref.Name, err)
-	}
-
-	// Update Kptfile
-	if err := kpt.UpdateKptfileUpstream(m.name, resources.Spec.Resources, upstream, lock); err != nil {
-		return repository.PackageResources{}, fmt.Errorf("failed to apply upstream lock to package %q: %w", ref.Name, err)
-	}
-
-	return repository.PackageResources{
-		Contents: resources.Spec.Resources,
-	}, nil
-}
-
-func (m *clonePackageMutation) cloneFromGit(ctx context.Context, gitPackage *api.GitPackage) (repository.PackageResources, error) {
-	// TODO: Cache unregistered repositories with appropriate cache eviction policy.
-	// TODO: Separate low-level repository access from Repository abstraction?
-
-	spec := configapi.GitRepository{
-		Repo:      gitPackage.Repo,
-		Directory: gitPackage.Directory,
-		SecretRef: configapi.SecretRef{
-			Name: gitPackage.SecretRef.Name,
-		},
+// cloneSubpackage clones the upstream package into the subpackage directory of
+// an existing package. The subpackage Kptfile records its own upstream lock so
+// the subpackage can later be updated independently of the parent package.
+func (m *clonePackageMutation) cloneSubpackage(ctx context.Context, resources repository.PackageResources, subpackage string) (repository.PackageResources, *api.Task, error) {
+	if err := validateSubpackage(subpackage); err != nil {
+		return repository.PackageResources{}, nil, err
 	}
-
-	dir, err := ioutil.TempDir("", "clone-git-package-*")
-	if err != nil {
-		return repository.PackageResources{}, fmt.Errorf("cannot create temporary directory to clone Git repository: %w", err)
+	if _, found := resources.Contents["Kptfile"]; !found {
+		return repository.PackageResources{}, nil, fmt.Errorf("cannot clone into subpackage %q; the package has no Kptfile", subpackage)
 	}
-	defer os.RemoveAll(dir)
-
-	r, err := git.OpenRepository(ctx, "", "", &spec, dir, git.GitRepositoryOptions{
-		CredentialResolver:         m.credentialResolver,
-		SkipMainBranchVerification: true, // We are only reading so we don't need the main branch to exist.
-	})
-	if err != nil {
-		return repository.PackageResources{}, fmt.Errorf("cannot clone Git repository: %w", err)
+	if existing := subpackageResources(resources, subpackage); len(existing.Contents) > 0 {
+		return repository.PackageResources{}, nil, fmt.Errorf("cannot clone into subpackage %q; directory is not empty", subpackage)
 	}
 
-	revision, lock, err := r.GetPackage(ctx, gitPackage.Ref, gitPackage.Directory)
+	fetched, err := (&PackageFetcher{
+		cad:                m.cad,
+		credentialResolver: m.credentialResolver,
+		referenceResolver:  m.referenceResolver,
+	}).fetchUpstream(ctx, &m.task.Clone.Upstream, m.namespace)
 	if err != nil {
-		return repository.PackageResources{}, fmt.Errorf("cannot find package %s@%s: %w", gitPackage.Directory, gitPackage.Ref, err)
+		return repository.PackageResources{}, nil, err
 	}
+	cloned := fetched.resources
 
-	resources, err := revision.GetResources(ctx)
-	if err != nil {
-		return repository.PackageResources{}, fmt.Errorf("cannot read package resources: %w", err)
+	name := path.Base(subpackage)
+	if fetched.upstream != nil {
+		if err := kpt.UpdateKptfileUpstream(name, cloned.Contents, *fetched.upstream, *fetched.lock); err != nil {
+			return repository.PackageResources{}, nil, fmt.Errorf("failed to apply upstream lock to subpackage %q: %w", subpackage, err)
+		}
+	} else if err := kpt.UpdateKptfileName(name, cloned.Contents); err != nil {
+		return repository.PackageResources{}, nil, fmt.Errorf("failed to clone package %q into subpackage %q: %w", upstreamName(&m.task.Clone.Upstream), subpackage, err)
 	}
 
-	contents := resources.Spec.Resources
-
-	// Update Kptfile
-	if err := kpt.UpdateKptfileUpstream(m.name, contents, v1.Upstream{
-		Type: v1.GitOrigin,
-		Git: &v1.Git{
-			Repo:      lock.Repo,
-			Directory: lock.Directory,
-			Ref:       lock.Ref,
-		},
-	}, v1.UpstreamLock{
-		Type: v1.GitOrigin,
-		Git:  &lock,
-	}); err != nil {
-		return repository.PackageResources{}, fmt.Errorf("failed to clone package %s@%s: %w", gitPackage.Directory, gitPackage.Ref, err)
+	if withMergeKey, err := ensureMergeKey(ctx, cloned); err != nil {
+		klog.Infof("failed to add merge-key to resources %v", err)
+	} else {
+		cloned = withMergeKey
 	}
 
-	return repository.PackageResources{
-		Contents: contents,
-	}, nil
-}
-
-func (m *clonePackageMutation) cloneFromOci(ctx context.Context, ociPackage *api.OciPackage) (repository.PackageResources, error) {
-	return repository.PackageResources{}, errors.New("clone from OCI is not implemented")
+	return replaceSubpackageResources(resources, subpackage, cloned), pinUpstreamImage(m.task, fetched), nil
 }
 
 func parseUpstreamRepository(name string) (string, error) {
@@ -48,5 +48,5 @@ func (m *editPackageMutation) Apply(ctx context.Context, resources repository.Pa
 
 	return repository.PackageResources{
 		Contents: sourceResources.Spec.Resources,
-	}, &api.Task{}, nil
+	}, m.task, nil
 }
@@ -74,10 +74,13 @@ info:
 		cad:               cad,
 	}
 
-	res, _, err := epm.Apply(context.Background(), repository.PackageResources{})
+	res, task, err := epm.Apply(context.Background(), repository.PackageResources{})
 	if err != nil {
 		t.Errorf("task apply failed: %v", err)
 	}
+	if diff := cmp.Diff(epm.task, task); diff != "" {
+		t.Errorf("recorded task mismatch (-want +got):\n%s", diff)
+	}
 
 	want := strings.TrimSpace(`
 apiVersion: kpt.dev/v1
@@ -130,3 +133,7 @@ func (f *fakeCaD) DeletePackageRevision(context.Context, *configapi.Repository,
 func (f *fakeCaD) ListFunctions(context.Context, *configapi.Repository) ([]repository.Function, error) {
 	return []repository.Function{}, nil
 }
+
+func (f *fakeCaD) ObjectCache() ObjectCache {
+	return nil
+}
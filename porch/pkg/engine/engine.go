@@ -23,6 +23,8 @@ import (
 	"path/filepath"
 	"reflect"
 
+	"github.com/GoogleContainerTools/kpt/internal/util/merge"
+	v1 "github.com/GoogleContainerTools/kpt/pkg/api/kptfile/v1"
 	"github.com/GoogleContainerTools/kpt/pkg/debug"
 	"github.com/GoogleContainerTools/kpt/pkg/fn"
 	api "github.com/GoogleContainerTools/kpt/porch/api/porch/v1alpha1"
@@ -47,8 +49,26 @@ type CaDEngine interface {
 	UpdatePackageResources(ctx context.Context, repositoryObj *configapi.Repository, oldPackage repository.PackageRevision, old, new *api.PackageRevisionResources) (repository.PackageRevision, error)
 	DeletePackageRevision(ctx context.Context, repositoryObj *configapi.Repository, obj repository.PackageRevision) error
 	ListFunctions(ctx context.Context, repositoryObj *configapi.Repository) ([]repository.Function, error)
+
+	// ObjectCache returns the cache of package revisions, which can be
+	// watched for changes.
+	ObjectCache() ObjectCache
 }
 
+// ObjectCache is a cache of package revisions that can be watched for
+// changes.
+type ObjectCache interface {
+	// WatchPackageRevisions calls watcher for the changes to package
+	// revisions after resourceVersion until ctx is done.
+	WatchPackageRevisions(ctx context.Context, resourceVersion string, watcher cache.PackageRevisionWatcher) error
+
+	// ResourceVersion returns the resource version of the latest change to
+	// the package revisions.
+	ResourceVersion() string
+}
+
+var _ ObjectCache = &cache.Cache{}
+
 func NewCaDEngine(opts ...EngineOption) (CaDEngine, error) {
 	engine := &cadEngine{}
 	for _, opt := range opts {
@@ -66,6 +86,7 @@ type cadEngine struct {
 	credentialResolver repository.CredentialResolver
 	referenceResolver  ReferenceResolver
 	userInfoProvider   repository.UserInfoProvider
+	updateSchemas      *merge.Schemas
 }
 
 var _ CaDEngine = &cadEngine{}
@@ -74,6 +95,10 @@ type mutation interface {
 	Apply(ctx context.Context, resources repository.PackageResources) (repository.PackageResources, *api.Task, error)
 }
 
+func (cad *cadEngine) ObjectCache() ObjectCache {
+	return cad.cache
+}
+
 func (cad *cadEngine) OpenRepository(ctx context.Context, repositorySpec *configapi.Repository) (repository.Repository, error) {
 	ctx, span := tracer.Start(ctx, "cadEngine::OpenRepository", trace.WithAttributes())
 	defer span.End()
@@ -110,9 +135,9 @@ func (cad *cadEngine) CreatePackageRevision(ctx context.Context, repositoryObj *
 
 	var mutations []mutation
 
-	// Unless first task is Init or Clone, insert Init to create an empty package.
+	// Unless first task is Init or Clone of the root package, insert Init to create an empty package.
 	tasks := obj.Spec.Tasks
-	if len(tasks) == 0 || (tasks[0].Type != api.TaskTypeInit && tasks[0].Type != api.TaskTypeClone) {
+	if len(tasks) == 0 || !createsRootPackage(&tasks[0]) {
 		mutations = append(mutations, &initPackageMutation{
 			name: obj.Spec.PackageName,
 			task: &api.Task{
@@ -134,13 +159,24 @@ func (cad *cadEngine) CreatePackageRevision(ctx context.Context, repositoryObj *
 	}
 
 	// Render package after creation.
-	mutations = cad.conditionalAddRender(mutations)
+	mutations = cad.conditionalAddRender(repositoryObj, mutations)
+
+	if readiness := newUpdateReadinessMutation(nil, obj); readiness != nil {
+		mutations = append(mutations, readiness)
+	}
 
 	baseResources := repository.PackageResources{}
-	if err := applyResourceMutations(ctx, draft, baseResources, mutations); err != nil {
+	resources, err := applyResourceMutations(ctx, draft, baseResources, mutations)
+	if err != nil {
 		return nil, err
 	}
 
+	if obj.Spec.Lifecycle == api.PackageRevisionLifecycleProposed {
+		if err := cad.checkRepositoryValidators(ctx, repositoryObj, resources, obj.Spec.Lifecycle); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := draft.UpdateLifecycle(ctx, obj.Spec.Lifecycle); err != nil {
 		return nil, err
 	}
@@ -149,6 +185,18 @@ func (cad *cadEngine) CreatePackageRevision(ctx context.Context, repositoryObj *
 	return draft.Close(ctx)
 }
 
+// createsRootPackage returns true if the task creates the root of a new package.
+func createsRootPackage(task *api.Task) bool {
+	switch task.Type {
+	case api.TaskTypeInit:
+		return true
+	case api.TaskTypeClone:
+		return task.Clone == nil || task.Clone.Subpackage == ""
+	default:
+		return false
+	}
+}
+
 func (cad *cadEngine) mapTaskToMutation(ctx context.Context, obj *api.PackageRevision, task *api.Task, isDeployment bool) (mutation, error) {
 	switch task.Type {
 	case api.TaskTypeInit:
@@ -237,8 +285,19 @@ func (cad *cadEngine) UpdatePackageRevision(ctx context.Context, repositoryObj *
 	}
 
 	var mutations []mutation
-	if len(oldObj.Spec.Tasks) != len(newObj.Spec.Tasks) {
-		return nil, fmt.Errorf("adding/removing tasks is not yet supported")
+	if len(oldObj.Spec.Tasks) > len(newObj.Spec.Tasks) {
+		return nil, fmt.Errorf("removing tasks is not yet supported")
+	}
+	// Upstream packages can be cloned into subpackages of a draft by adding
+	// clone tasks; other tasks cannot be added yet.
+	for i := len(oldObj.Spec.Tasks); i < len(newObj.Spec.Tasks); i++ {
+		newTask := &newObj.Spec.Tasks[i]
+		if newTask.Type != api.TaskTypeClone || newTask.Clone == nil || newTask.Clone.Subpackage == "" {
+			return nil, fmt.Errorf("adding tasks other than subpackage clone tasks is not yet supported")
+		}
+		if oldObj.Spec.Lifecycle != api.PackageRevisionLifecycleDraft {
+			return nil, fmt.Errorf("cannot clone into a subpackage of a package revision with lifecycle value %q", oldObj.Spec.Lifecycle)
+		}
 	}
 
 	for i := range oldObj.Spec.Tasks {
@@ -260,9 +319,16 @@ func (cad *cadEngine) UpdatePackageRevision(ctx context.Context, repositoryObj *
 				return nil, fmt.Errorf("clone not set for task of type %q", newTask.Type)
 			}
 
+			if oldTask.Clone != nil && oldTask.Clone.Subpackage != newTask.Clone.Subpackage {
+				return nil, fmt.Errorf("changing the subpackage of a clone task is not supported")
+			}
+
+			// Each subpackage has its own upstream, so only the last clone task
+			// into the same (sub)package can be changed.
 			var isLastCloneTask = true
 			for j := i + 1; j < len(oldObj.Spec.Tasks); j++ {
-				if oldObj.Spec.Tasks[j].Type == api.TaskTypeClone {
+				later := &oldObj.Spec.Tasks[j]
+				if later.Type == api.TaskTypeClone && later.Clone != nil && later.Clone.Subpackage == newTask.Clone.Subpackage {
 					isLastCloneTask = false
 				}
 			}
@@ -271,12 +337,14 @@ func (cad *cadEngine) UpdatePackageRevision(ctx context.Context, repositoryObj *
 				return nil, fmt.Errorf("only the last clone task for the revision can be changed")
 			}
 			mutation := &updatePackageMutation{
-				task:              newTask,
-				oldTask:           oldTask,
-				cad:               cad,
-				referenceResolver: cad.referenceResolver,
-				namespace:         repositoryObj.Namespace,
-				pkgName:           oldObj.GetName(),
+				task:               newTask,
+				oldTask:            oldTask,
+				cad:                cad,
+				credentialResolver: cad.credentialResolver,
+				referenceResolver:  cad.referenceResolver,
+				namespace:          repositoryObj.Namespace,
+				pkgName:            oldObj.GetName(),
+				updateSchemas:      cad.updateSchemas,
 			}
 			mutations = append(mutations, mutation)
 
@@ -285,8 +353,16 @@ func (cad *cadEngine) UpdatePackageRevision(ctx context.Context, repositoryObj *
 		}
 	}
 
+	for i := len(oldObj.Spec.Tasks); i < len(newObj.Spec.Tasks); i++ {
+		mutation, err := cad.mapTaskToMutation(ctx, newObj, &newObj.Spec.Tasks[i], repositoryObj.Spec.Deployment)
+		if err != nil {
+			return nil, err
+		}
+		mutations = append(mutations, mutation)
+	}
+
 	// Re-render if we are making changes.
-	mutations = cad.conditionalAddRender(mutations)
+	mutations = cad.conditionalAddRender(repositoryObj, mutations)
 
 	draft, err := repo.UpdatePackage(ctx, oldPackage)
 	if err != nil {
@@ -295,16 +371,42 @@ func (cad *cadEngine) UpdatePackageRevision(ctx context.Context, repositoryObj *
 
 	// TODO: Handle the case if alongside lifecycle change, tasks are changed too.
 	// Update package contents only if the package is in draft state
-	if oldObj.Spec.Lifecycle == api.PackageRevisionLifecycleDraft {
+	if oldObj.Spec.Lifecycle != api.PackageRevisionLifecycleDraft {
+		mutations = nil
+	}
+	// The readiness gates and conditions are kept in the Kptfile; unlike the
+	// rest of the package, they can change until the package is published.
+	if readiness := newUpdateReadinessMutation(oldObj, newObj); readiness != nil {
+		mutations = append(mutations, readiness)
+	}
+
+	var resources *repository.PackageResources
+	if len(mutations) > 0 {
 		apiResources, err := oldPackage.GetResources(ctx)
 		if err != nil {
 			return nil, fmt.Errorf("cannot get package resources: %w", err)
 		}
-		resources := repository.PackageResources{
+		applied, err := applyResourceMutations(ctx, draft, repository.PackageResources{
 			Contents: apiResources.Spec.Resources,
+		}, mutations)
+		if err != nil {
+			return nil, err
 		}
+		resources = &applied
+	}
 
-		if err := applyResourceMutations(ctx, draft, resources, mutations); err != nil {
+	// The repository validators must pass before the package is proposed or published.
+	if lifecycle := newObj.Spec.Lifecycle; lifecycle != oldObj.Spec.Lifecycle && lifecycle != api.PackageRevisionLifecycleDraft {
+		if resources == nil {
+			apiResources, err := oldPackage.GetResources(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("cannot get package resources: %w", err)
+			}
+			resources = &repository.PackageResources{
+				Contents: apiResources.Spec.Resources,
+			}
+		}
+		if err := cad.checkRepositoryValidators(ctx, repositoryObj, *resources, lifecycle); err != nil {
 			return nil, err
 		}
 	}
@@ -318,21 +420,23 @@ func (cad *cadEngine) UpdatePackageRevision(ctx context.Context, repositoryObj *
 }
 
 // conditionalAddRender adds a render mutation to the end of the mutations slice if the last
-// entry is not already a render mutation.
-func (cad *cadEngine) conditionalAddRender(mutations []mutation) []mutation {
+// entry is not already a render mutation. The render mutation evaluates the mutators and
+// validators of the repository after the package's own pipeline.
+func (cad *cadEngine) conditionalAddRender(repositoryObj *configapi.Repository, mutations []mutation) []mutation {
 	if len(mutations) == 0 {
 		return mutations
 	}
 
 	lastMutation := mutations[len(mutations)-1]
-	_, isRender := lastMutation.(*renderPackageMutation)
-	if isRender {
+	if render, isRender := lastMutation.(*renderPackageMutation); isRender {
+		render.repositoryFunctions = cad.repositoryFunctions(repositoryObj)
 		return mutations
 	}
 
 	return append(mutations, &renderPackageMutation{
-		renderer: cad.renderer,
-		runtime:  cad.runtime,
+		renderer:            cad.renderer,
+		runtime:             cad.runtime,
+		repositoryFunctions: cad.repositoryFunctions(repositoryObj),
 	})
 }
 
@@ -385,8 +489,9 @@ func (cad *cadEngine) UpdatePackageResources(ctx context.Context, repositoryObj
 			oldResources: old,
 		},
 		&renderPackageMutation{
-			renderer: cad.renderer,
-			runtime:  cad.runtime,
+			renderer:            cad.renderer,
+			runtime:             cad.runtime,
+			repositoryFunctions: cad.repositoryFunctions(repositoryObj),
 		},
 	}
 
@@ -398,7 +503,7 @@ func (cad *cadEngine) UpdatePackageResources(ctx context.Context, repositoryObj
 		Contents: apiResources.Spec.Resources,
 	}
 
-	if err := applyResourceMutations(ctx, draft, resources, mutations); err != nil {
+	if _, err := applyResourceMutations(ctx, draft, resources, mutations); err != nil {
 		return nil, err
 	}
 
@@ -406,23 +511,30 @@ func (cad *cadEngine) UpdatePackageResources(ctx context.Context, repositoryObj
 	return draft.Close(ctx)
 }
 
-func applyResourceMutations(ctx context.Context, draft repository.PackageDraft, baseResources repository.PackageResources, mutations []mutation) error {
+// applyResourceMutations applies the mutations to the draft, and returns the resulting resources.
+func applyResourceMutations(ctx context.Context, draft repository.PackageDraft, baseResources repository.PackageResources, mutations []mutation) (repository.PackageResources, error) {
 	for _, m := range mutations {
 		applied, task, err := m.Apply(ctx, baseResources)
 		if err != nil {
-			return err
+			return repository.PackageResources{}, err
+		}
+		// The outcome of the render is recorded with the rendered resources.
+		if render, ok := m.(*renderPackageMutation); ok {
+			if err := draft.UpdateRenderStatus(ctx, render.status); err != nil {
+				return repository.PackageResources{}, err
+			}
 		}
 		if err := draft.UpdateResources(ctx, &api.PackageRevisionResources{
 			Spec: api.PackageRevisionResourcesSpec{
 				Resources: applied.Contents,
 			},
 		}, task); err != nil {
-			return err
+			return repository.PackageResources{}, err
 		}
 		baseResources = applied
 	}
 
-	return nil
+	return baseResources, nil
 }
 
 func (cad *cadEngine) ListFunctions(ctx context.Context, repositoryObj *configapi.Repository) ([]repository.Function, error) {
@@ -443,93 +555,114 @@ func (cad *cadEngine) ListFunctions(ctx context.Context, repositoryObj *configap
 }
 
 type updatePackageMutation struct {
-	oldTask           *api.Task
-	task              *api.Task
-	cad               CaDEngine
-	referenceResolver ReferenceResolver
-	namespace         string
-	pkgName           string
+	oldTask            *api.Task
+	task               *api.Task
+	cad                CaDEngine
+	credentialResolver repository.CredentialResolver
+	referenceResolver  ReferenceResolver
+	namespace          string
+	pkgName            string
+	updateSchemas      *merge.Schemas
 }
 
 func (m *updatePackageMutation) Apply(ctx context.Context, resources repository.PackageResources) (repository.PackageResources, *api.Task, error) {
 	ctx, span := tracer.Start(ctx, "updatePackageMutation::Apply", trace.WithAttributes())
 	defer span.End()
 
-	currUpstreamPkgRef, err := m.currUpstream()
-	if err != nil {
-		return repository.PackageResources{}, nil, err
-	}
-
-	targetUpstream := m.task.Clone.Upstream
-	if targetUpstream.Type == api.RepositoryTypeGit || targetUpstream.Type == api.RepositoryTypeOCI {
-		return repository.PackageResources{}, nil, fmt.Errorf("update is not supported for non-porch upstream packages")
+	// A subpackage is updated on its own, leaving the rest of the package untouched.
+	local := resources
+	subpackage := m.task.Clone.Subpackage
+	if subpackage != "" {
+		if err := validateSubpackage(subpackage); err != nil {
+			return repository.PackageResources{}, nil, err
+		}
+		local = subpackageResources(resources, subpackage)
 	}
 
-	originalResources, err := (&PackageFetcher{
-		cad:               m.cad,
-		referenceResolver: m.referenceResolver,
-	}).FetchResources(ctx, currUpstreamPkgRef, m.namespace)
+	currUpstream, err := m.currUpstream(local)
 	if err != nil {
-		return repository.PackageResources{}, nil, fmt.Errorf("error fetching the resources for package %s with ref %+v",
-			m.pkgName, *currUpstreamPkgRef)
+		return repository.PackageResources{}, nil, err
 	}
+	targetUpstream := &m.task.Clone.Upstream
 
-	upstreamRevision, err := (&PackageFetcher{
-		cad:               m.cad,
-		referenceResolver: m.referenceResolver,
-	}).FetchRevision(ctx, targetUpstream.UpstreamRef, m.namespace)
+	fetcher := &PackageFetcher{
+		cad:                m.cad,
+		credentialResolver: m.credentialResolver,
+		referenceResolver:  m.referenceResolver,
+	}
+	original, err := fetcher.fetchUpstream(ctx, currUpstream, m.namespace)
 	if err != nil {
-		return repository.PackageResources{}, nil, fmt.Errorf("error fetching revision for target upstream %s", targetUpstream.UpstreamRef.Name)
+		return repository.PackageResources{}, nil, fmt.Errorf("error fetching the resources for package %s with upstream %s: %w",
+			m.pkgName, upstreamName(currUpstream), err)
 	}
-	upstreamResources, err := upstreamRevision.GetResources(ctx)
+	upstream, err := fetcher.fetchUpstream(ctx, targetUpstream, m.namespace)
 	if err != nil {
-		return repository.PackageResources{}, nil, fmt.Errorf("error fetching resources for target upstream %s", targetUpstream.UpstreamRef.Name)
+		return repository.PackageResources{}, nil, fmt.Errorf("error fetching the resources for target upstream %s: %w", upstreamName(targetUpstream), err)
 	}
 
 	klog.Infof("performing pkg upgrade operation for pkg %s resource counts local[%d] original[%d] upstream[%d]",
-		m.pkgName, len(resources.Contents), len(originalResources.Spec.Resources), len(upstreamResources.Spec.Resources))
+		m.pkgName, len(local.Contents), len(original.resources.Contents), len(upstream.resources.Contents))
 
 	// May be have packageUpdater part of engine to make it easy for testing ?
-	updatedResources, err := (&defaultPackageUpdater{}).Update(ctx,
-		resources,
-		repository.PackageResources{
-			Contents: originalResources.Spec.Resources,
-		},
-		repository.PackageResources{
-			Contents: upstreamResources.Spec.Resources,
-		})
+	updatedResources, err := (&defaultPackageUpdater{schemas: m.updateSchemas}).Update(ctx,
+		local,
+		original.resources,
+		upstream.resources)
 	if err != nil {
-		return repository.PackageResources{}, nil, fmt.Errorf("error updating the package to revision %s", targetUpstream.UpstreamRef.Name)
+		return repository.PackageResources{}, nil, fmt.Errorf("error updating the package to upstream %s: %w", upstreamName(targetUpstream), err)
 	}
 
-	newUpstream, newUpstreamLock, err := upstreamRevision.GetLock()
-	if err != nil {
-		return repository.PackageResources{}, nil, fmt.Errorf("error fetching the resources for package revisions %s", targetUpstream.UpstreamRef.Name)
-	}
-	if err := kpt.UpdateKptfileUpstream("", updatedResources.Contents, newUpstream, newUpstreamLock); err != nil {
-		return repository.PackageResources{}, nil, fmt.Errorf("failed to apply upstream lock to package %q: %w", m.pkgName, err)
+	// The Kptfile cannot record OCI upstreams; the clone task records them.
+	if upstream.upstream != nil {
+		if err := kpt.UpdateKptfileUpstream("", updatedResources.Contents, *upstream.upstream, *upstream.lock); err != nil {
+			return repository.PackageResources{}, nil, fmt.Errorf("failed to apply upstream lock to package %q: %w", m.pkgName, err)
+		}
 	}
 
 	// ensure merge-key comment is added to newly added resources.
 	result, err := ensureMergeKey(ctx, updatedResources)
 	if err != nil {
 		klog.Infof("failed to add merge key comments: %v", err)
+		result = updatedResources
 	}
-	return result, m.task, nil
+	if subpackage != "" {
+		result = replaceSubpackageResources(resources, subpackage, result)
+	}
+	return result, pinUpstreamImage(m.task, upstream), nil
 }
 
-// Currently assumption is that downstream packages will be forked from a porch package.
-// As per current implementation, upstream package ref is stored in an old clone task, but this may
-// change so the logic of figuring out current upstream will live in this function.
-func (m *updatePackageMutation) currUpstream() (*api.PackageRevisionRef, error) {
+// currUpstream returns the upstream the package is currently cloned from. The
+// upstream is stored in the old clone task, but this may change, so the logic
+// of figuring out the current upstream lives in this function. The upstream
+// may be a registered package, or a package in a Git repository or OCI image
+// which isn't registered with Porch.
+//
+// The ref of a Git upstream may be a branch which has moved on since the
+// package was cloned, so the package is fetched at the commit recorded in the
+// upstream lock of the local Kptfile instead. OCI images are pinned to their
+// digest in the clone task.
+func (m *updatePackageMutation) currUpstream(local repository.PackageResources) (*api.UpstreamPackage, error) {
 	if m.oldTask == nil || m.oldTask.Clone == nil {
 		return nil, fmt.Errorf("package %s does not have upstream info", m.pkgName)
 	}
 	upstream := m.oldTask.Clone.Upstream
-	if upstream.Type == api.RepositoryTypeGit || upstream.Type == api.RepositoryTypeOCI {
-		return nil, fmt.Errorf("upstream package must be porch native package. Found it to be %s", upstream.Type)
+	if upstream.Git == nil {
+		return &upstream, nil
+	}
+	kptfile, found := local.Contents[v1.KptFileName]
+	if !found {
+		return &upstream, nil
+	}
+	lock, err := kpt.GetUpstreamLock(kptfile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read upstream lock of package %s: %w", m.pkgName, err)
+	}
+	if lock != nil && lock.Git != nil && lock.Git.Commit != "" && lock.Git.Repo == upstream.Git.Repo {
+		git := *upstream.Git
+		git.Ref = lock.Git.Commit
+		upstream.Git = &git
 	}
-	return upstream.UpstreamRef, nil
+	return &upstream, nil
 }
 
 func writeResourcesToDirectory(dir string, resources repository.PackageResources) error {
@@ -46,7 +46,7 @@ func (pr *PackageRevision) Lifecycle() v1alpha1.PackageRevisionLifecycle {
 }
 
 func (pr *PackageRevision) GetPackageRevision() *v1alpha1.PackageRevision {
-	return nil
+	return pr.PackageRevision
 }
 
 func (f *PackageRevision) GetResources(context.Context) (*v1alpha1.PackageRevisionResources, error) {
@@ -17,6 +17,7 @@ package engine
 import (
 	"fmt"
 
+	"github.com/GoogleContainerTools/kpt/internal/util/merge"
 	"github.com/GoogleContainerTools/kpt/pkg/fn"
 	"github.com/GoogleContainerTools/kpt/porch/pkg/cache"
 	"github.com/GoogleContainerTools/kpt/porch/pkg/kpt"
@@ -114,3 +115,10 @@ func WithUserInfoProvider(provider repository.UserInfoProvider) EngineOption {
 		return nil
 	})
 }
+
+func WithUpdateSchemas(schemas *merge.Schemas) EngineOption {
+	return EngineOptionFunc(func(engine *cadEngine) error {
+		engine.updateSchemas = schemas
+		return nil
+	})
+}
@@ -17,15 +17,193 @@ package engine
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
 
+	v1 "github.com/GoogleContainerTools/kpt/pkg/api/kptfile/v1"
 	api "github.com/GoogleContainerTools/kpt/porch/api/porch/v1alpha1"
 	configapi "github.com/GoogleContainerTools/kpt/porch/api/porchconfig/v1alpha1"
+	"github.com/GoogleContainerTools/kpt/porch/pkg/git"
+	"github.com/GoogleContainerTools/kpt/porch/pkg/oci"
 	"github.com/GoogleContainerTools/kpt/porch/pkg/repository"
 )
 
 type PackageFetcher struct {
-	cad               CaDEngine
-	referenceResolver ReferenceResolver
+	cad                CaDEngine
+	credentialResolver repository.CredentialResolver
+	referenceResolver  ReferenceResolver
+}
+
+// upstreamPackage is the contents of an upstream package, and the upstream
+// and lock recorded in the Kptfile of the packages cloned from it. The
+// Kptfile cannot record OCI upstreams; upstream and lock are nil for them.
+type upstreamPackage struct {
+	resources repository.PackageResources
+	upstream  *v1.Upstream
+	lock      *v1.UpstreamLock
+	// digest is the digest of an OCI image, and empty for other upstreams.
+	digest string
+}
+
+// fetchUpstream fetches the upstream package of a clone task, either from a
+// registered repository, or from a Git repository or OCI image which need
+// not be registered with Porch.
+func (p *PackageFetcher) fetchUpstream(ctx context.Context, upstream *api.UpstreamPackage, namespace string) (*upstreamPackage, error) {
+	switch {
+	case upstream.UpstreamRef != nil:
+		if upstream.UpstreamRef.Name == "" {
+			return nil, fmt.Errorf("upstreamRef.name is required")
+		}
+		revision, err := p.FetchRevision(ctx, upstream.UpstreamRef, namespace)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch package revision %q: %w", upstream.UpstreamRef.Name, err)
+		}
+		resources, err := revision.GetResources(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read contents of package %q: %w", upstream.UpstreamRef.Name, err)
+		}
+		kptUpstream, lock, err := revision.GetLock()
+		if err != nil {
+			return nil, fmt.Errorf("cannot determine upstream lock for package %q: %w", upstream.UpstreamRef.Name, err)
+		}
+		return &upstreamPackage{
+			resources: repository.PackageResources{Contents: resources.Spec.Resources},
+			upstream:  &kptUpstream,
+			lock:      &lock,
+		}, nil
+
+	case upstream.Git != nil:
+		return p.fetchGit(ctx, upstream.Git)
+
+	case upstream.Oci != nil:
+		return p.fetchOci(ctx, upstream.Oci)
+
+	default:
+		return nil, fmt.Errorf("invalid upstream (neither of git, oci, nor upstream were specified)")
+	}
+}
+
+func (p *PackageFetcher) fetchGit(ctx context.Context, gitPackage *api.GitPackage) (*upstreamPackage, error) {
+	// TODO: Cache unregistered repositories with appropriate cache eviction policy.
+	// TODO: Separate low-level repository access from Repository abstraction?
+
+	spec := configapi.GitRepository{
+		Repo:      gitPackage.Repo,
+		Directory: gitPackage.Directory,
+		SecretRef: configapi.SecretRef{
+			Name: gitPackage.SecretRef.Name,
+		},
+	}
+
+	dir, err := ioutil.TempDir("", "clone-git-package-*")
+	if err != nil {
+		return nil, fmt.Errorf("cannot create temporary directory to clone Git repository: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	r, err := git.OpenRepository(ctx, "", "", &spec, dir, git.GitRepositoryOptions{
+		CredentialResolver:         p.credentialResolver,
+		SkipMainBranchVerification: true, // We are only reading so we don't need the main branch to exist.
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot clone Git repository: %w", err)
+	}
+
+	revision, lock, err := r.GetPackage(ctx, gitPackage.Ref, gitPackage.Directory)
+	if err != nil {
+		return nil, fmt.Errorf("cannot find package %s@%s: %w", gitPackage.Directory, gitPackage.Ref, err)
+	}
+
+	resources, err := revision.GetResources(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read package resources: %w", err)
+	}
+
+	return &upstreamPackage{
+		resources: repository.PackageResources{Contents: resources.Spec.Resources},
+		upstream: &v1.Upstream{
+			Type: v1.GitOrigin,
+			Git: &v1.Git{
+				Repo:      lock.Repo,
+				Directory: lock.Directory,
+				Ref:       lock.Ref,
+			},
+		},
+		lock: &v1.UpstreamLock{
+			Type: v1.GitOrigin,
+			Git:  &lock,
+		},
+	}, nil
+}
+
+func (p *PackageFetcher) fetchOci(ctx context.Context, ociPackage *api.OciPackage) (*upstreamPackage, error) {
+	// TODO: Cache unregistered images, sharing the cache of the OCI repositories.
+	dir, err := ioutil.TempDir("", "clone-oci-package-*")
+	if err != nil {
+		return nil, fmt.Errorf("cannot create temporary directory to pull OCI image: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	storage, err := oci.NewStorage(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	imageName, err := resolveImage(ctx, storage, ociPackage.Image)
+	if err != nil {
+		return nil, fmt.Errorf("cannot resolve image %q: %w", ociPackage.Image, err)
+	}
+
+	resources, err := storage.LoadResources(ctx, imageName)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read package resources from image %q: %w", ociPackage.Image, err)
+	}
+
+	return &upstreamPackage{resources: *resources, digest: imageName.Digest}, nil
+}
+
+// pinUpstreamImage returns the clone task with the OCI image pinned to the
+// digest it was fetched at, since tags can be moved. The package is updated
+// from the pinned image later, so the merge base is the image it was cloned
+// from. Other tasks are returned unchanged.
+func pinUpstreamImage(task *api.Task, fetched *upstreamPackage) *api.Task {
+	oci := task.Clone.Upstream.Oci
+	if oci == nil || fetched.digest == "" || strings.Contains(oci.Image, "@") {
+		return task
+	}
+	pinned := task.DeepCopy()
+	pinned.Clone.Upstream.Oci.Image = oci.Image + "@" + fetched.digest
+	return pinned
+}
+
+// resolveImage returns the digest of the image, which is referenced by digest
+// or by tag.
+func resolveImage(ctx context.Context, storage *oci.Storage, image string) (*oci.ImageDigestName, error) {
+	if i := strings.LastIndex(image, "@"); i >= 0 {
+		return &oci.ImageDigestName{
+			Image:  image[:i],
+			Digest: image[i+1:],
+		}, nil
+	}
+	tagName, err := oci.ParseImageTagName(image)
+	if err != nil {
+		return nil, err
+	}
+	return storage.LookupImageTag(ctx, *tagName)
+}
+
+// upstreamName describes the upstream package in messages.
+func upstreamName(upstream *api.UpstreamPackage) string {
+	switch {
+	case upstream.UpstreamRef != nil:
+		return upstream.UpstreamRef.Name
+	case upstream.Git != nil:
+		return fmt.Sprintf("%s/%s@%s", strings.TrimSuffix(upstream.Git.Repo, "/"), upstream.Git.Directory, upstream.Git.Ref)
+	case upstream.Oci != nil:
+		return upstream.Oci.Image
+	}
+	return ""
 }
 
 func (p *PackageFetcher) FetchRevision(ctx context.Context, packageRef *api.PackageRevisionRef, namespace string) (repository.PackageRevision, error) {
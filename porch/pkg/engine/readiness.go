@@ -0,0 +1,110 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	kptfile "github.com/GoogleContainerTools/kpt/pkg/api/kptfile/v1"
+	api "github.com/GoogleContainerTools/kpt/porch/api/porch/v1alpha1"
+	"github.com/GoogleContainerTools/kpt/porch/pkg/kpt"
+	"github.com/GoogleContainerTools/kpt/porch/pkg/repository"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// updateReadinessMutation stores the readiness gates and the conditions of
+// the package revision in its Kptfile. The change is recorded as a patch.
+type updateReadinessMutation struct {
+	gates      []api.ReadinessGate
+	conditions []api.Condition
+}
+
+var _ mutation = &updateReadinessMutation{}
+
+// newUpdateReadinessMutation returns the mutation storing the readiness gates
+// and conditions of newObj, or nil if they are the same as those of oldObj.
+// The conditions derived by porch are never stored.
+func newUpdateReadinessMutation(oldObj, newObj *api.PackageRevision) mutation {
+	var oldGates []api.ReadinessGate
+	var oldConditions []api.Condition
+	if oldObj != nil {
+		oldGates = oldObj.Spec.ReadinessGates
+		oldConditions = repository.StoredConditions(oldObj.Status.Conditions)
+	}
+	newGates := newObj.Spec.ReadinessGates
+	newConditions := repository.StoredConditions(newObj.Status.Conditions)
+
+	gatesChanged := (len(oldGates) > 0 || len(newGates) > 0) && !reflect.DeepEqual(oldGates, newGates)
+	conditionsChanged := (len(oldConditions) > 0 || len(newConditions) > 0) && !reflect.DeepEqual(oldConditions, newConditions)
+	if !gatesChanged && !conditionsChanged {
+		return nil
+	}
+	return &updateReadinessMutation{
+		gates:      newGates,
+		conditions: newConditions,
+	}
+}
+
+func (m *updateReadinessMutation) Apply(ctx context.Context, resources repository.PackageResources) (repository.PackageResources, *api.Task, error) {
+	ctx, span := tracer.Start(ctx, "updateReadinessMutation::Apply", trace.WithAttributes())
+	defer span.End()
+
+	oldKptfile, found := resources.Contents[kptfile.KptFileName]
+	if !found {
+		return repository.PackageResources{}, nil, fmt.Errorf("package is missing Kptfile")
+	}
+
+	var gates []kptfile.ReadinessGate
+	for _, gate := range m.gates {
+		gates = append(gates, kptfile.ReadinessGate{ConditionType: gate.ConditionType})
+	}
+	var conditions []kptfile.Condition
+	for _, condition := range m.conditions {
+		conditions = append(conditions, kptfile.Condition{
+			Type:    condition.Type,
+			Status:  kptfile.ConditionStatus(condition.Status),
+			Reason:  condition.Reason,
+			Message: condition.Message,
+		})
+	}
+
+	newKptfile, err := kpt.UpdateReadiness(oldKptfile, gates, conditions)
+	if err != nil {
+		return repository.PackageResources{}, nil, err
+	}
+
+	result := repository.PackageResources{
+		Contents: map[string]string{},
+	}
+	for k, v := range resources.Contents {
+		result.Contents[k] = v
+	}
+	result.Contents[kptfile.KptFileName] = newKptfile
+
+	patchSpec, err := GeneratePatch(kptfile.KptFileName, oldKptfile, newKptfile)
+	if err != nil {
+		return repository.PackageResources{}, nil, fmt.Errorf("error generating patch: %w", err)
+	}
+	task := &api.Task{
+		Type: api.TaskTypePatch,
+		Patch: &api.PackagePatchTaskSpec{
+			Patches: []api.PatchSpec{patchSpec},
+		},
+	}
+
+	return result, task, nil
+}
@@ -0,0 +1,84 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/GoogleContainerTools/kpt/internal/pkg"
+	kptfile "github.com/GoogleContainerTools/kpt/pkg/api/kptfile/v1"
+	api "github.com/GoogleContainerTools/kpt/porch/api/porch/v1alpha1"
+	"github.com/GoogleContainerTools/kpt/porch/pkg/repository"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUpdateReadiness(t *testing.T) {
+	old := &api.PackageRevision{
+		Status: api.PackageRevisionStatus{
+			Conditions: repository.RenderConditions(nil),
+		},
+	}
+	if m := newUpdateReadinessMutation(old, old.DeepCopy()); m != nil {
+		t.Fatalf("newUpdateReadinessMutation() returned a mutation for an unchanged package revision")
+	}
+
+	new := old.DeepCopy()
+	new.Spec.ReadinessGates = []api.ReadinessGate{{ConditionType: "Reviewed"}}
+	new.Status.Conditions = append(new.Status.Conditions, api.Condition{
+		Type:   "Reviewed",
+		Status: api.ConditionFalse,
+		Reason: "Pending",
+	})
+	m := newUpdateReadinessMutation(old, new)
+	if m == nil {
+		t.Fatalf("newUpdateReadinessMutation() returned no mutation for changed readiness gates")
+	}
+
+	resources := repository.PackageResources{
+		Contents: map[string]string{
+			kptfile.KptFileName: `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: example
+info:
+  description: example package
+`,
+		},
+	}
+	updated, task, err := m.Apply(context.Background(), resources)
+	if err != nil {
+		t.Fatalf("Apply() failed: %v", err)
+	}
+	if task == nil || task.Type != api.TaskTypePatch {
+		t.Errorf("Apply() returned task %v; want a patch", task)
+	}
+
+	kf, err := pkg.DecodeKptfile(strings.NewReader(updated.Contents[kptfile.KptFileName]))
+	if err != nil {
+		t.Fatalf("Cannot decode updated Kptfile: %v", err)
+	}
+	if got, want := repository.KptfileReadinessGates(kf), new.Spec.ReadinessGates; !cmp.Equal(want, got) {
+		t.Errorf("Unexpected readiness gates (-want, +got): %s", cmp.Diff(want, got))
+	}
+	// Conditions derived by porch are not stored.
+	if got, want := repository.KptfileConditions(kf), new.Status.Conditions[2:]; !cmp.Equal(want, got) {
+		t.Errorf("Unexpected conditions (-want, +got): %s", cmp.Diff(want, got))
+	}
+	if got, want := kf.Info.Description, "example package"; got != want {
+		t.Errorf("Description changed to %q; want %q", got, want)
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"path"
 	"strings"
 
+	fnresult "github.com/GoogleContainerTools/kpt/pkg/api/fnresult/v1"
 	"github.com/GoogleContainerTools/kpt/pkg/fn"
 	api "github.com/GoogleContainerTools/kpt/porch/api/porch/v1alpha1"
 	"github.com/GoogleContainerTools/kpt/porch/pkg/repository"
@@ -31,6 +32,11 @@ import (
 type renderPackageMutation struct {
 	renderer fn.Renderer
 	runtime  fn.FunctionRuntime
+
+	// repositoryFunctions are evaluated after the package's own pipeline.
+	repositoryFunctions *repositoryFunctions
+	// status is the outcome of the last Apply.
+	status *repository.RenderStatus
 }
 
 var _ mutation = &renderPackageMutation{}
@@ -39,6 +45,7 @@ func (m *renderPackageMutation) Apply(ctx context.Context, resources repository.
 	ctx, span := tracer.Start(ctx, "renderPackageMutation::Apply", trace.WithAttributes())
 	defer span.End()
 
+	m.status = &repository.RenderStatus{}
 	fs := filesys.MakeFsInMemory()
 
 	pkgPath, err := writeResources(fs, resources)
@@ -51,12 +58,14 @@ func (m *renderPackageMutation) Apply(ctx context.Context, resources repository.
 		// TODO: we should handle this better
 		klog.Warningf("skipping render as no package was found")
 	} else {
-		if err := m.renderer.Render(ctx, fs, fn.RenderOptions{
+		results, err := m.renderer.Render(ctx, fs, fn.RenderOptions{
 			PkgPath: pkgPath,
 			Runtime: m.runtime,
-		}); err != nil {
+		})
+		if err != nil {
 			return repository.PackageResources{}, nil, err
 		}
+		m.status.Results = convertResults(results)
 	}
 
 	result, err := readResources(fs)
@@ -64,6 +73,13 @@ func (m *renderPackageMutation) Apply(ctx context.Context, resources repository.
 		return repository.PackageResources{}, nil, err
 	}
 
+	if m.repositoryFunctions != nil {
+		result, m.status.FunctionResults, err = m.repositoryFunctions.evaluate(ctx, result)
+		if err != nil {
+			return repository.PackageResources{}, nil, err
+		}
+	}
+
 	// TODO: There are internal tasks not represented in the API; Update the Apply interface to enable them.
 	return result, &api.Task{
 		Type: "eval",
@@ -74,6 +90,48 @@ func (m *renderPackageMutation) Apply(ctx context.Context, resources repository.
 	}, nil
 }
 
+// convertResults converts the results of the kpt functions to the API.
+func convertResults(results *fnresult.ResultList) *api.ResultList {
+	if results == nil {
+		return nil
+	}
+	converted := &api.ResultList{
+		ExitCode: results.ExitCode,
+	}
+	for _, result := range results.Items {
+		item := api.Result{
+			Image:    result.Image,
+			ExecPath: result.ExecPath,
+			Stderr:   result.Stderr,
+			ExitCode: result.ExitCode,
+		}
+		for _, r := range result.Results {
+			resultItem := api.ResultItem{
+				Message:  r.Message,
+				Severity: string(r.Severity),
+				Tags:     r.Tags,
+			}
+			if r.ResourceRef != nil {
+				resultItem.ResourceRef = &api.ResourceIdentifier{
+					APIVersion: r.ResourceRef.APIVersion,
+					Kind:       r.ResourceRef.Kind,
+					Name:       r.ResourceRef.Name,
+					Namespace:  r.ResourceRef.Namespace,
+				}
+			}
+			if r.Field != nil {
+				resultItem.Field = &api.Field{Path: r.Field.Path}
+			}
+			if r.File != nil {
+				resultItem.File = &api.File{Path: r.File.Path, Index: r.File.Index}
+			}
+			item.Results = append(item.Results, resultItem)
+		}
+		converted.Items = append(converted.Items, item)
+	}
+	return converted
+}
+
 // TODO: Implement filesystem abstraction directly rather than on top of PackageResources
 func writeResources(fs filesys.FileSystem, resources repository.PackageResources) (string, error) {
 	var packageDir string // path to the topmost directory containing Kptfile
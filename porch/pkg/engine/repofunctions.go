@@ -0,0 +1,166 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/GoogleContainerTools/kpt/pkg/fn"
+	api "github.com/GoogleContainerTools/kpt/porch/api/porch/v1alpha1"
+	configapi "github.com/GoogleContainerTools/kpt/porch/api/porchconfig/v1alpha1"
+	"github.com/GoogleContainerTools/kpt/porch/pkg/repository"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// repositoryFunctions are the mutators and validators of a repository, which
+// are evaluated on every change to the packages in the repository, similar to
+// admission controllers.
+type repositoryFunctions struct {
+	namespace         string
+	mutators          []configapi.FunctionEval
+	validators        []configapi.FunctionEval
+	runtime           fn.FunctionRuntime
+	referenceResolver ReferenceResolver
+}
+
+// repositoryFunctions returns the functions of the repository, or nil if the
+// repository has none.
+func (cad *cadEngine) repositoryFunctions(repositoryObj *configapi.Repository) *repositoryFunctions {
+	if len(repositoryObj.Spec.Mutators) == 0 && len(repositoryObj.Spec.Validators) == 0 {
+		return nil
+	}
+	return &repositoryFunctions{
+		namespace:         repositoryObj.Namespace,
+		mutators:          repositoryObj.Spec.Mutators,
+		validators:        repositoryObj.Spec.Validators,
+		runtime:           cad.runtime,
+		referenceResolver: cad.referenceResolver,
+	}
+}
+
+// evaluate evaluates the mutators and then the validators on the resources.
+// A failed mutator rejects the change, while failed validators are only
+// reported in the results.
+func (f *repositoryFunctions) evaluate(ctx context.Context, resources repository.PackageResources) (repository.PackageResources, []api.FunctionResult, error) {
+	ctx, span := tracer.Start(ctx, "repositoryFunctions::evaluate", trace.WithAttributes())
+	defer span.End()
+
+	var results []api.FunctionResult
+	for _, mutator := range f.mutators {
+		image, err := f.resolveImage(ctx, mutator)
+		if err != nil {
+			return repository.PackageResources{}, nil, fmt.Errorf("cannot evaluate repository mutator: %w", err)
+		}
+		mutated, err := f.eval(ctx, image, mutator, resources)
+		if err != nil {
+			return repository.PackageResources{}, nil, fmt.Errorf("repository mutator %s failed: %w", image, err)
+		}
+		resources = mutated
+		results = append(results, api.FunctionResult{
+			Image:        image,
+			FunctionType: api.FunctionTypeMutator,
+			Passed:       true,
+		})
+	}
+
+	return resources, append(results, f.validate(ctx, resources)...), nil
+}
+
+// validate evaluates the validators on the resources.
+func (f *repositoryFunctions) validate(ctx context.Context, resources repository.PackageResources) []api.FunctionResult {
+	var results []api.FunctionResult
+	for _, validator := range f.validators {
+		result := api.FunctionResult{
+			FunctionType: api.FunctionTypeValidator,
+			Passed:       true,
+		}
+		image, err := f.resolveImage(ctx, validator)
+		if err == nil {
+			result.Image = image
+			// The output of validators is discarded.
+			_, err = f.eval(ctx, image, validator, resources)
+		} else if validator.FunctionRef != nil {
+			result.Image = validator.FunctionRef.Name
+		}
+		if err != nil {
+			result.Passed = false
+			result.Message = err.Error()
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func (f *repositoryFunctions) eval(ctx context.Context, image string, function configapi.FunctionEval, resources repository.PackageResources) (repository.PackageResources, error) {
+	m := &evalFunctionMutation{
+		runtime: f.runtime,
+		task: &api.Task{
+			Type: api.TaskTypeEval,
+			Eval: &api.FunctionEvalTaskSpec{
+				Image:     image,
+				ConfigMap: function.ConfigMap,
+			},
+		},
+	}
+	result, _, err := m.Apply(ctx, resources)
+	return result, err
+}
+
+// resolveImage returns the image of the function, resolving function
+// references through the Function API.
+func (f *repositoryFunctions) resolveImage(ctx context.Context, function configapi.FunctionEval) (string, error) {
+	switch {
+	case function.Image != "" && function.FunctionRef != nil:
+		return "", fmt.Errorf("image and functionRef are mutually exclusive (image %q, functionRef %q)", function.Image, function.FunctionRef.Name)
+	case function.Image != "":
+		return function.Image, nil
+	case function.FunctionRef == nil || function.FunctionRef.Name == "":
+		return "", fmt.Errorf("either image or functionRef must be specified")
+	}
+
+	if f.referenceResolver == nil {
+		return "", fmt.Errorf("cannot resolve function %q", function.FunctionRef.Name)
+	}
+	var resolved api.Function
+	if err := f.referenceResolver.ResolveReference(ctx, f.namespace, function.FunctionRef.Name, &resolved); err != nil {
+		return "", fmt.Errorf("cannot find function %s/%s: %w", f.namespace, function.FunctionRef.Name, err)
+	}
+	if resolved.Spec.Image == "" {
+		return "", fmt.Errorf("function %s/%s has no image", f.namespace, function.FunctionRef.Name)
+	}
+	return resolved.Spec.Image, nil
+}
+
+// checkRepositoryValidators returns an error if any of the validators of the
+// repository fails on the resources. It's called before a package revision
+// is proposed or published.
+func (cad *cadEngine) checkRepositoryValidators(ctx context.Context, repositoryObj *configapi.Repository, resources repository.PackageResources, lifecycle api.PackageRevisionLifecycle) error {
+	functions := cad.repositoryFunctions(repositoryObj)
+	if functions == nil {
+		return nil
+	}
+	var failed []string
+	for _, result := range functions.validate(ctx, resources) {
+		if !result.Passed {
+			failed = append(failed, fmt.Sprintf("%s: %s", result.Image, result.Message))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("cannot change lifecycle to %s; repository validators failed: %s", lifecycle, strings.Join(failed, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	api "github.com/GoogleContainerTools/kpt/porch/api/porch/v1alpha1"
+	configapi "github.com/GoogleContainerTools/kpt/porch/api/porchconfig/v1alpha1"
+	"github.com/GoogleContainerTools/kpt/porch/pkg/repository"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+type fakeFunctionResolver struct {
+	images map[string]string
+}
+
+func (r *fakeFunctionResolver) ResolveReference(ctx context.Context, namespace, name string, result Object) error {
+	image, found := r.images[name]
+	if !found {
+		return fmt.Errorf("function %q not found", name)
+	}
+	result.(*api.Function).Spec.Image = image
+	return nil
+}
+
+const repositoryFunctionsKptfile = `apiVersion: kpt.dev/v1
+kind: Kptfile
+metadata:
+  name: app
+`
+
+const repositoryFunctionsConfigMap = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+data:
+  key: value
+`
+
+func TestRepositoryFunctions(t *testing.T) {
+	functions := &repositoryFunctions{
+		namespace: "default",
+		mutators: []configapi.FunctionEval{
+			{
+				Image:     "gcr.io/kpt-fn/set-namespace:v0.4.1",
+				ConfigMap: map[string]string{"namespace": "policy"},
+			},
+		},
+		validators: []configapi.FunctionEval{
+			{
+				FunctionRef: &configapi.FunctionRef{Name: "builtins:set-namespace:v0.4.1"},
+				ConfigMap:   map[string]string{"namespace": "policy"},
+			},
+			{
+				Image: "gcr.io/kpt-fn/starlark:v0.4.3",
+				ConfigMap: map[string]string{
+					"source": `fail("config maps are not allowed")`,
+				},
+			},
+			{
+				FunctionRef: &configapi.FunctionRef{Name: "missing"},
+			},
+		},
+		runtime: newBuiltinRuntime(),
+		referenceResolver: &fakeFunctionResolver{images: map[string]string{
+			"builtins:set-namespace:v0.4.1": "gcr.io/kpt-fn/set-namespace:v0.4.1",
+		}},
+	}
+
+	resources, results, err := functions.evaluate(context.Background(), repository.PackageResources{
+		Contents: map[string]string{
+			"Kptfile":        repositoryFunctionsKptfile,
+			"configmap.yaml": repositoryFunctionsConfigMap,
+		},
+	})
+	if err != nil {
+		t.Fatalf("evaluate failed: %v", err)
+	}
+
+	if got := resources.Contents["configmap.yaml"]; !strings.Contains(got, "namespace: policy") {
+		t.Errorf("Mutator wasn't applied to configmap.yaml:\n%s", got)
+	}
+
+	want := []api.FunctionResult{
+		{Image: "gcr.io/kpt-fn/set-namespace:v0.4.1", FunctionType: api.FunctionTypeMutator, Passed: true},
+		{Image: "gcr.io/kpt-fn/set-namespace:v0.4.1", FunctionType: api.FunctionTypeValidator, Passed: true},
+		{Image: "gcr.io/kpt-fn/starlark:v0.4.3", FunctionType: api.FunctionTypeValidator, Passed: false},
+		{Image: "missing", FunctionType: api.FunctionTypeValidator, Passed: false},
+	}
+	if diff := cmp.Diff(want, results, cmpopts.IgnoreFields(api.FunctionResult{}, "Message")); diff != "" {
+		t.Errorf("Unexpected results (-want, +got): %s", diff)
+	}
+	for _, result := range results {
+		if !result.Passed && result.Message == "" {
+			t.Errorf("Failed validator %s has no message", result.Image)
+		}
+	}
+
+	functions.mutators = []configapi.FunctionEval{{Image: "gcr.io/kpt-fn/unknown:v1"}}
+	if _, _, err := functions.evaluate(context.Background(), resources); err == nil {
+		t.Errorf("evaluate succeeded with a missing mutator; want error")
+	}
+}
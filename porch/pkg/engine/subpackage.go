@@ -0,0 +1,68 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/GoogleContainerTools/kpt/porch/pkg/repository"
+)
+
+// validateSubpackage checks that subpackage is a clean path relative to the
+// root of the package which does not escape the package.
+func validateSubpackage(subpackage string) error {
+	if subpackage == "" || subpackage == "." {
+		return fmt.Errorf("subpackage path must not be empty")
+	}
+	if path.IsAbs(subpackage) || path.Clean(subpackage) != subpackage || subpackage == ".." || strings.HasPrefix(subpackage, "../") {
+		return fmt.Errorf("invalid subpackage path %q; must be a clean path relative to the package root", subpackage)
+	}
+	return nil
+}
+
+// subpackageResources returns the resources in the subpackage directory, with
+// paths relative to the subpackage.
+func subpackageResources(resources repository.PackageResources, subpackage string) repository.PackageResources {
+	prefix := subpackage + "/"
+	result := repository.PackageResources{
+		Contents: map[string]string{},
+	}
+	for k, v := range resources.Contents {
+		if strings.HasPrefix(k, prefix) {
+			result.Contents[strings.TrimPrefix(k, prefix)] = v
+		}
+	}
+	return result
+}
+
+// replaceSubpackageResources replaces the contents of the subpackage directory
+// with the given resources, whose paths are relative to the subpackage.
+func replaceSubpackageResources(resources repository.PackageResources, subpackage string, subpackageResources repository.PackageResources) repository.PackageResources {
+	prefix := subpackage + "/"
+	result := repository.PackageResources{
+		Contents: map[string]string{},
+	}
+	for k, v := range resources.Contents {
+		if !strings.HasPrefix(k, prefix) {
+			result.Contents[k] = v
+		}
+	}
+	for k, v := range subpackageResources.Contents {
+		result.Contents[prefix+k] = v
+	}
+	return result
+}
@@ -0,0 +1,137 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	internalpkg "github.com/GoogleContainerTools/kpt/internal/pkg"
+	kptfile "github.com/GoogleContainerTools/kpt/pkg/api/kptfile/v1"
+	"github.com/GoogleContainerTools/kpt/porch/api/porch/v1alpha1"
+	"github.com/GoogleContainerTools/kpt/porch/pkg/repository"
+)
+
+func TestValidateSubpackage(t *testing.T) {
+	for _, tc := range []struct {
+		subpackage string
+		valid      bool
+	}{
+		{"apps", true},
+		{"apps/configmap", true},
+		{"", false},
+		{".", false},
+		{"/apps", false},
+		{"apps/", false},
+		{"./apps", false},
+		{"..", false},
+		{"../apps", false},
+		{"apps/../../etc", false},
+	} {
+		if err := validateSubpackage(tc.subpackage); (err == nil) != tc.valid {
+			t.Errorf("validateSubpackage(%q) = %v, want valid %t", tc.subpackage, err, tc.valid)
+		}
+	}
+}
+
+func TestCloneAndUpdateSubpackage(t *testing.T) {
+	upstream, main := startUpdatedConfigMapRepo(t)
+	ctx := context.Background()
+
+	parent, _, err := (&initPackageMutation{
+		name: "parent",
+		task: &v1alpha1.Task{
+			Type: v1alpha1.TaskTypeInit,
+			Init: &v1alpha1.PackageInitTaskSpec{Description: "parent package"},
+		},
+	}).Apply(ctx, repository.PackageResources{})
+	if err != nil {
+		t.Fatalf("Failed to initialize parent package: %v", err)
+	}
+	parentKptfile := parent.Contents[kptfile.KptFileName]
+
+	const subpackage = "apps/configmap"
+	oldTask := &v1alpha1.Task{
+		Type: v1alpha1.TaskTypeClone,
+		Clone: &v1alpha1.PackageCloneTaskSpec{
+			Subpackage: subpackage,
+			Upstream:   upstream("v1"),
+		},
+	}
+	clone := &clonePackageMutation{
+		task:      oldTask,
+		namespace: "test-namespace",
+		name:      "parent",
+	}
+	cloned, _, err := clone.Apply(ctx, parent)
+	if err != nil {
+		t.Fatalf("Failed to clone subpackage: %v", err)
+	}
+	if got := cloned.Contents[kptfile.KptFileName]; got != parentKptfile {
+		t.Errorf("Parent Kptfile changed by subpackage clone:\n%s", got)
+	}
+	if _, found := cloned.Contents[subpackage+"/configmap.yaml"]; !found {
+		t.Errorf("Cloned subpackage doesn't contain configmap.yaml")
+	}
+
+	// Cloning into the same directory again must fail.
+	if _, _, err := clone.Apply(ctx, cloned); err == nil {
+		t.Errorf("Cloning into non-empty subpackage succeeded, want error")
+	}
+
+	// Change the subpackage downstream.
+	cloned.Contents[subpackage+"/configmap.yaml"] = strings.Replace(cloned.Contents[subpackage+"/configmap.yaml"], "  key: value\n", "  key: value\n  local-key: local-value\n", 1)
+
+	newTask := &v1alpha1.Task{
+		Type: v1alpha1.TaskTypeClone,
+		Clone: &v1alpha1.PackageCloneTaskSpec{
+			Subpackage: subpackage,
+			Upstream:   upstream("main"),
+		},
+	}
+	updated, _, err := (&updatePackageMutation{
+		oldTask:   oldTask,
+		task:      newTask,
+		namespace: "test-namespace",
+		pkgName:   "parent",
+	}).Apply(ctx, cloned)
+	if err != nil {
+		t.Fatalf("Failed to update subpackage: %v", err)
+	}
+
+	if got := updated.Contents[kptfile.KptFileName]; got != parentKptfile {
+		t.Errorf("Parent Kptfile changed by subpackage update:\n%s", got)
+	}
+	for _, want := range []string{"local-key: local-value", "upstream-key: upstream-value"} {
+		if got := updated.Contents[subpackage+"/configmap.yaml"]; !strings.Contains(got, want) {
+			t.Errorf("updated configmap.yaml doesn't contain %q:\n%s", want, got)
+		}
+	}
+
+	kf, err := internalpkg.DecodeKptfile(strings.NewReader(updated.Contents[subpackage+"/"+kptfile.KptFileName]))
+	if err != nil {
+		t.Fatalf("Failed to decode subpackage Kptfile: %v", err)
+	}
+	if got, want := kf.Name, "configmap"; got != want {
+		t.Errorf("Subpackage Kptfile name = %q, want %q", got, want)
+	}
+	if kf.Upstream == nil || kf.Upstream.Git == nil || kf.Upstream.Git.Ref != "main" {
+		t.Errorf("Subpackage Kptfile upstream = %+v, want ref main", kf.Upstream)
+	}
+	if kf.UpstreamLock == nil || kf.UpstreamLock.Git == nil || kf.UpstreamLock.Git.Commit != main.String() {
+		t.Errorf("Subpackage Kptfile upstream lock = %+v, want commit %s", kf.UpstreamLock, main)
+	}
+}
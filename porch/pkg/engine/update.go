@@ -21,6 +21,7 @@ import (
 	"path/filepath"
 
 	"github.com/GoogleContainerTools/kpt/internal/printer"
+	"github.com/GoogleContainerTools/kpt/internal/util/merge"
 	"github.com/GoogleContainerTools/kpt/internal/util/update"
 	"github.com/GoogleContainerTools/kpt/porch/pkg/repository"
 )
@@ -31,7 +32,10 @@ type packageUpdater interface {
 }
 
 // defaultPackageUpdater implements packageUpdater interface.
-type defaultPackageUpdater struct{}
+type defaultPackageUpdater struct {
+	// schemas are used to merge lists of custom resources.
+	schemas *merge.Schemas
+}
 
 func (m *defaultPackageUpdater) Update(
 	ctx context.Context,
@@ -95,6 +99,7 @@ func (m *defaultPackageUpdater) do(ctx context.Context, localPkgDir, originalPkg
 			UpdatedPath:    updatedPath,
 			OriginPath:     originPath,
 			IsRoot:         isRoot,
+			Schemas:        m.schemas,
 		}
 		updater := update.ResourceMergeUpdater{}
 		if err := updater.Update(updateOptions); err != nil {
@@ -17,8 +17,17 @@ package engine
 import (
 	"context"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	internalpkg "github.com/GoogleContainerTools/kpt/internal/pkg"
+	kptfile "github.com/GoogleContainerTools/kpt/pkg/api/kptfile/v1"
+	"github.com/GoogleContainerTools/kpt/porch/api/porch/v1alpha1"
+	"github.com/GoogleContainerTools/kpt/porch/pkg/repository"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/google/go-cmp/cmp"
 )
 
@@ -63,3 +72,219 @@ func TestPkgUpdate(t *testing.T) {
 		}
 	}
 }
+
+// startUpdatedConfigMapRepo starts a git server with the configmap package
+// tagged v1 and a change to it committed to main. It returns a function
+// creating references to the package at a given ref, and the main commit.
+func startUpdatedConfigMapRepo(t *testing.T) (func(ref string) v1alpha1.UpstreamPackage, plumbing.Hash) {
+	repo := createConfigMapRepo(t)
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatalf("Failed to resolve HEAD: %v", err)
+	}
+	if _, err := repo.CreateTag("v1", head.Hash(), nil); err != nil {
+		t.Fatalf("Failed to tag v1: %v", err)
+	}
+	main := commitConfigMapChange(t, repo)
+	return configMapUpstream(startGitServer(t, repo)), main
+}
+
+// createConfigMapRepo creates a git repository with the configmap package.
+func createConfigMapRepo(t *testing.T) *gogit.Repository {
+	testdata, err := filepath.Abs(filepath.Join(".", "testdata", "clone"))
+	if err != nil {
+		t.Fatalf("Failed to find testdata: %v", err)
+	}
+	return createRepoWithContents(t, testdata)
+}
+
+// commitConfigMapChange commits a change to the configmap package to the
+// checked out branch, and returns the new commit.
+func commitConfigMapChange(t *testing.T, repo *gogit.Repository) plumbing.Hash {
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("Failed to get git repository worktree: %v", err)
+	}
+	f, err := wt.Filesystem.Create("configmap/configmap.yaml")
+	if err != nil {
+		t.Fatalf("Failed to open configmap.yaml: %v", err)
+	}
+	if _, err := f.Write([]byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: configmap-name
+  namespace: configmap-namespace
+data:
+  key: value
+  upstream-key: upstream-value
+`)); err != nil {
+		t.Fatalf("Failed to write configmap.yaml: %v", err)
+	}
+	f.Close()
+	sig := object.Signature{
+		Name:  "Porch Unit Test",
+		Email: "porch-unit-test@kpt.dev",
+		When:  time.Now(),
+	}
+	commit, err := wt.Commit("Update configmap", &gogit.CommitOptions{All: true, Author: &sig, Committer: &sig})
+	if err != nil {
+		t.Fatalf("Failed to commit upstream change: %v", err)
+	}
+	return commit
+}
+
+// configMapUpstream returns a function creating references to the configmap
+// package in the git repository at addr.
+func configMapUpstream(addr string) func(ref string) v1alpha1.UpstreamPackage {
+	return func(ref string) v1alpha1.UpstreamPackage {
+		return v1alpha1.UpstreamPackage{
+			Type: v1alpha1.RepositoryTypeGit,
+			Git: &v1alpha1.GitPackage{
+				Repo:      addr,
+				Ref:       ref,
+				Directory: "configmap",
+			},
+		}
+	}
+}
+
+func TestUpdateFromGit(t *testing.T) {
+	upstream, main := startUpdatedConfigMapRepo(t)
+
+	oldTask := &v1alpha1.Task{
+		Type:  v1alpha1.TaskTypeClone,
+		Clone: &v1alpha1.PackageCloneTaskSpec{Upstream: upstream("v1")},
+	}
+	cloned, _, err := (&clonePackageMutation{
+		task:      oldTask,
+		namespace: "test-namespace",
+		name:      "test-configmap",
+	}).Apply(context.Background(), repository.PackageResources{})
+	if err != nil {
+		t.Fatalf("Failed to clone package: %v", err)
+	}
+
+	// Change the package downstream.
+	cloned.Contents["configmap.yaml"] = strings.Replace(cloned.Contents["configmap.yaml"], "  key: value\n", "  key: value\n  local-key: local-value\n", 1)
+
+	newTask := &v1alpha1.Task{
+		Type:  v1alpha1.TaskTypeClone,
+		Clone: &v1alpha1.PackageCloneTaskSpec{Upstream: upstream("main")},
+	}
+	updated, task, err := (&updatePackageMutation{
+		oldTask:   oldTask,
+		task:      newTask,
+		namespace: "test-namespace",
+		pkgName:   "test-configmap",
+	}).Apply(context.Background(), cloned)
+	if err != nil {
+		t.Fatalf("Failed to update package: %v", err)
+	}
+	if task != newTask {
+		t.Errorf("update returned task %v, want %v", task, newTask)
+	}
+
+	for _, want := range []string{"local-key: local-value", "upstream-key: upstream-value"} {
+		if got := updated.Contents["configmap.yaml"]; !strings.Contains(got, want) {
+			t.Errorf("updated configmap.yaml doesn't contain %q:\n%s", want, got)
+		}
+	}
+
+	kf, err := internalpkg.DecodeKptfile(strings.NewReader(updated.Contents[kptfile.KptFileName]))
+	if err != nil {
+		t.Fatalf("Failed to decode updated Kptfile: %v", err)
+	}
+	if got, want := kf.Name, "test-configmap"; got != want {
+		t.Errorf("Kptfile name = %q, want %q", got, want)
+	}
+	if kf.Upstream == nil || kf.Upstream.Git == nil || kf.Upstream.Git.Ref != "main" {
+		t.Errorf("Kptfile upstream = %+v, want ref main", kf.Upstream)
+	}
+	if kf.UpstreamLock == nil || kf.UpstreamLock.Git == nil || kf.UpstreamLock.Git.Commit != main.String() {
+		t.Errorf("Kptfile upstream lock = %+v, want commit %s", kf.UpstreamLock, main)
+	}
+}
+
+// TestUpdateFromMovedBranch updates a package cloned from a branch which has
+// moved on since. The merge base is the commit the package was cloned from,
+// not the current head of the branch.
+func TestUpdateFromMovedBranch(t *testing.T) {
+	repo := createConfigMapRepo(t)
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatalf("Failed to resolve HEAD: %v", err)
+	}
+	stable := plumbing.NewBranchReferenceName("stable")
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(stable, head.Hash())); err != nil {
+		t.Fatalf("Failed to create branch stable: %v", err)
+	}
+	upstream := configMapUpstream(startGitServer(t, repo))
+
+	oldTask := &v1alpha1.Task{
+		Type:  v1alpha1.TaskTypeClone,
+		Clone: &v1alpha1.PackageCloneTaskSpec{Upstream: upstream("stable")},
+	}
+	cloned, _, err := (&clonePackageMutation{
+		task:      oldTask,
+		namespace: "test-namespace",
+		name:      "test-configmap",
+	}).Apply(context.Background(), repository.PackageResources{})
+	if err != nil {
+		t.Fatalf("Failed to clone package: %v", err)
+	}
+	cloned.Contents["configmap.yaml"] = strings.Replace(cloned.Contents["configmap.yaml"], "  key: value\n", "  key: value\n  local-key: local-value\n", 1)
+
+	// The upstream change is committed to main, and stable moves to it too.
+	main := commitConfigMapChange(t, repo)
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(stable, main)); err != nil {
+		t.Fatalf("Failed to move branch stable: %v", err)
+	}
+
+	updated, _, err := (&updatePackageMutation{
+		oldTask: oldTask,
+		task: &v1alpha1.Task{
+			Type:  v1alpha1.TaskTypeClone,
+			Clone: &v1alpha1.PackageCloneTaskSpec{Upstream: upstream("main")},
+		},
+		namespace: "test-namespace",
+		pkgName:   "test-configmap",
+	}).Apply(context.Background(), cloned)
+	if err != nil {
+		t.Fatalf("Failed to update package: %v", err)
+	}
+	for _, want := range []string{"local-key: local-value", "upstream-key: upstream-value"} {
+		if got := updated.Contents["configmap.yaml"]; !strings.Contains(got, want) {
+			t.Errorf("updated configmap.yaml doesn't contain %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestPinUpstreamImage(t *testing.T) {
+	const digest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	ociTask := func(image string) *v1alpha1.Task {
+		return &v1alpha1.Task{
+			Type: v1alpha1.TaskTypeClone,
+			Clone: &v1alpha1.PackageCloneTaskSpec{
+				Upstream: v1alpha1.UpstreamPackage{
+					Type: v1alpha1.RepositoryTypeOCI,
+					Oci:  &v1alpha1.OciPackage{Image: image},
+				},
+			},
+		}
+	}
+
+	task := ociTask("gcr.io/example/package:v1")
+	pinned := pinUpstreamImage(task, &upstreamPackage{digest: digest})
+	if got, want := pinned.Clone.Upstream.Oci.Image, "gcr.io/example/package:v1@"+digest; got != want {
+		t.Errorf("pinned image = %q, want %q", got, want)
+	}
+	if got, want := task.Clone.Upstream.Oci.Image, "gcr.io/example/package:v1"; got != want {
+		t.Errorf("original task image changed to %q, want %q", got, want)
+	}
+
+	// Images which are already pinned are left alone.
+	task = ociTask("gcr.io/example/package@" + digest)
+	if got := pinUpstreamImage(task, &upstreamPackage{digest: digest}); got != task {
+		t.Errorf("pinUpstreamImage returned %v, want the unchanged task", got)
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"strings"
 
 	"github.com/GoogleContainerTools/kpt/porch/api/porch/v1alpha1"
+	"github.com/GoogleContainerTools/kpt/porch/pkg/repository"
 	"github.com/go-git/go-git/v5/plumbing/object"
 )
 
@@ -42,6 +43,10 @@ type gitAnnotation struct {
 
 	// Tasks holds the tasks performed if there are more than one.
 	Tasks []*v1alpha1.Task `json:"tasks,omitempty"`
+
+	// RenderStatus holds the outcome of the last render of the package
+	// revision.
+	RenderStatus *repository.RenderStatus `json:"renderStatus,omitempty"`
 }
 
 // ExtractGitAnnotations reads the gitAnnotations from the given commit.
@@ -40,6 +40,8 @@ type gitPackageDraft struct {
 	commit    plumbing.Hash       // Current HEAD of the package changes (commit sha)
 	tree      plumbing.Hash       // Cached tree of the package itself, some descendent of commit.Tree()
 	tasks     []v1alpha1.Task
+	// renderStatus is recorded with the next commit of the draft.
+	renderStatus *repository.RenderStatus
 }
 
 var _ repository.PackageDraft = &gitPackageDraft{}
@@ -71,6 +73,8 @@ func (d *gitPackageDraft) UpdateResources(ctx context.Context, new *v1alpha1.Pac
 		PackagePath: d.path,
 		Revision:    d.revision,
 		Tasks:       []*v1alpha1.Task{change},
+
+		RenderStatus: d.renderStatus,
 	}
 	message := "Intermediate commit"
 	if change != nil {
@@ -94,6 +98,11 @@ func (d *gitPackageDraft) UpdateResources(ctx context.Context, new *v1alpha1.Pac
 	return nil
 }
 
+func (d *gitPackageDraft) UpdateRenderStatus(ctx context.Context, status *repository.RenderStatus) error {
+	d.renderStatus = status
+	return nil
+}
+
 func (d *gitPackageDraft) UpdateLifecycle(ctx context.Context, new v1alpha1.PackageRevisionLifecycle) error {
 	d.lifecycle = new
 	return nil
@@ -174,7 +183,7 @@ func (r *gitRepository) closeDraft(ctx context.Context, d *gitPackageDraft) (*gi
 		return nil, err
 	}
 
-	return &gitPackageRevision{
+	rev := &gitPackageRevision{
 		repo:     d.parent,
 		path:     d.path,
 		revision: d.revision,
@@ -183,7 +192,17 @@ func (r *gitRepository) closeDraft(ctx context.Context, d *gitPackageDraft) (*gi
 		tree:     d.tree,
 		commit:   newRef.Hash(),
 		tasks:    d.tasks,
-	}, nil
+
+		renderStatus: d.renderStatus,
+	}
+	if d.lifecycle == v1alpha1.PackageRevisionLifecyclePublished {
+		commit, err := r.repo.CommitObject(rev.commit)
+		if err != nil {
+			return nil, fmt.Errorf("cannot resolve published commit %s: %w", rev.commit, err)
+		}
+		rev.setPublished(commit)
+	}
+	return rev, nil
 }
 
 func (r *gitRepository) commitPackageToMain(ctx context.Context, d *gitPackageDraft) (commitHash, newPackageTreeHash plumbing.Hash, base *plumbing.Reference, err error) {
@@ -241,6 +260,8 @@ func (r *gitRepository) commitPackageToMain(ctx context.Context, d *gitPackageDr
 		PackagePath: d.path,
 		Revision:    d.revision,
 		Tasks:       tasks,
+
+		RenderStatus: d.renderStatus,
 	}
 	message := fmt.Sprintf("Approve %s", packagePath)
 	message, err = AnnotateCommitMessage(message, annotation)
@@ -22,6 +22,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	kptfilev1 "github.com/GoogleContainerTools/kpt/pkg/api/kptfile/v1"
@@ -143,6 +144,19 @@ type gitRepository struct {
 	cachedCredentials  transport.AuthMethod
 	credentialResolver repository.CredentialResolver
 	userInfoProvider   repository.UserInfoProvider
+
+	// loadedRefsMutex guards loadedRefs.
+	loadedRefsMutex sync.Mutex
+	// loadedRefs are the package revisions loaded from each ref by the last
+	// listing. They are reused while the ref points to the same commit, so
+	// that only the refs which changed since are loaded again.
+	loadedRefs map[plumbing.ReferenceName]loadedRef
+}
+
+// loadedRef are the package revisions loaded from a ref at a commit.
+type loadedRef struct {
+	hash      plumbing.Hash
+	revisions []repository.PackageRevision
 }
 
 func (r *gitRepository) ListPackageRevisions(ctx context.Context, filter repository.ListPackageRevisionFilter) ([]repository.PackageRevision, error) {
@@ -158,6 +172,26 @@ func (r *gitRepository) ListPackageRevisions(ctx context.Context, filter reposit
 		return nil, err
 	}
 
+	r.loadedRefsMutex.Lock()
+	previous := r.loadedRefs
+	r.loadedRefsMutex.Unlock()
+	loaded := map[plumbing.ReferenceName]loadedRef{}
+
+	// load returns the package revisions at the ref, reusing those loaded by
+	// the previous listing if the ref didn't change since.
+	load := func(ref *plumbing.Reference, loader func() ([]repository.PackageRevision, error)) ([]repository.PackageRevision, error) {
+		if prev, ok := previous[ref.Name()]; ok && prev.hash == ref.Hash() {
+			loaded[ref.Name()] = prev
+			return prev.revisions, nil
+		}
+		revisions, err := loader()
+		if err != nil {
+			return nil, err
+		}
+		loaded[ref.Name()] = loadedRef{hash: ref.Hash(), revisions: revisions}
+		return revisions, nil
+	}
+
 	var main *plumbing.Reference
 	var drafts []repository.PackageRevision
 	var result []repository.PackageRevision
@@ -176,17 +210,25 @@ func (r *gitRepository) ListPackageRevisions(ctx context.Context, filter reposit
 			continue
 
 		case isProposedBranchNameInLocal(ref.Name()), isDraftBranchNameInLocal(ref.Name()):
-			draft, err := r.loadDraft(ctx, ref)
+			draft, err := load(ref, func() ([]repository.PackageRevision, error) {
+				draft, err := r.loadDraft(ctx, ref)
+				if err != nil || draft == nil {
+					return nil, err
+				}
+				return []repository.PackageRevision{draft}, nil
+			})
 			if err != nil {
 				return nil, fmt.Errorf("failed to load package draft %q: %w", name.String(), err)
 			}
-			if draft != nil {
-				drafts = append(drafts, draft)
+			if len(draft) != 0 {
+				drafts = append(drafts, draft...)
 			} else {
 				klog.Warningf("no package draft found for ref %v", ref)
 			}
 		case isTagInLocalRepo(ref.Name()):
-			tagged, err := r.loadTaggedPackages(ctx, ref)
+			tagged, err := load(ref, func() ([]repository.PackageRevision, error) {
+				return r.loadTaggedPackages(ctx, ref)
+			})
 			if err != nil {
 				return nil, fmt.Errorf("failed to load packages from tag %q: %w", name, err)
 			}
@@ -200,7 +242,9 @@ func (r *gitRepository) ListPackageRevisions(ctx context.Context, filter reposit
 
 	if main != nil {
 		// TODO: ignore packages that are unchanged in main branch, compared to a tagged version?
-		mainpkgs, err := r.discoverFinalizedPackages(ctx, main)
+		mainpkgs, err := load(main, func() ([]repository.PackageRevision, error) {
+			return r.discoverFinalizedPackages(ctx, main)
+		})
 		if err != nil {
 			return nil, err
 		}
@@ -217,6 +261,11 @@ func (r *gitRepository) ListPackageRevisions(ctx context.Context, filter reposit
 		}
 	}
 
+	// Refs which no longer exist are dropped.
+	r.loadedRefsMutex.Lock()
+	r.loadedRefs = loaded
+	r.loadedRefsMutex.Unlock()
+
 	return result, nil
 }
 
@@ -291,6 +340,8 @@ func (r *gitRepository) UpdatePackage(ctx context.Context, old repository.Packag
 		tree:      rev.tree,
 		commit:    rev.commit,
 		tasks:     rev.tasks,
+
+		renderStatus: rev.renderStatus,
 	}, nil
 }
 
@@ -790,7 +841,9 @@ func (r *gitRepository) pushAndCleanup(ctx context.Context, ph *pushRefSpecBuild
 	return nil
 }
 
-func (r *gitRepository) loadTasks(ctx context.Context, startCommit *object.Commit, packagePath, revision string) ([]v1alpha1.Task, error) {
+// loadTasks returns the tasks of the package revision, and the results of the
+// repository functions recorded with its last change.
+func (r *gitRepository) loadTasks(ctx context.Context, startCommit *object.Commit, packagePath, revision string) ([]v1alpha1.Task, *repository.RenderStatus, error) {
 	var logOptions = git.LogOptions{
 		From:  startCommit.Hash,
 		Order: git.LogOrderCommitterTime,
@@ -812,10 +865,11 @@ func (r *gitRepository) loadTasks(ctx context.Context, startCommit *object.Commi
 
 	commits, err := r.repo.Log(&logOptions)
 	if err != nil {
-		return nil, fmt.Errorf("error walking commits: %w", err)
+		return nil, nil, fmt.Errorf("error walking commits: %w", err)
 	}
 
 	var tasks []v1alpha1.Task
+	var renderStatus *repository.RenderStatus
 
 	visitCommit := func(commit *object.Commit) error {
 		gitAnnotations, err := ExtractGitAnnotations(commit)
@@ -825,6 +879,11 @@ func (r *gitRepository) loadTasks(ctx context.Context, startCommit *object.Commi
 
 		for _, gitAnnotation := range gitAnnotations {
 			if gitAnnotation.PackagePath == packagePath && gitAnnotation.Revision == revision {
+				// Commits are visited newest first, so the last render is
+				// in the first annotation which has one.
+				if renderStatus == nil {
+					renderStatus = gitAnnotation.RenderStatus
+				}
 				if gitAnnotation.Task != nil {
 					tasks = append(tasks, *gitAnnotation.Task)
 				}
@@ -846,13 +905,13 @@ func (r *gitRepository) loadTasks(ctx context.Context, startCommit *object.Commi
 	}
 
 	if err := commits.ForEach(visitCommit); err != nil {
-		return nil, fmt.Errorf("error visiting commits: %w", err)
+		return nil, nil, fmt.Errorf("error visiting commits: %w", err)
 	}
 
 	// We need to reverse the tasks so they appear in chronological order
 	reverseSlice(tasks)
 
-	return tasks, nil
+	return tasks, renderStatus, nil
 }
 
 // See https://eli.thegreenplace.net/2021/generic-functions-on-slices-with-go-type-parameters/
@@ -231,6 +231,143 @@ func (g GitSuite) TestGitPackageRoundTrip(t *testing.T) {
 	}
 }
 
+// TestRenderStatus verifies the status of a package revision is reconstructed from the commit annotations.
+func (g GitSuite) TestRenderStatus(t *testing.T) {
+	tempdir := t.TempDir()
+	serverRepo := InitEmptyRepositoryWithWorktree(t, filepath.Join(tempdir, "repo"))
+	if err := g.initRepo(serverRepo); err != nil {
+		t.Fatalf("failed to init repo: %v", err)
+	}
+
+	ctx := context.Background()
+	spec := &configapi.GitRepository{
+		Repo:   ServeExistingRepository(t, serverRepo),
+		Branch: g.branch,
+	}
+
+	const (
+		repositoryName = "status"
+		packageName    = "test-package"
+		namespace      = "default"
+	)
+	key := repository.PackageRevisionKey{Repository: repositoryName, Package: packageName, Revision: "v1"}
+
+	repo, err := OpenRepository(ctx, repositoryName, namespace, spec, filepath.Join(tempdir, "work"), GitRepositoryOptions{})
+	if err != nil {
+		t.Fatalf("failed to open repository: %v", err)
+	}
+
+	renderStatus := &repository.RenderStatus{
+		Results: &v1alpha1.ResultList{
+			Items: []v1alpha1.Result{{
+				Image:   "gcr.io/kpt-fn/set-labels:v0.1",
+				Results: []v1alpha1.ResultItem{{Message: "labels set", Severity: "info"}},
+			}},
+		},
+		FunctionResults: []v1alpha1.FunctionResult{
+			{Image: "gcr.io/kpt-fn/kubeval:v0.3", FunctionType: v1alpha1.FunctionTypeValidator, Message: "invalid"},
+		},
+	}
+
+	draft, err := repo.CreatePackageRevision(ctx, &v1alpha1.PackageRevision{
+		Spec: v1alpha1.PackageRevisionSpec{
+			PackageName:    packageName,
+			Revision:       key.Revision,
+			RepositoryName: repositoryName,
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreatePackageRevision failed: %v", err)
+	}
+	if err := draft.UpdateRenderStatus(ctx, renderStatus); err != nil {
+		t.Fatalf("UpdateRenderStatus failed: %v", err)
+	}
+	resources := &v1alpha1.PackageRevisionResources{}
+	resources.Spec.Resources = map[string]string{"Kptfile": Kptfile}
+	if err := draft.UpdateResources(ctx, resources, &v1alpha1.Task{}); err != nil {
+		t.Fatalf("UpdateResources failed: %v", err)
+	}
+	created, err := draft.Close(ctx)
+	if err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	update, err := repo.UpdatePackage(ctx, created)
+	if err != nil {
+		t.Fatalf("UpdatePackage failed: %v", err)
+	}
+	if err := update.UpdateLifecycle(ctx, v1alpha1.PackageRevisionLifecyclePublished); err != nil {
+		t.Fatalf("UpdateLifecycle failed: %v", err)
+	}
+	if _, err := update.Close(ctx); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// Someone else commits to the branch without changing the package, which
+	// must not change who published it.
+	branchRef := resolveReference(t, serverRepo, BranchName(g.branch).RefInRemote())
+	head := getCommitObject(t, serverRepo, branchRef.Hash())
+	later := &object.Commit{
+		Author:       object.Signature{Name: "Someone Else", Email: "else@example.com", When: head.Author.When.Add(time.Hour)},
+		Committer:    object.Signature{Name: "Someone Else", Email: "else@example.com", When: head.Author.When.Add(time.Hour)},
+		Message:      "Unrelated change",
+		TreeHash:     head.TreeHash,
+		ParentHashes: []plumbing.Hash{head.Hash},
+	}
+	eo := serverRepo.Storer.NewEncodedObject()
+	if err := later.Encode(eo); err != nil {
+		t.Fatalf("failed to encode commit: %v", err)
+	}
+	laterHash, err := serverRepo.Storer.SetEncodedObject(eo)
+	if err != nil {
+		t.Fatalf("failed to store commit: %v", err)
+	}
+	if err := serverRepo.Storer.SetReference(plumbing.NewHashReference(branchRef.Name(), laterHash)); err != nil {
+		t.Fatalf("failed to update branch: %v", err)
+	}
+
+	// Open the repository again, so the status is read from the commits.
+	reopened, err := OpenRepository(ctx, repositoryName, namespace, spec, filepath.Join(tempdir, "reopened"), GitRepositoryOptions{})
+	if err != nil {
+		t.Fatalf("failed to reopen repository: %v", err)
+	}
+	revisions, err := reopened.ListPackageRevisions(ctx, repository.ListPackageRevisionFilter{})
+	if err != nil {
+		t.Fatalf("ListPackageRevisions failed: %v", err)
+	}
+	status := findPackage(t, revisions, key).GetPackageRevision().Status
+
+	wantConditions := []v1alpha1.Condition{
+		{Type: v1alpha1.ConditionTypeRendered, Status: v1alpha1.ConditionTrue, Reason: repository.ReasonRenderSucceeded, Message: "1 function(s) executed"},
+		{Type: v1alpha1.ConditionTypeValidated, Status: v1alpha1.ConditionFalse, Reason: repository.ReasonValidationFailed, Message: "gcr.io/kpt-fn/kubeval:v0.3: invalid"},
+	}
+	if diff := cmp.Diff(wantConditions, status.Conditions); diff != "" {
+		t.Errorf("Unexpected conditions (-want, +got): %s", diff)
+	}
+	if diff := cmp.Diff(renderStatus.Results, status.RenderResults); diff != "" {
+		t.Errorf("Unexpected render results (-want, +got): %s", diff)
+	}
+	if diff := cmp.Diff(renderStatus.FunctionResults, status.RepositoryFunctionResults); diff != "" {
+		t.Errorf("Unexpected repository function results (-want, +got): %s", diff)
+	}
+	if got, want := status.PublishedBy, porchSignatureName; got != want {
+		t.Errorf("PublishedBy: got %q, want %q", got, want)
+	}
+	if status.PublishTimestamp.IsZero() {
+		t.Errorf("PublishTimestamp is not set")
+	}
+
+	// The package on the branch was published by the same commit.
+	branchKey := repository.PackageRevisionKey{Repository: repositoryName, Package: packageName, Revision: g.branch}
+	branchStatus := findPackage(t, revisions, branchKey).GetPackageRevision().Status
+	if got, want := branchStatus.PublishedBy, porchSignatureName; got != want {
+		t.Errorf("PublishedBy on branch: got %q, want %q", got, want)
+	}
+	if got, want := branchStatus.PublishTimestamp, status.PublishTimestamp; !got.Equal(&want) {
+		t.Errorf("PublishTimestamp on branch: got %v, want %v", got, want)
+	}
+}
+
 // initRepo is a helper that creates a first commit, ensuring the repo is not empty.
 func (g GitSuite) initRepo(repo *gogit.Repository) error {
 	store := repo.Storer
@@ -800,7 +937,8 @@ func (g GitSuite) TestRefreshRepo(t *testing.T) {
 	}
 
 	// Confirm we listed some package(s)
-	findPackage(t, all, repository.PackageRevisionKey{Repository: "refresh", Package: "basens", Revision: "v2"})
+	basensName := repository.PackageRevisionKey{Repository: "refresh", Package: "basens", Revision: "v2"}
+	basens := findPackage(t, all, basensName)
 	packageMustNotExist(t, all, newPackageName)
 
 	// Create package in the upstream repository
@@ -852,6 +990,11 @@ func (g GitSuite) TestRefreshRepo(t *testing.T) {
 		t.Fatalf("ListPackageRevisions(Refresh) failed; %v", err)
 	}
 	findPackage(t, all, newPackageName)
+
+	// The revisions of the unchanged tags are not reloaded.
+	if got := findPackage(t, all, basensName); got != basens {
+		t.Errorf("PackageRevision %q was reloaded although its tag did not change", basensName)
+	}
 }
 
 // The test deletes packages on the upstream one by one and validates they were
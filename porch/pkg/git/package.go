@@ -28,6 +28,7 @@ import (
 	"github.com/GoogleContainerTools/kpt/porch/api/porch/v1alpha1"
 	"github.com/GoogleContainerTools/kpt/porch/pkg/repository"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 )
@@ -41,6 +42,13 @@ type gitPackageRevision struct {
 	tree     plumbing.Hash       // Cached tree of the package itself, some descendent of commit.Tree()
 	commit   plumbing.Hash       // Current version of the package (commit sha)
 	tasks    []v1alpha1.Task
+	// renderStatus is the outcome of the last render of the package
+	// revision, or nil if it was never rendered.
+	renderStatus *repository.RenderStatus
+	// publishedBy and publishedAt are the author and the time of the
+	// commit which published the package revision.
+	publishedBy string
+	publishedAt time.Time
 }
 
 var _ repository.PackageRevision = &gitPackageRevision{}
@@ -73,7 +81,8 @@ func (p *gitPackageRevision) uid() types.UID {
 func (p *gitPackageRevision) GetPackageRevision() *v1alpha1.PackageRevision {
 	key := p.Key()
 
-	_, lock, _ := p.GetUpstreamLock()
+	kf, _ := p.kptfile()
+	_, lock := upstreamOf(kf)
 
 	lockCopy := &v1alpha1.UpstreamLock{}
 	// TODO: Use kpt definition of UpstreamLock in the package revision status
@@ -91,6 +100,9 @@ func (p *gitPackageRevision) GetPackageRevision() *v1alpha1.PackageRevision {
 		}
 	}
 
+	status := p.status(lockCopy)
+	status.Conditions = append(status.Conditions, repository.KptfileConditions(kf)...)
+
 	return &v1alpha1.PackageRevision{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "PackageRevision",
@@ -112,13 +124,35 @@ func (p *gitPackageRevision) GetPackageRevision() *v1alpha1.PackageRevision {
 
 			Lifecycle: p.Lifecycle(),
 			Tasks:     p.tasks,
+
+			ReadinessGates: repository.KptfileReadinessGates(kf),
 		},
-		Status: v1alpha1.PackageRevisionStatus{
-			UpstreamLock: lockCopy,
-		},
+		Status: status,
 	}
 }
 
+func (p *gitPackageRevision) status(lock *v1alpha1.UpstreamLock) v1alpha1.PackageRevisionStatus {
+	status := v1alpha1.PackageRevisionStatus{
+		UpstreamLock: lock,
+		Conditions:   repository.RenderConditions(p.renderStatus),
+	}
+	if p.renderStatus != nil {
+		status.RenderResults = p.renderStatus.Results
+		status.RepositoryFunctionResults = p.renderStatus.FunctionResults
+	}
+	if p.Lifecycle() == v1alpha1.PackageRevisionLifecyclePublished {
+		status.PublishedBy = p.publishedBy
+		status.PublishTimestamp = metav1.Time{Time: p.publishedAt}
+	}
+	return status
+}
+
+// setPublished records the commit which published the package revision.
+func (p *gitPackageRevision) setPublished(commit *object.Commit) {
+	p.publishedBy = commit.Author.Name
+	p.publishedAt = commit.Author.When
+}
+
 func (p *gitPackageRevision) GetResources(ctx context.Context) (*v1alpha1.PackageRevisionResources, error) {
 	resources := map[string]string{}
 
@@ -175,25 +209,39 @@ func (p *gitPackageRevision) GetResources(ctx context.Context) (*v1alpha1.Packag
 
 // GetUpstreamLock returns the upstreamLock info present in the Kptfile of the package.
 func (p *gitPackageRevision) GetUpstreamLock() (kptfile.Upstream, kptfile.UpstreamLock, error) {
+	kf, err := p.kptfile()
+	if err != nil {
+		return kptfile.Upstream{}, kptfile.UpstreamLock{}, fmt.Errorf("cannot determine package lock; %w", err)
+	}
+	upstream, lock := upstreamOf(kf)
+	return upstream, lock, nil
+}
+
+// kptfile returns the Kptfile of the package, or nil if the package has none.
+func (p *gitPackageRevision) kptfile() (*kptfile.KptFile, error) {
 	resources, err := p.GetResources(context.Background())
 	if err != nil {
-		return kptfile.Upstream{}, kptfile.UpstreamLock{}, fmt.Errorf("cannot determine package lock; cannot retrieve resources: %w", err)
+		return nil, fmt.Errorf("cannot retrieve resources: %w", err)
 	}
 
-	// get the upstream package URL from the Kptfile
-	var kf *kptfile.KptFile
-	if contents, found := resources.Spec.Resources[kptfile.KptFileName]; found {
-		kf, err = pkg.DecodeKptfile(strings.NewReader(contents))
-		if err != nil {
-			return kptfile.Upstream{}, kptfile.UpstreamLock{}, fmt.Errorf("cannot decode Kptfile: %w", err)
-		}
+	contents, found := resources.Spec.Resources[kptfile.KptFileName]
+	if !found {
+		return nil, nil
+	}
+	kf, err := pkg.DecodeKptfile(strings.NewReader(contents))
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode Kptfile: %w", err)
 	}
+	return kf, nil
+}
 
-	if kf.Upstream == nil || kf.UpstreamLock == nil || kf.Upstream.Git == nil {
+// upstreamOf returns the upstream package recorded in the Kptfile.
+func upstreamOf(kf *kptfile.KptFile) (kptfile.Upstream, kptfile.UpstreamLock) {
+	if kf == nil || kf.Upstream == nil || kf.UpstreamLock == nil || kf.Upstream.Git == nil {
 		// the package doesn't have any upstream package.
-		return kptfile.Upstream{}, kptfile.UpstreamLock{}, nil
+		return kptfile.Upstream{}, kptfile.UpstreamLock{}
 	}
-	return *kf.Upstream, *kf.UpstreamLock, nil
+	return *kf.Upstream, *kf.UpstreamLock
 }
 
 // GetLock returns the self version of the package. Think of it as the Git commit information
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"path"
 
+	"github.com/GoogleContainerTools/kpt/porch/api/porch/v1alpha1"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/filemode"
 	"github.com/go-git/go-git/v5/plumbing/object"
@@ -53,12 +54,12 @@ type packageListEntry struct {
 // TODO: Can packageListEntry just _be_ a gitPackageRevision?
 func (p *packageListEntry) buildGitPackageRevision(ctx context.Context, revision string, ref *plumbing.Reference) (*gitPackageRevision, error) {
 	repo := p.parent.parent
-	tasks, err := repo.loadTasks(ctx, p.parent.commit, p.path, revision)
+	tasks, renderStatus, err := repo.loadTasks(ctx, p.parent.commit, p.path, revision)
 	if err != nil {
 		return nil, err
 	}
 
-	return &gitPackageRevision{
+	rev := &gitPackageRevision{
 		repo:     repo,
 		path:     p.path,
 		revision: revision,
@@ -67,7 +68,59 @@ func (p *packageListEntry) buildGitPackageRevision(ctx context.Context, revision
 		tree:     p.treeHash,
 		commit:   p.parent.commit.Hash,
 		tasks:    tasks,
-	}, nil
+
+		renderStatus: renderStatus,
+	}
+	if rev.Lifecycle() == v1alpha1.PackageRevisionLifecyclePublished {
+		commit, err := p.lastChangeCommit()
+		if err != nil {
+			return nil, err
+		}
+		rev.setPublished(commit)
+	}
+	return rev, nil
+}
+
+// lastChangeCommit returns the commit which last approved or changed the
+// package, walking back from the commit at which the package was found. The
+// package on a branch is usually found at a later commit, which changed other
+// packages, so that commit doesn't tell who published the package.
+func (p *packageListEntry) lastChangeCommit() (*object.Commit, error) {
+	commit := p.parent.commit
+	for {
+		annotations, err := ExtractGitAnnotations(commit)
+		if err != nil {
+			return nil, err
+		}
+		for _, annotation := range annotations {
+			if annotation.PackagePath == p.path {
+				return commit, nil
+			}
+		}
+		if commit.NumParents() == 0 {
+			return commit, nil
+		}
+		parent, err := commit.Parent(0)
+		if err != nil {
+			return nil, fmt.Errorf("cannot resolve parent of commit %v: %w", commit.Hash, err)
+		}
+		parentTree, err := parent.Tree()
+		if err != nil {
+			return nil, fmt.Errorf("cannot resolve commit %v to tree (corrupted repository?): %w", parent.Hash, err)
+		}
+		if p.path != "" {
+			parentTree, err = parentTree.Tree(p.path)
+			if err == object.ErrDirectoryNotFound {
+				return commit, nil
+			} else if err != nil {
+				return nil, fmt.Errorf("error getting tree %s: %w", p.path, err)
+			}
+		}
+		if parentTree.Hash != p.treeHash {
+			return commit, nil
+		}
+		commit = parent
+	}
 }
 
 // DiscoveryPackagesOptions holds the configuration for walking a git tree
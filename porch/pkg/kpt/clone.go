@@ -46,6 +46,16 @@ func UpdateUpstream(kptfileContents string, name string, upstream kptfilev1.Upst
 	return string(b), nil
 }
 
+// GetUpstreamLock returns the upstream lock recorded in the Kptfile, or nil
+// if the Kptfile has none.
+func GetUpstreamLock(kptfileContents string) (*kptfilev1.UpstreamLock, error) {
+	kptfile, err := internalpkg.DecodeKptfile(strings.NewReader(kptfileContents))
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse Kptfile: %w", err)
+	}
+	return kptfile.UpstreamLock, nil
+}
+
 func UpdateName(kptfileContents string, name string) (string, error) {
 	kptfile, err := internalpkg.DecodeKptfile(strings.NewReader(kptfileContents))
 	if err != nil {
@@ -68,6 +68,37 @@ func (r *ociRepository) loadTasks(ctx context.Context, imageRef ImageDigestName)
 	return tasks, nil
 }
 
+// renderStatusAnnotation is the manifest annotation holding the outcome of
+// the last render of the package, encoded as json.
+const renderStatusAnnotation = "dev.kpt.package.renderstatus"
+
+func (r *ociRepository) loadRenderStatus(ctx context.Context, imageRef ImageDigestName) (*repository.RenderStatus, error) {
+	ctx, span := tracer.Start(ctx, "ociRepository::loadRenderStatus", trace.WithAttributes(
+		attribute.Stringer("image", imageRef),
+	))
+	defer span.End()
+
+	ociImage, err := r.storage.toRemoteImage(ctx, imageRef)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := r.storage.cachedManifest(ctx, ociImage)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching manifest for image: %w", err)
+	}
+
+	value, found := manifest.Annotations[renderStatusAnnotation]
+	if !found {
+		return nil, nil
+	}
+	status := &repository.RenderStatus{}
+	if err := json.Unmarshal([]byte(value), status); err != nil {
+		klog.Warningf("failed to unmarshal render status of %s: %v", imageRef, err)
+		return nil, nil
+	}
+	return status, nil
+}
+
 func (r *Storage) LookupImageTag(ctx context.Context, imageName ImageTagName) (*ImageDigestName, error) {
 	ctx, span := tracer.Start(ctx, "Storage::LookupImageTag", trace.WithAttributes(
 		attribute.Stringer("image", imageName),
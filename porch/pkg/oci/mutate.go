@@ -109,6 +109,8 @@ type ociPackageDraft struct {
 	parent *ociRepository
 
 	tasks []api.Task
+	// renderStatus is recorded in the manifest annotations of the image.
+	renderStatus *repository.RenderStatus
 
 	base      v1.Image
 	tag       name.Tag
@@ -187,6 +189,11 @@ func (p *ociPackageDraft) UpdateResources(ctx context.Context, new *api.PackageR
 	return nil
 }
 
+func (p *ociPackageDraft) UpdateRenderStatus(ctx context.Context, status *repository.RenderStatus) error {
+	p.renderStatus = status
+	return nil
+}
+
 func (p *ociPackageDraft) UpdateLifecycle(ctx context.Context, new api.PackageRevisionLifecycle) error {
 	return errors.New("OCI package lifecycle not implemented")
 }
@@ -206,6 +213,16 @@ func (p *ociPackageDraft) Close(ctx context.Context) (repository.PackageRevision
 		return nil, fmt.Errorf("failed to append image layers: %w", err)
 	}
 
+	if p.renderStatus != nil {
+		b, err := json.Marshal(p.renderStatus)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal render status: %w", err)
+		}
+		img = mutate.Annotations(img, map[string]string{
+			renderStatusAnnotation: string(b),
+		}).(v1.Image)
+	}
+
 	// TODO: We have a race condition here; there's no way to indicate that we want to create / not update an existing tag
 	if err := remote.Write(ref, img, option); err != nil {
 		return nil, fmt.Errorf("failed to push image %s: %w", ref, err)
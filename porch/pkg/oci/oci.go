@@ -19,6 +19,7 @@ import (
 	"crypto/sha1"
 	"encoding/hex"
 	"fmt"
+	"sync"
 	"time"
 
 	kptfile "github.com/GoogleContainerTools/kpt/pkg/api/kptfile/v1"
@@ -58,6 +59,19 @@ type ociRepository struct {
 	spec      configapi.OciRepository
 
 	storage *Storage
+
+	// loadedImagesMutex guards loadedImages.
+	loadedImagesMutex sync.Mutex
+	// loadedImages are the tasks and render status loaded from the images
+	// by the last listing. Images are immutable, so they are reused while
+	// the image is still tagged and only newly tagged images are loaded.
+	loadedImages map[ImageDigestName]loadedImage
+}
+
+// loadedImage are the package revision details loaded from an image.
+type loadedImage struct {
+	tasks        []v1alpha1.Task
+	renderStatus *repository.RenderStatus
 }
 
 var _ repository.Repository = &ociRepository{}
@@ -85,6 +99,11 @@ func (r *ociRepository) ListPackageRevisions(ctx context.Context, filter reposit
 
 	klog.Infof("tags: %#v", tags)
 
+	r.loadedImagesMutex.Lock()
+	previous := r.loadedImages
+	r.loadedImagesMutex.Unlock()
+	loaded := map[ImageDigestName]loadedImage{}
+
 	var result []repository.PackageRevision
 	for _, childName := range tags.Children {
 		path := fmt.Sprintf("%s/%s", r.spec.Registry, childName)
@@ -129,11 +148,16 @@ func (r *ociRepository) ListPackageRevisions(ctx context.Context, filter reposit
 				}
 				p.uid = constructUID(p.packageName + ":" + p.revision)
 
-				tasks, err := r.loadTasks(ctx, p.digestName)
-				if err != nil {
-					return nil, err
+				image, found := previous[p.digestName]
+				if !found {
+					image, err = r.loadImage(ctx, p.digestName)
+					if err != nil {
+						return nil, err
+					}
 				}
-				p.tasks = tasks
+				loaded[p.digestName] = image
+				p.tasks = image.tasks
+				p.renderStatus = image.renderStatus
 
 				if filter.Matches(p) {
 					result = append(result, p)
@@ -142,9 +166,27 @@ func (r *ociRepository) ListPackageRevisions(ctx context.Context, filter reposit
 		}
 	}
 
+	// Images which are no longer tagged are dropped.
+	r.loadedImagesMutex.Lock()
+	r.loadedImages = loaded
+	r.loadedImagesMutex.Unlock()
+
 	return result, nil
 }
 
+// loadImage loads the package revision details from the image.
+func (r *ociRepository) loadImage(ctx context.Context, name ImageDigestName) (loadedImage, error) {
+	tasks, err := r.loadTasks(ctx, name)
+	if err != nil {
+		return loadedImage{}, err
+	}
+	renderStatus, err := r.loadRenderStatus(ctx, name)
+	if err != nil {
+		return loadedImage{}, err
+	}
+	return loadedImage{tasks: tasks, renderStatus: renderStatus}, nil
+}
+
 func (r *ociRepository) buildPackageRevision(ctx context.Context, name ImageDigestName, packageName string, revision string, created time.Time) (repository.PackageRevision, error) {
 	if r.content != configapi.RepositoryContentPackage {
 		return nil, fmt.Errorf("repository is not a package repo, type is %v", r.content)
@@ -169,6 +211,12 @@ func (r *ociRepository) buildPackageRevision(ctx context.Context, name ImageDige
 	}
 	p.tasks = tasks
 
+	renderStatus, err := r.loadRenderStatus(ctx, p.digestName)
+	if err != nil {
+		return nil, err
+	}
+	p.renderStatus = renderStatus
+
 	return p, nil
 }
 
@@ -309,6 +357,9 @@ type ociPackageRevision struct {
 	parent *ociRepository
 
 	tasks []v1alpha1.Task
+	// renderStatus is the outcome of the last render of the package, or
+	// nil if it was never rendered.
+	renderStatus *repository.RenderStatus
 }
 
 var _ repository.PackageRevision = &ociPackageRevision{}
@@ -382,7 +433,21 @@ func (p *ociPackageRevision) GetPackageRevision() *v1alpha1.PackageRevision {
 
 			Tasks: p.tasks,
 		},
+		Status: p.status(),
+	}
+}
+
+func (p *ociPackageRevision) status() v1alpha1.PackageRevisionStatus {
+	// OCI packages are published when pushed.
+	status := v1alpha1.PackageRevisionStatus{
+		Conditions:       repository.RenderConditions(p.renderStatus),
+		PublishTimestamp: metav1.Time{Time: p.created},
+	}
+	if p.renderStatus != nil {
+		status.RenderResults = p.renderStatus.Results
+		status.RepositoryFunctionResults = p.renderStatus.FunctionResults
 	}
+	return status
 }
 
 func (p *ociPackageRevision) GetUpstreamLock() (kptfile.Upstream, kptfile.UpstreamLock, error) {
@@ -28,10 +28,14 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func RunBackground(ctx context.Context, coreClient client.WithWatch, cache *cache.Cache) {
+// RunBackground starts the background routine, which keeps the cache in sync
+// with the Repository objects and writes the Repository statuses. If
+// syncStatus is not nil, the routine also writes the sync results it queues.
+func RunBackground(ctx context.Context, coreClient client.WithWatch, cache *cache.Cache, syncStatus *RepoSyncStatusUpdater) {
 	b := background{
 		coreClient: coreClient,
 		cache:      cache,
+		syncStatus: syncStatus,
 	}
 	go b.run(ctx)
 }
@@ -40,6 +44,7 @@ func RunBackground(ctx context.Context, coreClient client.WithWatch, cache *cach
 type background struct {
 	coreClient client.WithWatch
 	cache      *cache.Cache
+	syncStatus *RepoSyncStatusUpdater
 }
 
 const (
@@ -68,6 +73,11 @@ func (b *background) run(ctx context.Context) {
 	ticker := time.NewTicker(1 * time.Minute)
 	defer ticker.Stop()
 
+	var syncUpdates <-chan struct{}
+	if b.syncStatus != nil {
+		syncUpdates = b.syncStatus.updates()
+	}
+
 loop:
 	for {
 		select {
@@ -115,6 +125,9 @@ loop:
 				klog.Errorf("Periodic repository refresh failed: %v", err)
 			}
 
+		case <-syncUpdates:
+			b.syncStatus.writePending(ctx)
+
 		case <-ctx.Done():
 			if ctx.Err() != nil {
 				klog.V(2).Infof("exiting background poller, because context is done: %v", ctx.Err())
@@ -162,6 +175,7 @@ func (b *background) runOnce(ctx context.Context) error {
 }
 
 func (b *background) cacheRepository(ctx context.Context, repo *configapi.Repository) error {
+	patch := client.MergeFrom(repo.DeepCopy())
 	var condition v1.Condition
 	if _, err := b.cache.OpenRepository(ctx, repo); err == nil {
 		condition = v1.Condition{
@@ -182,8 +196,10 @@ func (b *background) cacheRepository(ctx context.Context, repo *configapi.Reposi
 		}
 	}
 
+	// repo may be stale, so patch the conditions rather than updating the
+	// whole status.
 	meta.SetStatusCondition(&repo.Status.Conditions, condition)
-	if err := b.coreClient.Status().Update(ctx, repo); err != nil {
+	if err := b.coreClient.Status().Patch(ctx, repo, patch); err != nil {
 		return fmt.Errorf("error updating repository status: %w", err)
 	}
 	return nil
@@ -20,6 +20,7 @@ import (
 	"github.com/GoogleContainerTools/kpt/porch/pkg/repository"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/selection"
 )
 
@@ -51,6 +52,33 @@ type packageFilter struct {
 
 	// Repository restricts to repositories with the given name.
 	Repository string
+
+	// Labels filters by the labels of the package revisions; nil matches all.
+	Labels labels.Selector
+}
+
+// Matches returns true if the provided PackageRevision satisfies the conditions in the filter.
+func (f *packageFilter) Matches(p repository.PackageRevision) bool {
+	if f.Namespace != "" && f.Namespace != p.GetPackageRevision().Namespace {
+		return false
+	}
+	if f.Repository != "" && f.Repository != p.Key().Repository {
+		return false
+	}
+	if !f.matchesLabels(p) {
+		return false
+	}
+	return f.ListPackageRevisionFilter.Matches(p)
+}
+
+// matchesLabels returns true if the labels of the package revision match the
+// label selector of the filter. PackageRevisionResources are selected by the
+// labels of their package revisions.
+func (f *packageFilter) matchesLabels(p repository.PackageRevision) bool {
+	if f.Labels == nil || f.Labels.Empty() {
+		return true
+	}
+	return f.Labels.Matches(labels.Set(p.GetPackageRevision().Labels))
 }
 
 // parsePackageRevisionFieldSelector parses client-provided fields.Selector into a packageFilter
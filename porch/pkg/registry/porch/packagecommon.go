@@ -79,6 +79,9 @@ func (r *packageCommon) listPackages(ctx context.Context, filter packageFilter,
 			return err
 		}
 		for _, rev := range revisions {
+			if !filter.matchesLabels(rev) {
+				continue
+			}
 			if err := callback(rev); err != nil {
 				return err
 			}
@@ -132,6 +135,7 @@ func (r *packageCommon) getPackageRevision(ctx context.Context, name string, opt
 	}
 
 	obj := pkg.GetPackageRevision()
+	r.newUpstreamTracker().setCondition(ctx, obj)
 	return obj, nil
 }
 
@@ -159,7 +163,10 @@ func (r *packageCommon) updatePackageRevision(ctx context.Context, name string,
 
 	var oldRuntimeObj runtime.Object // We have to be runtime.Object (and not *api.PackageRevision) or else nil-checks fail (because a nil object is not a nil interface)
 	if !isCreate {
-		oldRuntimeObj = oldPackage.GetPackageRevision()
+		oldObj := oldPackage.GetPackageRevision()
+		// Readiness gates may depend on the status of the upstream.
+		r.newUpstreamTracker().setCondition(ctx, oldObj)
+		oldRuntimeObj = oldObj
 	}
 
 	newRuntimeObj, err := objInfo.UpdatedObject(ctx, oldRuntimeObj)
@@ -245,6 +252,7 @@ func (r *packageCommon) updatePackageRevision(ctx context.Context, name string,
 		}
 
 		updated := rev.GetPackageRevision()
+		r.newUpstreamTracker().setCondition(ctx, updated)
 
 		return updated, false, nil
 	} else {
@@ -255,6 +263,16 @@ func (r *packageCommon) updatePackageRevision(ctx context.Context, name string,
 		}
 
 		created := rev.GetPackageRevision()
+		r.newUpstreamTracker().setCondition(ctx, created)
 		return created, true, nil
 	}
 }
+
+// resourceVersion returns the resource version of the latest change to the
+// package revisions, which watches can be resumed from.
+func (r *packageCommon) resourceVersion() string {
+	if objectCache := r.cad.ObjectCache(); objectCache != nil {
+		return objectCache.ResourceVersion()
+	}
+	return ""
+}
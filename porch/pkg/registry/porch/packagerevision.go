@@ -27,9 +27,11 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apimachinery/pkg/watch"
 	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
 	"k8s.io/apiserver/pkg/registry/rest"
 	"k8s.io/klog/v2"
@@ -46,6 +48,7 @@ var _ rest.Storage = &packageRevisions{}
 var _ rest.Lister = &packageRevisions{}
 var _ rest.Getter = &packageRevisions{}
 var _ rest.Scoper = &packageRevisions{}
+var _ rest.Watcher = &packageRevisions{}
 var _ rest.Creater = &packageRevisions{}
 var _ rest.Updater = &packageRevisions{}
 var _ rest.GracefulDeleter = &packageRevisions{}
@@ -78,9 +81,16 @@ func (r *packageRevisions) List(ctx context.Context, options *metainternalversio
 	if err != nil {
 		return nil, err
 	}
+	filter.Labels = options.LabelSelector
 
+	// The resource version is read before listing, so watches from it don't
+	// miss changes made while listing.
+	result.ResourceVersion = r.packageCommon.resourceVersion()
+
+	upstreams := r.packageCommon.newUpstreamTracker()
 	if err := r.packageCommon.listPackages(ctx, filter, func(p repository.PackageRevision) error {
 		item := p.GetPackageRevision()
+		upstreams.setCondition(ctx, item)
 		result.Items = append(result.Items, *item)
 		return nil
 	}); err != nil {
@@ -90,6 +100,31 @@ func (r *packageRevisions) List(ctx context.Context, options *metainternalversio
 	return result, nil
 }
 
+// Watch implements the Watcher interface, starting a watch of the objects
+// matching the field selector in options.
+func (r *packageRevisions) Watch(ctx context.Context, options *metainternalversion.ListOptions) (watch.Interface, error) {
+	ctx, span := tracer.Start(ctx, "packageRevisions::Watch", trace.WithAttributes())
+	defer span.End()
+
+	var fieldSelector fields.Selector
+	if options != nil {
+		fieldSelector = options.FieldSelector
+	}
+	filter, err := parsePackageRevisionFieldSelector(fieldSelector)
+	if err != nil {
+		return nil, err
+	}
+	if options != nil {
+		filter.Labels = options.LabelSelector
+	}
+
+	return r.packageCommon.watchPackages(ctx, filter, options, func(ctx context.Context, eventType watch.EventType, p repository.PackageRevision, upstreams *upstreamTracker) (runtime.Object, error) {
+		obj := p.GetPackageRevision()
+		upstreams.setCondition(ctx, obj)
+		return obj, nil
+	})
+}
+
 // Get implements the Getter interface
 func (r *packageRevisions) Get(ctx context.Context, name string, options *metav1.GetOptions) (runtime.Object, error) {
 	ctx, span := tracer.Start(ctx, "packageRevisions::Get", trace.WithAttributes())
@@ -145,6 +180,7 @@ func (r *packageRevisions) Create(ctx context.Context, runtimeObject runtime.Obj
 	}
 
 	created := rev.GetPackageRevision()
+	r.packageCommon.newUpstreamTracker().setCondition(ctx, created)
 	return created, nil
 }
 
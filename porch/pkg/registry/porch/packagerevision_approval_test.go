@@ -15,6 +15,7 @@
 package porch
 
 import (
+	"context"
 	"testing"
 
 	api "github.com/GoogleContainerTools/kpt/porch/api/porch/v1alpha1"
@@ -64,3 +65,61 @@ func TestApprovalUpdateStrategy(t *testing.T) {
 		}
 	}
 }
+
+func TestApprovalReadinessGates(t *testing.T) {
+	s := packageRevisionApprovalStrategy{}
+	ctx := context.Background()
+
+	gates := []api.ReadinessGate{{ConditionType: "Reviewed"}, {ConditionType: "PolicyChecked"}}
+	for _, tc := range []struct {
+		name       string
+		conditions []api.Condition
+		valid      bool
+	}{
+		{
+			name:  "no conditions",
+			valid: false,
+		},
+		{
+			name: "some conditions true",
+			conditions: []api.Condition{
+				{Type: "Reviewed", Status: api.ConditionTrue},
+				{Type: "PolicyChecked", Status: api.ConditionUnknown},
+			},
+			valid: false,
+		},
+		{
+			name: "all conditions true",
+			conditions: []api.Condition{
+				{Type: "Reviewed", Status: api.ConditionTrue},
+				{Type: "PolicyChecked", Status: api.ConditionTrue},
+				{Type: "Other", Status: api.ConditionFalse},
+			},
+			valid: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			oldRev := &api.PackageRevision{
+				Spec: api.PackageRevisionSpec{
+					Lifecycle:      api.PackageRevisionLifecycleProposed,
+					ReadinessGates: gates,
+				},
+				Status: api.PackageRevisionStatus{
+					Conditions: tc.conditions,
+				},
+			}
+			for _, lifecycle := range []api.PackageRevisionLifecycle{api.PackageRevisionLifecyclePublished, api.PackageRevisionLifecycleDraft} {
+				newRev := oldRev.DeepCopy()
+				newRev.Spec.Lifecycle = lifecycle
+
+				allErrs := s.ValidateUpdate(ctx, newRev, oldRev)
+				// Rejecting the proposal back to draft is always allowed.
+				if valid := tc.valid || lifecycle == api.PackageRevisionLifecycleDraft; valid && len(allErrs) > 0 {
+					t.Errorf("Update to %s failed unexpectedly: %v", lifecycle, allErrs.ToAggregate().Error())
+				} else if !valid && len(allErrs) == 0 {
+					t.Errorf("Update to %s should fail but didn't", lifecycle)
+				}
+			}
+		})
+	}
+}
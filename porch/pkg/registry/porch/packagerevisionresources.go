@@ -25,8 +25,10 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
 	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
 	"k8s.io/apiserver/pkg/registry/rest"
 	"k8s.io/klog/v2"
@@ -42,6 +44,7 @@ var _ rest.Storage = &packageRevisionResources{}
 var _ rest.Lister = &packageRevisionResources{}
 var _ rest.Getter = &packageRevisionResources{}
 var _ rest.Scoper = &packageRevisionResources{}
+var _ rest.Watcher = &packageRevisionResources{}
 var _ rest.Updater = &packageRevisionResources{}
 
 func (r *packageRevisionResources) New() runtime.Object {
@@ -72,6 +75,11 @@ func (r *packageRevisionResources) List(ctx context.Context, options *metaintern
 	if err != nil {
 		return nil, err
 	}
+	filter.Labels = options.LabelSelector
+
+	// The resource version is read before listing, so watches from it don't
+	// miss changes made while listing.
+	result.ResourceVersion = r.packageCommon.resourceVersion()
 
 	if err := r.packageCommon.listPackages(ctx, filter, func(p repository.PackageRevision) error {
 		item, err := p.GetResources(ctx)
@@ -87,6 +95,42 @@ func (r *packageRevisionResources) List(ctx context.Context, options *metaintern
 	return result, nil
 }
 
+// Watch implements the Watcher interface, starting a watch of the objects
+// matching the field selector in options.
+func (r *packageRevisionResources) Watch(ctx context.Context, options *metainternalversion.ListOptions) (watch.Interface, error) {
+	ctx, span := tracer.Start(ctx, "packageRevisionResources::Watch", trace.WithAttributes())
+	defer span.End()
+
+	var fieldSelector fields.Selector
+	if options != nil {
+		fieldSelector = options.FieldSelector
+	}
+	filter, err := parsePackageRevisionResourcesFieldSelector(fieldSelector)
+	if err != nil {
+		return nil, err
+	}
+	if options != nil {
+		filter.Labels = options.LabelSelector
+	}
+
+	return r.packageCommon.watchPackages(ctx, filter, options, func(ctx context.Context, eventType watch.EventType, p repository.PackageRevision, _ *upstreamTracker) (runtime.Object, error) {
+		obj, err := p.GetResources(ctx)
+		if err != nil && eventType == watch.Deleted {
+			// The resources of deleted package revisions may be gone, but
+			// watchers only need to know which object was deleted.
+			rev := p.GetPackageRevision()
+			return &api.PackageRevisionResources{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "PackageRevisionResources",
+					APIVersion: api.SchemeGroupVersion.Identifier(),
+				},
+				ObjectMeta: rev.ObjectMeta,
+			}, nil
+		}
+		return obj, err
+	})
+}
+
 // Get implements the Getter interface
 func (r *packageRevisionResources) Get(ctx context.Context, name string, options *metav1.GetOptions) (runtime.Object, error) {
 	ctx, span := tracer.Start(ctx, "packageRevisionResources::Get", trace.WithAttributes())
@@ -75,9 +75,15 @@ func (s packageRevisionApprovalStrategy) ValidateUpdate(ctx context.Context, obj
 
 	switch lifecycle := newRevision.Spec.Lifecycle; lifecycle {
 	// TODO: signal rejection of the approval differently than by returning to draft?
-	case api.PackageRevisionLifecycleDraft, api.PackageRevisionLifecyclePublished:
+	case api.PackageRevisionLifecycleDraft:
 		// valid
 
+	case api.PackageRevisionLifecyclePublished:
+		if notReady := unmetReadinessGates(oldRevision); len(notReady) > 0 {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "readinessGates"), notReady,
+				fmt.Sprintf("cannot approve package until all readiness conditions are true; not ready: %s", strings.Join(notReady, ","))))
+		}
+
 	default:
 		allErrs = append(allErrs,
 			field.Invalid(field.NewPath("spec", "lifecycle"), lifecycle, fmt.Sprintf("value for approval can be only one of %s",
@@ -90,6 +96,23 @@ func (s packageRevisionApprovalStrategy) ValidateUpdate(ctx context.Context, obj
 	return allErrs
 }
 
+// unmetReadinessGates returns the condition types of the readiness gates of
+// the package revision whose conditions are not true.
+func unmetReadinessGates(obj *api.PackageRevision) []string {
+	ready := map[string]bool{}
+	for _, condition := range obj.Status.Conditions {
+		ready[condition.Type] = condition.Status == api.ConditionTrue
+	}
+
+	var notReady []string
+	for _, gate := range obj.Spec.ReadinessGates {
+		if !ready[gate.ConditionType] {
+			notReady = append(notReady, gate.ConditionType)
+		}
+	}
+	return notReady
+}
+
 func (s packageRevisionApprovalStrategy) Canonicalize(obj runtime.Object) {}
 
 var _ SimpleRESTCreateStrategy = packageRevisionApprovalStrategy{}
@@ -0,0 +1,108 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package porch
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	configapi "github.com/GoogleContainerTools/kpt/porch/api/porchconfig/v1alpha1"
+	"github.com/GoogleContainerTools/kpt/porch/pkg/cache"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// NewRepoSyncStatusUpdater returns a notifier which records the outcome of
+// each repository synchronization in the status of the Repository.
+// Notifications only queue the outcome; the status is written by the
+// background routine (see RunBackground).
+func NewRepoSyncStatusUpdater(coreClient client.Client) *RepoSyncStatusUpdater {
+	return &RepoSyncStatusUpdater{
+		coreClient: coreClient,
+		pending:    map[client.ObjectKey]syncResult{},
+		signal:     make(chan struct{}, 1),
+	}
+}
+
+// RepoSyncStatusUpdater records lastSyncTime and lastSyncError of Repositories.
+type RepoSyncStatusUpdater struct {
+	coreClient client.Client
+
+	mutex sync.Mutex
+	// pending holds the latest unwritten sync result of each repository.
+	pending map[client.ObjectKey]syncResult
+	// signal is sent to when pending becomes non-empty.
+	signal chan struct{}
+}
+
+type syncResult struct {
+	time time.Time
+	err  error
+}
+
+var _ cache.RepoSyncNotifier = &RepoSyncStatusUpdater{}
+
+// NotifyRepoSynced queues the sync result for the background routine. It
+// doesn't block, and doesn't use ctx, which may be the context of the user
+// request that triggered the synchronization.
+func (u *RepoSyncStatusUpdater) NotifyRepoSynced(ctx context.Context, repositorySpec *configapi.Repository, syncTime time.Time, syncErr error) {
+	u.mutex.Lock()
+	u.pending[client.ObjectKeyFromObject(repositorySpec)] = syncResult{time: syncTime, err: syncErr}
+	u.mutex.Unlock()
+
+	select {
+	case u.signal <- struct{}{}:
+	default:
+	}
+}
+
+// updates returns the channel that is sent to when there are sync results to write.
+func (u *RepoSyncStatusUpdater) updates() <-chan struct{} {
+	return u.signal
+}
+
+// writePending writes the queued sync results to the Repository statuses.
+func (u *RepoSyncStatusUpdater) writePending(ctx context.Context) {
+	u.mutex.Lock()
+	pending := u.pending
+	u.pending = map[client.ObjectKey]syncResult{}
+	u.mutex.Unlock()
+
+	for key, result := range pending {
+		if err := u.writeSyncStatus(ctx, key, result); err != nil && !apierrors.IsNotFound(err) {
+			klog.Warningf("Failed to update sync status of repository %s: %v", key, err)
+		}
+	}
+}
+
+// writeSyncStatus patches only lastSyncTime and lastSyncError so that it
+// doesn't overwrite status written concurrently by others.
+func (u *RepoSyncStatusUpdater) writeSyncStatus(ctx context.Context, key client.ObjectKey, result syncResult) error {
+	var repo configapi.Repository
+	if err := u.coreClient.Get(ctx, key, &repo); err != nil {
+		return err
+	}
+	patch := client.MergeFrom(repo.DeepCopy())
+	lastSyncTime := v1.NewTime(result.time)
+	repo.Status.LastSyncTime = &lastSyncTime
+	repo.Status.LastSyncError = ""
+	if result.err != nil {
+		repo.Status.LastSyncError = result.err.Error()
+	}
+	return u.coreClient.Status().Patch(ctx, &repo, patch)
+}
@@ -0,0 +1,75 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package porch
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	configapi "github.com/GoogleContainerTools/kpt/porch/api/porchconfig/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRepoSyncStatusUpdater(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := configapi.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build scheme: %v", err)
+	}
+	repo := &configapi.Repository{
+		ObjectMeta: v1.ObjectMeta{Namespace: "default", Name: "blueprints"},
+		Status: configapi.RepositoryStatus{
+			Conditions: []v1.Condition{{
+				Type:   configapi.RepositoryReady,
+				Status: v1.ConditionTrue,
+				Reason: configapi.ReasonReady,
+			}},
+		},
+	}
+	coreClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(repo).Build()
+	updater := NewRepoSyncStatusUpdater(coreClient)
+
+	// The request context is done by the time the status is written.
+	ctx, cancel := context.WithCancel(context.Background())
+	first := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	updater.NotifyRepoSynced(ctx, repo, first, nil)
+	updater.NotifyRepoSynced(ctx, repo, first.Add(time.Minute), errors.New("authentication required"))
+	cancel()
+
+	select {
+	case <-updater.updates():
+	default:
+		t.Fatalf("NotifyRepoSynced didn't signal pending updates")
+	}
+	updater.writePending(context.Background())
+
+	var got configapi.Repository
+	if err := coreClient.Get(context.Background(), client.ObjectKeyFromObject(repo), &got); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.Status.LastSyncTime == nil || !got.Status.LastSyncTime.Time.Equal(first.Add(time.Minute)) {
+		t.Errorf("LastSyncTime = %v, want %v", got.Status.LastSyncTime, first.Add(time.Minute))
+	}
+	if got, want := got.Status.LastSyncError, "authentication required"; got != want {
+		t.Errorf("LastSyncError = %q, want %q", got, want)
+	}
+	if len(got.Status.Conditions) != 1 || got.Status.Conditions[0].Type != configapi.RepositoryReady {
+		t.Errorf("Conditions = %v, want the Ready condition to be preserved", got.Status.Conditions)
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package porch
+
+import (
+	"context"
+	"fmt"
+
+	api "github.com/GoogleContainerTools/kpt/porch/api/porch/v1alpha1"
+	"github.com/GoogleContainerTools/kpt/porch/pkg/repository"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+)
+
+// Reasons of the UpToDateWithUpstream condition:
+const (
+	reasonUpToDate              = "UpToDate"
+	reasonUpstreamUpdated       = "UpstreamUpdated"
+	reasonUpstreamNotRegistered = "UpstreamNotRegistered"
+	reasonUpstreamUnknown       = "UpstreamUnknown"
+)
+
+// upstreamTracker sets the UpToDateWithUpstream condition of package
+// revisions, comparing the upstream package revision they were cloned from
+// with the latest revision of the upstream package. The latest revisions are
+// memoized, so a tracker should only be used for a single request, or for a
+// watch which forgets the latest revision of the packages that change.
+type upstreamTracker struct {
+	common *packageCommon
+	// latest holds the latest revision of the package by the name of its
+	// package revisions.
+	latest map[string]repository.PackageRevision
+}
+
+func (r *packageCommon) newUpstreamTracker() *upstreamTracker {
+	return &upstreamTracker{
+		common: r,
+		latest: map[string]repository.PackageRevision{},
+	}
+}
+
+// setCondition adds the UpToDateWithUpstream condition to the package
+// revision, unless the package revision wasn't cloned.
+func (t *upstreamTracker) setCondition(ctx context.Context, obj *api.PackageRevision) {
+	var clone *api.PackageCloneTaskSpec
+	for i := range obj.Spec.Tasks {
+		if task := &obj.Spec.Tasks[i]; task.Type == api.TaskTypeClone && task.Clone != nil {
+			clone = task.Clone
+		}
+	}
+	if clone == nil {
+		return
+	}
+
+	condition := api.Condition{
+		Type:   api.ConditionTypeUpToDateWithUpstream,
+		Status: api.ConditionUnknown,
+	}
+	if ref := clone.Upstream.UpstreamRef; ref == nil {
+		condition.Reason = reasonUpstreamNotRegistered
+		condition.Message = "upstream is not a registered package"
+	} else if latest, err := t.latestRevision(ctx, obj.Namespace, ref.Name); err != nil {
+		condition.Reason = reasonUpstreamUnknown
+		condition.Message = err.Error()
+	} else if latest.KubeObjectName() == ref.Name {
+		condition.Status = api.ConditionTrue
+		condition.Reason = reasonUpToDate
+	} else {
+		condition.Status = api.ConditionFalse
+		condition.Reason = reasonUpstreamUpdated
+		condition.Message = fmt.Sprintf("revision %s of the upstream package is available (%s)", latest.Key().Revision, latest.KubeObjectName())
+	}
+	obj.Status.Conditions = append(obj.Status.Conditions, condition)
+}
+
+// forget drops the memoized latest revision of the package of the package
+// revision.
+func (t *upstreamTracker) forget(p repository.PackageRevision) {
+	key := p.Key()
+	for name, latest := range t.latest {
+		if k := latest.Key(); k.Repository == key.Repository && k.Package == key.Package {
+			delete(t.latest, name)
+		}
+	}
+}
+
+// latestRevision returns the latest revision of the package of the named
+// package revision.
+func (t *upstreamTracker) latestRevision(ctx context.Context, namespace, name string) (repository.PackageRevision, error) {
+	if latest, found := t.latest[name]; found {
+		return latest, nil
+	}
+
+	ctx = genericapirequest.WithNamespace(ctx, namespace)
+	upstream, err := t.common.getPackage(ctx, name)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("upstream package revision %s not found", name)
+		}
+		return nil, fmt.Errorf("cannot get upstream package revision %s: %w", name, err)
+	}
+
+	key := upstream.Key()
+	filter := packageFilter{
+		ListPackageRevisionFilter: repository.ListPackageRevisionFilter{Package: key.Package},
+		Repository:                key.Repository,
+	}
+	var latest repository.PackageRevision
+	if err := t.common.listPackages(ctx, filter, func(p repository.PackageRevision) error {
+		if p.GetPackageRevision().Labels[api.LatestPackageRevisionKey] == api.LatestPackageRevisionValue {
+			latest = p
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("cannot list revisions of upstream package %s: %w", key.Package, err)
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("upstream package %s has no published revisions", key.Package)
+	}
+
+	t.latest[name] = latest
+	return latest, nil
+}
@@ -0,0 +1,223 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package porch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/GoogleContainerTools/kpt/porch/pkg/cache"
+	"github.com/GoogleContainerTools/kpt/porch/pkg/repository"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/klog/v2"
+)
+
+// convertFunc converts a package revision to the object sent to watchers.
+// The upstream tracker is shared by all the events of the watch.
+type convertFunc func(ctx context.Context, eventType watch.EventType, p repository.PackageRevision, upstreams *upstreamTracker) (runtime.Object, error)
+
+// maxPendingEvents is the number of events queued for a watcher before the
+// watch is ended; the client has to relist.
+const maxPendingEvents = 1000
+
+// watchPackages starts a watch of the package revisions matching filter.
+// Unless the watch resumes from a resource version, the matching package
+// revisions are sent first as Added events.
+func (r *packageCommon) watchPackages(ctx context.Context, filter packageFilter, options *metainternalversion.ListOptions, convert convertFunc) (watch.Interface, error) {
+	if ns, namespaced := genericapirequest.NamespaceFrom(ctx); namespaced {
+		if filter.Namespace != "" && ns != filter.Namespace {
+			return nil, apierrors.NewBadRequest(fmt.Sprintf("conflicting namespaces specified: %q and %q", ns, filter.Namespace))
+		}
+		filter.Namespace = ns
+	}
+
+	objectCache := r.cad.ObjectCache()
+	if objectCache == nil {
+		return nil, apierrors.NewMethodNotSupported(r.gr, "watch")
+	}
+
+	resourceVersion := ""
+	initialEvents := true
+	if options != nil && options.ResourceVersion != "" && options.ResourceVersion != "0" {
+		resourceVersion = options.ResourceVersion
+		initialEvents = false
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	w := &packageWatcher{
+		cancel:     cancel,
+		resultChan: make(chan watch.Event),
+		wake:       make(chan struct{}, 1),
+	}
+
+	// The watcher is registered before listing so no change is missed
+	// between the list and the watch.
+	if err := objectCache.WatchPackageRevisions(ctx, resourceVersion, w.enqueue); err != nil {
+		cancel()
+		if errors.Is(err, cache.ErrResourceVersionExpired) {
+			return nil, apierrors.NewResourceExpired(err.Error())
+		}
+		return nil, err
+	}
+
+	var initial []repository.PackageRevision
+	if initialEvents {
+		if err := r.listPackages(ctx, filter, func(p repository.PackageRevision) error {
+			initial = append(initial, p)
+			return nil
+		}); err != nil {
+			cancel()
+			return nil, err
+		}
+	}
+
+	go w.run(ctx, filter, initial, convert, r.newUpstreamTracker())
+	return w, nil
+}
+
+// packageWatcher implements watch.Interface for package revisions. The
+// changes from the cache are queued, because the cache must not be blocked
+// by slow clients, and sent from a separate goroutine.
+type packageWatcher struct {
+	cancel     context.CancelFunc
+	resultChan chan watch.Event
+
+	mutex   sync.Mutex
+	pending []pendingEvent
+	// overflowed is set when the client fell too far behind; the pending
+	// events are dropped and the watch ends.
+	overflowed bool
+	// wake is signalled when an event is queued.
+	wake chan struct{}
+}
+
+type pendingEvent struct {
+	eventType watch.EventType
+	revision  repository.PackageRevision
+}
+
+var _ watch.Interface = &packageWatcher{}
+
+// Stop implements watch.Interface
+func (w *packageWatcher) Stop() {
+	w.cancel()
+}
+
+// ResultChan implements watch.Interface
+func (w *packageWatcher) ResultChan() <-chan watch.Event {
+	return w.resultChan
+}
+
+// enqueue is the cache.PackageRevisionWatcher of the watch.
+// The objects keep the resource versions of the package revisions, which the
+// cache accepts to resume watches from.
+func (w *packageWatcher) enqueue(eventType watch.EventType, rev repository.PackageRevision, _ string) bool {
+	w.mutex.Lock()
+	if len(w.pending) >= maxPendingEvents {
+		w.pending = nil
+		w.overflowed = true
+	} else {
+		w.pending = append(w.pending, pendingEvent{eventType: eventType, revision: rev})
+	}
+	overflowed := w.overflowed
+	w.mutex.Unlock()
+
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+	return !overflowed
+}
+
+// dequeue returns the pending events, and whether the queue overflowed.
+func (w *packageWatcher) dequeue() ([]pendingEvent, bool) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	events := w.pending
+	w.pending = nil
+	return events, w.overflowed
+}
+
+func (w *packageWatcher) run(ctx context.Context, filter packageFilter, initial []repository.PackageRevision, convert convertFunc, upstreams *upstreamTracker) {
+	defer close(w.resultChan)
+	defer w.cancel()
+
+	for _, rev := range initial {
+		if !w.send(ctx, filter, convert, upstreams, pendingEvent{eventType: watch.Added, revision: rev}) {
+			return
+		}
+	}
+
+	for {
+		events, overflowed := w.dequeue()
+		if overflowed {
+			// Like the watch cache of the apiserver, make the client relist.
+			status := apierrors.NewResourceExpired("too old resource version: the watch fell too far behind").ErrStatus
+			select {
+			case w.resultChan <- watch.Event{Type: watch.Error, Object: &status}:
+			case <-ctx.Done():
+			}
+			return
+		}
+		if len(events) == 0 {
+			select {
+			case <-w.wake:
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+		for _, e := range events {
+			if !w.send(ctx, filter, convert, upstreams, e) {
+				return
+			}
+		}
+	}
+}
+
+// send sends the event if the package revision matches the filter, and
+// returns false if the watch is over.
+func (w *packageWatcher) send(ctx context.Context, filter packageFilter, convert convertFunc, upstreams *upstreamTracker, e pendingEvent) bool {
+	// Any change to a package may change its latest revision.
+	upstreams.forget(e.revision)
+	if !filter.Matches(e.revision) {
+		return true
+	}
+
+	event := watch.Event{Type: e.eventType}
+	obj, err := convert(ctx, e.eventType, e.revision, upstreams)
+	if err != nil {
+		klog.Warningf("watch failed to convert package revision %s: %v", e.revision.KubeObjectName(), err)
+		event = watch.Event{
+			Type:   watch.Error,
+			Object: &apierrors.NewInternalError(err).ErrStatus,
+		}
+	} else {
+		event.Object = obj
+	}
+
+	select {
+	case w.resultChan <- event:
+		return event.Type != watch.Error
+	case <-ctx.Done():
+		return false
+	}
+}
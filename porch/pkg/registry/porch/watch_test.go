@@ -0,0 +1,109 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package porch
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	api "github.com/GoogleContainerTools/kpt/porch/api/porch/v1alpha1"
+	"github.com/GoogleContainerTools/kpt/porch/pkg/engine/fake"
+	"github.com/GoogleContainerTools/kpt/porch/pkg/repository"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+func newTestWatcher() *packageWatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &packageWatcher{
+		cancel:     cancel,
+		resultChan: make(chan watch.Event),
+		wake:       make(chan struct{}, 1),
+	}
+	convert := func(ctx context.Context, eventType watch.EventType, p repository.PackageRevision, _ *upstreamTracker) (runtime.Object, error) {
+		return p.GetPackageRevision(), nil
+	}
+	go w.run(ctx, packageFilter{Labels: labels.SelectorFromSet(labels.Set{"team": "a"})}, nil, convert, (&packageCommon{}).newUpstreamTracker())
+	return w
+}
+
+func testRevision(name, team string) *fake.PackageRevision {
+	return &fake.PackageRevision{
+		Name:               name,
+		PackageRevisionKey: repository.PackageRevisionKey{Repository: "blueprints", Package: name, Revision: "v1"},
+		PackageRevision: &api.PackageRevision{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"team": team}},
+		},
+	}
+}
+
+func nextEvent(t *testing.T, w *packageWatcher) (watch.Event, bool) {
+	select {
+	case event, ok := <-w.ResultChan():
+		return event, ok
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for watch event")
+		return watch.Event{}, false
+	}
+}
+
+func TestPackageWatcherLabelSelector(t *testing.T) {
+	w := newTestWatcher()
+	defer w.Stop()
+
+	w.enqueue(watch.Added, testRevision("team-b", "b"), "")
+	w.enqueue(watch.Added, testRevision("team-a", "a"), "")
+
+	event, ok := nextEvent(t, w)
+	if !ok {
+		t.Fatalf("watch ended unexpectedly")
+	}
+	if pr, isPR := event.Object.(*api.PackageRevision); event.Type != watch.Added || !isPR || pr.Name != "team-a" {
+		t.Errorf("got %s event for %v, want Added event for team-a", event.Type, event.Object)
+	}
+}
+
+func TestPackageWatcherOverflow(t *testing.T) {
+	w := newTestWatcher()
+	defer w.Stop()
+
+	// Hold the queue so the events pile up as if the client was slow.
+	w.mutex.Lock()
+	w.pending = make([]pendingEvent, maxPendingEvents)
+	for i := range w.pending {
+		w.pending[i] = pendingEvent{eventType: watch.Modified, revision: testRevision("team-b", "b")}
+	}
+	w.mutex.Unlock()
+
+	if w.enqueue(watch.Modified, testRevision("team-a", "a"), "") {
+		t.Errorf("enqueue returned true after the queue overflowed, want false")
+	}
+
+	event, ok := nextEvent(t, w)
+	if !ok {
+		t.Fatalf("watch ended without an error event")
+	}
+	status, isStatus := event.Object.(*metav1.Status)
+	if event.Type != watch.Error || !isStatus || status.Code != http.StatusGone {
+		t.Fatalf("got %s event %v, want Error event with status %d", event.Type, event.Object, http.StatusGone)
+	}
+	if _, ok := nextEvent(t, w); ok {
+		t.Errorf("watch continued after the queue overflowed")
+	}
+}
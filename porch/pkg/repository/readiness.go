@@ -0,0 +1,69 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	kptfile "github.com/GoogleContainerTools/kpt/pkg/api/kptfile/v1"
+	"github.com/GoogleContainerTools/kpt/porch/api/porch/v1alpha1"
+)
+
+// derivedConditionTypes are the types of the conditions which porch derives
+// from the package revision; they are never stored in the Kptfile.
+var derivedConditionTypes = map[string]bool{
+	v1alpha1.ConditionTypeRendered:             true,
+	v1alpha1.ConditionTypeValidated:            true,
+	v1alpha1.ConditionTypeUpToDateWithUpstream: true,
+}
+
+// StoredConditions returns the conditions which are stored in the Kptfile
+// of the package, i.e. all but the conditions derived by porch.
+func StoredConditions(conditions []v1alpha1.Condition) []v1alpha1.Condition {
+	var stored []v1alpha1.Condition
+	for _, condition := range conditions {
+		if !derivedConditionTypes[condition.Type] {
+			stored = append(stored, condition)
+		}
+	}
+	return stored
+}
+
+// KptfileReadinessGates returns the readiness gates declared in the Kptfile.
+func KptfileReadinessGates(kf *kptfile.KptFile) []v1alpha1.ReadinessGate {
+	if kf == nil || kf.Info == nil {
+		return nil
+	}
+	var gates []v1alpha1.ReadinessGate
+	for _, gate := range kf.Info.ReadinessGates {
+		gates = append(gates, v1alpha1.ReadinessGate{ConditionType: gate.ConditionType})
+	}
+	return gates
+}
+
+// KptfileConditions returns the conditions stored in the Kptfile.
+func KptfileConditions(kf *kptfile.KptFile) []v1alpha1.Condition {
+	if kf == nil || kf.Status == nil {
+		return nil
+	}
+	var conditions []v1alpha1.Condition
+	for _, condition := range kf.Status.Conditions {
+		conditions = append(conditions, v1alpha1.Condition{
+			Type:    condition.Type,
+			Status:  v1alpha1.ConditionStatus(condition.Status),
+			Reason:  condition.Reason,
+			Message: condition.Message,
+		})
+	}
+	return StoredConditions(conditions)
+}
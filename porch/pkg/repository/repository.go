@@ -67,6 +67,9 @@ type PackageRevision interface {
 
 type PackageDraft interface {
 	UpdateResources(ctx context.Context, new *v1alpha1.PackageRevisionResources, task *v1alpha1.Task) error
+	// Records the outcome of rendering the package. The status is stored
+	// with the next update of the resources.
+	UpdateRenderStatus(ctx context.Context, status *RenderStatus) error
 	// Updates desired lifecycle of the package. The lifecycle is applied on Close.
 	UpdateLifecycle(ctx context.Context, new v1alpha1.PackageRevisionLifecycle) error
 	// Finish round of updates.
@@ -0,0 +1,110 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/GoogleContainerTools/kpt/porch/api/porch/v1alpha1"
+)
+
+// RenderStatus is the outcome of the last render of a package revision. It
+// is stored with the package revision, so the status of the package revision
+// can be reconstructed from the repository.
+type RenderStatus struct {
+	// Results are the results of the functions of the package pipeline,
+	// or nil if the package has no pipeline to render.
+	Results *v1alpha1.ResultList `json:"results,omitempty"`
+	// FunctionResults are the results of the mutators and validators of
+	// the repository.
+	FunctionResults []v1alpha1.FunctionResult `json:"functionResults,omitempty"`
+}
+
+// Reasons of the conditions derived from the render status:
+const (
+	ReasonNotRendered      = "NotRendered"
+	ReasonRenderSucceeded  = "RenderSucceeded"
+	ReasonRenderFailed     = "RenderFailed"
+	ReasonValidationPassed = "ValidationPassed"
+	ReasonValidationFailed = "ValidationFailed"
+)
+
+// RenderConditions returns the Rendered and Validated conditions of a package
+// revision with the render status. The status is nil if the package revision
+// was never rendered.
+func RenderConditions(status *RenderStatus) []v1alpha1.Condition {
+	if status == nil {
+		return []v1alpha1.Condition{
+			{Type: v1alpha1.ConditionTypeRendered, Status: v1alpha1.ConditionUnknown, Reason: ReasonNotRendered},
+			{Type: v1alpha1.ConditionTypeValidated, Status: v1alpha1.ConditionUnknown, Reason: ReasonNotRendered},
+		}
+	}
+	return []v1alpha1.Condition{renderedCondition(status.Results), validatedCondition(status.FunctionResults)}
+}
+
+func renderedCondition(results *v1alpha1.ResultList) v1alpha1.Condition {
+	condition := v1alpha1.Condition{Type: v1alpha1.ConditionTypeRendered}
+	if results == nil {
+		condition.Status = v1alpha1.ConditionTrue
+		condition.Reason = ReasonRenderSucceeded
+		condition.Message = "package has no pipeline"
+		return condition
+	}
+
+	var errors []string
+	for _, result := range results.Items {
+		for _, item := range result.Results {
+			if item.Severity == "error" {
+				errors = append(errors, fmt.Sprintf("%s: %s", result.Image, item.Message))
+			}
+		}
+		if result.ExitCode != 0 && len(result.Results) == 0 {
+			errors = append(errors, fmt.Sprintf("%s: exit code %d", result.Image, result.ExitCode))
+		}
+	}
+	if results.ExitCode != 0 || len(errors) > 0 {
+		condition.Status = v1alpha1.ConditionFalse
+		condition.Reason = ReasonRenderFailed
+		condition.Message = strings.Join(errors, "; ")
+		return condition
+	}
+
+	condition.Status = v1alpha1.ConditionTrue
+	condition.Reason = ReasonRenderSucceeded
+	condition.Message = fmt.Sprintf("%d function(s) executed", len(results.Items))
+	return condition
+}
+
+func validatedCondition(results []v1alpha1.FunctionResult) v1alpha1.Condition {
+	condition := v1alpha1.Condition{Type: v1alpha1.ConditionTypeValidated}
+
+	var failed []string
+	for _, result := range results {
+		if result.FunctionType == v1alpha1.FunctionTypeValidator && !result.Passed {
+			failed = append(failed, fmt.Sprintf("%s: %s", result.Image, result.Message))
+		}
+	}
+	if len(failed) > 0 {
+		condition.Status = v1alpha1.ConditionFalse
+		condition.Reason = ReasonValidationFailed
+		condition.Message = strings.Join(failed, "; ")
+		return condition
+	}
+
+	condition.Status = v1alpha1.ConditionTrue
+	condition.Reason = ReasonValidationPassed
+	return condition
+}
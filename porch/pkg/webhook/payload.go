@@ -0,0 +1,254 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// pushNotification identifies the repositories to refresh.
+type pushNotification struct {
+	// urls are the addresses of the repository which changed.
+	urls []string
+	// namespace and name identify the Repository resource to refresh, if the
+	// notification targets the resource rather than the repository address.
+	namespace string
+	name      string
+}
+
+// payloadFormat knows how to parse and authenticate notifications sent by one
+// Git hosting service.
+type payloadFormat interface {
+	// name of the format, for logging.
+	name() string
+	// matches returns true if the request was sent in this format.
+	matches(r *http.Request) bool
+	// authenticate checks that the request was signed with the secret.
+	authenticate(r *http.Request, body []byte, secret []byte) bool
+	// parse returns the push notification, or nil if the event isn't a push.
+	parse(r *http.Request, body []byte) (*pushNotification, error)
+}
+
+// formats are the supported payload formats, in the order they are detected.
+// Gitea is detected before GitHub because it sends GitHub headers too.
+var formats = []payloadFormat{
+	giteaFormat{},
+	githubFormat{},
+	gitlabFormat{},
+	genericFormat{},
+}
+
+func detectFormat(r *http.Request) payloadFormat {
+	for _, f := range formats {
+		if f.matches(r) {
+			return f
+		}
+	}
+	return genericFormat{}
+}
+
+// hostedRepository is the repository section common to the GitHub and Gitea payloads.
+type hostedRepository struct {
+	CloneURL string `json:"clone_url"`
+	SSHURL   string `json:"ssh_url"`
+	HTMLURL  string `json:"html_url"`
+}
+
+type hostedPayload struct {
+	Repository hostedRepository `json:"repository"`
+}
+
+func parseHostedPayload(body []byte) (*pushNotification, error) {
+	var payload hostedPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("cannot parse push payload: %w", err)
+	}
+	repo := payload.Repository
+	return &pushNotification{
+		urls: nonEmpty(repo.CloneURL, repo.SSHURL, repo.HTMLURL),
+	}, nil
+}
+
+// githubFormat parses GitHub push events.
+// https://docs.github.com/en/developers/webhooks-and-events/webhooks/webhook-events-and-payloads#push
+type githubFormat struct{}
+
+func (githubFormat) name() string { return "github" }
+
+func (githubFormat) matches(r *http.Request) bool {
+	return r.Header.Get("X-GitHub-Event") != ""
+}
+
+func (githubFormat) authenticate(r *http.Request, body []byte, secret []byte) bool {
+	signature := r.Header.Get("X-Hub-Signature-256")
+	if !strings.HasPrefix(signature, "sha256=") {
+		return false
+	}
+	return validHMAC(body, secret, strings.TrimPrefix(signature, "sha256="))
+}
+
+func (githubFormat) parse(r *http.Request, body []byte) (*pushNotification, error) {
+	if r.Header.Get("X-GitHub-Event") != "push" {
+		return nil, nil
+	}
+	return parseHostedPayload(body)
+}
+
+// giteaFormat parses Gitea push events.
+// https://docs.gitea.io/en-us/webhooks/
+type giteaFormat struct{}
+
+func (giteaFormat) name() string { return "gitea" }
+
+func (giteaFormat) matches(r *http.Request) bool {
+	return r.Header.Get("X-Gitea-Event") != ""
+}
+
+func (giteaFormat) authenticate(r *http.Request, body []byte, secret []byte) bool {
+	return validHMAC(body, secret, r.Header.Get("X-Gitea-Signature"))
+}
+
+func (giteaFormat) parse(r *http.Request, body []byte) (*pushNotification, error) {
+	if r.Header.Get("X-Gitea-Event") != "push" {
+		return nil, nil
+	}
+	return parseHostedPayload(body)
+}
+
+// gitlabFormat parses GitLab push and tag push events.
+// https://docs.gitlab.com/ee/user/project/integrations/webhook_events.html#push-events
+type gitlabFormat struct{}
+
+type gitlabProject struct {
+	GitHTTPURL string `json:"git_http_url"`
+	GitSSHURL  string `json:"git_ssh_url"`
+	WebURL     string `json:"web_url"`
+}
+
+type gitlabPayload struct {
+	Project    gitlabProject `json:"project"`
+	Repository struct {
+		GitHTTPURL string `json:"git_http_url"`
+		GitSSHURL  string `json:"git_ssh_url"`
+		Homepage   string `json:"homepage"`
+	} `json:"repository"`
+}
+
+func (gitlabFormat) name() string { return "gitlab" }
+
+func (gitlabFormat) matches(r *http.Request) bool {
+	return r.Header.Get("X-Gitlab-Event") != ""
+}
+
+func (gitlabFormat) authenticate(r *http.Request, body []byte, secret []byte) bool {
+	return validToken(r.Header.Get("X-Gitlab-Token"), secret)
+}
+
+func (gitlabFormat) parse(r *http.Request, body []byte) (*pushNotification, error) {
+	switch r.Header.Get("X-Gitlab-Event") {
+	case "Push Hook", "Tag Push Hook":
+	default:
+		return nil, nil
+	}
+	var payload gitlabPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("cannot parse push payload: %w", err)
+	}
+	return &pushNotification{
+		urls: nonEmpty(
+			payload.Project.GitHTTPURL, payload.Project.GitSSHURL, payload.Project.WebURL,
+			payload.Repository.GitHTTPURL, payload.Repository.GitSSHURL, payload.Repository.Homepage),
+	}, nil
+}
+
+// genericFormat parses notifications from other sources, such as CI
+// pipelines. The payload identifies either the address of the repository,
+// or the Repository resource:
+//
+//	{"repo": "https://github.com/GoogleCloudPlatform/blueprints.git"}
+//	{"namespace": "default", "name": "blueprints"}
+type genericFormat struct{}
+
+type genericPayload struct {
+	// Repo is the address of the Git repository or OCI registry which changed.
+	Repo string `json:"repo,omitempty"`
+	// Namespace of the Repository resource to refresh.
+	Namespace string `json:"namespace,omitempty"`
+	// Name of the Repository resource to refresh.
+	Name string `json:"name,omitempty"`
+}
+
+func (genericFormat) name() string { return "generic" }
+
+func (genericFormat) matches(r *http.Request) bool {
+	return true
+}
+
+func (genericFormat) authenticate(r *http.Request, body []byte, secret []byte) bool {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return false
+	}
+	return validToken(strings.TrimPrefix(auth, "Bearer "), secret)
+}
+
+func (genericFormat) parse(r *http.Request, body []byte) (*pushNotification, error) {
+	var payload genericPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("cannot parse push payload: %w", err)
+	}
+	switch {
+	case payload.Name != "":
+		if payload.Namespace == "" {
+			return nil, fmt.Errorf("namespace is required when name is specified")
+		}
+		return &pushNotification{namespace: payload.Namespace, name: payload.Name}, nil
+	case payload.Repo != "":
+		return &pushNotification{urls: []string{payload.Repo}}, nil
+	default:
+		return nil, fmt.Errorf("payload must specify either repo, or namespace and name")
+	}
+}
+
+func validHMAC(body, secret []byte, signature string) bool {
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+func validToken(token string, secret []byte) bool {
+	return subtle.ConstantTimeCompare([]byte(token), secret) == 1
+}
+
+func nonEmpty(values ...string) []string {
+	var result []string
+	for _, v := range values {
+		if v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
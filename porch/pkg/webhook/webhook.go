@@ -0,0 +1,184 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package webhook implements the HTTP endpoint which receives push
+// notifications from Git hosting services and refreshes the affected
+// repositories in the Porch cache, instead of waiting for the next poll.
+package webhook
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	configapi "github.com/GoogleContainerTools/kpt/porch/api/porchconfig/v1alpha1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// maxPayloadSize limits the size of the notification payloads.
+const maxPayloadSize = 10 << 20
+
+// Refresher refreshes repositories in the cache.
+type Refresher interface {
+	// RefreshRepository triggers refresh of the repository; it returns false
+	// if the repository isn't cached.
+	RefreshRepository(repositorySpec *configapi.Repository) (bool, error)
+}
+
+type Options struct {
+	// Secret authenticates the notifications. It is the secret used to sign
+	// GitHub and Gitea payloads, the GitLab secret token, or the bearer token
+	// of generic notifications. All notifications are rejected if it is
+	// empty.
+	Secret []byte
+}
+
+type handler struct {
+	coreClient client.Reader
+	refresher  Refresher
+	secret     []byte
+}
+
+// NewHandler returns the handler of push notifications, which refreshes the
+// Repositories the notifications refer to.
+func NewHandler(coreClient client.Reader, refresher Refresher, opts Options) http.Handler {
+	return &handler{
+		coreClient: coreClient,
+		refresher:  refresher,
+		secret:     opts.Secret,
+	}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST is supported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPayloadSize))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("cannot read payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	format := detectFormat(r)
+	if len(h.secret) == 0 {
+		klog.Warningf("Rejected %s push notification; no webhook secret is configured", format.name())
+		http.Error(w, "webhook secret is not configured", http.StatusUnauthorized)
+		return
+	}
+	if !format.authenticate(r, body, h.secret) {
+		klog.Warningf("Rejected %s push notification with invalid credentials", format.name())
+		http.Error(w, "invalid credentials", http.StatusUnauthorized)
+		return
+	}
+
+	notification, err := format.parse(r, body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if notification == nil {
+		// Not a push, for example a GitHub ping.
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	var repositories configapi.RepositoryList
+	if err := h.coreClient.List(r.Context(), &repositories); err != nil {
+		klog.Errorf("Cannot list repositories: %v", err)
+		http.Error(w, "cannot list repositories", http.StatusInternalServerError)
+		return
+	}
+
+	var refreshed []string
+	for i := range repositories.Items {
+		repo := &repositories.Items[i]
+		if !notification.matches(repo) {
+			continue
+		}
+		name := repo.Namespace + "/" + repo.Name
+		cached, err := h.refresher.RefreshRepository(repo)
+		switch {
+		case err != nil:
+			klog.Warningf("Cannot refresh repository %s: %v", name, err)
+		case !cached:
+			klog.Infof("Repository %s is not cached yet; skipping refresh", name)
+		default:
+			klog.Infof("Refreshing repository %s after %s push notification", name, format.name())
+			refreshed = append(refreshed, name)
+		}
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	fmt.Fprintf(w, "refreshing %d repositories\n", len(refreshed))
+	for _, name := range refreshed {
+		fmt.Fprintln(w, name)
+	}
+}
+
+// matches returns true if the notification refers to the repository.
+func (n *pushNotification) matches(repo *configapi.Repository) bool {
+	if n.name != "" {
+		return repo.Namespace == n.namespace && repo.Name == n.name
+	}
+
+	var address string
+	switch repo.Spec.Type {
+	case configapi.RepositoryTypeGit:
+		if repo.Spec.Git == nil {
+			return false
+		}
+		address = repo.Spec.Git.Repo
+	case configapi.RepositoryTypeOCI:
+		if repo.Spec.Oci == nil {
+			return false
+		}
+		address = repo.Spec.Oci.Registry
+	default:
+		return false
+	}
+
+	address = normalizeURL(address)
+	for _, url := range n.urls {
+		if normalizeURL(url) == address {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeURL reduces the different forms of a repository address to
+// host/path, so that for example the HTTPS and SSH addresses compare equal:
+//
+//	https://github.com/org/repo.git
+//	git@github.com:org/repo.git
+//	ssh://git@github.com/org/repo
+func normalizeURL(url string) string {
+	url = strings.TrimSpace(url)
+	if i := strings.Index(url, "://"); i >= 0 {
+		url = url[i+3:]
+	} else if i := strings.Index(url, ":"); i >= 0 && !strings.Contains(url[:i], "/") {
+		// scp-like syntax: user@host:path
+		url = url[:i] + "/" + url[i+1:]
+	}
+	if i := strings.Index(url, "@"); i >= 0 && i < strings.Index(url+"/", "/") {
+		url = url[i+1:]
+	}
+	url = strings.TrimSuffix(url, "/")
+	url = strings.TrimSuffix(url, ".git")
+	return strings.ToLower(url)
+}
@@ -0,0 +1,208 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	configapi "github.com/GoogleContainerTools/kpt/porch/api/porchconfig/v1alpha1"
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type fakeRefresher struct {
+	refreshed []string
+}
+
+func (f *fakeRefresher) RefreshRepository(repositorySpec *configapi.Repository) (bool, error) {
+	f.refreshed = append(f.refreshed, repositorySpec.Namespace+"/"+repositorySpec.Name)
+	return true, nil
+}
+
+func gitRepository(namespace, name, address string) *configapi.Repository {
+	return &configapi.Repository{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec: configapi.RepositorySpec{
+			Type: configapi.RepositoryTypeGit,
+			Git:  &configapi.GitRepository{Repo: address},
+		},
+	}
+}
+
+func newTestHandler(t *testing.T, secret string) (http.Handler, *fakeRefresher) {
+	scheme := runtime.NewScheme()
+	if err := configapi.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build scheme: %v", err)
+	}
+	coreClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		gitRepository("default", "blueprints", "https://github.com/platkrm/blueprints.git"),
+		gitRepository("team", "blueprints", "git@github.com:platkrm/blueprints.git"),
+		gitRepository("default", "deployments", "https://gitlab.com/platkrm/deployments"),
+		gitRepository("default", "gitea", "http://gitea.example.com/platkrm/catalog.git"),
+	).Build()
+	refresher := &fakeRefresher{}
+	return NewHandler(coreClient, refresher, Options{Secret: []byte(secret)}), refresher
+}
+
+func sign(body, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestHandler(t *testing.T) {
+	const githubPush = `{"ref": "refs/heads/main", "repository": {"clone_url": "https://github.com/platkrm/blueprints.git", "ssh_url": "git@github.com:platkrm/blueprints.git"}}`
+	const gitlabPush = `{"ref": "refs/tags/v1", "project": {"git_http_url": "https://gitlab.com/platkrm/deployments.git", "web_url": "https://gitlab.com/platkrm/deployments"}}`
+	const giteaPush = `{"ref": "refs/heads/main", "repository": {"clone_url": "http://gitea.example.com/platkrm/catalog.git"}}`
+
+	for _, tc := range []struct {
+		name       string
+		secret     string
+		headers    map[string]string
+		body       string
+		wantStatus int
+		want       []string
+	}{
+		{
+			name:       "github push",
+			secret:     "s3cr3t",
+			headers:    map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature-256": "sha256=" + sign(githubPush, "s3cr3t")},
+			body:       githubPush,
+			wantStatus: http.StatusAccepted,
+			want:       []string{"default/blueprints", "team/blueprints"},
+		},
+		{
+			name:       "github ping",
+			secret:     "s3cr3t",
+			headers:    map[string]string{"X-GitHub-Event": "ping", "X-Hub-Signature-256": "sha256=" + sign(`{"zen": "Keep it logically awesome."}`, "s3cr3t")},
+			body:       `{"zen": "Keep it logically awesome."}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "github push without secret configured",
+			headers:    map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature-256": "sha256=" + sign(githubPush, "")},
+			body:       githubPush,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "github push with invalid signature",
+			secret:     "s3cr3t",
+			headers:    map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature-256": "sha256=" + sign(githubPush, "wrong")},
+			body:       githubPush,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "gitlab tag push",
+			secret:     "s3cr3t",
+			headers:    map[string]string{"X-Gitlab-Event": "Tag Push Hook", "X-Gitlab-Token": "s3cr3t"},
+			body:       gitlabPush,
+			wantStatus: http.StatusAccepted,
+			want:       []string{"default/deployments"},
+		},
+		{
+			name:       "gitea push",
+			secret:     "s3cr3t",
+			headers:    map[string]string{"X-Gitea-Event": "push", "X-GitHub-Event": "push", "X-Gitea-Signature": sign(giteaPush, "s3cr3t")},
+			body:       giteaPush,
+			wantStatus: http.StatusAccepted,
+			want:       []string{"default/gitea"},
+		},
+		{
+			name:       "generic repository address",
+			secret:     "s3cr3t",
+			headers:    map[string]string{"Authorization": "Bearer s3cr3t"},
+			body:       `{"repo": "ssh://git@github.com/platkrm/blueprints"}`,
+			wantStatus: http.StatusAccepted,
+			want:       []string{"default/blueprints", "team/blueprints"},
+		},
+		{
+			name:       "generic repository resource",
+			secret:     "s3cr3t",
+			headers:    map[string]string{"Authorization": "Bearer s3cr3t"},
+			body:       `{"namespace": "team", "name": "blueprints"}`,
+			wantStatus: http.StatusAccepted,
+			want:       []string{"team/blueprints"},
+		},
+		{
+			name:       "generic without credentials",
+			secret:     "s3cr3t",
+			body:       `{"namespace": "team", "name": "blueprints"}`,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "generic without secret configured",
+			body:       `{"namespace": "team", "name": "blueprints"}`,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "generic invalid payload",
+			secret:     "s3cr3t",
+			headers:    map[string]string{"Authorization": "Bearer s3cr3t"},
+			body:       `{}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unknown repository",
+			secret:     "s3cr3t",
+			headers:    map[string]string{"Authorization": "Bearer s3cr3t"},
+			body:       `{"repo": "https://github.com/platkrm/unknown.git"}`,
+			wantStatus: http.StatusAccepted,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			handler, refresher := newTestHandler(t, tc.secret)
+
+			req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(tc.body))
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if got, want := rec.Code, tc.wantStatus; got != want {
+				t.Errorf("status = %d, want %d; body: %s", got, want, rec.Body.String())
+			}
+			sort.Strings(refresher.refreshed)
+			if diff := cmp.Diff(tc.want, refresher.refreshed); diff != "" {
+				t.Errorf("refreshed repositories (-want,+got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestNormalizeURL(t *testing.T) {
+	for _, tc := range []struct {
+		url, want string
+	}{
+		{"https://github.com/platkrm/blueprints.git", "github.com/platkrm/blueprints"},
+		{"https://user@github.com/platkrm/blueprints/", "github.com/platkrm/blueprints"},
+		{"git@github.com:platkrm/blueprints.git", "github.com/platkrm/blueprints"},
+		{"ssh://git@github.com/PlatKRM/blueprints", "github.com/platkrm/blueprints"},
+		{"us-docker.pkg.dev/project/deployments", "us-docker.pkg.dev/project/deployments"},
+	} {
+		if got := normalizeURL(tc.url); got != tc.want {
+			t.Errorf("normalizeURL(%q) = %q, want %q", tc.url, got, tc.want)
+		}
+	}
+}
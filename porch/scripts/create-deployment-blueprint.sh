@@ -172,6 +172,13 @@ function main() {
      "${DESTINATION}/0-remoterootsyncsets.yaml"
   cp "${PORCH_DIR}/controllers/remoterootsync/config/rbac/role.yaml" \
      "${DESTINATION}/0-remoterootsync-role.yaml"
+  # PackageVariant controller
+  cp "${PORCH_DIR}/controllers/packagevariants/config/crd/bases/config.porch.kpt.dev_packagevariants.yaml" \
+     "${DESTINATION}/0-packagevariants.yaml"
+  cp "${PORCH_DIR}/controllers/packagevariants/config/crd/bases/config.porch.kpt.dev_packagevariantsets.yaml" \
+     "${DESTINATION}/0-packagevariantsets.yaml"
+  cp "${PORCH_DIR}/controllers/packagevariants/config/rbac/role.yaml" \
+     "${DESTINATION}/0-packagevariant-controller-role.yaml"
   # Repository CRD
   cp "./api/porchconfig/v1alpha1/config.porch.kpt.dev_repositories.yaml" \
      "${DESTINATION}/0-repositories.yaml"
@@ -25,6 +25,7 @@ import (
 
 	kptcommands "github.com/GoogleContainerTools/kpt/commands"
 	"github.com/GoogleContainerTools/kpt/internal/docs/generated/overview"
+	"github.com/GoogleContainerTools/kpt/internal/gitutil"
 	"github.com/GoogleContainerTools/kpt/internal/printer"
 	"github.com/GoogleContainerTools/kpt/internal/util/cmdutil"
 	"github.com/spf13/cobra"
@@ -94,9 +95,13 @@ func GetMain(ctx context.Context) *cobra.Command {
 	cmd.PersistentFlags().BoolVar(&cmdutil.StackOnError, "stack-trace", false,
 		"Print a stack-trace on failure")
 
-	if _, err := exec.LookPath("git"); err != nil {
-		fmt.Fprintf(os.Stderr, "kpt requires that `git` is installed and on the PATH")
-		os.Exit(1)
+	// git is only required if upstream repos aren't fetched with go-git.
+	if !gitutil.UseGoGit() {
+		if _, err := exec.LookPath("git"); err != nil {
+			fmt.Fprintf(os.Stderr, "kpt requires that `git` is installed and on the PATH, or that %s=%s is set",
+				gitutil.GitClientEnv, gitutil.GoGitClient)
+			os.Exit(1)
+		}
 	}
 
 	replace(cmd)